@@ -21,5 +21,5 @@ import (
 )
 
 func init() {
-	nrLoader.DefaultRegistry.RegisterComponent(kubernetes.NewResolver, "kubernetes")
+	nrLoader.DefaultRegistry.RegisterComponent(nrLoader.WithKubernetesInstances(kubernetes.NewResolver), "kubernetes")
 }
@@ -21,5 +21,5 @@ import (
 )
 
 func init() {
-	nrLoader.DefaultRegistry.RegisterComponent(mdns.NewResolver, "mdns")
+	nrLoader.DefaultRegistry.RegisterComponent(nrLoader.WithMDNSInstances(mdns.NewResolver), "mdns")
 }
@@ -63,6 +63,56 @@ type ConfigurationSpec struct {
 	LoggingSpec *LoggingSpec `json:"logging,omitempty"`
 	// +optional
 	WasmSpec *WasmSpec `json:"wasm,omitempty"`
+	// +optional
+	AppRoutingSpec *AppRoutingSpec `json:"appRouting,omitempty"`
+	// +optional
+	InvocationCacheSpec *InvocationCacheSpec `json:"invocationCache,omitempty"`
+}
+
+// AppRoutingSpec describes how service invocation requests to logical app IDs are routed.
+type AppRoutingSpec struct {
+	// +optional
+	Routes []AppRoute `json:"routes,omitempty"`
+}
+
+// AppRoute splits the requests sent to a logical app ID across several backing app IDs.
+type AppRoute struct {
+	AppID string `json:"appId"`
+	// Requests matching one of these are sent to its app ID, in order.
+	// +optional
+	Matches []AppRouteMatch `json:"matches,omitempty"`
+	// The other requests are split across these by weight.
+	// +optional
+	Targets []WeightedAppTarget `json:"targets,omitempty"`
+}
+
+// AppRouteMatch sends the requests that have all the given header values to AppID.
+type AppRouteMatch struct {
+	Headers map[string]string `json:"headers"`
+	AppID   string            `json:"appId"`
+}
+
+// WeightedAppTarget is a backing app ID of a route.
+type WeightedAppTarget struct {
+	AppID  string `json:"appId"`
+	Weight int    `json:"weight"`
+}
+
+// InvocationCacheSpec configures the cache, in the caller's sidecar, of the responses to service invocation GET requests.
+type InvocationCacheSpec struct {
+	// Maximum size of all the cached responses, in MB. Defaults to 64.
+	// +optional
+	MaxSizeMB int `json:"maxSizeMB,omitempty"`
+	// +optional
+	Rules []InvocationCacheRule `json:"rules,omitempty"`
+}
+
+// InvocationCacheRule enables caching the responses of an app.
+type InvocationCacheRule struct {
+	AppID string `json:"appId"`
+	// Patterns of the methods whose responses are cached, as in path.Match. All methods if empty.
+	// +optional
+	Methods []string `json:"methods,omitempty"`
 }
 
 // APISpec describes the configuration for Dapr APIs.
@@ -159,6 +159,77 @@ func (in *AppPolicySpec) DeepCopy() *AppPolicySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppRoute) DeepCopyInto(out *AppRoute) {
+	*out = *in
+	if in.Matches != nil {
+		in, out := &in.Matches, &out.Matches
+		*out = make([]AppRouteMatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]WeightedAppTarget, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppRoute.
+func (in *AppRoute) DeepCopy() *AppRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(AppRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppRouteMatch) DeepCopyInto(out *AppRouteMatch) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppRouteMatch.
+func (in *AppRouteMatch) DeepCopy() *AppRouteMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(AppRouteMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppRoutingSpec) DeepCopyInto(out *AppRoutingSpec) {
+	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]AppRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppRoutingSpec.
+func (in *AppRoutingSpec) DeepCopy() *AppRoutingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AppRoutingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComponentsSpec) DeepCopyInto(out *ComponentsSpec) {
 	*out = *in
@@ -312,6 +383,16 @@ func (in *ConfigurationSpec) DeepCopyInto(out *ConfigurationSpec) {
 		*out = new(WasmSpec)
 		**out = **in
 	}
+	if in.AppRoutingSpec != nil {
+		in, out := &in.AppRoutingSpec, &out.AppRoutingSpec
+		*out = new(AppRoutingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InvocationCacheSpec != nil {
+		in, out := &in.InvocationCacheSpec, &out.InvocationCacheSpec
+		*out = new(InvocationCacheSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigurationSpec.
@@ -380,6 +461,48 @@ func (in *HandlerSpec) DeepCopy() *HandlerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvocationCacheRule) DeepCopyInto(out *InvocationCacheRule) {
+	*out = *in
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvocationCacheRule.
+func (in *InvocationCacheRule) DeepCopy() *InvocationCacheRule {
+	if in == nil {
+		return nil
+	}
+	out := new(InvocationCacheRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InvocationCacheSpec) DeepCopyInto(out *InvocationCacheSpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]InvocationCacheRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InvocationCacheSpec.
+func (in *InvocationCacheSpec) DeepCopy() *InvocationCacheSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InvocationCacheSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoggingSpec) DeepCopyInto(out *LoggingSpec) {
 	*out = *in
@@ -717,6 +840,21 @@ func (in *WasmSpec) DeepCopy() *WasmSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightedAppTarget) DeepCopyInto(out *WeightedAppTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightedAppTarget.
+func (in *WeightedAppTarget) DeepCopy() *WeightedAppTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightedAppTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ZipkinSpec) DeepCopyInto(out *ZipkinSpec) {
 	*out = *in
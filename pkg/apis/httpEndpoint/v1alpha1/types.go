@@ -53,6 +53,26 @@ func (h HTTPEndpoint) NameValuePairs() []common.NameValuePair {
 	return h.Spec.Headers
 }
 
+// GetBaseURLs returns BaseURL followed by the additional BaseURLs, without
+// duplicates or empty values.
+func (h HTTPEndpoint) GetBaseURLs() []string {
+	urls := make([]string, 0, 1+len(h.Spec.BaseURLs))
+	seen := make(map[string]struct{}, 1+len(h.Spec.BaseURLs))
+	for _, u := range append([]string{h.Spec.BaseURL}, h.Spec.BaseURLs...) {
+		if _, ok := seen[u]; ok || u == "" {
+			continue
+		}
+		seen[u] = struct{}{}
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// HasOAuth2 returns a bool indicating if the HTTP endpoint uses OAuth2 client credentials.
+func (h HTTPEndpoint) HasOAuth2() bool {
+	return h.Spec.Authentication != nil && h.Spec.Authentication.OAuth2 != nil
+}
+
 // nil returns a bool indicating if a tls private key has a secret reference
 func (h HTTPEndpoint) HasTLSPrivateKeySecret() bool {
 	return h.Spec.ClientTLS != nil && h.Spec.ClientTLS.PrivateKey != nil && h.Spec.ClientTLS.PrivateKey.SecretKeyRef != nil && h.Spec.ClientTLS.PrivateKey.SecretKeyRef.Name != ""
@@ -86,10 +106,113 @@ func (h HTTPEndpoint) HasTLSPrivateKey() bool {
 // HTTPEndpointSpec describes an access specification for allowing external service invocations.
 type HTTPEndpointSpec struct {
 	BaseURL string `json:"baseUrl" validate:"required"`
+	// BaseURLs are additional base URLs of the endpoint, such as regional
+	// replicas. Requests are load balanced across BaseURL and BaseURLs.
+	//+optional
+	BaseURLs []string `json:"baseUrls,omitempty"`
+	//+optional
+	LoadBalancing *LoadBalancing `json:"loadBalancing,omitempty"`
+	//+optional
+	HealthCheck *HealthCheck `json:"healthCheck,omitempty"`
 	//+optional
 	Headers []common.NameValuePair `json:"headers"`
 	//+optional
 	ClientTLS *common.TLS `json:"clientTLS,omitempty"`
+	//+optional
+	Authentication *Authentication `json:"authentication,omitempty"`
+}
+
+// Load balancing strategies for HTTP endpoints with multiple base URLs.
+const (
+	// LoadBalancingRoundRobin sends requests to each base URL in turn.
+	LoadBalancingRoundRobin = "roundRobin"
+	// LoadBalancingLeastOutstanding sends requests to the base URL with the
+	// fewest requests in flight.
+	LoadBalancingLeastOutstanding = "leastOutstanding"
+	// LoadBalancingPriority sends requests to the first healthy base URL, in
+	// the order in which they are declared.
+	LoadBalancingPriority = "priority"
+)
+
+// LoadBalancing describes how requests are distributed across the base URLs
+// of an HTTP endpoint.
+type LoadBalancing struct {
+	// Strategy is one of "roundRobin" (the default), "leastOutstanding" or "priority".
+	//+optional
+	Strategy string `json:"strategy,omitempty"`
+}
+
+// HealthCheck describes the active health probes of the base URLs of an HTTP
+// endpoint. Base URLs that fail the probes are ejected from load balancing
+// until they pass again.
+type HealthCheck struct {
+	// Path that is probed with a GET request on each base URL. Any 2xx
+	// response is healthy.
+	Path string `json:"path"`
+	// Interval between probes, as a Go duration. Defaults to 10s.
+	//+optional
+	Interval string `json:"interval,omitempty"`
+	// Timeout of each probe, as a Go duration. Defaults to 5s.
+	//+optional
+	Timeout string `json:"timeout,omitempty"`
+	// FailureThreshold is the number of consecutive failed probes after which
+	// a base URL is ejected. Defaults to 3.
+	//+optional
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// SuccessThreshold is the number of consecutive successful probes after
+	// which an ejected base URL is added back. Defaults to 1.
+	//+optional
+	SuccessThreshold int `json:"successThreshold,omitempty"`
+}
+
+// Authentication describes how requests to an HTTP endpoint are authenticated.
+type Authentication struct {
+	//+optional
+	OAuth2 *OAuth2 `json:"oauth2,omitempty"`
+}
+
+// OAuth2 describes the OAuth2 client credentials flow used to obtain the
+// access token sent in the Authorization header of requests to an HTTP
+// endpoint. Tokens are cached and refreshed before they expire.
+type OAuth2 struct {
+	// TokenURL is the URL of the token endpoint of the authorization server.
+	TokenURL string `json:"tokenUrl"`
+	// ClientID is the client identifier, inline or from the secret store.
+	ClientID SecretValue `json:"clientId"`
+	// ClientSecret is the client secret, inline or from the secret store.
+	ClientSecret SecretValue `json:"clientSecret"`
+	//+optional
+	Scopes []string `json:"scopes,omitempty"`
+	// Audience is sent as the "audience" parameter of token requests, if set.
+	//+optional
+	Audience string `json:"audience,omitempty"`
+	// RefreshBefore is how long before its expiry a token is refreshed, as a
+	// Go duration. Defaults to 30s.
+	//+optional
+	RefreshBefore string `json:"refreshBefore,omitempty"`
+}
+
+// SecretValue is a value set in plaintext or read from the secret store of the HTTP endpoint.
+type SecretValue struct {
+	// Value of the property, in plaintext.
+	//+optional
+	Value *common.DynamicValue `json:"value,omitempty"`
+	// SecretKeyRef is the reference of a value in a secret store component.
+	//+optional
+	SecretKeyRef *common.SecretKeyRef `json:"secretKeyRef,omitempty"`
+}
+
+// HasSecret returns a bool indicating if the value has a secret reference.
+func (v SecretValue) HasSecret() bool {
+	return v.SecretKeyRef != nil && v.SecretKeyRef.Name != ""
+}
+
+// String returns the plaintext value, or an empty string if it isn't set.
+func (v SecretValue) String() string {
+	if v.Value == nil {
+		return ""
+	}
+	return v.Value.String()
 }
 
 // Auth represents authentication details for the component.
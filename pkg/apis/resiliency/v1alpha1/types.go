@@ -48,6 +48,8 @@ type Policies struct {
 	Timeouts        map[string]string         `json:"timeouts,omitempty" yaml:"timeouts,omitempty"`
 	Retries         map[string]Retry          `json:"retries,omitempty" yaml:"retries,omitempty"`
 	CircuitBreakers map[string]CircuitBreaker `json:"circuitBreakers,omitempty" yaml:"circuitBreakers,omitempty"`
+	Hedging         map[string]Hedging        `json:"hedging,omitempty" yaml:"hedging,omitempty"`
+	LoadBalancers   map[string]LoadBalancer   `json:"loadBalancers,omitempty" yaml:"loadBalancers,omitempty"`
 }
 
 type Retry struct {
@@ -64,6 +66,33 @@ type CircuitBreaker struct {
 	Trip        string `json:"trip,omitempty" yaml:"trip,omitempty"`
 }
 
+// Hedging sends duplicate requests to other instances of an app when the
+// first one takes longer than Delay, and uses the first successful response.
+// Only idempotent methods are hedged: those invoked with an idempotent HTTP
+// verb, and the ones listed in Methods.
+type Hedging struct {
+	Delay       string   `json:"delay,omitempty" yaml:"delay,omitempty"`
+	MaxAttempts int      `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+	Methods     []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+}
+
+// LoadBalancer configures how daprd balances requests across the instances
+// of an app returned by the name resolver.
+type LoadBalancer struct {
+	// Strategy is one of roundRobin (the default), leastRequests or consistentHash.
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	// HashHeader is the request header hashed by the consistentHash strategy.
+	HashHeader       string            `json:"hashHeader,omitempty" yaml:"hashHeader,omitempty"`
+	OutlierDetection *OutlierDetection `json:"outlierDetection,omitempty" yaml:"outlierDetection,omitempty"`
+}
+
+// OutlierDetection ejects instances of an app after consecutive failed requests.
+type OutlierDetection struct {
+	ConsecutiveErrors  int    `json:"consecutiveErrors,omitempty" yaml:"consecutiveErrors,omitempty"`
+	BaseEjectionTime   string `json:"baseEjectionTime,omitempty" yaml:"baseEjectionTime,omitempty"`
+	MaxEjectionPercent *int   `json:"maxEjectionPercent,omitempty" yaml:"maxEjectionPercent,omitempty"`
+}
+
 type Targets struct {
 	Apps       map[string]EndpointPolicyNames  `json:"apps,omitempty" yaml:"apps,omitempty"`
 	Actors     map[string]ActorPolicyNames     `json:"actors,omitempty" yaml:"actors,omitempty"`
@@ -86,6 +115,18 @@ type EndpointPolicyNames struct {
 	Retry                   string `json:"retry,omitempty" yaml:"retry,omitempty"`
 	CircuitBreaker          string `json:"circuitBreaker,omitempty" yaml:"circuitBreaker,omitempty"`
 	CircuitBreakerCacheSize int    `json:"circuitBreakerCacheSize,omitempty" yaml:"circuitBreakerCacheSize,omitempty"`
+	Hedging                 string `json:"hedging,omitempty" yaml:"hedging,omitempty"`
+	LoadBalancer            string `json:"loadBalancer,omitempty" yaml:"loadBalancer,omitempty"`
+	// Policies for the gRPC methods matching a glob, such as "mypackage.Orders/*".
+	// The first match wins; empty policy names are inherited from the app.
+	Methods []MethodPolicyNames `json:"methods,omitempty" yaml:"methods,omitempty"`
+}
+
+type MethodPolicyNames struct {
+	Match          string `json:"match" yaml:"match"`
+	Timeout        string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Retry          string `json:"retry,omitempty" yaml:"retry,omitempty"`
+	CircuitBreaker string `json:"circuitBreaker,omitempty" yaml:"circuitBreaker,omitempty"`
 }
 
 type ActorPolicyNames struct {
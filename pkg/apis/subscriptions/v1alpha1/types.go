@@ -40,6 +40,8 @@ type SubscriptionSpec struct {
 	Route           string            `json:"route"`
 	BulkSubscribe   BulkSubscribe     `json:"bulkSubscribe,omitempty"`
 	DeadLetterTopic string            `json:"deadLetterTopic,omitempty"`
+	// +optional
+	DeliveryLimits DeliveryLimits `json:"deliveryLimits,omitempty"`
 }
 
 // BulkSubscribe encapsulates the bulk subscription configuration for a topic.
@@ -50,6 +52,14 @@ type BulkSubscribe struct {
 	MaxAwaitDurationMs int32 `json:"maxAwaitDurationMs,omitempty"`
 }
 
+// DeliveryLimits encapsulates the limits applied to deliveries of a topic to the app.
+type DeliveryLimits struct {
+	// +optional
+	MaxConcurrency int32 `json:"maxConcurrency,omitempty"`
+	// +optional
+	MaxMessagesPerSecond int32 `json:"maxMessagesPerSecond,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 
 // SubscriptionList is a list of Dapr event sources.
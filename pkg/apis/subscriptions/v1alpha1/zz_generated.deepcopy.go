@@ -40,6 +40,21 @@ func (in *BulkSubscribe) DeepCopy() *BulkSubscribe {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeliveryLimits) DeepCopyInto(out *DeliveryLimits) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeliveryLimits.
+func (in *DeliveryLimits) DeepCopy() *DeliveryLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(DeliveryLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Subscription) DeepCopyInto(out *Subscription) {
 	*out = *in
@@ -114,6 +129,7 @@ func (in *SubscriptionSpec) DeepCopyInto(out *SubscriptionSpec) {
 		}
 	}
 	out.BulkSubscribe = in.BulkSubscribe
+	out.DeliveryLimits = in.DeliveryLimits
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionSpec.
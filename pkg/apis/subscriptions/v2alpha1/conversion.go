@@ -41,6 +41,10 @@ func (s *Subscription) ConvertTo(dstRaw conversion.Hub) error {
 	dst.Spec.Route = s.Spec.Routes.Default
 	dst.Spec.DeadLetterTopic = s.Spec.DeadLetterTopic
 	dst.Spec.BulkSubscribe = *convertBulkSubscriptionV2alpha1ToV1alpha1(&s.Spec.BulkSubscribe)
+	dst.Spec.DeliveryLimits = v1alpha1.DeliveryLimits{
+		MaxConcurrency:       s.Spec.DeliveryLimits.MaxConcurrency,
+		MaxMessagesPerSecond: s.Spec.DeliveryLimits.MaxMessagesPerSecond,
+	}
 
 	// +kubebuilder:docs-gen:collapse=rote conversion
 	return nil
@@ -79,6 +83,10 @@ func (s *Subscription) ConvertFrom(srcRaw conversion.Hub) error {
 	s.Spec.Routes.Default = src.Spec.Route
 	s.Spec.DeadLetterTopic = src.Spec.DeadLetterTopic
 	s.Spec.BulkSubscribe = *convertBulkSubscriptionV1alpha1ToV2alpha1(&src.Spec.BulkSubscribe)
+	s.Spec.DeliveryLimits = DeliveryLimits{
+		MaxConcurrency:       src.Spec.DeliveryLimits.MaxConcurrency,
+		MaxMessagesPerSecond: src.Spec.DeliveryLimits.MaxMessagesPerSecond,
+	}
 
 	// +kubebuilder:docs-gen:collapse=rote conversion
 	return nil
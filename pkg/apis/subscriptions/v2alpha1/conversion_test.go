@@ -29,6 +29,10 @@ func TestConversion(t *testing.T) {
 				MaxMessagesCount:   10,
 				MaxAwaitDurationMs: 1000,
 			},
+			DeliveryLimits: v2alpha1.DeliveryLimits{
+				MaxConcurrency:       5,
+				MaxMessagesPerSecond: 100,
+			},
 		},
 	}
 
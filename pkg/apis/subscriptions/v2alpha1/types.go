@@ -47,6 +47,9 @@ type SubscriptionSpec struct {
 	DeadLetterTopic string `json:"deadLetterTopic,omitempty"`
 	// The option to enable bulk subscription for this topic.
 	BulkSubscribe BulkSubscribe `json:"bulkSubscribe,omitempty"`
+	// The optional limits on concurrent and per-second deliveries to the app for this topic.
+	// +optional
+	DeliveryLimits DeliveryLimits `json:"deliveryLimits,omitempty"`
 }
 
 // BulkSubscribe encapsulates the bulk subscription configuration for a topic.
@@ -57,6 +60,17 @@ type BulkSubscribe struct {
 	MaxAwaitDurationMs int32 `json:"maxAwaitDurationMs,omitempty"`
 }
 
+// DeliveryLimits encapsulates the limits applied to deliveries of a topic to the app.
+// A value of zero means no limit.
+type DeliveryLimits struct {
+	// The maximum number of deliveries to the app that can be in flight at the same time.
+	// +optional
+	MaxConcurrency int32 `json:"maxConcurrency,omitempty"`
+	// The maximum number of messages delivered to the app per second.
+	// +optional
+	MaxMessagesPerSecond int32 `json:"maxMessagesPerSecond,omitempty"`
+}
+
 // Routes encapsulates the rules and optional default path for a topic.
 type Routes struct {
 	// The list of rules for this topic.
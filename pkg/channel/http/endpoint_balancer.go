@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	httpEndpointV1alpha1 "github.com/dapr/dapr/pkg/apis/httpEndpoint/v1alpha1"
+	diag "github.com/dapr/dapr/pkg/diagnostics"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultHealthCheckInterval         = 10 * time.Second
+	defaultHealthCheckTimeout          = 5 * time.Second
+	defaultHealthCheckFailureThreshold = 3
+	defaultHealthCheckSuccessThreshold = 1
+)
+
+var log = logger.NewLogger("dapr.channel.http")
+
+// endpointTarget is a base URL of an HTTP endpoint.
+type endpointTarget struct {
+	url         string
+	outstanding atomic.Int64
+	healthy     atomic.Bool
+
+	// Only accessed by the probe goroutine.
+	failures  int
+	successes int
+}
+
+// release must be called once the request sent to the target has completed.
+func (t *endpointTarget) release() {
+	t.outstanding.Add(-1)
+}
+
+// endpointBalancer distributes the requests of an HTTP endpoint across its
+// base URLs, skipping the ones ejected by the health probes.
+type endpointBalancer struct {
+	name     string
+	strategy string
+	spec     string
+	targets  []*endpointTarget
+	next     atomic.Uint64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// needsEndpointBalancer returns true if requests to the endpoint can't simply
+// be sent to its BaseURL.
+func needsEndpointBalancer(endpoint httpEndpointV1alpha1.HTTPEndpoint) bool {
+	return len(endpoint.GetBaseURLs()) > 1 || endpoint.Spec.HealthCheck != nil
+}
+
+// endpointBalancerSpec returns the part of the endpoint configuration which
+// the balancer is built from, so that balancers can be rebuilt when it changes.
+func endpointBalancerSpec(endpoint httpEndpointV1alpha1.HTTPEndpoint) string {
+	spec := fmt.Sprintf("%v", endpoint.GetBaseURLs())
+	if endpoint.Spec.LoadBalancing != nil {
+		spec += "|" + endpoint.Spec.LoadBalancing.Strategy
+	}
+	if endpoint.Spec.HealthCheck != nil {
+		spec += fmt.Sprintf("|%+v", *endpoint.Spec.HealthCheck)
+	}
+	return spec
+}
+
+// newEndpointBalancer creates a balancer for the endpoint and starts the
+// health probes, if configured. Probes use the given client.
+func newEndpointBalancer(endpoint httpEndpointV1alpha1.HTTPEndpoint, client *http.Client) (*endpointBalancer, error) {
+	b := &endpointBalancer{
+		name:     endpoint.Name,
+		strategy: httpEndpointV1alpha1.LoadBalancingRoundRobin,
+		spec:     endpointBalancerSpec(endpoint),
+	}
+
+	if lb := endpoint.Spec.LoadBalancing; lb != nil && lb.Strategy != "" {
+		switch lb.Strategy {
+		case httpEndpointV1alpha1.LoadBalancingRoundRobin,
+			httpEndpointV1alpha1.LoadBalancingLeastOutstanding,
+			httpEndpointV1alpha1.LoadBalancingPriority:
+			b.strategy = lb.Strategy
+		default:
+			return nil, fmt.Errorf("invalid load balancing strategy %s for http endpoint %s", lb.Strategy, endpoint.Name)
+		}
+	}
+
+	for _, u := range endpoint.GetBaseURLs() {
+		t := &endpointTarget{url: u}
+		t.healthy.Store(true)
+		b.targets = append(b.targets, t)
+	}
+	if len(b.targets) == 0 {
+		return nil, fmt.Errorf("http endpoint %s has no base URL", endpoint.Name)
+	}
+
+	hc := endpoint.Spec.HealthCheck
+	if hc == nil {
+		return b, nil
+	}
+
+	interval, timeout := defaultHealthCheckInterval, defaultHealthCheckTimeout
+	var err error
+	if hc.Interval != "" {
+		interval, err = time.ParseDuration(hc.Interval)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid health check interval %s for http endpoint %s", hc.Interval, endpoint.Name)
+		}
+	}
+	if hc.Timeout != "" {
+		timeout, err = time.ParseDuration(hc.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid health check timeout %s for http endpoint %s", hc.Timeout, endpoint.Name)
+		}
+	}
+	failureThreshold := hc.FailureThreshold
+	if failureThreshold <= 0 {
+		failureThreshold = defaultHealthCheckFailureThreshold
+	}
+	successThreshold := hc.SuccessThreshold
+	if successThreshold <= 0 {
+		successThreshold = defaultHealthCheckSuccessThreshold
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	for _, t := range b.targets {
+		diag.DefaultMonitoring.ServiceInvocationEndpointHealth(b.name, t.url, true)
+
+		b.wg.Add(1)
+		go func(t *endpointTarget) {
+			defer b.wg.Done()
+			b.probeLoop(ctx, client, t, endpoint, interval, timeout, failureThreshold, successThreshold)
+		}(t)
+	}
+
+	return b, nil
+}
+
+// pick returns the target to send the next request to.
+// If all targets are ejected, requests are balanced across all of them rather
+// than failing outright.
+func (b *endpointBalancer) pick() *endpointTarget {
+	candidates := make([]*endpointTarget, 0, len(b.targets))
+	for _, t := range b.targets {
+		if t.healthy.Load() {
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.targets
+	}
+
+	var t *endpointTarget
+	switch b.strategy {
+	case httpEndpointV1alpha1.LoadBalancingPriority:
+		t = candidates[0]
+	case httpEndpointV1alpha1.LoadBalancingLeastOutstanding:
+		// Start from a rotating offset so that ties are spread evenly.
+		offset := int(b.next.Add(1) % uint64(len(candidates)))
+		for i := range candidates {
+			c := candidates[(offset+i)%len(candidates)]
+			if t == nil || c.outstanding.Load() < t.outstanding.Load() {
+				t = c
+			}
+		}
+	default:
+		t = candidates[(b.next.Add(1)-1)%uint64(len(candidates))]
+	}
+
+	t.outstanding.Add(1)
+	return t
+}
+
+// close stops the health probes.
+func (b *endpointBalancer) close() {
+	if b.cancel != nil {
+		b.cancel()
+		b.wg.Wait()
+	}
+}
+
+func (b *endpointBalancer) probeLoop(ctx context.Context, client *http.Client, t *endpointTarget, endpoint httpEndpointV1alpha1.HTTPEndpoint,
+	interval, timeout time.Duration, failureThreshold, successThreshold int,
+) {
+	probeURL := strings.TrimSuffix(t.url, "/") + "/" + strings.TrimPrefix(endpoint.Spec.HealthCheck.Path, "/")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ok := probeEndpointTarget(ctx, client, probeURL, endpoint, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+		b.recordProbe(t, ok, failureThreshold, successThreshold)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordProbe updates the health of a target with the result of a probe.
+func (b *endpointBalancer) recordProbe(t *endpointTarget, ok bool, failureThreshold, successThreshold int) {
+	if ok {
+		t.failures = 0
+		t.successes++
+		if !t.healthy.Load() && t.successes >= successThreshold {
+			log.Infof("Base URL %s of http endpoint %s is healthy again", t.url, b.name)
+			t.healthy.Store(true)
+			diag.DefaultMonitoring.ServiceInvocationEndpointHealth(b.name, t.url, true)
+		}
+		return
+	}
+
+	t.successes = 0
+	t.failures++
+	if t.healthy.Load() && t.failures >= failureThreshold {
+		log.Warnf("Ejecting base URL %s of http endpoint %s after %d failed health probes", t.url, b.name, t.failures)
+		t.healthy.Store(false)
+		diag.DefaultMonitoring.ServiceInvocationEndpointHealth(b.name, t.url, false)
+	}
+}
+
+func probeEndpointTarget(ctx context.Context, client *http.Client, probeURL string, endpoint httpEndpointV1alpha1.HTTPEndpoint, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL, nil)
+	if err != nil {
+		return false
+	}
+	for _, hdr := range endpoint.Spec.Headers {
+		req.Header.Set(hdr.Name, hdr.Value.String())
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
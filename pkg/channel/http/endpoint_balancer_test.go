@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	httpEndpointV1alpha1 "github.com/dapr/dapr/pkg/apis/httpEndpoint/v1alpha1"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+)
+
+func newBalancerTestEndpoint(strategy string, urls ...string) httpEndpointV1alpha1.HTTPEndpoint {
+	return httpEndpointV1alpha1.HTTPEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "myendpoint"},
+		Spec: httpEndpointV1alpha1.HTTPEndpointSpec{
+			BaseURL:       urls[0],
+			BaseURLs:      urls[1:],
+			LoadBalancing: &httpEndpointV1alpha1.LoadBalancing{Strategy: strategy},
+		},
+	}
+}
+
+func TestEndpointBalancerPick(t *testing.T) {
+	t.Run("round robin", func(t *testing.T) {
+		b, err := newEndpointBalancer(newBalancerTestEndpoint("", "http://a", "http://b", "http://c"), http.DefaultClient)
+		require.NoError(t, err)
+
+		var picked []string
+		for i := 0; i < 6; i++ {
+			target := b.pick()
+			picked = append(picked, target.url)
+			target.release()
+		}
+		assert.Equal(t, []string{"http://a", "http://b", "http://c", "http://a", "http://b", "http://c"}, picked)
+	})
+
+	t.Run("least outstanding", func(t *testing.T) {
+		b, err := newEndpointBalancer(newBalancerTestEndpoint(httpEndpointV1alpha1.LoadBalancingLeastOutstanding, "http://a", "http://b"), http.DefaultClient)
+		require.NoError(t, err)
+
+		first := b.pick()
+		second := b.pick()
+		assert.NotEqual(t, first.url, second.url)
+
+		first.release()
+		assert.Equal(t, first.url, b.pick().url)
+	})
+
+	t.Run("priority fails over to the next healthy URL", func(t *testing.T) {
+		b, err := newEndpointBalancer(newBalancerTestEndpoint(httpEndpointV1alpha1.LoadBalancingPriority, "http://a", "http://b", "http://c"), http.DefaultClient)
+		require.NoError(t, err)
+
+		assert.Equal(t, "http://a", b.pick().url)
+		b.targets[0].healthy.Store(false)
+		assert.Equal(t, "http://b", b.pick().url)
+	})
+
+	t.Run("all URLs ejected", func(t *testing.T) {
+		b, err := newEndpointBalancer(newBalancerTestEndpoint(httpEndpointV1alpha1.LoadBalancingPriority, "http://a", "http://b"), http.DefaultClient)
+		require.NoError(t, err)
+
+		b.targets[0].healthy.Store(false)
+		b.targets[1].healthy.Store(false)
+		assert.Equal(t, "http://a", b.pick().url)
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		_, err := newEndpointBalancer(newBalancerTestEndpoint("random", "http://a", "http://b"), http.DefaultClient)
+		require.Error(t, err)
+	})
+}
+
+func TestEndpointBalancerRecordProbe(t *testing.T) {
+	b, err := newEndpointBalancer(newBalancerTestEndpoint("", "http://a", "http://b"), http.DefaultClient)
+	require.NoError(t, err)
+	target := b.targets[0]
+
+	b.recordProbe(target, false, 2, 2)
+	assert.True(t, target.healthy.Load())
+	b.recordProbe(target, false, 2, 2)
+	assert.False(t, target.healthy.Load())
+
+	b.recordProbe(target, true, 2, 2)
+	assert.False(t, target.healthy.Load())
+	b.recordProbe(target, true, 2, 2)
+	assert.True(t, target.healthy.Load())
+}
+
+func TestInvokeMethodHTTPEndpointBalancing(t *testing.T) {
+	newServer := func(healthy *atomic.Bool, calls *atomic.Int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/healthz" {
+				if !healthy.Load() {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+				return
+			}
+			calls.Add(1)
+			io.WriteString(w, "ok")
+		}))
+	}
+
+	var healthyA, healthyB atomic.Bool
+	var callsA, callsB atomic.Int32
+	healthyA.Store(true)
+	healthyB.Store(true)
+	serverA := newServer(&healthyA, &callsA)
+	defer serverA.Close()
+	serverB := newServer(&healthyB, &callsB)
+	defer serverB.Close()
+
+	endpoint := newBalancerTestEndpoint(httpEndpointV1alpha1.LoadBalancingRoundRobin, serverA.URL, serverB.URL)
+	endpoint.Spec.HealthCheck = &httpEndpointV1alpha1.HealthCheck{
+		Path:             "/healthz",
+		Interval:         "10ms",
+		FailureThreshold: 1,
+	}
+	store := compstore.New()
+	store.AddHTTPEndpoint(endpoint)
+
+	c := Channel{
+		client:    http.DefaultClient,
+		compStore: store,
+	}
+	defer func() {
+		for _, b := range c.balancers {
+			b.close()
+		}
+	}()
+
+	invoke := func() {
+		req := invokev1.NewInvokeMethodRequest("method").WithHTTPExtension(http.MethodGet, "")
+		defer req.Close()
+		resp, err := c.InvokeMethod(context.Background(), req, "myendpoint")
+		require.NoError(t, err)
+		defer resp.Close()
+		assert.Equal(t, int32(http.StatusOK), resp.Status().Code)
+	}
+
+	for i := 0; i < 4; i++ {
+		invoke()
+	}
+	assert.Equal(t, int32(2), callsA.Load())
+	assert.Equal(t, int32(2), callsB.Load())
+
+	healthyA.Store(false)
+	assert.Eventually(t, func() bool {
+		return !c.balancers["myendpoint"].targets[0].healthy.Load()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		invoke()
+	}
+	assert.Equal(t, int32(2), callsA.Load())
+	assert.Equal(t, int32(6), callsB.Load())
+
+	healthyA.Store(true)
+	assert.Eventually(t, func() bool {
+		return c.balancers["myendpoint"].targets[0].healthy.Load()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for _, target := range c.balancers["myendpoint"].targets {
+		assert.Equal(t, int64(0), target.outstanding.Load())
+	}
+}
@@ -17,17 +17,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	commonapi "github.com/dapr/dapr/pkg/apis/common"
+	httpEndpointV1alpha1 "github.com/dapr/dapr/pkg/apis/httpEndpoint/v1alpha1"
 	"github.com/dapr/dapr/pkg/apphealth"
 	"github.com/dapr/dapr/pkg/channel"
 	"github.com/dapr/dapr/pkg/config"
@@ -65,6 +69,12 @@ type Channel struct {
 	appHealthCheckPath    string
 	appHealth             *apphealth.AppHealth
 	pipeline              httpMiddleware.Pipeline
+
+	// Balancers of the HTTP endpoints with multiple base URLs or health
+	// checks, keyed by endpoint name.
+	balancers     map[string]*endpointBalancer
+	balancersLock sync.Mutex
+	closed        bool
 }
 
 // ChannelConfiguration is the configuration used to create an HTTP AppChannel.
@@ -213,10 +223,14 @@ func (h *Channel) HealthProbe(ctx context.Context) (bool, error) {
 }
 
 func (h *Channel) invokeMethodV1(ctx context.Context, req *invokev1.InvokeMethodRequest, appID string) (*invokev1.InvokeMethodResponse, error) {
-	channelReq, err := h.constructRequest(ctx, req, appID)
+	channelReq, target, err := h.constructRequest(ctx, req, appID)
 	if err != nil {
 		return nil, err
 	}
+	if target != nil {
+		defer target.release()
+		diag.DefaultMonitoring.ServiceInvocationEndpointRequestSent(appID, target.url)
+	}
 
 	if h.ch != nil {
 		h.ch <- struct{}{}
@@ -237,12 +251,17 @@ func (h *Channel) invokeMethodV1(ctx context.Context, req *invokev1.InvokeMethod
 		rw := &RWRecorder{
 			W: &bytes.Buffer{},
 		}
+		var upgraded *http.Response
 		execPipeline := h.pipeline.Apply(http.HandlerFunc(func(wr http.ResponseWriter, r *http.Request) {
 			// Send request to user application
 			// (Body is closed below, but linter isn't detecting that)
 			//nolint:bodyclose
 			clientResp, clientErr := h.client.Do(r)
-			if clientResp != nil {
+			if clientResp != nil && clientResp.StatusCode == http.StatusSwitchingProtocols {
+				// The body of upgraded connections can't be recorded
+				upgraded = clientResp
+				wr.WriteHeader(clientResp.StatusCode)
+			} else if clientResp != nil {
 				copyHeader(wr.Header(), clientResp.Header)
 				wr.WriteHeader(clientResp.StatusCode)
 				_, _ = io.Copy(wr, clientResp.Body)
@@ -252,7 +271,11 @@ func (h *Channel) invokeMethodV1(ctx context.Context, req *invokev1.InvokeMethod
 			}
 		}))
 		execPipeline.ServeHTTP(rw, channelReq)
-		resp = rw.Result() //nolint:bodyclose
+		if upgraded != nil {
+			resp = upgraded
+		} else {
+			resp = rw.Result() //nolint:bodyclose
+		}
 	} else {
 		// Send request to user application
 		// (Body is closed below, but linter isn't detecting that)
@@ -271,26 +294,41 @@ func (h *Channel) invokeMethodV1(ctx context.Context, req *invokev1.InvokeMethod
 
 	if err != nil {
 		diag.DefaultHTTPMonitoring.ClientRequestCompleted(ctx, strconv.Itoa(http.StatusInternalServerError), contentLength, elapsedMs)
+		if target != nil {
+			diag.DefaultMonitoring.ServiceInvocationEndpointResponseReceived(appID, target.url, http.StatusInternalServerError, startRequest)
+		}
 		return nil, err
 	}
 
-	rsp, err := h.parseChannelResponse(req, resp)
+	rsp, err := h.parseChannelResponse(ctx, req, resp)
 	if err != nil {
 		diag.DefaultHTTPMonitoring.ClientRequestCompleted(ctx, strconv.Itoa(http.StatusInternalServerError), contentLength, elapsedMs)
+		if target != nil {
+			diag.DefaultMonitoring.ServiceInvocationEndpointResponseReceived(appID, target.url, http.StatusInternalServerError, startRequest)
+		}
 		return nil, err
 	}
 
 	diag.DefaultHTTPMonitoring.ClientRequestCompleted(ctx, strconv.Itoa(int(rsp.Status().Code)), contentLength, elapsedMs)
+	if target != nil {
+		diag.DefaultMonitoring.ServiceInvocationEndpointResponseReceived(appID, target.url, rsp.Status().Code, startRequest)
+	}
 
 	return rsp, nil
 }
 
-func (h *Channel) constructRequest(ctx context.Context, req *invokev1.InvokeMethodRequest, appID string) (*http.Request, error) {
+// constructRequest builds the request to the app or HTTP endpoint.
+// If the base URL of an HTTP endpoint was picked by a balancer, the target is
+// returned and must be released once the request completes.
+func (h *Channel) constructRequest(ctx context.Context, req *invokev1.InvokeMethodRequest, appID string) (*http.Request, *endpointTarget, error) {
 	// Construct app channel URI: VERB http://localhost:3000/method?query1=value1
 	msg := req.Message()
 	verb := msg.HttpExtension.Verb.String()
 	method := msg.Method
-	var headers []commonapi.NameValuePair
+	var (
+		headers []commonapi.NameValuePair
+		target  *endpointTarget
+	)
 
 	uri := strings.Builder{}
 
@@ -300,7 +338,16 @@ func (h *Channel) constructRequest(ctx context.Context, req *invokev1.InvokeMeth
 		if strings.HasPrefix(appID, "https://") || strings.HasPrefix(appID, "http://") {
 			uri.WriteString(appID)
 		} else if endpoint, ok := h.compStore.GetHTTPEndpoint(appID); ok {
-			uri.WriteString(endpoint.Spec.BaseURL)
+			baseURL := endpoint.Spec.BaseURL
+			if needsEndpointBalancer(endpoint) {
+				b, err := h.endpointBalancer(endpoint)
+				if err != nil {
+					return nil, nil, err
+				}
+				target = b.pick()
+				baseURL = target.url
+			}
+			uri.WriteString(baseURL)
 			headers = endpoint.Spec.Headers
 		} else {
 			uri.WriteString(h.baseAddress)
@@ -320,9 +367,18 @@ func (h *Channel) constructRequest(ctx context.Context, req *invokev1.InvokeMeth
 		uri.WriteString(qs)
 	}
 
-	channelReq, err := http.NewRequestWithContext(ctx, verb, uri.String(), req.RawData())
+	// The data of upgrade requests is sent over the upgraded connection
+	var body io.Reader = http.NoBody
+	if !req.IsUpgrade() {
+		body = req.RawData()
+	}
+
+	channelReq, err := http.NewRequestWithContext(ctx, verb, uri.String(), body)
 	if err != nil {
-		return nil, err
+		if target != nil {
+			target.release()
+		}
+		return nil, nil, err
 	}
 
 	// Recover headers
@@ -342,7 +398,10 @@ func (h *Channel) constructRequest(ctx context.Context, req *invokev1.InvokeMeth
 	if cl := channelReq.Header.Get(invokev1.ContentLengthHeader); cl != "" {
 		v, err := strconv.ParseInt(cl, 10, 64)
 		if err != nil {
-			return nil, err
+			if target != nil {
+				target.release()
+			}
+			return nil, nil, err
 		}
 
 		channelReq.ContentLength = v
@@ -361,17 +420,89 @@ func (h *Channel) constructRequest(ctx context.Context, req *invokev1.InvokeMeth
 		channelReq.Header.Set(securityConsts.APITokenHeader, h.appHeaderToken)
 	}
 
-	return channelReq, nil
+	return channelReq, target, nil
+}
+
+// Close stops the health probes of the HTTP endpoints balanced by the channel.
+// Requests in flight are not interrupted.
+func (h *Channel) Close() error {
+	h.balancersLock.Lock()
+	defer h.balancersLock.Unlock()
+
+	h.closed = true
+	for name, b := range h.balancers {
+		go b.close()
+		delete(h.balancers, name)
+	}
+	return nil
+}
+
+// StartEndpoint starts balancing the requests of the HTTP endpoint and probing
+// its base URLs, if it has several of them or a health check. The balancer is
+// stopped when the channel is closed.
+func (h *Channel) StartEndpoint(endpoint httpEndpointV1alpha1.HTTPEndpoint) error {
+	if !needsEndpointBalancer(endpoint) {
+		return nil
+	}
+	_, err := h.endpointBalancer(endpoint)
+	return err
+}
+
+// endpointBalancer returns the balancer of an HTTP endpoint, creating it if
+// needed or if the endpoint's base URLs, strategy or health check changed.
+func (h *Channel) endpointBalancer(endpoint httpEndpointV1alpha1.HTTPEndpoint) (*endpointBalancer, error) {
+	spec := endpointBalancerSpec(endpoint)
+
+	h.balancersLock.Lock()
+	defer h.balancersLock.Unlock()
+
+	b, ok := h.balancers[endpoint.Name]
+	if ok && b.spec == spec {
+		return b, nil
+	}
+
+	nb, err := newEndpointBalancer(endpoint, h.client)
+	if err != nil {
+		return nil, err
+	}
+	if h.closed {
+		// Requests that started before the channel was closed still get a
+		// target, but the balancer's probes are stopped right away.
+		go nb.close()
+		return nb, nil
+	}
+	if ok {
+		// In-flight requests hold their own target, so the old balancer can be
+		// stopped right away.
+		go b.close()
+	}
+	if h.balancers == nil {
+		h.balancers = make(map[string]*endpointBalancer)
+	}
+	h.balancers[endpoint.Name] = nb
+	return nb, nil
 }
 
-func (h *Channel) parseChannelResponse(req *invokev1.InvokeMethodRequest, channelResp *http.Response) (*invokev1.InvokeMethodResponse, error) {
+func (h *Channel) parseChannelResponse(ctx context.Context, req *invokev1.InvokeMethodRequest, channelResp *http.Response) (*invokev1.InvokeMethodResponse, error) {
 	contentType := channelResp.Header.Get("content-type")
 
 	// Limit response body if needed
 	var body io.ReadCloser
-	if h.maxResponseBodySizeMB > 0 {
+	switch {
+	case channelResp.StatusCode == http.StatusSwitchingProtocols:
+		// The app upgraded the connection: the data of the request is written
+		// to it, and the data of the response is read from it
+		rwc, ok := channelResp.Body.(io.ReadWriteCloser)
+		if !ok {
+			channelResp.Body.Close()
+			return nil, errors.New("the connection upgraded by the app is not writable")
+		}
+		conn := &upgradedConn{ReadWriteCloser: rwc}
+		go writeUpgradedConn(ctx, conn, req.RawData())
+		body = conn
+	case h.maxResponseBodySizeMB > 0:
 		body = streamutils.LimitReadCloser(channelResp.Body, int64(h.maxResponseBodySizeMB)<<20)
-	} else {
+	default:
 		body = channelResp.Body
 	}
 
@@ -385,6 +516,49 @@ func (h *Channel) parseChannelResponse(req *invokev1.InvokeMethodRequest, channe
 	return rsp, nil
 }
 
+// upgradedConn is a connection upgraded by the app. Reading from it after it's
+// closed returns io.EOF, as the tunnel is over.
+type upgradedConn struct {
+	io.ReadWriteCloser
+	closed atomic.Bool
+}
+
+func (c *upgradedConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	if err != nil && c.closed.Load() {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (c *upgradedConn) Close() error {
+	c.closed.Store(true)
+	return c.ReadWriteCloser.Close()
+}
+
+// writeUpgradedConn copies the data of an upgrade request to the connection
+// upgraded by the app. The connection is closed once the caller stops sending
+// data or the invocation is canceled.
+func writeUpgradedConn(ctx context.Context, conn *upgradedConn, data io.Reader) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if data != nil {
+		_, err := io.Copy(conn, data)
+		if err != nil {
+			log.Debugf("Failed to write to the upgraded connection: %v", err)
+		}
+	}
+	conn.Close()
+}
+
 func copyHeader(dst http.Header, src http.Header) {
 	for k, vv := range src {
 		for _, v := range vv {
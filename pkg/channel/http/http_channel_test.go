@@ -404,6 +404,24 @@ func TestInvokeMethodHeaders(t *testing.T) {
 	})
 }
 
+// testUpgradeEchoHandler upgrades the connection to a protocol that sends back the data it receives
+type testUpgradeEchoHandler struct{}
+
+func (t *testUpgradeEchoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("upgrade") != "echo" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	brw.Flush()
+	io.Copy(conn, brw)
+}
+
 func TestInvokeMethod(t *testing.T) {
 	th := &testQueryStringHandler{t: t, serverURL: ""}
 	ctx := context.Background()
@@ -459,6 +477,46 @@ func TestInvokeMethod(t *testing.T) {
 	})
 }
 
+func TestInvokeMethodUpgrade(t *testing.T) {
+	server := httptest.NewServer(&testUpgradeEchoHandler{})
+	defer server.Close()
+
+	c := Channel{
+		baseAddress: server.URL,
+		client:      http.DefaultClient,
+		compStore:   compstore.New(),
+		tracingSpec: &config.TracingSpec{
+			SamplingRate: "0",
+		},
+	}
+
+	pr, pw := io.Pipe()
+	fakeReq := invokev1.NewInvokeMethodRequest("method").
+		WithHTTPExtension(http.MethodGet, "").
+		WithMetadata(map[string][]string{"Connection": {"Upgrade"}, "Upgrade": {"echo"}}).
+		WithRawData(pr)
+	defer fakeReq.Close()
+	require.True(t, fakeReq.IsUpgrade())
+
+	resp, err := c.InvokeMethod(context.Background(), fakeReq, "")
+	require.NoError(t, err)
+	defer resp.Close()
+	require.True(t, resp.IsUpgrade())
+
+	// Data flows in both directions over the upgraded connection
+	_, err = pw.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(resp.RawData(), buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+
+	// The connection is closed when the caller is done
+	pw.Close()
+	_, err = io.ReadAll(resp.RawData())
+	require.NoError(t, err)
+}
+
 func TestInvokeMethodMaxConcurrency(t *testing.T) {
 	ctx := context.Background()
 	t.Run("single concurrency", func(t *testing.T) {
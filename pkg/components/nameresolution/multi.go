@@ -0,0 +1,205 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nameresolution
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grandcat/zeroconf"
+
+	nr "github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// instancesRefreshInterval is the time after which the instances of an
+	// app are looked up again.
+	instancesRefreshInterval = 5 * time.Second
+	// instancesTTL is how long the instances of an app are returned after
+	// they were last looked up.
+	instancesTTL = 30 * time.Second
+	// instancesLookupTimeout is the timeout of a lookup of the instances of
+	// an app; mDNS browses the network for this whole duration.
+	instancesLookupTimeout = time.Second
+)
+
+// ResolverMulti is implemented by name resolvers that can return the
+// addresses of all the instances of an app.
+type ResolverMulti interface {
+	// ResolveIDMulti returns the addresses of all the instances of an app.
+	// An empty list means the instances are not known yet.
+	ResolveIDMulti(ctx context.Context, req nr.ResolveRequest) ([]string, error)
+}
+
+// lookupInstancesFn returns the addresses of all the instances of an app.
+type lookupInstancesFn func(ctx context.Context, req nr.ResolveRequest) ([]string, error)
+
+type appInstances struct {
+	addresses  []string
+	updated    time.Time
+	refreshing bool
+}
+
+// resolverMulti adds ResolverMulti to a name resolver. The instances of the
+// apps are looked up in the background, so resolving them never blocks.
+type resolverMulti struct {
+	nr.Resolver
+	lookup lookupInstancesFn
+	logger logger.Logger
+
+	lock sync.Mutex
+	apps map[string]*appInstances
+}
+
+func newResolverMulti(resolver nr.Resolver, lookup lookupInstancesFn, logger logger.Logger) *resolverMulti {
+	return &resolverMulti{
+		Resolver: resolver,
+		lookup:   lookup,
+		logger:   logger,
+		apps:     make(map[string]*appInstances),
+	}
+}
+
+// ResolveIDMulti returns the instances of the app looked up last, and starts
+// a new lookup if they are stale.
+func (r *resolverMulti) ResolveIDMulti(_ context.Context, req nr.ResolveRequest) ([]string, error) {
+	key := req.ID + "." + req.Namespace
+	now := time.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	app, ok := r.apps[key]
+	if !ok {
+		app = &appInstances{}
+		r.apps[key] = app
+	}
+	if !app.refreshing && now.Sub(app.updated) > instancesRefreshInterval {
+		app.refreshing = true
+		go r.refresh(key, req)
+	}
+	if now.Sub(app.updated) > instancesTTL {
+		return nil, nil
+	}
+	return app.addresses, nil
+}
+
+func (r *resolverMulti) refresh(key string, req nr.ResolveRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), instancesLookupTimeout)
+	defer cancel()
+	addresses, err := r.lookup(ctx, req)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	app := r.apps[key]
+	app.refreshing = false
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Warnf("Failed to look up the instances of app %s: %v", req.ID, err)
+		}
+		return
+	}
+	if len(addresses) == 0 {
+		// The app is no longer resolved: forget it until it's requested again.
+		delete(r.apps, key)
+		return
+	}
+	app.addresses = addresses
+	app.updated = time.Now()
+}
+
+// WithKubernetesInstances adds ResolverMulti to the Kubernetes name resolver.
+// The instances are the addresses of the headless service of the app.
+func WithKubernetesInstances(factory FactoryMethod) FactoryMethod {
+	return func(l logger.Logger) nr.Resolver {
+		resolver := factory(l)
+		return newResolverMulti(resolver, func(ctx context.Context, req nr.ResolveRequest) ([]string, error) {
+			address, err := resolver.ResolveID(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			host, port, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %s: %w", address, err)
+			}
+			ips, err := net.DefaultResolver.LookupHost(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			addresses := make([]string, len(ips))
+			for i, ip := range ips {
+				addresses[i] = net.JoinHostPort(ip, port)
+			}
+			return addresses, nil
+		}, l)
+	}
+}
+
+// WithMDNSInstances adds ResolverMulti to the mDNS name resolver. The
+// instances are the ones answering a browse of the network for the app.
+func WithMDNSInstances(factory FactoryMethod) FactoryMethod {
+	return func(l logger.Logger) nr.Resolver {
+		return newResolverMulti(factory(l), browseMDNS, l)
+	}
+}
+
+// browseMDNS browses the network for the instances of an app until ctx is
+// done. Like the mDNS resolver, it prefers the IPv4 address of an instance.
+func browseMDNS(ctx context.Context, req nr.ResolveRequest) ([]string, error) {
+	resolver, err := zeroconf.NewResolver(zeroconf.SelectIPTraffic(zeroconf.IPv4AndIPv6))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(chan *zeroconf.ServiceEntry, 16)
+	if err = resolver.Browse(ctx, req.ID, "local.", entries); err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	seen := make(map[string]struct{})
+	handleEntry := func(entry *zeroconf.ServiceEntry) {
+		if len(entry.Text) == 0 || entry.Text[0] != req.ID {
+			return
+		}
+		var addr string
+		switch {
+		case len(entry.AddrIPv4) > 0:
+			addr = entry.AddrIPv4[0].String() + ":" + strconv.Itoa(entry.Port)
+		case len(entry.AddrIPv6) > 0:
+			addr = entry.AddrIPv6[0].String() + ":" + strconv.Itoa(entry.Port)
+		default:
+			return
+		}
+		if _, ok := seen[addr]; !ok {
+			seen[addr] = struct{}{}
+			addresses = append(addresses, addr)
+		}
+	}
+	for {
+		select {
+		case entry := <-entries:
+			handleEntry(entry)
+		case <-ctx.Done():
+			for len(entries) > 0 {
+				handleEntry(<-entries)
+			}
+			return addresses, nil
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nameresolution
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nr "github.com/dapr/components-contrib/nameresolution"
+	"github.com/dapr/kit/logger"
+)
+
+type fakeResolver struct {
+	nr.Resolver
+}
+
+func (fakeResolver) ResolveID(context.Context, nr.ResolveRequest) (string, error) {
+	return "127.0.0.1:50002", nil
+}
+
+func TestResolverMulti(t *testing.T) {
+	var lookups atomic.Int32
+	addresses := []string{"10.0.0.1:50002", "10.0.0.2:50002"}
+	r := newResolverMulti(nil, func(ctx context.Context, req nr.ResolveRequest) ([]string, error) {
+		lookups.Add(1)
+		if req.ID == "gone" {
+			return nil, nil
+		}
+		return addresses, nil
+	}, logger.NewLogger("test"))
+	req := nr.ResolveRequest{ID: "app1", Namespace: "default", Port: 50002}
+
+	t.Run("instances are looked up in the background", func(t *testing.T) {
+		res, err := r.ResolveIDMulti(context.Background(), req)
+		require.NoError(t, err)
+		assert.Empty(t, res)
+
+		assert.Eventually(t, func() bool {
+			res, _ = r.ResolveIDMulti(context.Background(), req)
+			return len(res) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, addresses, res)
+		assert.Equal(t, int32(1), lookups.Load())
+	})
+
+	t.Run("stale instances are looked up again", func(t *testing.T) {
+		r.lock.Lock()
+		r.apps["app1.default"].updated = time.Now().Add(-2 * instancesRefreshInterval)
+		r.lock.Unlock()
+
+		res, err := r.ResolveIDMulti(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, addresses, res)
+		assert.Eventually(t, func() bool {
+			return lookups.Load() == 2
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("apps no longer resolved are forgotten", func(t *testing.T) {
+		_, err := r.ResolveIDMulti(context.Background(), nr.ResolveRequest{ID: "gone", Namespace: "default"})
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			_, ok := r.apps["gone.default"]
+			return !ok
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestWithKubernetesInstances(t *testing.T) {
+	factory := WithKubernetesInstances(func(logger.Logger) nr.Resolver {
+		return fakeResolver{}
+	})
+	r := factory(logger.NewLogger("test"))
+	multi, ok := r.(ResolverMulti)
+	require.True(t, ok)
+
+	req := nr.ResolveRequest{ID: "app1", Namespace: "default", Port: 50002}
+	addr, err := r.ResolveID(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:50002", addr)
+
+	assert.Eventually(t, func() bool {
+		res, _ := multi.ResolveIDMulti(context.Background(), req)
+		return assert.ObjectsAreEqual([]string{"127.0.0.1:50002"}, res)
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -16,6 +16,7 @@ package state
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 
 	"github.com/cenkalti/backoff/v4"
@@ -40,12 +41,45 @@ func requestWithKey[T stateRequestConstraint](reqs []T, key string) int {
 	return -1
 }
 
+// BulkStoreResult is the outcome of the operation on a key of a bulk set or delete.
+type BulkStoreResult struct {
+	Key string
+	// Nil if the operation succeeded.
+	Error error
+}
+
 // PerformBulkStoreOperation performs a bulk set or delete using resiliency, retrying operations that fail only when they can be retried.
 func PerformBulkStoreOperation[T stateRequestConstraint](
 	ctx context.Context, reqs []T, policyDef *resiliency.PolicyDefinition, opts state.BulkStoreOpts,
 	execSingle func(ctx context.Context, req *T) error,
 	execMulti func(ctx context.Context, reqs []T, opts state.BulkStoreOpts) error,
 ) error {
+	_, err := PerformBulkStoreOperationWithResults(ctx, reqs, policyDef, opts, execSingle, execMulti)
+	return err
+}
+
+// PerformBulkStoreOperationWithResults is like PerformBulkStoreOperation, and also returns the outcome of each operation, in the order of the requests.
+// The outcome of an operation is the one of the last attempt that included it.
+func PerformBulkStoreOperationWithResults[T stateRequestConstraint](
+	ctx context.Context, reqs []T, policyDef *resiliency.PolicyDefinition, opts state.BulkStoreOpts,
+	execSingle func(ctx context.Context, req *T) error,
+	execMulti func(ctx context.Context, reqs []T, opts state.BulkStoreOpts) error,
+) ([]BulkStoreResult, error) {
+	var (
+		outcomesLock sync.Mutex
+		outcomes     = make(map[string]error, len(reqs))
+	)
+	record := func(attempted []T, err error, keyErrs map[string]error) {
+		outcomesLock.Lock()
+		defer outcomesLock.Unlock()
+		for _, r := range attempted {
+			outcomes[r.GetKey()] = err
+		}
+		for k, e := range keyErrs {
+			outcomes[k] = e
+		}
+	}
+
 	var reqsAtomic atomic.Pointer[[]T]
 	reqsAtomic.Store(&reqs)
 	policyRunner := resiliency.NewRunnerWithOptions(ctx,
@@ -77,6 +111,7 @@ func PerformBulkStoreOperation[T stateRequestConstraint](
 		// In this case, we never need to filter out operations
 		if len(rReqs) == 1 {
 			rErr = execSingle(ctx, &rReqs[0])
+			record(rReqs, rErr, nil)
 			if rErr != nil {
 				// Check if it's an etag error, which is not retriable
 				// In that case, wrap inside a permanent backoff error
@@ -93,17 +128,20 @@ func PerformBulkStoreOperation[T stateRequestConstraint](
 
 		// If there's no error, short-circuit
 		if rErr == nil {
+			record(rReqs, nil, nil)
 			return nil, nil
 		}
 
 		// Check if we have a multi-error; if not, return the error as-is
 		mErr, ok := rErr.(interface{ Unwrap() []error })
 		if !ok {
+			record(rReqs, rErr, nil)
 			return nil, rErr
 		}
 		errs := mErr.Unwrap()
 		if len(errs) == 0 {
 			// Should never happen…
+			record(rReqs, rErr, nil)
 			return nil, rErr
 		}
 
@@ -111,13 +149,16 @@ func PerformBulkStoreOperation[T stateRequestConstraint](
 		// We can retry if at least one error is not an etag error
 		var canRetry, etagInvalid bool
 		retry := make([]string, 0, len(errs))
+		keyErrs := make(map[string]error, len(errs))
 		for _, e := range errs {
 			// Check if it's a BulkStoreError
 			// If not, we will cause all operations to be retried, because the error was not a multi BulkStoreError
 			var bse state.BulkStoreError
 			if !errors.As(e, &bse) {
+				record(rReqs, rErr, nil)
 				return nil, rErr
 			}
+			keyErrs[bse.Key()] = bse.Unwrap()
 
 			// If it's not an etag error, the operation can retry this failed item
 			if etagErr := bse.ETagError(); etagErr == nil {
@@ -139,8 +180,21 @@ func PerformBulkStoreOperation[T stateRequestConstraint](
 			}
 			rErr = backoff.Permanent(etagErr)
 		}
+		record(rReqs, nil, keyErrs)
 		return retry, rErr
 	})
 
-	return err
+	// Operations that were never attempted failed with the error of the runner
+	outcomesLock.Lock()
+	defer outcomesLock.Unlock()
+	res := make([]BulkStoreResult, len(reqs))
+	for i, r := range reqs {
+		res[i].Key = r.GetKey()
+		if e, ok := outcomes[res[i].Key]; ok {
+			res[i].Error = e
+		} else {
+			res[i].Error = err
+		}
+	}
+	return res, err
 }
@@ -324,3 +324,87 @@ func TestPerformBulkStoreOperation(t *testing.T) {
 		})
 	})
 }
+
+func TestPerformBulkStoreOperationWithResults(t *testing.T) {
+	simulatedErr := errors.New("simulated")
+	etagMismatchErr := state.NewETagError(state.ETagMismatch, simulatedErr)
+
+	res := resiliency.FromConfigurations(logger.NewLogger("test"), &resiliencyV1alpha1.Resiliency{
+		Spec: resiliencyV1alpha1.ResiliencySpec{
+			Policies: resiliencyV1alpha1.Policies{
+				Retries: map[string]resiliencyV1alpha1.Retry{
+					"singleRetry": {
+						MaxRetries: ptr.Of(1),
+						Policy:     "constant",
+						Duration:   "10ms",
+					},
+				},
+			},
+			Targets: resiliencyV1alpha1.Targets{
+				Components: map[string]resiliencyV1alpha1.ComponentPolicyNames{
+					"mystate": {
+						Outbound: resiliencyV1alpha1.PolicyNames{Retry: "singleRetry"},
+					},
+				},
+			},
+		},
+	})
+	policyDef := res.ComponentOutboundPolicy("mystate", resiliency.Statestore)
+	reqs := []state.SetRequest{
+		{Key: "key1"},
+		{Key: "key2"},
+		{Key: "key3"},
+	}
+
+	t.Run("outcome of the last attempt of each key", func(t *testing.T) {
+		count := atomic.Uint32{}
+		results, err := PerformBulkStoreOperationWithResults(context.Background(), reqs, policyDef, state.BulkStoreOpts{},
+			func(ctx context.Context, req *state.SetRequest) error {
+				// key1 is retried alone
+				require.Equal(t, "key1", req.Key)
+				count.Add(1)
+				return nil
+			},
+			func(ctx context.Context, req []state.SetRequest, opts state.BulkStoreOpts) error {
+				count.Add(1)
+				return errors.Join(
+					state.NewBulkStoreError("key1", simulatedErr),
+					state.NewBulkStoreError("key2", etagMismatchErr),
+				)
+			},
+		)
+		require.NoError(t, err)
+		require.Equal(t, uint32(2), count.Load())
+		require.Len(t, results, 3)
+		require.Equal(t, BulkStoreResult{Key: "key1"}, results[0])
+		require.Equal(t, "key2", results[1].Key)
+		require.ErrorIs(t, results[1].Error, etagMismatchErr)
+		require.Equal(t, BulkStoreResult{Key: "key3"}, results[2])
+	})
+
+	t.Run("all keys fail with errors that aren't per key", func(t *testing.T) {
+		results, err := PerformBulkStoreOperationWithResults(context.Background(), reqs, policyDef, state.BulkStoreOpts{},
+			nil, // The single method should not be invoked, so this will panic if it happens
+			func(ctx context.Context, req []state.SetRequest, opts state.BulkStoreOpts) error {
+				return simulatedErr
+			},
+		)
+		require.Equal(t, simulatedErr, err)
+		for _, r := range results {
+			require.Equal(t, simulatedErr, r.Error)
+		}
+	})
+
+	t.Run("parallelism of the store is passed", func(t *testing.T) {
+		require.NoError(t, SaveStateConfiguration("bulkstore", map[string]string{"bulkParallelism": "4"}))
+		_, err := PerformBulkStoreOperationWithResults(context.Background(), reqs, policyDef, GetBulkStoreOpts("bulkstore"),
+			nil, // The single method should not be invoked, so this will panic if it happens
+			func(ctx context.Context, req []state.SetRequest, opts state.BulkStoreOpts) error {
+				require.Equal(t, 4, opts.Parallelism)
+				return nil
+			},
+		)
+		require.NoError(t, err)
+		require.Error(t, SaveStateConfiguration("bulkstore", map[string]string{"bulkParallelism": "-1"}))
+	})
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+)
+
+// KeysLister is implemented by the state stores that can list the keys they
+// contain. It's preferred to queries to scan a store.
+type KeysLister interface {
+	ListKeys(ctx context.Context, token string, limit int) (keys []string, nextToken string, err error)
+}
+
+// CanListKeys returns true if the keys of the store can be listed, either
+// directly or with a query.
+func CanListKeys(store state.Store) bool {
+	if _, ok := store.(KeysLister); ok {
+		return true
+	}
+	_, ok := store.(state.Querier)
+	return ok
+}
+
+// ListKeys returns a page of the keys in the store, as they are saved, and the
+// token of the next page, empty on the last one.
+func ListKeys(ctx context.Context, store state.Store, token string, limit int) ([]string, string, error) {
+	if lister, ok := store.(KeysLister); ok {
+		return lister.ListKeys(ctx, token, limit)
+	}
+
+	res, err := store.(state.Querier).Query(ctx, &state.QueryRequest{
+		Query: query.Query{
+			QueryFields: query.QueryFields{
+				Page: query.Pagination{Limit: limit, Token: token},
+			},
+		},
+	})
+	if err != nil || res == nil {
+		return nil, "", err
+	}
+	keys := make([]string, len(res.Results))
+	for i, item := range res.Results {
+		keys[i] = item.Key
+	}
+	return keys, res.Token, nil
+}
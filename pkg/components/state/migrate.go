@@ -0,0 +1,426 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/kit/logger"
+)
+
+const defaultMigrationPageSize = 100
+
+var log = logger.NewLogger("dapr.state.migration")
+
+var (
+	// ErrInvalidKeyMigration is returned when a key migration can't be started.
+	ErrInvalidKeyMigration = errors.New("invalid key migration")
+	// ErrKeyMigrationRunning is returned when a key migration is started while
+	// another one is running on the same store.
+	ErrKeyMigrationRunning = errors.New("a key migration is already running")
+
+	errKeyExists = errors.New("key exists")
+	errNoETag    = errors.New("the state store returned no ETag, so the record can't be moved safely")
+)
+
+// KeyMigrationState is the state of a key migration.
+type KeyMigrationState string
+
+const (
+	KeyMigrationRunning   KeyMigrationState = "running"
+	KeyMigrationCompleted KeyMigrationState = "completed"
+	KeyMigrationFailed    KeyMigrationState = "failed"
+)
+
+// KeyMigrationOptions configures the migration of the keys of an app from a
+// key prefix strategy to another.
+type KeyMigrationOptions struct {
+	// Strategy the keys are saved with. Defaults to the one of the store.
+	From string `json:"from,omitempty"`
+	// Strategy to save the keys with.
+	To string `json:"to"`
+	// Group for the group strategy and templates. Defaults to the one of the
+	// store.
+	Group string `json:"group,omitempty"`
+	// Number of keys requested to the store at a time.
+	PageSize int `json:"pageSize,omitempty"`
+	// Count the keys to migrate without changing them.
+	DryRun bool `json:"dryRun,omitempty"`
+	// Called after records were moved, to invalidate the values cached for
+	// the store.
+	OnMigrated func() `json:"-"`
+}
+
+// KeyMigrationResult reports the outcome of a key migration.
+type KeyMigrationResult struct {
+	// Number of keys scanned, migrated, skipped because they weren't created
+	// by the strategy the keys are migrated from or were deleted meanwhile,
+	// not migrated because the new key already exists, and because of errors.
+	// Moving records can shift the pages of the stores that list keys by
+	// offset, so Scanned and Skipped may count some keys more than once.
+	Scanned   int64 `json:"scanned"`
+	Migrated  int64 `json:"migrated"`
+	Skipped   int64 `json:"skipped"`
+	Conflicts int64 `json:"conflicts"`
+	Failed    int64 `json:"failed"`
+}
+
+// KeyMigrationStatus reports the progress of a key migration running in the
+// background.
+type KeyMigrationStatus struct {
+	KeyMigrationResult
+	StoreName   string            `json:"storeName"`
+	State       KeyMigrationState `json:"state"`
+	DryRun      bool              `json:"dryRun"`
+	Error       string            `json:"error,omitempty"`
+	StartedAt   *time.Time        `json:"startedAt,omitempty"`
+	CompletedAt *time.Time        `json:"completedAt,omitempty"`
+}
+
+type keyMigration struct {
+	lock   sync.Mutex
+	status KeyMigrationStatus
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	migrationsLock sync.Mutex
+	migrations     = map[string]*keyMigration{}
+)
+
+func (m *keyMigration) snapshot() KeyMigrationStatus {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.status
+}
+
+// StartKeyMigration validates a key migration and runs it in the background,
+// until it completes or StopKeyMigration is called.
+func StartKeyMigration(store state.Store, storeName, appID string, opts KeyMigrationOptions) (KeyMigrationStatus, error) {
+	m, err := newKeyMigrator(store, storeName, appID, opts)
+	if err != nil {
+		return KeyMigrationStatus{}, err
+	}
+
+	migrationsLock.Lock()
+	defer migrationsLock.Unlock()
+	if km, ok := migrations[storeName]; ok && km.snapshot().State == KeyMigrationRunning {
+		return KeyMigrationStatus{}, ErrKeyMigrationRunning
+	}
+
+	now := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	km := &keyMigration{
+		status: KeyMigrationStatus{
+			StoreName: storeName,
+			State:     KeyMigrationRunning,
+			DryRun:    opts.DryRun,
+			StartedAt: &now,
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	m.onProgress = func(res KeyMigrationResult) {
+		km.lock.Lock()
+		km.status.KeyMigrationResult = res
+		km.lock.Unlock()
+	}
+	migrations[storeName] = km
+
+	go func() {
+		defer close(km.done)
+		defer cancel()
+		res, err := m.migrate(ctx)
+		now := time.Now()
+		km.lock.Lock()
+		km.status.KeyMigrationResult = res
+		km.status.CompletedAt = &now
+		if err != nil {
+			km.status.State = KeyMigrationFailed
+			km.status.Error = err.Error()
+		} else {
+			km.status.State = KeyMigrationCompleted
+		}
+		km.lock.Unlock()
+		if err != nil {
+			log.Errorf("Failed to migrate the keys of state store %s: %v", storeName, err)
+			return
+		}
+		log.Infof("Completed the migration of the keys of state store %s: %d keys scanned, %d migrated, %d conflicts, %d failed",
+			storeName, res.Scanned, res.Migrated, res.Conflicts, res.Failed)
+	}()
+	return km.snapshot(), nil
+}
+
+// GetKeyMigrationStatus returns the status of the last key migration of a
+// state store since the sidecar started, and false if there's none.
+func GetKeyMigrationStatus(storeName string) (KeyMigrationStatus, bool) {
+	migrationsLock.Lock()
+	km, ok := migrations[storeName]
+	migrationsLock.Unlock()
+	if !ok {
+		return KeyMigrationStatus{}, false
+	}
+	return km.snapshot(), true
+}
+
+// StopKeyMigration stops the key migration of a state store, if one is
+// running, and waits for it to return. It's called when the state store is
+// closed.
+func StopKeyMigration(storeName string) {
+	migrationsLock.Lock()
+	km, ok := migrations[storeName]
+	migrationsLock.Unlock()
+	if !ok {
+		return
+	}
+	km.cancel()
+	<-km.done
+}
+
+// MigrateKeys moves the records an app saved with a key prefix strategy to the
+// keys another strategy creates. Records are copied as they are, so they can
+// be read after switching the store to the new strategy.
+func MigrateKeys(ctx context.Context, store state.Store, storeName, appID string, opts KeyMigrationOptions) (KeyMigrationResult, error) {
+	m, err := newKeyMigrator(store, storeName, appID, opts)
+	if err != nil {
+		return KeyMigrationResult{}, err
+	}
+	return m.migrate(ctx)
+}
+
+type keyMigrator struct {
+	store      state.Store
+	storeName  string
+	appID      string
+	opts       KeyMigrationOptions
+	from, to   *StoreConfiguration
+	onProgress func(KeyMigrationResult)
+}
+
+func newKeyMigrator(store state.Store, storeName, appID string, opts KeyMigrationOptions) (*keyMigrator, error) {
+	if !CanListKeys(store) {
+		return nil, fmt.Errorf("%w: state store %s supports neither querying nor listing keys", ErrInvalidKeyMigration, storeName)
+	}
+
+	current := getStateConfiguration(storeName)
+	if opts.From == "" {
+		opts.From = current.keyPrefixStrategy
+	}
+	if opts.Group == "" {
+		opts.Group = current.keyPrefixGroup
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultMigrationPageSize
+	}
+	from, err := NewStoreConfiguration(opts.From, opts.Group)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyMigration, err)
+	}
+	if opts.To == "" {
+		return nil, fmt.Errorf("%w: the strategy to migrate to is required", ErrInvalidKeyMigration)
+	}
+	to, err := NewStoreConfiguration(opts.To, opts.Group)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyMigration, err)
+	}
+	return &keyMigrator{
+		store:     store,
+		storeName: storeName,
+		appID:     appID,
+		opts:      opts,
+		from:      from,
+		to:        to,
+	}, nil
+}
+
+// migrate moves the records page by page. Moving records can shift the pages
+// of the store, so the keys are listed again until a pass moves no record.
+func (m *keyMigrator) migrate(ctx context.Context) (KeyMigrationResult, error) {
+	var res KeyMigrationResult
+	// Keys that can't be moved aren't retried by later passes.
+	done := make(map[string]struct{})
+	_, transactional := m.store.(state.TransactionalStore)
+	prefix, suffix := m.to.keyAffixes(m.storeName, m.appID)
+
+	for pass := 0; ; pass++ {
+		moved := false
+		token := ""
+		for {
+			keys, next, err := ListKeys(ctx, m.store, token, m.opts.PageSize)
+			if err != nil {
+				return res, fmt.Errorf("failed to list keys: %w", err)
+			}
+			pageMoved := false
+			for _, savedKey := range keys {
+				if _, ok := done[savedKey]; ok {
+					continue
+				}
+				key, ok := m.from.originalKey(savedKey, m.storeName, m.appID)
+				// Keys created by the strategy migrated to include the ones
+				// moved by this migration.
+				if _, isNew := m.to.originalKey(savedKey, m.storeName, m.appID); !ok || isNew {
+					if pass == 0 {
+						res.Scanned++
+						res.Skipped++
+					}
+					continue
+				}
+				res.Scanned++
+
+				migrated, err := migrateKey(ctx, m.store, savedKey, prefix+key+suffix, transactional, m.opts.DryRun)
+				switch {
+				case errors.Is(err, errKeyExists):
+					res.Conflicts++
+					done[savedKey] = struct{}{}
+				case err != nil:
+					if ctx.Err() != nil {
+						return res, ctx.Err()
+					}
+					log.Warnf("Failed to migrate key %s of state store %s: %v", savedKey, m.storeName, err)
+					res.Failed++
+					done[savedKey] = struct{}{}
+				case migrated:
+					res.Migrated++
+					pageMoved = true
+				default:
+					res.Skipped++
+				}
+			}
+			if pageMoved && !m.opts.DryRun {
+				moved = true
+				if m.opts.OnMigrated != nil {
+					m.opts.OnMigrated()
+				}
+			}
+			if m.onProgress != nil {
+				m.onProgress(res)
+			}
+			if next == "" || len(keys) == 0 {
+				break
+			}
+			token = next
+		}
+		if !moved {
+			return res, nil
+		}
+	}
+}
+
+// migrateKey moves a record to a new key, unless a record with that key
+// exists already. Records changed meanwhile are left in place.
+func migrateKey(ctx context.Context, store state.Store, oldKey, newKey string, transactional bool, dryRun bool) (bool, error) {
+	existing, err := store.Get(ctx, &state.GetRequest{Key: newKey})
+	if err != nil {
+		return false, err
+	}
+	if existing != nil && existing.Data != nil {
+		return false, errKeyExists
+	}
+
+	res, err := store.Get(ctx, &state.GetRequest{Key: oldKey})
+	if err != nil {
+		return false, err
+	}
+	if res == nil || res.Data == nil {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	if res.ETag == nil {
+		return false, errNoETag
+	}
+
+	// With first-write concurrency and no ETag, the record is only written if
+	// the key doesn't exist.
+	set := state.SetRequest{
+		Key:     newKey,
+		Value:   res.Data,
+		Options: state.SetStateOption{Concurrency: state.FirstWrite},
+	}
+	// Keep the time-to-live of the record
+	if exp, ok := res.Metadata[state.GetRespMetaKeyTTLExpireTime]; ok {
+		if t, pErr := time.Parse(time.RFC3339, exp); pErr == nil {
+			ttl := int64(math.Ceil(time.Until(t).Seconds()))
+			if ttl <= 0 {
+				// Expired
+				return false, nil
+			}
+			set.Metadata = map[string]string{metadata.TTLMetadataKey: strconv.FormatInt(ttl, 10)}
+		}
+	}
+	del := state.DeleteRequest{
+		Key:     oldKey,
+		ETag:    res.ETag,
+		Options: state.DeleteStateOption{Concurrency: state.FirstWrite},
+	}
+
+	if transactional {
+		err = store.(state.TransactionalStore).Multi(ctx, &state.TransactionalStateRequest{
+			Operations: []state.TransactionalStateOperation{set, del},
+		})
+		return checkMigrateErr(err)
+	}
+
+	if err = store.Set(ctx, &set); err != nil {
+		return checkMigrateErr(err)
+	}
+	// The ETag of the copy, unless it was written again already.
+	var copyETag *string
+	copied, err := store.Get(ctx, &state.GetRequest{Key: newKey})
+	if err != nil {
+		return false, fmt.Errorf("copied to %s, but failed to read the copy: %w", newKey, err)
+	}
+	if copied != nil && bytes.Equal(copied.Data, res.Data) {
+		copyETag = copied.ETag
+	}
+	if err = store.Delete(ctx, &del); err != nil {
+		var etagErr *state.ETagError
+		if errors.As(err, &etagErr) && etagErr.Kind() == state.ETagMismatch {
+			// The record changed after it was copied: put it back in place,
+			// unless the copy was written again too.
+			if copyETag != nil {
+				_ = store.Delete(ctx, &state.DeleteRequest{
+					Key:     newKey,
+					ETag:    copyETag,
+					Options: state.DeleteStateOption{Concurrency: state.FirstWrite},
+				})
+			}
+			return false, nil
+		}
+		return false, fmt.Errorf("copied to %s, but failed to delete the old record: %w", newKey, err)
+	}
+	return true, nil
+}
+
+// checkMigrateErr maps the ETag errors of moving a record: a mismatch means
+// that either the new key was created or the record changed meanwhile.
+func checkMigrateErr(err error) (bool, error) {
+	var etagErr *state.ETagError
+	if errors.As(err, &etagErr) && etagErr.Kind() == state.ETagMismatch {
+		return false, errKeyExists
+	}
+	return err == nil, err
+}
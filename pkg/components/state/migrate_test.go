@@ -0,0 +1,265 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+// migrationTestStore is an in-memory store that can list its keys.
+type migrationTestStore struct {
+	state.Store
+	lock    sync.Mutex
+	items   map[string][]byte
+	etags   map[string]int
+	noETags bool
+	// beforeDelete, if set, is called with the lock held before a delete.
+	beforeDelete func(key string)
+}
+
+func newMigrationTestStore(items map[string][]byte) *migrationTestStore {
+	return &migrationTestStore{items: items, etags: map[string]int{}}
+}
+
+func (s *migrationTestStore) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.items[req.Key]
+	if !ok {
+		return &state.GetResponse{}, nil
+	}
+	if s.noETags {
+		return &state.GetResponse{Data: data}, nil
+	}
+	etag := strconv.Itoa(s.etags[req.Key])
+	return &state.GetResponse{Data: data, ETag: &etag}, nil
+}
+
+// checkETag implements first-write concurrency; without an ETag, the key
+// must not exist. The caller must hold the lock.
+func (s *migrationTestStore) checkETag(key string, etag *string, concurrency string) error {
+	if concurrency != state.FirstWrite {
+		return nil
+	}
+	_, exists := s.items[key]
+	if (etag == nil && exists) || (etag != nil && (!exists || *etag != strconv.Itoa(s.etags[key]))) {
+		return state.NewETagError(state.ETagMismatch, nil)
+	}
+	return nil
+}
+
+func (s *migrationTestStore) Set(ctx context.Context, req *state.SetRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.checkETag(req.Key, req.ETag, req.Options.Concurrency); err != nil {
+		return err
+	}
+	s.items[req.Key] = req.Value.([]byte)
+	s.etags[req.Key]++
+	return nil
+}
+
+func (s *migrationTestStore) Delete(ctx context.Context, req *state.DeleteRequest) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.beforeDelete != nil {
+		s.beforeDelete(req.Key)
+	}
+	if err := s.checkETag(req.Key, req.ETag, req.Options.Concurrency); err != nil {
+		return err
+	}
+	delete(s.items, req.Key)
+	return nil
+}
+
+func (s *migrationTestStore) ListKeys(ctx context.Context, token string, limit int) ([]string, string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	keys := make([]string, 0, len(s.items))
+	for k := range s.items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(token)
+	end := start + limit
+	if end >= len(keys) {
+		return keys[start:], "", nil
+	}
+	return keys[start:end], strconv.Itoa(end), nil
+}
+
+func TestMigrateKeys(t *testing.T) {
+	require.NoError(t, SaveStateConfiguration("migration-store", map[string]string{strategyKey: strategyAppid, strategyGroupKey: "shop"}))
+
+	newStore := func() *migrationTestStore {
+		return newMigrationTestStore(map[string][]byte{
+			"app1||a":        []byte("1"),
+			"app1||b":        []byte("2"),
+			"app1||c":        []byte("3"),
+			"app2||a":        []byte("4"),
+			"app1||t||id||k": []byte("5"),
+			"shop-c":         []byte("6"),
+		})
+	}
+
+	t.Run("keys are moved to the new strategy", func(t *testing.T) {
+		store := newStore()
+		res, err := MigrateKeys(context.Background(), store, "migration-store", "app1", KeyMigrationOptions{
+			To:       "{group}-{key}",
+			PageSize: 2,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), res.Migrated)
+		assert.Equal(t, int64(1), res.Conflicts)
+		assert.Zero(t, res.Failed)
+		// Keys listed again because of the moves are skipped again.
+		assert.GreaterOrEqual(t, res.Scanned, int64(6))
+		assert.Equal(t, map[string][]byte{
+			"shop-a":         []byte("1"),
+			"shop-b":         []byte("2"),
+			"app1||c":        []byte("3"),
+			"app2||a":        []byte("4"),
+			"app1||t||id||k": []byte("5"),
+			"shop-c":         []byte("6"),
+		}, store.items)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		store := newStore()
+		res, err := MigrateKeys(context.Background(), store, "migration-store", "app1", KeyMigrationOptions{
+			To:     strategyGroup,
+			DryRun: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), res.Migrated)
+		assert.Equal(t, newStore().items, store.items)
+	})
+
+	t.Run("from another strategy", func(t *testing.T) {
+		store := newStore()
+		res, err := MigrateKeys(context.Background(), store, "migration-store", "app1", KeyMigrationOptions{
+			From: "{group}-{key}",
+			To:   strategyAppid,
+		})
+		require.NoError(t, err)
+		// The key exists already
+		assert.Equal(t, int64(1), res.Conflicts)
+		assert.Zero(t, res.Migrated)
+	})
+
+	t.Run("records without ETag are not moved", func(t *testing.T) {
+		store := newStore()
+		store.noETags = true
+		res, err := MigrateKeys(context.Background(), store, "migration-store", "app1", KeyMigrationOptions{To: "{group}-{key}"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), res.Failed)
+		assert.Equal(t, int64(1), res.Conflicts)
+		assert.Equal(t, newStore().items, store.items)
+	})
+
+	t.Run("records changed while moved are put back", func(t *testing.T) {
+		store := newMigrationTestStore(map[string][]byte{"app1||a": []byte("1")})
+		store.beforeDelete = func(key string) {
+			if key == "app1||a" {
+				store.items[key] = []byte("2")
+				store.etags[key]++
+			}
+		}
+		moved, err := migrateKey(context.Background(), store, "app1||a", "a", false, false)
+		require.NoError(t, err)
+		assert.False(t, moved)
+		assert.Equal(t, map[string][]byte{"app1||a": []byte("2")}, store.items)
+	})
+
+	t.Run("copies written again are kept", func(t *testing.T) {
+		store := newMigrationTestStore(map[string][]byte{"app1||a": []byte("1")})
+		store.beforeDelete = func(key string) {
+			if key == "app1||a" {
+				store.items[key] = []byte("2")
+				store.etags[key]++
+				store.items["a"] = []byte("3")
+				store.etags["a"]++
+			}
+		}
+		moved, err := migrateKey(context.Background(), store, "app1||a", "a", false, false)
+		require.NoError(t, err)
+		assert.False(t, moved)
+		assert.Equal(t, map[string][]byte{"app1||a": []byte("2"), "a": []byte("3")}, store.items)
+	})
+
+	t.Run("pages shifted by moved keys", func(t *testing.T) {
+		items := map[string][]byte{}
+		for i := 0; i < 25; i++ {
+			items["app1||k"+strconv.Itoa(i)] = []byte(strconv.Itoa(i))
+		}
+		store := newMigrationTestStore(items)
+		res, err := MigrateKeys(context.Background(), store, "migration-store", "app1", KeyMigrationOptions{To: strategyNone, PageSize: 5})
+		require.NoError(t, err)
+		assert.Equal(t, int64(25), res.Migrated)
+		assert.Zero(t, res.Failed)
+		assert.Len(t, store.items, 25)
+		for i := 0; i < 25; i++ {
+			assert.Equal(t, []byte(strconv.Itoa(i)), store.items["k"+strconv.Itoa(i)])
+		}
+	})
+
+	t.Run("in the background", func(t *testing.T) {
+		store := newStore()
+		var purged int
+		status, err := StartKeyMigration(store, "migration-store", "app1", KeyMigrationOptions{
+			To:         "{group}-{key}",
+			OnMigrated: func() { purged++ },
+		})
+		require.NoError(t, err)
+		assert.Equal(t, KeyMigrationRunning, status.State)
+
+		require.Eventually(t, func() bool {
+			status, _ = GetKeyMigrationStatus("migration-store")
+			return status.State != KeyMigrationRunning
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, KeyMigrationCompleted, status.State)
+		assert.Equal(t, int64(2), status.Migrated)
+		assert.Equal(t, 1, purged)
+		StopKeyMigration("migration-store")
+
+		_, err = StartKeyMigration(store, "migration-store", "app1", KeyMigrationOptions{})
+		require.ErrorIs(t, err, ErrInvalidKeyMigration)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opts := range []KeyMigrationOptions{
+			{},
+			{To: "{nope}-{key}"},
+			{From: "a||b", To: strategyNone},
+			{To: "{group}-{key}", Group: "a||b"},
+		} {
+			_, err := MigrateKeys(context.Background(), newStore(), "migration-store", "app1", opts)
+			require.ErrorIs(t, err, ErrInvalidKeyMigration)
+		}
+
+		_, err := MigrateKeys(context.Background(), struct{ state.Store }{}, "migration-store", "app1", KeyMigrationOptions{To: strategyNone})
+		require.ErrorIs(t, err, ErrInvalidKeyMigration)
+	})
+}
@@ -16,88 +16,220 @@ package state
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/dapr/components-contrib/state"
 )
 
 const (
-	strategyKey = "keyprefix"
+	strategyKey      = "keyprefix"
+	strategyGroupKey = "keyprefixgroup"
+	parallelismKey   = "bulkparallelism"
 
 	strategyNamespace = "namespace"
 	strategyAppid     = "appid"
 	strategyStoreName = "name"
+	strategyGroup     = "group"
 	strategyNone      = "none"
 	strategyDefault   = strategyAppid
 
 	daprSeparator = "||"
+
+	// Placeholders of key prefix templates, such as "{namespace}-{appID}-{key}".
+	templateKey       = "{key}"
+	templateAppID     = "{appID}"
+	templateNamespace = "{namespace}"
+	templateStoreName = "{storeName}"
+	templateGroup     = "{group}"
 )
 
 var (
 	statesConfigurationLock sync.RWMutex
 	statesConfiguration     = map[string]*StoreConfiguration{}
 	namespace               = os.Getenv("NAMESPACE")
+
+	templatePlaceholderRegex = regexp.MustCompile(`\{[^{}]*\}`)
 )
 
 type StoreConfiguration struct {
 	keyPrefixStrategy string
+	// Shared prefix of the apps in a group, for the group strategy and templates.
+	keyPrefixGroup string
+	// Limit of the operations of a bulk set or delete performed in parallel
+	// when the store has no native bulk support; no limit if 0.
+	bulkParallelism int
 }
 
 func SaveStateConfiguration(storeName string, metadata map[string]string) error {
 	strategy := strategyDefault
+	group := ""
+	parallelism := 0
 	for k, v := range metadata {
-		if strings.ToLower(k) == strategyKey { //nolint:gocritic
-			strategy = strings.ToLower(v)
-			break
+		switch strings.ToLower(k) {
+		case strategyKey:
+			strategy = v
+		case strategyGroupKey:
+			group = v
+		case parallelismKey:
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid bulk parallelism '%s'", v)
+			}
+			parallelism = n
 		}
 	}
 
-	err := checkKeyIllegal(strategy)
+	c, err := NewStoreConfiguration(strategy, group)
 	if err != nil {
 		return err
 	}
+	c.bulkParallelism = parallelism
 
 	statesConfigurationLock.Lock()
-	statesConfiguration[storeName] = &StoreConfiguration{keyPrefixStrategy: strategy}
+	statesConfiguration[storeName] = c
 	statesConfigurationLock.Unlock()
 	return nil
 }
 
+// NewStoreConfiguration validates a key prefix strategy: one of the
+// predefined ones, a template containing {key}, or a fixed prefix.
+// "group" is the group strategy only when a group is set; otherwise it's
+// the fixed prefix it was before the strategy existed.
+func NewStoreConfiguration(strategy, group string) (*StoreConfiguration, error) {
+	if !isTemplate(strategy) {
+		strategy = strings.ToLower(strategy)
+	}
+	if err := checkKeyIllegal(strategy); err != nil {
+		return nil, err
+	}
+	if err := checkKeyIllegal(group); err != nil {
+		return nil, err
+	}
+
+	if isTemplate(strategy) {
+		keys := 0
+		for _, p := range templatePlaceholderRegex.FindAllString(strategy, -1) {
+			switch p {
+			case templateKey:
+				keys++
+			case templateAppID, templateNamespace, templateStoreName:
+			case templateGroup:
+				if group == "" {
+					return nil, fmt.Errorf("key prefix template '%s' requires a group", strategy)
+				}
+			default:
+				return nil, fmt.Errorf("key prefix template '%s' contains unknown placeholder %s", strategy, p)
+			}
+		}
+		if keys != 1 {
+			return nil, fmt.Errorf("key prefix template '%s' must contain %s once", strategy, templateKey)
+		}
+	}
+
+	return &StoreConfiguration{keyPrefixStrategy: strategy, keyPrefixGroup: group}, nil
+}
+
 func GetModifiedStateKey(key, storeName, appID string) (string, error) {
 	if err := checkKeyIllegal(key); err != nil {
 		return "", err
 	}
 
-	stateConfiguration := getStateConfiguration(storeName)
-	switch stateConfiguration.keyPrefixStrategy {
+	prefix, suffix := getStateConfiguration(storeName).keyAffixes(storeName, appID)
+	return prefix + key + suffix, nil
+}
+
+// GetBulkStoreOpts returns the options of the bulk sets and deletes on a store.
+func GetBulkStoreOpts(storeName string) state.BulkStoreOpts {
+	return state.BulkStoreOpts{Parallelism: getStateConfiguration(storeName).bulkParallelism}
+}
+
+func GetOriginalStateKey(modifiedStateKey string) string {
+	splits := strings.SplitN(modifiedStateKey, daprSeparator, 3)
+	if len(splits) <= 1 {
+		return modifiedStateKey
+	}
+	return splits[1]
+}
+
+// GetOriginalStateKeyForStore is like GetOriginalStateKey, and also supports
+// the keys of stores that use key prefix templates.
+func GetOriginalStateKeyForStore(modifiedStateKey, storeName, appID string) string {
+	c := getStateConfiguration(storeName)
+	if !isTemplate(c.keyPrefixStrategy) {
+		return GetOriginalStateKey(modifiedStateKey)
+	}
+	if key, ok := c.originalKey(modifiedStateKey, storeName, appID); ok {
+		return key
+	}
+	return modifiedStateKey
+}
+
+// GetAppStateKey returns the key an app saved a record with, and false if
+// the record wasn't saved by the app with the key prefix strategy of the store.
+func GetAppStateKey(savedKey, storeName, appID string) (string, bool) {
+	return getStateConfiguration(storeName).originalKey(savedKey, storeName, appID)
+}
+
+// keyAffixes returns the strings that the strategy adds before and after the
+// keys of an app.
+func (c *StoreConfiguration) keyAffixes(storeName, appID string) (string, string) {
+	switch c.keyPrefixStrategy {
 	case strategyNone:
-		return key, nil
+		return "", ""
 	case strategyStoreName:
-		return storeName + daprSeparator + key, nil
+		return storeName + daprSeparator, ""
 	case strategyAppid:
 		if appID == "" {
-			return key, nil
+			return "", ""
 		}
-		return appID + daprSeparator + key, nil
+		return appID + daprSeparator, ""
 	case strategyNamespace:
 		if appID == "" {
-			return key, nil
+			return "", ""
 		}
 		if namespace == "" {
 			// if namespace is empty, fallback to app id strategy
-			return appID + daprSeparator + key, nil
+			return appID + daprSeparator, ""
+		}
+		return namespace + "." + appID + daprSeparator, ""
+	case strategyGroup:
+		if c.keyPrefixGroup != "" {
+			return c.keyPrefixGroup + daprSeparator, ""
 		}
-		return namespace + "." + appID + daprSeparator + key, nil
-	default:
-		return stateConfiguration.keyPrefixStrategy + daprSeparator + key, nil
 	}
+
+	if !isTemplate(c.keyPrefixStrategy) {
+		return c.keyPrefixStrategy + daprSeparator, ""
+	}
+	expanded := strings.NewReplacer(
+		templateAppID, appID,
+		templateNamespace, namespace,
+		templateStoreName, storeName,
+		templateGroup, c.keyPrefixGroup,
+	).Replace(c.keyPrefixStrategy)
+	prefix, suffix, _ := strings.Cut(expanded, templateKey)
+	return prefix, suffix
 }
 
-func GetOriginalStateKey(modifiedStateKey string) string {
-	splits := strings.SplitN(modifiedStateKey, daprSeparator, 3)
-	if len(splits) <= 1 {
-		return modifiedStateKey
+// originalKey returns the key an app saved, if the saved key was created by
+// the strategy.
+func (c *StoreConfiguration) originalKey(savedKey, storeName, appID string) (string, bool) {
+	prefix, suffix := c.keyAffixes(storeName, appID)
+	if len(savedKey) < len(prefix)+len(suffix) || !strings.HasPrefix(savedKey, prefix) || !strings.HasSuffix(savedKey, suffix) {
+		return "", false
 	}
-	return splits[1]
+	key := savedKey[len(prefix) : len(savedKey)-len(suffix)]
+	if key == "" || checkKeyIllegal(key) != nil {
+		return "", false
+	}
+	return key, true
+}
+
+func isTemplate(strategy string) bool {
+	return strings.Contains(strategy, "{")
 }
 
 func getStateConfiguration(storeName string) *StoreConfiguration {
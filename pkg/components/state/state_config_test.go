@@ -164,6 +164,48 @@ func TestPrefix_StoreNotInitial(t *testing.T) {
 	require.Equal(t, key, originalStateKey)
 }
 
+func TestGroupPrefix(t *testing.T) {
+	err := SaveStateConfiguration("store-group", map[string]string{strategyKey: strategyGroup, "keyPrefixGroup": "shop"})
+	require.NoError(t, err)
+
+	modifiedStateKey, _ := GetModifiedStateKey(key, "store-group", "appid1")
+	require.Equal(t, "shop||state-key-1234567", modifiedStateKey)
+	require.Equal(t, key, GetOriginalStateKeyForStore(modifiedStateKey, "store-group", "appid1"))
+
+	// Without a group, "group" is a fixed prefix.
+	err = SaveStateConfiguration("store-group-missing", map[string]string{strategyKey: strategyGroup})
+	require.NoError(t, err)
+	modifiedStateKey, _ = GetModifiedStateKey(key, "store-group-missing", "appid1")
+	require.Equal(t, "group||state-key-1234567", modifiedStateKey)
+	require.Equal(t, key, GetOriginalStateKeyForStore(modifiedStateKey, "store-group-missing", "appid1"))
+}
+
+func TestTemplatePrefix(t *testing.T) {
+	namespace = "ns1"
+	defer func() {
+		namespace = ""
+	}()
+
+	err := SaveStateConfiguration("store-template", map[string]string{strategyKey: "{namespace}-{appID}-{key}"})
+	require.NoError(t, err)
+	modifiedStateKey, _ := GetModifiedStateKey(key, "store-template", "appid1")
+	require.Equal(t, "ns1-appid1-state-key-1234567", modifiedStateKey)
+	require.Equal(t, key, GetOriginalStateKeyForStore(modifiedStateKey, "store-template", "appid1"))
+	// Keys not created by the template are returned as they are
+	require.Equal(t, "other", GetOriginalStateKeyForStore("other", "store-template", "appid1"))
+
+	err = SaveStateConfiguration("store-template2", map[string]string{strategyKey: "{group}/{key}@{storeName}", "keyprefixgroup": "shop"})
+	require.NoError(t, err)
+	modifiedStateKey, _ = GetModifiedStateKey(key, "store-template2", "appid1")
+	require.Equal(t, "shop/state-key-1234567@store-template2", modifiedStateKey)
+	require.Equal(t, key, GetOriginalStateKeyForStore(modifiedStateKey, "store-template2", "appid1"))
+
+	for _, template := range []string{"{appID}-", "{key}-{key}", "{key}-{other}", "{group}-{key}", "{appID}||{key}"} {
+		_, err = NewStoreConfiguration(template, "")
+		require.Error(t, err, template)
+	}
+}
+
 func TestStateConfigRace(t *testing.T) {
 	t.Run("data race between SaveStateConfiguration and GetModifiedStateKey", func(t *testing.T) {
 		var wg sync.WaitGroup
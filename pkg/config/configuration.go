@@ -98,20 +98,22 @@ type AccessControlListOperationAction struct {
 }
 
 type ConfigurationSpec struct {
-	HTTPPipelineSpec    *PipelineSpec       `json:"httpPipeline,omitempty" yaml:"httpPipeline,omitempty"`
-	AppHTTPPipelineSpec *PipelineSpec       `json:"appHttpPipeline,omitempty" yaml:"appHttpPipeline,omitempty"`
-	TracingSpec         *TracingSpec        `json:"tracing,omitempty" yaml:"tracing,omitempty"`
-	MTLSSpec            *MTLSSpec           `json:"mtls,omitempty" yaml:"mtls,omitempty"`
-	MetricSpec          *MetricSpec         `json:"metric,omitempty" yaml:"metric,omitempty"`
-	MetricsSpec         *MetricSpec         `json:"metrics,omitempty" yaml:"metrics,omitempty"`
-	Secrets             *SecretsSpec        `json:"secrets,omitempty" yaml:"secrets,omitempty"`
-	AccessControlSpec   *AccessControlSpec  `json:"accessControl,omitempty" yaml:"accessControl,omitempty"`
-	NameResolutionSpec  *NameResolutionSpec `json:"nameResolution,omitempty" yaml:"nameResolution,omitempty"`
-	Features            []FeatureSpec       `json:"features,omitempty" yaml:"features,omitempty"`
-	APISpec             *APISpec            `json:"api,omitempty" yaml:"api,omitempty"`
-	ComponentsSpec      *ComponentsSpec     `json:"components,omitempty" yaml:"components,omitempty"`
-	LoggingSpec         *LoggingSpec        `json:"logging,omitempty" yaml:"logging,omitempty"`
-	WasmSpec            *WasmSpec           `json:"wasm,omitempty" yaml:"wasm,omitempty"`
+	HTTPPipelineSpec    *PipelineSpec        `json:"httpPipeline,omitempty" yaml:"httpPipeline,omitempty"`
+	AppHTTPPipelineSpec *PipelineSpec        `json:"appHttpPipeline,omitempty" yaml:"appHttpPipeline,omitempty"`
+	TracingSpec         *TracingSpec         `json:"tracing,omitempty" yaml:"tracing,omitempty"`
+	MTLSSpec            *MTLSSpec            `json:"mtls,omitempty" yaml:"mtls,omitempty"`
+	MetricSpec          *MetricSpec          `json:"metric,omitempty" yaml:"metric,omitempty"`
+	MetricsSpec         *MetricSpec          `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	Secrets             *SecretsSpec         `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+	AccessControlSpec   *AccessControlSpec   `json:"accessControl,omitempty" yaml:"accessControl,omitempty"`
+	NameResolutionSpec  *NameResolutionSpec  `json:"nameResolution,omitempty" yaml:"nameResolution,omitempty"`
+	Features            []FeatureSpec        `json:"features,omitempty" yaml:"features,omitempty"`
+	APISpec             *APISpec             `json:"api,omitempty" yaml:"api,omitempty"`
+	ComponentsSpec      *ComponentsSpec      `json:"components,omitempty" yaml:"components,omitempty"`
+	LoggingSpec         *LoggingSpec         `json:"logging,omitempty" yaml:"logging,omitempty"`
+	WasmSpec            *WasmSpec            `json:"wasm,omitempty" yaml:"wasm,omitempty"`
+	AppRoutingSpec      *AppRoutingSpec      `json:"appRouting,omitempty" yaml:"appRouting,omitempty"`
+	InvocationCacheSpec *InvocationCacheSpec `json:"invocationCache,omitempty" yaml:"invocationCache,omitempty"`
 }
 
 type SecretsSpec struct {
@@ -130,6 +132,46 @@ type PipelineSpec struct {
 	Handlers []HandlerSpec `json:"handlers,omitempty" yaml:"handlers,omitempty"`
 }
 
+// AppRoutingSpec describes how service invocation requests to logical app IDs are routed.
+type AppRoutingSpec struct {
+	Routes []AppRoute `json:"routes,omitempty" yaml:"routes,omitempty"`
+}
+
+// AppRoute splits the requests sent to a logical app ID across several backing app IDs.
+// Requests matching one of Matches are sent to its app ID; the others are split by weight across Targets.
+type AppRoute struct {
+	AppID   string              `json:"appId" yaml:"appId"`
+	Matches []AppRouteMatch     `json:"matches,omitempty" yaml:"matches,omitempty"`
+	Targets []WeightedAppTarget `json:"targets,omitempty" yaml:"targets,omitempty"`
+}
+
+// AppRouteMatch sends the requests that have all the given header values to AppID.
+type AppRouteMatch struct {
+	Headers map[string]string `json:"headers" yaml:"headers"`
+	AppID   string            `json:"appId" yaml:"appId"`
+}
+
+// WeightedAppTarget is a backing app ID of a route.
+type WeightedAppTarget struct {
+	AppID  string `json:"appId" yaml:"appId"`
+	Weight int    `json:"weight" yaml:"weight"`
+}
+
+// InvocationCacheSpec configures the cache, in the caller's sidecar, of the responses to service invocation GET requests.
+// Responses are cached only for the apps that have a rule, as allowed by their Cache-Control and ETag headers.
+type InvocationCacheSpec struct {
+	// Maximum size of all the cached responses, in MB. Defaults to 64.
+	MaxSizeMB int                   `json:"maxSizeMB,omitempty" yaml:"maxSizeMB,omitempty"`
+	Rules     []InvocationCacheRule `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+// InvocationCacheRule enables caching the responses of an app.
+// Methods are path.Match patterns; if empty, all the methods are cached.
+type InvocationCacheRule struct {
+	AppID   string   `json:"appId" yaml:"appId"`
+	Methods []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+}
+
 // APISpec describes the configuration for Dapr APIs.
 type APISpec struct {
 	// List of allowed APIs. Can be used in conjunction with denied.
@@ -29,6 +29,16 @@ const (
 	BulkGet                  = "bulk_get"
 	BulkDelete               = "bulk_delete"
 	CryptoOp                 = "crypto_op"
+
+	// Outcomes of the processing of outbox messages.
+	OutboxPublished          = "published"
+	OutboxDiscarded          = "discarded"
+	OutboxDeadLettered       = "dead_lettered"
+	OutboxVerificationFailed = "verification_failed"
+
+	// Results of the lookups in the state cache.
+	StateCacheHit  = "hit"
+	StateCacheMiss = "miss"
 )
 
 // componentMetrics holds dapr runtime metrics for components.
@@ -38,6 +48,7 @@ type componentMetrics struct {
 	bulkPubsubIngressCount      *stats.Int64Measure
 	bulkPubsubEventIngressCount *stats.Int64Measure
 	bulkPubsubIngressLatency    *stats.Float64Measure
+	pubsubIngressThrottledCount *stats.Int64Measure
 	pubsubEgressCount           *stats.Int64Measure
 	pubsubEgressLatency         *stats.Float64Measure
 	bulkPubsubEgressCount       *stats.Int64Measure
@@ -49,8 +60,11 @@ type componentMetrics struct {
 	outputBindingCount   *stats.Int64Measure
 	outputBindingLatency *stats.Float64Measure
 
-	stateCount   *stats.Int64Measure
-	stateLatency *stats.Float64Measure
+	stateCount         *stats.Int64Measure
+	stateLatency       *stats.Float64Measure
+	stateOutboxCount   *stats.Int64Measure
+	stateOutboxPending *stats.Int64Measure
+	stateCacheCount    *stats.Int64Measure
 
 	configurationCount   *stats.Int64Measure
 	configurationLatency *stats.Float64Measure
@@ -89,6 +103,10 @@ func newComponentMetrics() *componentMetrics {
 			"component/pubsub_ingress/bulk/latencies",
 			"The consuming app event processing latency for the bulk pub/sub component.",
 			stats.UnitMilliseconds),
+		pubsubIngressThrottledCount: stats.Int64(
+			"component/pubsub_ingress/throttled/count",
+			"The number of incoming messages whose delivery to the app was delayed by the subscription delivery limits.",
+			stats.UnitDimensionless),
 		pubsubEgressCount: stats.Int64(
 			"component/pubsub_egress/count",
 			"The number of outgoing messages published to the pub/sub component.",
@@ -133,6 +151,18 @@ func newComponentMetrics() *componentMetrics {
 			"component/state/latencies",
 			"The latency of the response from the state component.",
 			stats.UnitMilliseconds),
+		stateOutboxCount: stats.Int64(
+			"component/state/outbox/count",
+			"The number of outbox messages processed for the state component, by outcome.",
+			stats.UnitDimensionless),
+		stateOutboxPending: stats.Int64(
+			"component/state/outbox/pending",
+			"The number of outbox messages of the state component this instance is verifying and publishing.",
+			stats.UnitDimensionless),
+		stateCacheCount: stats.Int64(
+			"component/state/cache/count",
+			"The number of lookups in the cache of the state component, by result.",
+			stats.UnitDimensionless),
 		configurationCount: stats.Int64(
 			"component/configuration/count",
 			"The number of operations performed on the configuration component.",
@@ -172,6 +202,7 @@ func (c *componentMetrics) Init(appID, namespace string) error {
 		diagUtils.NewMeasureView(c.bulkPubsubIngressLatency, []tag.Key{appIDKey, componentKey, namespaceKey, processStatusKey, topicKey}, defaultLatencyDistribution),
 		diagUtils.NewMeasureView(c.bulkPubsubIngressCount, []tag.Key{appIDKey, componentKey, namespaceKey, processStatusKey, topicKey}, view.Count()),
 		diagUtils.NewMeasureView(c.bulkPubsubEventIngressCount, []tag.Key{appIDKey, componentKey, namespaceKey, processStatusKey, topicKey}, view.Count()),
+		diagUtils.NewMeasureView(c.pubsubIngressThrottledCount, []tag.Key{appIDKey, componentKey, namespaceKey, topicKey}, view.Sum()),
 		diagUtils.NewMeasureView(c.pubsubEgressLatency, []tag.Key{appIDKey, componentKey, namespaceKey, successKey, topicKey}, defaultLatencyDistribution),
 		diagUtils.NewMeasureView(c.pubsubEgressCount, []tag.Key{appIDKey, componentKey, namespaceKey, successKey, topicKey}, view.Count()),
 		diagUtils.NewMeasureView(c.inputBindingLatency, []tag.Key{appIDKey, componentKey, namespaceKey, successKey}, defaultLatencyDistribution),
@@ -180,6 +211,9 @@ func (c *componentMetrics) Init(appID, namespace string) error {
 		diagUtils.NewMeasureView(c.outputBindingCount, []tag.Key{appIDKey, componentKey, namespaceKey, operationKey, successKey}, view.Count()),
 		diagUtils.NewMeasureView(c.stateLatency, []tag.Key{appIDKey, componentKey, namespaceKey, operationKey, successKey}, defaultLatencyDistribution),
 		diagUtils.NewMeasureView(c.stateCount, []tag.Key{appIDKey, componentKey, namespaceKey, operationKey, successKey}, view.Count()),
+		diagUtils.NewMeasureView(c.stateOutboxCount, []tag.Key{appIDKey, componentKey, namespaceKey, processStatusKey}, view.Count()),
+		diagUtils.NewMeasureView(c.stateOutboxPending, []tag.Key{appIDKey, componentKey, namespaceKey}, view.LastValue()),
+		diagUtils.NewMeasureView(c.stateCacheCount, []tag.Key{appIDKey, componentKey, namespaceKey, resultKey}, view.Count()),
 		diagUtils.NewMeasureView(c.configurationLatency, []tag.Key{appIDKey, componentKey, namespaceKey, operationKey, successKey}, defaultLatencyDistribution),
 		diagUtils.NewMeasureView(c.configurationCount, []tag.Key{appIDKey, componentKey, namespaceKey, operationKey, successKey}, view.Count()),
 		diagUtils.NewMeasureView(c.secretLatency, []tag.Key{appIDKey, componentKey, namespaceKey, operationKey, successKey}, defaultLatencyDistribution),
@@ -233,6 +267,16 @@ func (c *componentMetrics) BulkPubsubIngressEventEntries(ctx context.Context, co
 	}
 }
 
+// PubsubIngressThrottled records the metrics for pub/sub ingress messages whose delivery was delayed by the subscription delivery limits.
+func (c *componentMetrics) PubsubIngressThrottled(ctx context.Context, component, topic string, eventCount int64) {
+	if c.enabled && eventCount > 0 {
+		stats.RecordWithTags(
+			ctx,
+			diagUtils.WithTags(c.pubsubIngressThrottledCount.Name(), appIDKey, c.appID, componentKey, component, namespaceKey, c.namespace, topicKey, topic),
+			c.pubsubIngressThrottledCount.M(eventCount))
+	}
+}
+
 // BulkPubsubEgressEvent records the metris for a pub/sub egress event.
 // eventCount if greater than zero implies successful publish of few/all events in the bulk publish call
 func (c *componentMetrics) BulkPubsubEgressEvent(ctx context.Context, component, topic string, success bool, eventCount int64, elapsed float64) {
@@ -325,6 +369,40 @@ func (c *componentMetrics) StateInvoked(ctx context.Context, component, operatio
 	}
 }
 
+// StateOutboxEvent records the outcome of the processing of an outbox message
+// of a state component.
+func (c *componentMetrics) StateOutboxEvent(ctx context.Context, component, processStatus string) {
+	if c.enabled {
+		stats.RecordWithTags(
+			ctx,
+			diagUtils.WithTags(c.stateOutboxCount.Name(), appIDKey, c.appID, componentKey, component, namespaceKey, c.namespace, processStatusKey, processStatus),
+			c.stateOutboxCount.M(1))
+	}
+}
+
+// StateOutboxPending records the number of outbox messages of a state
+// component that this instance received and has not published, discarded or
+// dead-lettered yet.
+func (c *componentMetrics) StateOutboxPending(ctx context.Context, component string, pending int64) {
+	if c.enabled {
+		stats.RecordWithTags(
+			ctx,
+			diagUtils.WithTags(c.stateOutboxPending.Name(), appIDKey, c.appID, componentKey, component, namespaceKey, c.namespace),
+			c.stateOutboxPending.M(pending))
+	}
+}
+
+// StateCacheRequest records the result of looking up a key in the cache of a
+// state component.
+func (c *componentMetrics) StateCacheRequest(ctx context.Context, component, result string) {
+	if c.enabled {
+		stats.RecordWithTags(
+			ctx,
+			diagUtils.WithTags(c.stateCacheCount.Name(), appIDKey, c.appID, componentKey, component, namespaceKey, c.namespace, resultKey, result),
+			c.stateCacheCount.M(1))
+	}
+}
+
 // ConfigurationInvoked records the metrics for a configuration event.
 func (c *componentMetrics) ConfigurationInvoked(ctx context.Context, component, operation string, success bool, elapsed float64) {
 	if c.enabled {
@@ -14,6 +14,7 @@ const (
 )
 
 func componentsMetrics() *componentMetrics {
+	CleanupRegisteredViews()
 	c := newComponentMetrics()
 	c.Init("test", "default")
 
@@ -45,6 +46,19 @@ func TestPubSub(t *testing.T) {
 		assert.Equal(t, float64(1), viewData[0].Data.(*view.DistributionData).Min)
 	})
 
+	t.Run("record ingress throttled count", func(t *testing.T) {
+		c := componentsMetrics()
+
+		c.PubsubIngressThrottled(context.Background(), componentName, "A", 3)
+
+		viewData, _ := view.RetrieveData("component/pubsub_ingress/throttled/count")
+		v := view.Find("component/pubsub_ingress/throttled/count")
+
+		allTagsPresent(t, v, viewData[0].Tags)
+
+		assert.Equal(t, float64(3), viewData[0].Data.(*view.SumData).Value)
+	})
+
 	t.Run("record egress latency", func(t *testing.T) {
 		c := componentsMetrics()
 
@@ -132,6 +146,43 @@ func TestState(t *testing.T) {
 		allTagsPresent(t, v, viewData[0].Tags)
 		assert.Equal(t, float64(1), viewData[0].Data.(*view.DistributionData).Min)
 	})
+
+	t.Run("record state outbox count", func(t *testing.T) {
+		c := componentsMetrics()
+
+		c.StateOutboxEvent(context.Background(), componentName, OutboxPublished)
+
+		viewData, _ := view.RetrieveData("component/state/outbox/count")
+		v := view.Find("component/state/outbox/count")
+
+		allTagsPresent(t, v, viewData[0].Tags)
+	})
+
+	t.Run("record state outbox pending", func(t *testing.T) {
+		c := componentsMetrics()
+
+		c.StateOutboxPending(context.Background(), componentName, 2)
+		c.StateOutboxPending(context.Background(), componentName, 1)
+
+		viewData, _ := view.RetrieveData("component/state/outbox/pending")
+		v := view.Find("component/state/outbox/pending")
+
+		allTagsPresent(t, v, viewData[0].Tags)
+		assert.Equal(t, float64(1), viewData[0].Data.(*view.LastValueData).Value)
+	})
+
+	t.Run("record state cache requests", func(t *testing.T) {
+		c := componentsMetrics()
+
+		c.StateCacheRequest(context.Background(), componentName, StateCacheHit)
+		c.StateCacheRequest(context.Background(), componentName, StateCacheMiss)
+
+		viewData, _ := view.RetrieveData("component/state/cache/count")
+		v := view.Find("component/state/cache/count")
+
+		assert.Len(t, viewData, 2)
+		allTagsPresent(t, v, viewData[0].Tags)
+	})
 }
 
 func TestConfiguration(t *testing.T) {
@@ -40,6 +40,7 @@ const (
 	DaprAPIStatusCodeSpanAttributeKey = "dapr.status_code"
 	DaprAPIProtocolSpanAttributeKey   = "dapr.protocol"
 	DaprAPIInvokeMethod               = "dapr.invoke_method"
+	DaprAPIInvokeRouteTarget          = "dapr.invoke_route_target"
 	DaprAPIActorTypeID                = "dapr.actor"
 
 	DaprAPIHTTPSpanAttrValue = "http"
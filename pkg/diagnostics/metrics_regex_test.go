@@ -18,6 +18,7 @@ func TestRegexRulesSingle(t *testing.T) {
 	methodKey := tag.MustNewKey("method")
 	testStat := stats.Int64(statName, "Stat used in unit test", stats.UnitDimensionless)
 
+	CleanupRegisteredViews()
 	InitMetrics("testAppId2", "", []config.MetricsRule{
 		{
 			Name: statName,
@@ -14,6 +14,7 @@ var (
 	CircuitBreakerPolicy PolicyType = "circuitbreaker"
 	RetryPolicy          PolicyType = "retry"
 	TimeoutPolicy        PolicyType = "timeout"
+	HedgingPolicy        PolicyType = "hedging"
 
 	OutboundPolicyFlowDirection PolicyFlowDirection = "outbound"
 	InboundPolicyFlowDirection  PolicyFlowDirection = "inbound"
@@ -30,6 +30,9 @@ var (
 	flowDirectionKey    = tag.MustNewKey("flow_direction")
 	targetKey           = tag.MustNewKey("target")
 	typeKey             = tag.MustNewKey("type")
+	baseURLKey          = tag.MustNewKey("base_url")
+	addressKey          = tag.MustNewKey("address")
+	resultKey           = tag.MustNewKey("result")
 )
 
 const (
@@ -76,6 +79,22 @@ type serviceMetrics struct {
 	serviceInvocationResponseReceivedTotal   *stats.Int64Measure
 	serviceInvocationResponseReceivedLatency *stats.Float64Measure
 
+	// gRPC proxy metrics, per method
+	serviceInvocationProxyResponseReceivedTotal   *stats.Int64Measure
+	serviceInvocationProxyResponseReceivedLatency *stats.Float64Measure
+
+	// HTTP endpoint metrics, per base URL
+	serviceInvocationEndpointRequestSentTotal        *stats.Int64Measure
+	serviceInvocationEndpointResponseReceivedTotal   *stats.Int64Measure
+	serviceInvocationEndpointResponseReceivedLatency *stats.Float64Measure
+	serviceInvocationEndpointHealthy                 *stats.Int64Measure
+	serviceInvocationInstanceEjectionsTotal          *stats.Int64Measure
+	serviceInvocationInstanceEjected                 *stats.Int64Measure
+	serviceInvocationRouteTotal                      *stats.Int64Measure
+	serviceInvocationWebSocketBytesTotal             *stats.Int64Measure
+	serviceInvocationWebSocketMessagesTotal          *stats.Int64Measure
+	serviceInvocationCacheRequestsTotal              *stats.Int64Measure
+
 	appID   string
 	ctx     context.Context
 	enabled bool
@@ -202,6 +221,66 @@ func newServiceMetrics() *serviceMetrics {
 			"The latency of service invocation response.",
 			stats.UnitMilliseconds),
 
+		// HTTP endpoints
+		serviceInvocationEndpointRequestSentTotal: stats.Int64(
+			"runtime/service_invocation/http_endpoint/req_sent_total",
+			"The number of requests sent to each base URL of HTTP endpoints.",
+			stats.UnitDimensionless),
+		serviceInvocationEndpointResponseReceivedTotal: stats.Int64(
+			"runtime/service_invocation/http_endpoint/res_recv_total",
+			"The number of responses received from each base URL of HTTP endpoints.",
+			stats.UnitDimensionless),
+		serviceInvocationEndpointResponseReceivedLatency: stats.Float64(
+			"runtime/service_invocation/http_endpoint/res_recv_latency_ms",
+			"The latency of the responses of each base URL of HTTP endpoints.",
+			stats.UnitMilliseconds),
+		serviceInvocationEndpointHealthy: stats.Int64(
+			"runtime/service_invocation/http_endpoint/healthy",
+			"Whether a base URL of an HTTP endpoint passes its health probes (1) or is ejected (0).",
+			stats.UnitDimensionless),
+
+		// Client-side load balancing
+		serviceInvocationInstanceEjectionsTotal: stats.Int64(
+			"runtime/service_invocation/instance/ejections_total",
+			"The number of times an instance of an app was ejected by outlier detection.",
+			stats.UnitDimensionless),
+		serviceInvocationInstanceEjected: stats.Int64(
+			"runtime/service_invocation/instance/ejected",
+			"Whether an instance of an app is currently ejected by outlier detection (1) or not (0).",
+			stats.UnitDimensionless),
+
+		// gRPC proxy
+		serviceInvocationProxyResponseReceivedTotal: stats.Int64(
+			"runtime/service_invocation/grpc_proxy/res_recv_total",
+			"The number of responses to proxied gRPC calls, per method and status code.",
+			stats.UnitDimensionless),
+		serviceInvocationProxyResponseReceivedLatency: stats.Float64(
+			"runtime/service_invocation/grpc_proxy/res_recv_latency_ms",
+			"The latency of proxied gRPC calls, per method and status code. For streams, this is the duration of the whole stream.",
+			stats.UnitMilliseconds),
+
+		// Traffic splitting
+		serviceInvocationRouteTotal: stats.Int64(
+			"runtime/service_invocation/route_total",
+			"The number of requests to a logical app ID routed to each of its backing app IDs.",
+			stats.UnitDimensionless),
+
+		// WebSocket tunnels
+		serviceInvocationWebSocketBytesTotal: stats.Int64(
+			"runtime/service_invocation/websocket/bytes_total",
+			"The number of bytes sent over WebSocket connections tunneled to each app.",
+			stats.UnitBytes),
+		serviceInvocationWebSocketMessagesTotal: stats.Int64(
+			"runtime/service_invocation/websocket/messages_total",
+			"The number of messages sent over WebSocket connections tunneled to each app.",
+			stats.UnitDimensionless),
+
+		// Response cache
+		serviceInvocationCacheRequestsTotal: stats.Int64(
+			"runtime/service_invocation/cache/requests_total",
+			"The number of cacheable requests to each app, by result: hit, miss or revalidated.",
+			stats.UnitDimensionless),
+
 		// TODO: use the correct context for each request
 		ctx:     context.Background(),
 		enabled: false,
@@ -244,6 +323,19 @@ func (s *serviceMetrics) Init(appID string) error {
 		diagUtils.NewMeasureView(s.serviceInvocationResponseSentTotal, []tag.Key{appIDKey, destinationAppIDKey, statusKey}, view.Count()),
 		diagUtils.NewMeasureView(s.serviceInvocationResponseReceivedTotal, []tag.Key{appIDKey, sourceAppIDKey, statusKey, typeKey}, view.Count()),
 		diagUtils.NewMeasureView(s.serviceInvocationResponseReceivedLatency, []tag.Key{appIDKey, sourceAppIDKey, statusKey}, defaultLatencyDistribution),
+
+		diagUtils.NewMeasureView(s.serviceInvocationEndpointRequestSentTotal, []tag.Key{appIDKey, destinationAppIDKey, baseURLKey}, view.Count()),
+		diagUtils.NewMeasureView(s.serviceInvocationEndpointResponseReceivedTotal, []tag.Key{appIDKey, destinationAppIDKey, baseURLKey, statusKey}, view.Count()),
+		diagUtils.NewMeasureView(s.serviceInvocationEndpointResponseReceivedLatency, []tag.Key{appIDKey, destinationAppIDKey, baseURLKey, statusKey}, defaultLatencyDistribution),
+		diagUtils.NewMeasureView(s.serviceInvocationEndpointHealthy, []tag.Key{appIDKey, destinationAppIDKey, baseURLKey}, view.LastValue()),
+		diagUtils.NewMeasureView(s.serviceInvocationInstanceEjectionsTotal, []tag.Key{appIDKey, destinationAppIDKey, addressKey}, view.Count()),
+		diagUtils.NewMeasureView(s.serviceInvocationInstanceEjected, []tag.Key{appIDKey, destinationAppIDKey, addressKey}, view.LastValue()),
+		diagUtils.NewMeasureView(s.serviceInvocationRouteTotal, []tag.Key{appIDKey, destinationAppIDKey, targetKey}, view.Count()),
+		diagUtils.NewMeasureView(s.serviceInvocationWebSocketBytesTotal, []tag.Key{appIDKey, destinationAppIDKey, flowDirectionKey}, view.Sum()),
+		diagUtils.NewMeasureView(s.serviceInvocationWebSocketMessagesTotal, []tag.Key{appIDKey, destinationAppIDKey, flowDirectionKey}, view.Sum()),
+		diagUtils.NewMeasureView(s.serviceInvocationCacheRequestsTotal, []tag.Key{appIDKey, destinationAppIDKey, resultKey}, view.Count()),
+		diagUtils.NewMeasureView(s.serviceInvocationProxyResponseReceivedTotal, []tag.Key{appIDKey, destinationAppIDKey, KeyClientMethod, KeyClientStatus, typeKey}, view.Count()),
+		diagUtils.NewMeasureView(s.serviceInvocationProxyResponseReceivedLatency, []tag.Key{appIDKey, destinationAppIDKey, KeyClientMethod, KeyClientStatus, typeKey}, defaultLatencyDistribution),
 	)
 }
 
@@ -566,3 +658,178 @@ func (s *serviceMetrics) ServiceInvocationStreamingResponseReceived(sourceAppID
 			s.serviceInvocationResponseReceivedTotal.M(1))
 	}
 }
+
+// ServiceInvocationProxyResponseReceived records the outcome of a proxied gRPC call to a method of an app.
+func (s *serviceMetrics) ServiceInvocationProxyResponseReceived(destinationAppID, method, status string, streaming bool, start time.Time) {
+	if s.enabled {
+		callType := typeUnary
+		if streaming {
+			callType = typeStreaming
+		}
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				s.serviceInvocationProxyResponseReceivedTotal.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, destinationAppID,
+				KeyClientMethod, method,
+				KeyClientStatus, status,
+				typeKey, callType),
+			s.serviceInvocationProxyResponseReceivedTotal.M(1))
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				s.serviceInvocationProxyResponseReceivedLatency.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, destinationAppID,
+				KeyClientMethod, method,
+				KeyClientStatus, status,
+				typeKey, callType),
+			s.serviceInvocationProxyResponseReceivedLatency.M(ElapsedSince(start)))
+	}
+}
+
+// ServiceInvocationEndpointRequestSent records a request sent to a base URL of an HTTP endpoint.
+func (s *serviceMetrics) ServiceInvocationEndpointRequestSent(endpoint, baseURL string) {
+	if s.enabled {
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				s.serviceInvocationEndpointRequestSentTotal.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, endpoint,
+				baseURLKey, baseURL),
+			s.serviceInvocationEndpointRequestSentTotal.M(1))
+	}
+}
+
+// ServiceInvocationEndpointResponseReceived records a response received from a base URL of an HTTP endpoint.
+func (s *serviceMetrics) ServiceInvocationEndpointResponseReceived(endpoint, baseURL string, status int32, start time.Time) {
+	if s.enabled {
+		statusCode := strconv.Itoa(int(status))
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				s.serviceInvocationEndpointResponseReceivedTotal.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, endpoint,
+				baseURLKey, baseURL,
+				statusKey, statusCode),
+			s.serviceInvocationEndpointResponseReceivedTotal.M(1))
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				s.serviceInvocationEndpointResponseReceivedLatency.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, endpoint,
+				baseURLKey, baseURL,
+				statusKey, statusCode),
+			s.serviceInvocationEndpointResponseReceivedLatency.M(ElapsedSince(start)))
+	}
+}
+
+// ServiceInvocationEndpointHealth records whether a base URL of an HTTP endpoint is healthy.
+func (s *serviceMetrics) ServiceInvocationEndpointHealth(endpoint, baseURL string, healthy bool) {
+	if s.enabled {
+		var v int64
+		if healthy {
+			v = 1
+		}
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				s.serviceInvocationEndpointHealthy.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, endpoint,
+				baseURLKey, baseURL),
+			s.serviceInvocationEndpointHealthy.M(v))
+	}
+}
+
+// ServiceInvocationInstanceEjected records that an instance of an app was ejected by outlier detection.
+func (s *serviceMetrics) ServiceInvocationInstanceEjected(destinationAppID, address string) {
+	if s.enabled {
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				s.serviceInvocationInstanceEjectionsTotal.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, destinationAppID,
+				addressKey, address),
+			s.serviceInvocationInstanceEjectionsTotal.M(1))
+		s.recordInstanceEjected(destinationAppID, address, 1)
+	}
+}
+
+// ServiceInvocationInstanceRestored records that an ejected instance of an app receives requests again.
+func (s *serviceMetrics) ServiceInvocationInstanceRestored(destinationAppID, address string) {
+	if s.enabled {
+		s.recordInstanceEjected(destinationAppID, address, 0)
+	}
+}
+
+func (s *serviceMetrics) recordInstanceEjected(destinationAppID, address string, v int64) {
+	stats.RecordWithTags(
+		s.ctx,
+		diagUtils.WithTags(
+			s.serviceInvocationInstanceEjected.Name(),
+			appIDKey, s.appID,
+			destinationAppIDKey, destinationAppID,
+			addressKey, address),
+		s.serviceInvocationInstanceEjected.M(v))
+}
+
+// ServiceInvocationRouted records that a request to a logical app ID was routed to a backing app ID.
+func (s *serviceMetrics) ServiceInvocationRouted(destinationAppID, target string) {
+	if s.enabled {
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				s.serviceInvocationRouteTotal.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, destinationAppID,
+				targetKey, target),
+			s.serviceInvocationRouteTotal.M(1))
+	}
+}
+
+// ServiceInvocationWebSocketTraffic records data sent over a WebSocket connection tunneled to an app.
+// The outbound direction is from the caller to the app.
+func (s *serviceMetrics) ServiceInvocationWebSocketTraffic(destinationAppID string, direction PolicyFlowDirection, bytes, messages int64) {
+	if !s.enabled {
+		return
+	}
+	for _, m := range []struct {
+		measure *stats.Int64Measure
+		value   int64
+	}{
+		{s.serviceInvocationWebSocketBytesTotal, bytes},
+		{s.serviceInvocationWebSocketMessagesTotal, messages},
+	} {
+		if m.value <= 0 {
+			continue
+		}
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				m.measure.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, destinationAppID,
+				flowDirectionKey, string(direction)),
+			m.measure.M(m.value))
+	}
+}
+
+// ServiceInvocationCacheRequest records the result of looking up the response to a request in the invocation cache.
+func (s *serviceMetrics) ServiceInvocationCacheRequest(destinationAppID, result string) {
+	if s.enabled {
+		stats.RecordWithTags(
+			s.ctx,
+			diagUtils.WithTags(
+				s.serviceInvocationCacheRequestsTotal.Name(),
+				appIDKey, s.appID,
+				destinationAppIDKey, destinationAppID,
+				resultKey, result),
+			s.serviceInvocationCacheRequestsTotal.M(1))
+	}
+}
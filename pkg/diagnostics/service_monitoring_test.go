@@ -77,6 +77,129 @@ func TestServiceInvocation(t *testing.T) {
 
 		allTagsPresent(t, v2, viewData2[0].Tags)
 	})
+
+	t.Run("record http endpoint request sent and response received", func(t *testing.T) {
+		s := servicesMetrics()
+
+		s.ServiceInvocationEndpointRequestSent("myendpoint", "https://eu.example.com")
+		s.ServiceInvocationEndpointResponseReceived("myendpoint", "https://eu.example.com", 200, time.Now())
+
+		for _, name := range []string{
+			"runtime/service_invocation/http_endpoint/req_sent_total",
+			"runtime/service_invocation/http_endpoint/res_recv_total",
+			"runtime/service_invocation/http_endpoint/res_recv_latency_ms",
+		} {
+			viewData, _ := view.RetrieveData(name)
+			v := view.Find(name)
+
+			allTagsPresent(t, v, viewData[0].Tags)
+			RequireTagExist(t, viewData, NewTag(baseURLKey.Name(), "https://eu.example.com"))
+		}
+	})
+
+	t.Run("record http endpoint health", func(t *testing.T) {
+		s := servicesMetrics()
+
+		s.ServiceInvocationEndpointHealth("myendpoint", "https://us.example.com", false)
+
+		viewData, _ := view.RetrieveData("runtime/service_invocation/http_endpoint/healthy")
+		v := view.Find("runtime/service_invocation/http_endpoint/healthy")
+
+		allTagsPresent(t, v, viewData[0].Tags)
+		assert.Equal(t, float64(0), viewData[0].Data.(*view.LastValueData).Value)
+	})
+
+	t.Run("record instance ejections", func(t *testing.T) {
+		s := servicesMetrics()
+
+		s.ServiceInvocationInstanceEjected("app1", "10.0.0.1:50002")
+
+		viewData, _ := view.RetrieveData("runtime/service_invocation/instance/ejections_total")
+		v := view.Find("runtime/service_invocation/instance/ejections_total")
+		allTagsPresent(t, v, viewData[0].Tags)
+		RequireTagExist(t, viewData, NewTag(addressKey.Name(), "10.0.0.1:50002"))
+
+		viewData, _ = view.RetrieveData("runtime/service_invocation/instance/ejected")
+		assert.Equal(t, float64(1), viewData[0].Data.(*view.LastValueData).Value)
+
+		s.ServiceInvocationInstanceRestored("app1", "10.0.0.1:50002")
+		viewData, _ = view.RetrieveData("runtime/service_invocation/instance/ejected")
+		assert.Equal(t, float64(0), viewData[0].Data.(*view.LastValueData).Value)
+	})
+
+	t.Run("record proxied gRPC responses", func(t *testing.T) {
+		s := servicesMetrics()
+
+		s.ServiceInvocationProxyResponseReceived("app1", "/mypackage.Orders/Get", "Unavailable", false, time.Now())
+		s.ServiceInvocationProxyResponseReceived("app1", "/mypackage.Orders/Watch", "OK", true, time.Now())
+
+		viewData, _ := view.RetrieveData("runtime/service_invocation/grpc_proxy/res_recv_total")
+		v := view.Find("runtime/service_invocation/grpc_proxy/res_recv_total")
+
+		assert.Len(t, viewData, 2)
+		allTagsPresent(t, v, viewData[0].Tags)
+		RequireTagExist(t, viewData, NewTag(KeyClientMethod.Name(), "/mypackage.Orders/Get"))
+		RequireTagExist(t, viewData, NewTag(KeyClientStatus.Name(), "Unavailable"))
+		RequireTagExist(t, viewData, NewTag(typeKey.Name(), typeStreaming))
+
+		viewData, _ = view.RetrieveData("runtime/service_invocation/grpc_proxy/res_recv_latency_ms")
+		assert.Len(t, viewData, 2)
+	})
+
+	t.Run("record WebSocket traffic", func(t *testing.T) {
+		s := servicesMetrics()
+
+		s.ServiceInvocationWebSocketTraffic("app1", OutboundPolicyFlowDirection, 10, 1)
+		s.ServiceInvocationWebSocketTraffic("app1", OutboundPolicyFlowDirection, 20, 0)
+		s.ServiceInvocationWebSocketTraffic("app1", InboundPolicyFlowDirection, 5, 2)
+
+		viewData, _ := view.RetrieveData("runtime/service_invocation/websocket/bytes_total")
+		v := view.Find("runtime/service_invocation/websocket/bytes_total")
+
+		assert.Len(t, viewData, 2)
+		allTagsPresent(t, v, viewData[0].Tags)
+		RequireTagExist(t, viewData, NewTag(destinationAppIDKey.Name(), "app1"))
+		RequireTagExist(t, viewData, NewTag(flowDirectionKey.Name(), string(OutboundPolicyFlowDirection)))
+		for _, row := range viewData {
+			for _, tag := range row.Tags {
+				if tag.Key == flowDirectionKey && tag.Value == string(OutboundPolicyFlowDirection) {
+					assert.Equal(t, float64(30), row.Data.(*view.SumData).Value)
+				}
+			}
+		}
+
+		viewData, _ = view.RetrieveData("runtime/service_invocation/websocket/messages_total")
+		assert.Len(t, viewData, 2)
+	})
+
+	t.Run("record routed requests", func(t *testing.T) {
+		s := servicesMetrics()
+
+		s.ServiceInvocationRouted("checkout", "checkout-v2")
+
+		viewData, _ := view.RetrieveData("runtime/service_invocation/route_total")
+		v := view.Find("runtime/service_invocation/route_total")
+
+		allTagsPresent(t, v, viewData[0].Tags)
+		RequireTagExist(t, viewData, NewTag(destinationAppIDKey.Name(), "checkout"))
+		RequireTagExist(t, viewData, NewTag(targetKey.Name(), "checkout-v2"))
+	})
+
+	t.Run("record cache requests", func(t *testing.T) {
+		s := servicesMetrics()
+
+		s.ServiceInvocationCacheRequest("catalog", "hit")
+		s.ServiceInvocationCacheRequest("catalog", "miss")
+
+		viewData, _ := view.RetrieveData("runtime/service_invocation/cache/requests_total")
+		v := view.Find("runtime/service_invocation/cache/requests_total")
+
+		assert.Len(t, viewData, 2)
+		allTagsPresent(t, v, viewData[0].Tags)
+		RequireTagExist(t, viewData, NewTag(destinationAppIDKey.Name(), "catalog"))
+		RequireTagExist(t, viewData, NewTag(resultKey.Name(), "hit"))
+		RequireTagExist(t, viewData, NewTag(resultKey.Name(), "miss"))
+	})
 }
 
 func TestSerivceMonitoringInit(t *testing.T) {
@@ -78,6 +78,9 @@ func CleanupRegisteredViews(viewNames ...string) {
 	defaultViewsToClean := []string{
 		"runtime/actor/timers",
 		"runtime/actor/reminders",
+		"runtime/service_invocation/http_endpoint/healthy",
+		"runtime/service_invocation/instance/ejected",
+		"component/state/outbox/pending",
 	}
 
 	// append default views to clean if not already present
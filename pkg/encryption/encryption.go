@@ -42,6 +42,11 @@ const (
 type ComponentEncryptionKeys struct {
 	Primary   Key
 	Secondary Key
+	// Set when the keys are in a crypto component.
+	Envelope *EnvelopeKeys
+	// Paths of the fields of JSON values to encrypt. Values are encrypted
+	// in full if empty.
+	Fields []string
 }
 
 // Key holds the key to encrypt an arbitrary object.
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+)
+
+const (
+	cryptoComponentKey      = "encryptionCryptoComponent"
+	keyNamesKey             = "encryptionKeyNames"
+	keyWrapAlgorithmKey     = "encryptionKeyWrapAlgorithm"
+	defaultKeyWrapAlgorithm = "A256KW"
+
+	// Prefix of the records encrypted with envelope encryption.
+	// It can't appear in AES-GCM records, which start with base64-encoded data.
+	envelopePrefix = "env1:"
+	// Size of the data encryption keys, in bytes: AES-256.
+	dataKeySize = 32
+)
+
+// EnvelopeKeys holds the keys, stored in a crypto component, used to wrap
+// the data encryption key generated for each record.
+type EnvelopeKeys struct {
+	// Name of the crypto component.
+	Component string
+	Provider  contribCrypto.SubtleCrypto
+	// Names of the keys in the crypto component. The first one wraps the
+	// keys of new records, the others are only used to read existing ones.
+	KeyNames  []string
+	Algorithm string
+}
+
+// envelope is the format of the records encrypted with envelope encryption.
+type envelope struct {
+	// Name of the key that wrapped the data encryption key.
+	KeyName    string `json:"kid"`
+	Algorithm  string `json:"alg"`
+	WrappedKey []byte `json:"dek"`
+	Tag        []byte `json:"tag,omitempty"`
+	// Nonce and ciphertext of the value, encrypted with AES-GCM.
+	Data []byte `json:"data"`
+}
+
+// ComponentEnvelopeKeys checks if a component definition references a crypto
+// component to wrap data encryption keys with, and returns nil if not.
+func ComponentEnvelopeKeys(component v1alpha1.Component, getProvider func(name string) (contribCrypto.SubtleCrypto, bool)) (*EnvelopeKeys, error) {
+	keys := &EnvelopeKeys{Algorithm: defaultKeyWrapAlgorithm}
+	for _, m := range component.Spec.Metadata {
+		switch m.Name {
+		case cryptoComponentKey:
+			keys.Component = m.Value.String()
+		case keyNamesKey:
+			for _, name := range strings.Split(m.Value.String(), ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					keys.KeyNames = append(keys.KeyNames, name)
+				}
+			}
+		case keyWrapAlgorithmKey:
+			if alg := m.Value.String(); alg != "" {
+				keys.Algorithm = alg
+			}
+		}
+	}
+
+	if keys.Component == "" {
+		return nil, nil
+	}
+	if len(keys.KeyNames) == 0 {
+		return nil, fmt.Errorf("%s: %s is required with %s", errPrefix, keyNamesKey, cryptoComponentKey)
+	}
+	provider, ok := getProvider(keys.Component)
+	if !ok {
+		return nil, fmt.Errorf("%s: crypto component %s not found", errPrefix, keys.Component)
+	}
+	keys.Provider = provider
+	return keys, nil
+}
+
+// hasKey returns true if name is one of the configured keys.
+func (k *EnvelopeKeys) hasKey(name string) bool {
+	for _, n := range k.KeyNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptEnvelope encrypts the value with a new data encryption key, which is
+// wrapped with the current key and stored in the record.
+func encryptEnvelope(ctx context.Context, value []byte, keys *EnvelopeKeys) ([]byte, error) {
+	dek := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return value, err
+	}
+	dekJWK, err := jwk.FromRaw(dek)
+	if err != nil {
+		return value, err
+	}
+
+	env := envelope{
+		KeyName:   keys.KeyNames[0],
+		Algorithm: keys.Algorithm,
+	}
+	env.WrappedKey, env.Tag, err = keys.Provider.WrapKey(ctx, dekJWK, env.Algorithm, env.KeyName, nil, nil)
+	if err != nil {
+		return value, fmt.Errorf("failed to wrap the data encryption key with key %s: %w", env.KeyName, err)
+	}
+
+	aead, err := newDataKeyCipher(dek)
+	if err != nil {
+		return value, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return value, err
+	}
+	// The key name is authenticated so it can't be swapped
+	env.Data = aead.Seal(nonce, nonce, value, []byte(env.KeyName))
+
+	enc, err := json.Marshal(env)
+	if err != nil {
+		return value, err
+	}
+	return append([]byte(envelopePrefix), enc...), nil
+}
+
+// decryptEnvelope unwraps the data encryption key of the record and decrypts
+// the value with it.
+func decryptEnvelope(ctx context.Context, value []byte, keys *EnvelopeKeys) ([]byte, error) {
+	var env envelope
+	if err := json.Unmarshal(value[len(envelopePrefix):], &env); err != nil {
+		return value, fmt.Errorf("invalid envelope: %w", err)
+	}
+	if !keys.hasKey(env.KeyName) {
+		return value, fmt.Errorf("encryption key %s is not configured", env.KeyName)
+	}
+
+	dekJWK, err := keys.Provider.UnwrapKey(ctx, env.WrappedKey, env.Algorithm, env.KeyName, nil, env.Tag, nil)
+	if err != nil {
+		return value, fmt.Errorf("failed to unwrap the data encryption key with key %s: %w", env.KeyName, err)
+	}
+	var dek []byte
+	if err = dekJWK.Raw(&dek); err != nil {
+		return value, err
+	}
+
+	aead, err := newDataKeyCipher(dek)
+	if err != nil {
+		return value, err
+	}
+	nsize := aead.NonceSize()
+	if len(env.Data) < nsize {
+		return value, errors.New("invalid envelope: data is too short")
+	}
+	return aead.Open(nil, env.Data[:nsize], env.Data[nsize:], []byte(env.KeyName))
+}
+
+// isEnvelope returns true if the record was encrypted with envelope encryption.
+func isEnvelope(value []byte) bool {
+	return bytes.HasPrefix(value, []byte(envelopePrefix))
+}
+
+func newDataKeyCipher(dek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
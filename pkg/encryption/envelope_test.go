@@ -0,0 +1,225 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsV1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	contribCrypto "github.com/dapr/components-contrib/crypto"
+	commonapi "github.com/dapr/dapr/pkg/apis/common"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+)
+
+// mockCrypto "wraps" keys by XOR-ing them with the first byte of the key name.
+type mockCrypto struct {
+	contribCrypto.SubtleCrypto
+	keys map[string]bool
+}
+
+func (m *mockCrypto) WrapKey(ctx context.Context, plaintextKey jwk.Key, algorithm string, keyName string, nonce []byte, associatedData []byte) ([]byte, []byte, error) {
+	if !m.keys[keyName] {
+		return nil, nil, errors.New("key not found")
+	}
+	var raw []byte
+	if err := plaintextKey.Raw(&raw); err != nil {
+		return nil, nil, err
+	}
+	return xorKey(raw, keyName), nil, nil
+}
+
+func (m *mockCrypto) UnwrapKey(ctx context.Context, wrappedKey []byte, algorithm string, keyName string, nonce []byte, tag []byte, associatedData []byte) (jwk.Key, error) {
+	if !m.keys[keyName] {
+		return nil, errors.New("key not found")
+	}
+	return jwk.FromRaw(xorKey(wrappedKey, keyName))
+}
+
+func xorKey(key []byte, keyName string) []byte {
+	res := make([]byte, len(key))
+	for i := range key {
+		res[i] = key[i] ^ keyName[0]
+	}
+	return res
+}
+
+func metadataItem(name, value string) commonapi.NameValuePair {
+	return commonapi.NameValuePair{
+		Name:  name,
+		Value: commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte(value)}},
+	}
+}
+
+func TestComponentEnvelopeKeys(t *testing.T) {
+	provider := &mockCrypto{}
+	getProvider := func(name string) (contribCrypto.SubtleCrypto, bool) {
+		return provider, name == "kms"
+	}
+	newComponent := func(items ...commonapi.NameValuePair) v1alpha1.Component {
+		return v1alpha1.Component{Spec: v1alpha1.ComponentSpec{Metadata: items}}
+	}
+
+	t.Run("no crypto component", func(t *testing.T) {
+		keys, err := ComponentEnvelopeKeys(newComponent(metadataItem("foo", "bar")), getProvider)
+		require.NoError(t, err)
+		assert.Nil(t, keys)
+	})
+
+	t.Run("keys and algorithm", func(t *testing.T) {
+		keys, err := ComponentEnvelopeKeys(newComponent(
+			metadataItem(cryptoComponentKey, "kms"),
+			metadataItem(keyNamesKey, "key3, key2,key1"),
+		), getProvider)
+		require.NoError(t, err)
+		require.NotNil(t, keys)
+		assert.Equal(t, "kms", keys.Component)
+		assert.Equal(t, []string{"key3", "key2", "key1"}, keys.KeyNames)
+		assert.Equal(t, defaultKeyWrapAlgorithm, keys.Algorithm)
+		assert.Same(t, provider, keys.Provider)
+
+		keys, err = ComponentEnvelopeKeys(newComponent(
+			metadataItem(cryptoComponentKey, "kms"),
+			metadataItem(keyNamesKey, "key1"),
+			metadataItem(keyWrapAlgorithmKey, "RSA-OAEP-256"),
+		), getProvider)
+		require.NoError(t, err)
+		assert.Equal(t, "RSA-OAEP-256", keys.Algorithm)
+	})
+
+	t.Run("missing key names", func(t *testing.T) {
+		_, err := ComponentEnvelopeKeys(newComponent(metadataItem(cryptoComponentKey, "kms")), getProvider)
+		require.Error(t, err)
+	})
+
+	t.Run("crypto component not found", func(t *testing.T) {
+		_, err := ComponentEnvelopeKeys(newComponent(
+			metadataItem(cryptoComponentKey, "other"),
+			metadataItem(keyNamesKey, "key1"),
+		), getProvider)
+		require.Error(t, err)
+	})
+}
+
+func TestEnvelopeEncryption(t *testing.T) {
+	provider := &mockCrypto{keys: map[string]bool{"key1": true, "key2": true, "key3": true}}
+	value := []byte("hello world")
+
+	t.Run("value encrypted and decrypted successfully", func(t *testing.T) {
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{
+			Envelope: &EnvelopeKeys{Provider: provider, KeyNames: []string{"key1"}, Algorithm: defaultKeyWrapAlgorithm},
+		})
+
+		enc, err := TryEncryptValue(context.Background(), "test", value)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(enc, []byte(envelopePrefix)))
+		assert.NotContains(t, string(enc), string(value))
+
+		// Each record gets its own data key
+		enc2, err := TryEncryptValue(context.Background(), "test", value)
+		require.NoError(t, err)
+		assert.NotEqual(t, enc, enc2)
+
+		dec, err := TryDecryptValue(context.Background(), "test", enc)
+		require.NoError(t, err)
+		assert.Equal(t, value, dec)
+	})
+
+	t.Run("rotated keys", func(t *testing.T) {
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{
+			Envelope: &EnvelopeKeys{Provider: provider, KeyNames: []string{"key1"}},
+		})
+		old, err := TryEncryptValue(context.Background(), "test", value)
+		require.NoError(t, err)
+
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{
+			Envelope: &EnvelopeKeys{Provider: provider, KeyNames: []string{"key3", "key2", "key1"}},
+		})
+		enc, err := TryEncryptValue(context.Background(), "test", value)
+		require.NoError(t, err)
+		assert.Contains(t, string(enc), `"kid":"key3"`)
+
+		for _, v := range [][]byte{old, enc} {
+			dec, err := TryDecryptValue(context.Background(), "test", v)
+			require.NoError(t, err)
+			assert.Equal(t, value, dec)
+		}
+
+		// Records wrapped with keys that were removed can't be read
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{
+			Envelope: &EnvelopeKeys{Provider: provider, KeyNames: []string{"key3"}},
+		})
+		_, err = TryDecryptValue(context.Background(), "test", old)
+		require.Error(t, err)
+	})
+
+	t.Run("tampered key name", func(t *testing.T) {
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{
+			Envelope: &EnvelopeKeys{Provider: provider, KeyNames: []string{"key1", "key2"}},
+		})
+		enc, err := TryEncryptValue(context.Background(), "test", value)
+		require.NoError(t, err)
+
+		tampered := bytes.Replace(enc, []byte(`"kid":"key1"`), []byte(`"kid":"key2"`), 1)
+		_, err = TryDecryptValue(context.Background(), "test", tampered)
+		require.Error(t, err)
+	})
+
+	t.Run("existing AES-GCM records can still be read", func(t *testing.T) {
+		keyBytes := make([]byte, 32)
+		rand.Read(keyBytes)
+		pr := Key{Name: "primary", Key: hex.EncodeToString(keyBytes)}
+		pr.cipherObj, _ = createCipher(pr, AESGCMAlgorithm)
+
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{Primary: pr})
+		legacy, err := TryEncryptValue(context.Background(), "test", value)
+		require.NoError(t, err)
+
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{
+			Primary:  pr,
+			Envelope: &EnvelopeKeys{Provider: provider, KeyNames: []string{"key1"}},
+		})
+		dec, err := TryDecryptValue(context.Background(), "test", legacy)
+		require.NoError(t, err)
+		assert.Equal(t, value, dec)
+
+		// New records use envelope encryption
+		enc, err := TryEncryptValue(context.Background(), "test", value)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(enc, []byte(envelopePrefix)))
+
+		// Without the AES-GCM key, the old records can't be read
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{
+			Envelope: &EnvelopeKeys{Provider: provider, KeyNames: []string{"key1"}},
+		})
+		_, err = TryDecryptValue(context.Background(), "test", legacy)
+		require.Error(t, err)
+	})
+}
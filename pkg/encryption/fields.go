@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dapr/components-contrib/state/query"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+)
+
+const fieldsKey = "encryptionFields"
+
+// ComponentEncryptedFields returns the paths of the fields of JSON values
+// that a component encrypts, such as "ssn" or "card.number".
+func ComponentEncryptedFields(component v1alpha1.Component) []string {
+	var fields []string
+	for _, m := range component.Spec.Metadata {
+		if m.Name != fieldsKey {
+			continue
+		}
+		for _, f := range strings.Split(m.Value.String(), ",") {
+			if f = strings.Trim(strings.TrimSpace(f), "."); f != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// EncryptedFieldsStateStore returns true if a state store encrypts only some
+// fields of JSON values, so the others can be queried.
+func EncryptedFieldsStateStore(storeName string) bool {
+	keys, ok := encryptedStateStores[storeName]
+	return ok && len(keys.Fields) > 0
+}
+
+// QueryEncryptedField returns the first field encrypted by the state store
+// that the query filters or sorts by, if any.
+func QueryEncryptedField(storeName string, q query.Query) (string, bool) {
+	fields := encryptedStateStores[storeName].Fields
+	for _, s := range q.Sort {
+		if f, ok := matchField(fields, s.Key); ok {
+			return f, true
+		}
+	}
+	return filterEncryptedField(fields, q.Filters)
+}
+
+func filterEncryptedField(fields []string, filter any) (string, bool) {
+	switch v := filter.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if f, ok := matchField(fields, k); ok {
+				return f, true
+			}
+			if f, ok := filterEncryptedField(fields, child); ok {
+				return f, true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if f, ok := filterEncryptedField(fields, child); ok {
+				return f, true
+			}
+		}
+	}
+	return "", false
+}
+
+// matchField returns the encrypted field that contains the one at path.
+func matchField(fields []string, path string) (string, bool) {
+	for _, f := range fields {
+		if path == f || strings.HasPrefix(path, f+".") {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// isJSONObject returns true if the value is a JSON object.
+func isJSONObject(value []byte) bool {
+	value = bytes.TrimSpace(value)
+	return len(value) > 0 && value[0] == '{' && json.Valid(value)
+}
+
+// transformFields calls fn with the value of each field of a JSON object,
+// replacing it with the result. Missing and null fields are skipped.
+func transformFields(value []byte, fields []string, fn func(field string, raw json.RawMessage) (json.RawMessage, error)) ([]byte, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(value, &obj); err != nil {
+		return value, err
+	}
+	for _, f := range fields {
+		if err := transformField(obj, strings.Split(f, "."), func(raw json.RawMessage) (json.RawMessage, error) {
+			return fn(f, raw)
+		}); err != nil {
+			return value, err
+		}
+	}
+	return json.Marshal(obj)
+}
+
+func transformField(obj map[string]json.RawMessage, path []string, fn func(raw json.RawMessage) (json.RawMessage, error)) error {
+	raw, ok := obj[path[0]]
+	if !ok || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil
+	}
+
+	var err error
+	if len(path) == 1 {
+		obj[path[0]], err = fn(raw)
+		return err
+	}
+
+	var child map[string]json.RawMessage
+	if json.Unmarshal(raw, &child) != nil {
+		// Not an object, so the field doesn't exist
+		return nil
+	}
+	if err = transformField(child, path[1:], fn); err != nil {
+		return err
+	}
+	obj[path[0]], err = json.Marshal(child)
+	return err
+}
+
+// encryptFields replaces the fields of a JSON object with strings containing
+// their encrypted JSON value.
+func encryptFields(ctx context.Context, value []byte, keys ComponentEncryptionKeys) ([]byte, error) {
+	return transformFields(value, keys.Fields, func(field string, raw json.RawMessage) (json.RawMessage, error) {
+		enc, err := encryptValue(ctx, keys, raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt field %s: %w", field, err)
+		}
+		return json.Marshal(string(enc))
+	})
+}
+
+// decryptFields restores the encrypted fields of a JSON object.
+func decryptFields(ctx context.Context, storeName string, value []byte, keys ComponentEncryptionKeys) ([]byte, error) {
+	return transformFields(value, keys.Fields, func(field string, raw json.RawMessage) (json.RawMessage, error) {
+		var enc string
+		if err := json.Unmarshal(raw, &enc); err != nil {
+			return nil, fmt.Errorf("could not decrypt field %s for state store %s: value is not encrypted", field, storeName)
+		}
+		dec, err := decryptValue(ctx, storeName, []byte(enc), keys)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field, err)
+		}
+		if !json.Valid(dec) {
+			return nil, fmt.Errorf("could not decrypt field %s for state store %s: invalid JSON value", field, storeName)
+		}
+		return dec, nil
+	})
+}
+
+// fieldsNeedRotation returns true if any encrypted field of a JSON object
+// isn't encrypted with the current key.
+func fieldsNeedRotation(value []byte, keys ComponentEncryptionKeys) bool {
+	errRotate := errors.New("rotate")
+	_, err := transformFields(value, keys.Fields, func(field string, raw json.RawMessage) (json.RawMessage, error) {
+		var enc string
+		if json.Unmarshal(raw, &enc) != nil || valueNeedsRotation(keys, []byte(enc)) {
+			return nil, errRotate
+		}
+		return raw, nil
+	})
+	return err != nil
+}
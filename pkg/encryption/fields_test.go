@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state/query"
+	commonapi "github.com/dapr/dapr/pkg/apis/common"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+)
+
+func TestComponentEncryptedFields(t *testing.T) {
+	assert.Empty(t, ComponentEncryptedFields(v1alpha1.Component{}))
+
+	fields := ComponentEncryptedFields(v1alpha1.Component{Spec: v1alpha1.ComponentSpec{Metadata: []commonapi.NameValuePair{
+		metadataItem(fieldsKey, "ssn, card.number,,"),
+	}}})
+	assert.Equal(t, []string{"ssn", "card.number"}, fields)
+}
+
+func TestFieldEncryption(t *testing.T) {
+	key := newTestKey(t, "primary")
+	encryptedStateStores = map[string]ComponentEncryptionKeys{}
+	AddEncryptedStateStore("test", ComponentEncryptionKeys{Primary: key, Fields: []string{"ssn", "card.number", "card.cvv"}})
+
+	t.Run("only the fields are encrypted", func(t *testing.T) {
+		value := []byte(`{"name":"Jane","ssn":"123-45-6789","card":{"number":4111111111111111,"expiry":"12/30"},"age":42}`)
+		enc, err := TryEncryptValue(context.Background(), "test", value)
+		require.NoError(t, err)
+
+		var obj map[string]any
+		require.NoError(t, json.Unmarshal(enc, &obj))
+		assert.Equal(t, "Jane", obj["name"])
+		assert.Equal(t, float64(42), obj["age"])
+		assert.NotContains(t, string(enc), "123-45-6789")
+		assert.NotContains(t, string(enc), "4111111111111111")
+		card := obj["card"].(map[string]any)
+		assert.Equal(t, "12/30", card["expiry"])
+		assert.IsType(t, "", card["number"])
+		assert.NotContains(t, card, "cvv")
+
+		dec, err := TryDecryptValue(context.Background(), "test", enc)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(value), string(dec))
+	})
+
+	t.Run("values that aren't objects are encrypted in full", func(t *testing.T) {
+		for _, value := range []string{`"hello"`, `[1,2]`, `not json`} {
+			enc, err := TryEncryptValue(context.Background(), "test", []byte(value))
+			require.NoError(t, err)
+			assert.NotContains(t, string(enc), value)
+
+			dec, err := TryDecryptValue(context.Background(), "test", enc)
+			require.NoError(t, err)
+			assert.Equal(t, value, string(dec))
+		}
+	})
+
+	t.Run("null and non-object parents are left alone", func(t *testing.T) {
+		value := []byte(`{"ssn":null,"card":"none"}`)
+		enc, err := TryEncryptValue(context.Background(), "test", value)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(value), string(enc))
+
+		dec, err := TryDecryptValue(context.Background(), "test", enc)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(value), string(dec))
+	})
+
+	t.Run("unencrypted fields can't be read", func(t *testing.T) {
+		_, err := TryDecryptValue(context.Background(), "test", []byte(`{"ssn":"123-45-6789"}`))
+		require.Error(t, err)
+		_, err = TryDecryptValue(context.Background(), "test", []byte(`{"ssn":12345}`))
+		require.Error(t, err)
+	})
+
+	t.Run("state request values are serialized as JSON", func(t *testing.T) {
+		enc, err := TryEncryptStateValue(context.Background(), "test", map[string]any{"name": "Jane", "ssn": "123-45-6789"})
+		require.NoError(t, err)
+		dec, err := TryDecryptValue(context.Background(), "test", enc)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"name":"Jane","ssn":"123-45-6789"}`, string(dec))
+
+		enc, err = TryEncryptStateValue(context.Background(), "test", []byte(`{"ssn":"1"}`))
+		require.NoError(t, err)
+		dec, err = TryDecryptValue(context.Background(), "test", enc)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"ssn":"1"}`, string(dec))
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		enc, err := TryEncryptValue(context.Background(), "test", []byte(`{"ssn":"1","card":{"number":2}}`))
+		require.NoError(t, err)
+		keys := encryptedStateStores["test"]
+		assert.False(t, needsRotation(keys, enc))
+
+		keys.Primary = newTestKey(t, "new")
+		keys.Secondary = key
+		assert.True(t, needsRotation(keys, enc))
+	})
+}
+
+func TestQueryEncryptedField(t *testing.T) {
+	encryptedStateStores = map[string]ComponentEncryptionKeys{}
+	AddEncryptedStateStore("test", ComponentEncryptionKeys{Fields: []string{"ssn", "card"}})
+	assert.True(t, EncryptedFieldsStateStore("test"))
+
+	parse := func(q string) query.Query {
+		var res query.Query
+		require.NoError(t, json.Unmarshal([]byte(q), &res))
+		return res
+	}
+
+	_, ok := QueryEncryptedField("test", parse(`{"filter":{"AND":[{"EQ":{"name":"Jane"}},{"IN":{"age":[1,2]}}]},"sort":[{"key":"name"}]}`))
+	assert.False(t, ok)
+
+	field, ok := QueryEncryptedField("test", parse(`{"filter":{"OR":[{"EQ":{"name":"Jane"}},{"EQ":{"card.number":"1"}}]}}`))
+	assert.True(t, ok)
+	assert.Equal(t, "card", field)
+
+	field, ok = QueryEncryptedField("test", parse(`{"sort":[{"key":"ssn"}]}`))
+	assert.True(t, ok)
+	assert.Equal(t, "ssn", field)
+
+	_, ok = QueryEncryptedField("test", parse(`{"sort":[{"key":"ssnumber"}]}`))
+	assert.False(t, ok)
+}
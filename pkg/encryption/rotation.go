@@ -0,0 +1,445 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	compstate "github.com/dapr/dapr/pkg/components/state"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/utils"
+)
+
+const (
+	rotateOnStartupKey = "encryptionRotateOnStartup"
+	rotationRateKey    = "encryptionRotationRate"
+
+	// Prefix of the key of the record where the completion of the rotation of
+	// the records of an app is stored. Like the keys of actor reminders, it's
+	// outside of the keys of the app.
+	rotationMarkerPrefix    = "dapr-encryption-rotation"
+	defaultRotationPageSize = 100
+)
+
+var log = logger.NewLogger("dapr.encryption")
+
+// Errors returned by StartKeyRotation.
+var (
+	ErrStoreNotEncrypted   = errors.New("state store is not encrypted")
+	ErrRotationUnsupported = errors.New("state store supports neither querying nor listing keys")
+	ErrRotationRunning     = errors.New("a key rotation is already running")
+
+	errAlreadyRotated = errors.New("already rotated")
+	errNoETag         = errors.New("the state store returned no ETag, so the record can't be rewritten safely")
+)
+
+// RotationState is the state of a key rotation.
+type RotationState string
+
+const (
+	RotationRunning   RotationState = "running"
+	RotationCompleted RotationState = "completed"
+	RotationFailed    RotationState = "failed"
+)
+
+// RotationOptions configures a key rotation.
+type RotationOptions struct {
+	// Maximum number of records rewritten per second. No limit if zero.
+	RecordsPerSecond int `json:"recordsPerSecond,omitempty"`
+	// Number of keys requested to the store at a time.
+	PageSize int `json:"pageSize,omitempty"`
+	// Don't scan the store if a rotation to the current key has completed.
+	SkipIfCompleted bool `json:"-"`
+	// Called with the key, as saved, of each record rewritten, to invalidate
+	// the value cached for it.
+	OnRewritten func(key string) `json:"-"`
+}
+
+// RotationStatus reports the progress of a key rotation.
+type RotationStatus struct {
+	StoreName string        `json:"storeName"`
+	KeyName   string        `json:"keyName"`
+	State     RotationState `json:"state"`
+	// Number of records scanned, rewritten with the current key, left alone
+	// because they already use it or changed meanwhile, and not rewritten
+	// because of errors.
+	Scanned     int64      `json:"scanned"`
+	Rewritten   int64      `json:"rewritten"`
+	Skipped     int64      `json:"skipped"`
+	Failed      int64      `json:"failed"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// rotationMarker is stored in the state store once all its records use a key.
+type rotationMarker struct {
+	KeyName     string    `json:"keyName"`
+	CompletedAt time.Time `json:"completedAt"`
+	Rewritten   int64     `json:"rewritten"`
+}
+
+type rotation struct {
+	lock   sync.Mutex
+	status RotationStatus
+	appID  string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	rotationsLock sync.Mutex
+	rotations     = map[string]*rotation{}
+)
+
+// RotationOnStartup returns the options of the key rotation to start when the
+// state store is initialized, and false if none is configured.
+func RotationOnStartup(component v1alpha1.Component) (RotationOptions, bool) {
+	opts := RotationOptions{SkipIfCompleted: true}
+	enabled := false
+	for _, m := range component.Spec.Metadata {
+		switch m.Name {
+		case rotateOnStartupKey:
+			enabled = utils.IsTruthy(m.Value.String())
+		case rotationRateKey:
+			opts.RecordsPerSecond, _ = strconv.Atoi(m.Value.String())
+		}
+	}
+	return opts, enabled
+}
+
+// StartKeyRotation starts rewriting in the background all the records of an
+// app in an encrypted state store that don't use its current key. The
+// rotation runs until it completes or StopKeyRotation is called.
+func StartKeyRotation(storeName, appID string, store state.Store, opts RotationOptions) (RotationStatus, error) {
+	if !EncryptedStateStore(storeName) {
+		return RotationStatus{}, ErrStoreNotEncrypted
+	}
+	if !compstate.CanListKeys(store) {
+		return RotationStatus{}, ErrRotationUnsupported
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultRotationPageSize
+	}
+
+	rotationsLock.Lock()
+	defer rotationsLock.Unlock()
+	if r, ok := rotations[storeName]; ok && r.snapshot().State == RotationRunning {
+		return RotationStatus{}, ErrRotationRunning
+	}
+
+	now := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &rotation{
+		status: RotationStatus{
+			StoreName: storeName,
+			KeyName:   currentKeyName(encryptedStateStores[storeName]),
+			State:     RotationRunning,
+			StartedAt: &now,
+		},
+		appID:  appID,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	rotations[storeName] = r
+	go func() {
+		defer close(r.done)
+		defer cancel()
+		r.run(ctx, store, opts)
+	}()
+	return r.snapshot(), nil
+}
+
+// StopKeyRotation stops the key rotation of a state store, if one is running,
+// and waits for it to return. It's called when the state store is closed.
+func StopKeyRotation(storeName string) {
+	rotationsLock.Lock()
+	r, ok := rotations[storeName]
+	rotationsLock.Unlock()
+	if !ok {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+// KeyRotationStatus returns the status of the last key rotation of a state
+// store: the one running or completed since the sidecar started, or else the
+// one recorded in the store.
+func KeyRotationStatus(ctx context.Context, storeName, appID string, store state.Store) (RotationStatus, bool, error) {
+	rotationsLock.Lock()
+	r, ok := rotations[storeName]
+	rotationsLock.Unlock()
+	if ok {
+		return r.snapshot(), true, nil
+	}
+
+	marker, err := getRotationMarker(ctx, appID, store)
+	if err != nil || marker == nil {
+		return RotationStatus{}, false, err
+	}
+	return RotationStatus{
+		StoreName:   storeName,
+		KeyName:     marker.KeyName,
+		State:       RotationCompleted,
+		Rewritten:   marker.Rewritten,
+		CompletedAt: &marker.CompletedAt,
+	}, true, nil
+}
+
+func (r *rotation) snapshot() RotationStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.status
+}
+
+func (r *rotation) update(fn func(s *RotationStatus)) {
+	r.lock.Lock()
+	fn(&r.status)
+	r.lock.Unlock()
+}
+
+func (r *rotation) run(ctx context.Context, store state.Store, opts RotationOptions) {
+	status := r.snapshot()
+	err := r.rotate(ctx, store, opts)
+	if errors.Is(err, errAlreadyRotated) {
+		log.Debugf("Encryption keys of state store %s already rotated to %s", status.StoreName, status.KeyName)
+		now := time.Now()
+		r.update(func(s *RotationStatus) {
+			s.State = RotationCompleted
+			s.CompletedAt = &now
+		})
+		return
+	}
+	if err == nil {
+		if s := r.snapshot(); s.Failed > 0 {
+			err = fmt.Errorf("%d records could not be rewritten", s.Failed)
+		}
+	}
+	if err == nil {
+		err = setRotationMarker(ctx, r.appID, store, rotationMarker{
+			KeyName:     status.KeyName,
+			CompletedAt: time.Now().UTC(),
+			Rewritten:   r.snapshot().Rewritten,
+		})
+	}
+
+	now := time.Now()
+	r.update(func(s *RotationStatus) {
+		s.CompletedAt = &now
+		if err != nil {
+			s.State = RotationFailed
+			s.Error = err.Error()
+		} else {
+			s.State = RotationCompleted
+		}
+	})
+	status = r.snapshot()
+	if err != nil {
+		log.Errorf("Failed to rotate the encryption keys of state store %s: %v", status.StoreName, err)
+		return
+	}
+	log.Infof("Completed the rotation of state store %s to encryption key %s: %d records scanned, %d rewritten", status.StoreName, status.KeyName, status.Scanned, status.Rewritten)
+}
+
+func (r *rotation) rotate(ctx context.Context, store state.Store, opts RotationOptions) error {
+	status := r.snapshot()
+	storeName, keyName := status.StoreName, status.KeyName
+	if opts.SkipIfCompleted {
+		marker, err := getRotationMarker(ctx, r.appID, store)
+		if err != nil {
+			return err
+		}
+		if marker != nil && marker.KeyName == keyName {
+			return errAlreadyRotated
+		}
+	}
+
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if opts.RecordsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.RecordsPerSecond), 1)
+	}
+
+	log.Infof("Rotating the encryption keys of state store %s to %s", storeName, keyName)
+	token := ""
+	for {
+		keys, next, err := compstate.ListKeys(ctx, store, token, opts.PageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list keys: %w", err)
+		}
+		for _, key := range keys {
+			// Other apps may share the store.
+			if _, ok := compstate.GetAppStateKey(key, storeName, r.appID); !ok || key == rotationMarkerKey(r.appID) {
+				continue
+			}
+			rewritten, err := rotateRecord(ctx, storeName, store, key, limiter)
+			if err != nil {
+				log.Warnf("Failed to rotate the encryption key of record %s in state store %s: %v", key, storeName, err)
+			} else if rewritten && opts.OnRewritten != nil {
+				opts.OnRewritten(key)
+			}
+			r.update(func(s *RotationStatus) {
+				s.Scanned++
+				switch {
+				case err != nil:
+					s.Failed++
+				case rewritten:
+					s.Rewritten++
+				default:
+					s.Skipped++
+				}
+			})
+		}
+
+		s := r.snapshot()
+		log.Debugf("Rotating the encryption keys of state store %s: %d records scanned, %d rewritten, %d failed", storeName, s.Scanned, s.Rewritten, s.Failed)
+		if next == "" || len(keys) == 0 {
+			return nil
+		}
+		token = next
+	}
+}
+
+// rotateRecord rewrites a record with the current key, unless it already uses
+// it. Records changed since they were read are left alone; so records without
+// an ETag can't be rewritten.
+func rotateRecord(ctx context.Context, storeName string, store state.Store, key string, limiter *rate.Limiter) (bool, error) {
+	res, err := store.Get(ctx, &state.GetRequest{Key: key})
+	if err != nil {
+		return false, err
+	}
+	if res == nil || len(res.Data) == 0 {
+		return false, nil
+	}
+	keys := encryptedStateStores[storeName]
+	if !needsRotation(keys, res.Data) {
+		return false, nil
+	}
+	if res.ETag == nil {
+		return false, errNoETag
+	}
+
+	val, err := TryDecryptValue(ctx, storeName, res.Data)
+	if err != nil {
+		return false, err
+	}
+	enc, err := TryEncryptValue(ctx, storeName, val)
+	if err != nil {
+		return false, err
+	}
+
+	req := &state.SetRequest{
+		Key:   key,
+		Value: enc,
+		ETag:  res.ETag,
+		Options: state.SetStateOption{
+			Concurrency: state.FirstWrite,
+		},
+	}
+	// Keep the time-to-live of the record
+	if exp, ok := res.Metadata[state.GetRespMetaKeyTTLExpireTime]; ok {
+		if t, pErr := time.Parse(time.RFC3339, exp); pErr == nil {
+			ttl := int64(math.Ceil(time.Until(t).Seconds()))
+			if ttl <= 0 {
+				return false, nil
+			}
+			req.Metadata = map[string]string{metadata.TTLMetadataKey: strconv.FormatInt(ttl, 10)}
+		}
+	}
+
+	if err = limiter.Wait(ctx); err != nil {
+		return false, err
+	}
+	err = store.Set(ctx, req)
+	var etagErr *state.ETagError
+	if errors.As(err, &etagErr) && etagErr.Kind() == state.ETagMismatch {
+		// The record was written meanwhile, so it uses the current key
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// currentKeyName returns the name of the key new records are encrypted with.
+func currentKeyName(keys ComponentEncryptionKeys) string {
+	if keys.Envelope != nil {
+		return keys.Envelope.KeyNames[0]
+	}
+	return keys.Primary.Name
+}
+
+// needsRotation returns true if the record isn't encrypted with the key new
+// records are encrypted with.
+func needsRotation(keys ComponentEncryptionKeys, value []byte) bool {
+	if len(keys.Fields) > 0 && isJSONObject(value) {
+		return fieldsNeedRotation(value, keys)
+	}
+	return valueNeedsRotation(keys, value)
+}
+
+func valueNeedsRotation(keys ComponentEncryptionKeys, value []byte) bool {
+	if isEnvelope(value) {
+		var env envelope
+		if err := json.Unmarshal(value[len(envelopePrefix):], &env); err != nil {
+			return true
+		}
+		return keys.Envelope == nil || env.KeyName != keys.Envelope.KeyNames[0]
+	}
+
+	ind := bytes.LastIndex(value, []byte(separator))
+	return keys.Envelope != nil || ind < 0 || string(value[ind+len(separator):]) != keys.Primary.Name
+}
+
+// rotationMarkerKey returns the key of the rotation marker of an app.
+func rotationMarkerKey(appID string) string {
+	return rotationMarkerPrefix + separator + appID
+}
+
+func getRotationMarker(ctx context.Context, appID string, store state.Store) (*rotationMarker, error) {
+	res, err := store.Get(ctx, &state.GetRequest{Key: rotationMarkerKey(appID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the key rotation status: %w", err)
+	}
+	if res == nil || len(res.Data) == 0 {
+		return nil, nil
+	}
+	var marker rotationMarker
+	if err = json.Unmarshal(res.Data, &marker); err != nil {
+		return nil, fmt.Errorf("invalid key rotation status: %w", err)
+	}
+	return &marker, nil
+}
+
+func setRotationMarker(ctx context.Context, appID string, store state.Store, marker rotationMarker) error {
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	if err = store.Set(ctx, &state.SetRequest{Key: rotationMarkerKey(appID), Value: data}); err != nil {
+		return fmt.Errorf("failed to record the completion of the key rotation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/state"
+	commonapi "github.com/dapr/dapr/pkg/apis/common"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+)
+
+// rotationTestStore is an in-memory store that can list its keys.
+type rotationTestStore struct {
+	state.Store
+	lock  sync.Mutex
+	items map[string][]byte
+	etags map[string]int
+	// Called before each write, to simulate concurrent changes.
+	beforeSet func(key string)
+	noETags   bool
+}
+
+func newRotationTestStore() *rotationTestStore {
+	return &rotationTestStore{items: map[string][]byte{}, etags: map[string]int{}}
+}
+
+func (s *rotationTestStore) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.items[req.Key]
+	if !ok {
+		return &state.GetResponse{}, nil
+	}
+	if s.noETags {
+		return &state.GetResponse{Data: data}, nil
+	}
+	etag := strconv.Itoa(s.etags[req.Key])
+	return &state.GetResponse{Data: data, ETag: &etag}, nil
+}
+
+func (s *rotationTestStore) Set(ctx context.Context, req *state.SetRequest) error {
+	if s.beforeSet != nil {
+		s.beforeSet(req.Key)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if req.ETag != nil && *req.ETag != strconv.Itoa(s.etags[req.Key]) {
+		return state.NewETagError(state.ETagMismatch, nil)
+	}
+	s.items[req.Key] = req.Value.([]byte)
+	s.etags[req.Key]++
+	return nil
+}
+
+func (s *rotationTestStore) ListKeys(ctx context.Context, token string, limit int) ([]string, string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	keys := make([]string, 0, len(s.items))
+	for k := range s.items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(token)
+	end := start + limit
+	if end >= len(keys) {
+		return keys[start:], "", nil
+	}
+	return keys[start:end], strconv.Itoa(end), nil
+}
+
+func newTestKey(t *testing.T, name string) Key {
+	t.Helper()
+	keyBytes := make([]byte, 32)
+	rand.Read(keyBytes)
+	k := Key{Name: name, Key: hex.EncodeToString(keyBytes)}
+	var err error
+	k.cipherObj, err = createCipher(k, AESGCMAlgorithm)
+	require.NoError(t, err)
+	return k
+}
+
+func waitForRotation(t *testing.T, storeName string, store state.Store) RotationStatus {
+	t.Helper()
+	var status RotationStatus
+	require.Eventually(t, func() bool {
+		var found bool
+		var err error
+		status, found, err = KeyRotationStatus(context.Background(), storeName, "myapp", store)
+		return err == nil && found && status.State != RotationRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	return status
+}
+
+func TestRotationOnStartup(t *testing.T) {
+	_, ok := RotationOnStartup(v1alpha1.Component{})
+	assert.False(t, ok)
+
+	opts, ok := RotationOnStartup(v1alpha1.Component{Spec: v1alpha1.ComponentSpec{Metadata: []commonapi.NameValuePair{
+		metadataItem(rotateOnStartupKey, "true"),
+		metadataItem(rotationRateKey, "50"),
+	}}})
+	assert.True(t, ok)
+	assert.Equal(t, 50, opts.RecordsPerSecond)
+	assert.True(t, opts.SkipIfCompleted)
+}
+
+// testRotationMarkerKey is the key of the rotation marker of app "myapp",
+// which is outside of the keys of the app.
+const testRotationMarkerKey = "dapr-encryption-rotation||myapp"
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := newTestKey(t, "old")
+	newKey := newTestKey(t, "new")
+
+	// populate writes n records encrypted with the old key.
+	populate := func(t *testing.T, store *rotationTestStore, n int) {
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{Primary: oldKey})
+		for i := 0; i < n; i++ {
+			enc, err := TryEncryptValue(context.Background(), "test", []byte("value"+strconv.Itoa(i)))
+			require.NoError(t, err)
+			store.items["myapp||key"+strconv.Itoa(i)] = enc
+		}
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{Primary: newKey, Secondary: oldKey})
+	}
+
+	t.Run("records are rewritten with the primary key", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		store := newRotationTestStore()
+		populate(t, store, 25)
+
+		status, err := StartKeyRotation("test", "myapp", store, RotationOptions{PageSize: 10})
+		require.NoError(t, err)
+		assert.Equal(t, "new", status.KeyName)
+
+		status = waitForRotation(t, "test", store)
+		assert.Equal(t, RotationCompleted, status.State)
+		assert.Equal(t, int64(25), status.Scanned)
+		assert.Equal(t, int64(25), status.Rewritten)
+		assert.Zero(t, status.Failed)
+
+		// Without the old key, all records can still be read
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{Primary: newKey})
+		for i := 0; i < 25; i++ {
+			dec, err := TryDecryptValue(context.Background(), "test", store.items["myapp||key"+strconv.Itoa(i)])
+			require.NoError(t, err)
+			assert.Equal(t, "value"+strconv.Itoa(i), string(dec))
+		}
+
+		// The completion is recorded in the store
+		rotations = map[string]*rotation{}
+		status, found, err := KeyRotationStatus(context.Background(), "test", "myapp", store)
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, RotationCompleted, status.State)
+		assert.Equal(t, "new", status.KeyName)
+		assert.Equal(t, int64(25), status.Rewritten)
+	})
+
+	t.Run("records already using the current key are skipped", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		store := newRotationTestStore()
+		populate(t, store, 5)
+		enc, err := TryEncryptValue(context.Background(), "test", []byte("current"))
+		require.NoError(t, err)
+		store.items["myapp||current"] = enc
+
+		var rewritten atomic.Int64
+		_, err = StartKeyRotation("test", "myapp", store, RotationOptions{
+			OnRewritten: func(key string) {
+				assert.NotEqual(t, "myapp||current", key)
+				rewritten.Add(1)
+			},
+		})
+		require.NoError(t, err)
+		status := waitForRotation(t, "test", store)
+		assert.Equal(t, int64(6), status.Scanned)
+		assert.Equal(t, int64(5), status.Rewritten)
+		assert.Equal(t, int64(1), status.Skipped)
+		assert.Equal(t, int64(5), rewritten.Load())
+		assert.Equal(t, enc, store.items["myapp||current"])
+	})
+
+	t.Run("records changed meanwhile are left alone", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		store := newRotationTestStore()
+		populate(t, store, 2)
+		store.beforeSet = func(key string) {
+			if key == "myapp||key0" {
+				store.lock.Lock()
+				store.items[key] = []byte("changed")
+				store.etags[key]++
+				store.lock.Unlock()
+			}
+		}
+
+		_, err := StartKeyRotation("test", "myapp", store, RotationOptions{})
+		require.NoError(t, err)
+		status := waitForRotation(t, "test", store)
+		assert.Equal(t, RotationCompleted, status.State)
+		assert.Equal(t, int64(1), status.Rewritten)
+		assert.Equal(t, int64(1), status.Skipped)
+		assert.Equal(t, []byte("changed"), store.items["myapp||key0"])
+	})
+
+	t.Run("unreadable records fail the rotation", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		store := newRotationTestStore()
+		populate(t, store, 2)
+		store.items["myapp||bad"] = []byte("garbage")
+
+		_, err := StartKeyRotation("test", "myapp", store, RotationOptions{})
+		require.NoError(t, err)
+		status := waitForRotation(t, "test", store)
+		assert.Equal(t, RotationFailed, status.State)
+		assert.Equal(t, int64(1), status.Failed)
+		assert.NotEmpty(t, status.Error)
+		assert.NotContains(t, store.items, testRotationMarkerKey)
+	})
+
+	t.Run("completed rotations are skipped on startup", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		store := newRotationTestStore()
+		populate(t, store, 3)
+		_, err := StartKeyRotation("test", "myapp", store, RotationOptions{})
+		require.NoError(t, err)
+		waitForRotation(t, "test", store)
+		marker := store.items[testRotationMarkerKey]
+
+		rotations = map[string]*rotation{}
+		_, err = StartKeyRotation("test", "myapp", store, RotationOptions{SkipIfCompleted: true})
+		require.NoError(t, err)
+		status := waitForRotation(t, "test", store)
+		assert.Equal(t, RotationCompleted, status.State)
+		assert.Zero(t, status.Scanned)
+		assert.Equal(t, marker, store.items[testRotationMarkerKey])
+	})
+
+	t.Run("one rotation at a time", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		store := newRotationTestStore()
+		populate(t, store, 3)
+		block := make(chan struct{})
+		store.beforeSet = func(string) { <-block }
+
+		_, err := StartKeyRotation("test", "myapp", store, RotationOptions{})
+		require.NoError(t, err)
+		_, err = StartKeyRotation("test", "myapp", store, RotationOptions{})
+		require.ErrorIs(t, err, ErrRotationRunning)
+
+		close(block)
+		waitForRotation(t, "test", store)
+	})
+
+	t.Run("records of other apps are not scanned", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		store := newRotationTestStore()
+		populate(t, store, 2)
+		store.items["otherapp||key0"] = []byte("garbage")
+		store.items[rotationMarkerKey("otherapp")] = []byte("{}")
+
+		_, err := StartKeyRotation("test", "myapp", store, RotationOptions{})
+		require.NoError(t, err)
+		status := waitForRotation(t, "test", store)
+		assert.Equal(t, RotationCompleted, status.State)
+		assert.Equal(t, int64(2), status.Scanned)
+		assert.Equal(t, []byte("garbage"), store.items["otherapp||key0"])
+		assert.Equal(t, []byte("{}"), store.items[rotationMarkerKey("otherapp")])
+	})
+
+	t.Run("records without ETag are not rewritten", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		store := newRotationTestStore()
+		populate(t, store, 2)
+		store.noETags = true
+		old := store.items["myapp||key0"]
+
+		_, err := StartKeyRotation("test", "myapp", store, RotationOptions{})
+		require.NoError(t, err)
+		status := waitForRotation(t, "test", store)
+		assert.Equal(t, RotationFailed, status.State)
+		assert.Equal(t, int64(2), status.Failed)
+		assert.Equal(t, old, store.items["myapp||key0"])
+	})
+
+	t.Run("stopped rotations", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		store := newRotationTestStore()
+		populate(t, store, 3)
+		block := make(chan struct{})
+		store.beforeSet = func(string) { <-block }
+
+		_, err := StartKeyRotation("test", "myapp", store, RotationOptions{RecordsPerSecond: 1})
+		require.NoError(t, err)
+		stopped := make(chan struct{})
+		go func() {
+			StopKeyRotation("test")
+			close(stopped)
+		}()
+		close(block)
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("rotation not stopped")
+		}
+		status := waitForRotation(t, "test", store)
+		assert.Equal(t, RotationFailed, status.State)
+		assert.NotContains(t, store.items, testRotationMarkerKey)
+	})
+
+	t.Run("unsupported stores", func(t *testing.T) {
+		rotations = map[string]*rotation{}
+		encryptedStateStores = map[string]ComponentEncryptionKeys{}
+		_, err := StartKeyRotation("test", "myapp", newRotationTestStore(), RotationOptions{})
+		require.ErrorIs(t, err, ErrStoreNotEncrypted)
+
+		AddEncryptedStateStore("test", ComponentEncryptionKeys{Primary: newKey})
+		_, err = StartKeyRotation("test", "myapp", struct{ state.Store }{}, RotationOptions{})
+		require.ErrorIs(t, err, ErrRotationUnsupported)
+	})
+}
@@ -15,7 +15,9 @@ package encryption
 
 import (
 	"bytes"
+	"context"
 	b64 "encoding/base64"
+	"encoding/json"
 	"fmt"
 )
 
@@ -43,9 +45,39 @@ func EncryptedStateStore(storeName string) bool {
 
 // TryEncryptValue will try to encrypt a byte array if the state store has associated encryption keys.
 // The function will append the name of the key to the value for later extraction.
+// If the keys are in a crypto component, the value is encrypted with a new data key, which is stored wrapped in the record.
+// If the state store encrypts only some fields, and the value is a JSON object, only those fields are encrypted.
 // If no encryption keys exist, the function will return the bytes unmodified.
-func TryEncryptValue(storeName string, value []byte) ([]byte, error) {
+func TryEncryptValue(ctx context.Context, storeName string, value []byte) ([]byte, error) {
 	keys := encryptedStateStores[storeName]
+	if len(keys.Fields) > 0 && isJSONObject(value) {
+		return encryptFields(ctx, value, keys)
+	}
+	return encryptValue(ctx, keys, value)
+}
+
+// TryEncryptStateValue is like TryEncryptValue, for the value of a state request.
+// Values of state stores that encrypt only some fields are serialized as JSON.
+func TryEncryptStateValue(ctx context.Context, storeName string, value any) ([]byte, error) {
+	if !EncryptedFieldsStateStore(storeName) {
+		return TryEncryptValue(ctx, storeName, []byte(fmt.Sprintf("%v", value)))
+	}
+
+	data, ok := value.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	return TryEncryptValue(ctx, storeName, data)
+}
+
+func encryptValue(ctx context.Context, keys ComponentEncryptionKeys, value []byte) ([]byte, error) {
+	if keys.Envelope != nil {
+		return encryptEnvelope(ctx, value, keys.Envelope)
+	}
+
 	enc, err := encrypt(value, keys.Primary)
 	if err != nil {
 		return value, err
@@ -57,12 +89,30 @@ func TryEncryptValue(storeName string, value []byte) ([]byte, error) {
 
 // TryDecryptValue will try to decrypt a byte array if the state store has associated encryption keys.
 // If no encryption keys exist, the function will return the bytes unmodified.
-func TryDecryptValue(storeName string, value []byte) ([]byte, error) {
+func TryDecryptValue(ctx context.Context, storeName string, value []byte) ([]byte, error) {
 	if len(value) == 0 {
 		return []byte(""), nil
 	}
 
 	keys := encryptedStateStores[storeName]
+	if len(keys.Fields) > 0 && isJSONObject(value) {
+		return decryptFields(ctx, storeName, value, keys)
+	}
+	return decryptValue(ctx, storeName, value, keys)
+}
+
+func decryptValue(ctx context.Context, storeName string, value []byte, keys ComponentEncryptionKeys) ([]byte, error) {
+	if isEnvelope(value) {
+		if keys.Envelope == nil {
+			return value, fmt.Errorf("could not decrypt data for state store %s: no crypto component configured", storeName)
+		}
+		val, err := decryptEnvelope(ctx, value, keys.Envelope)
+		if err != nil {
+			return value, fmt.Errorf("could not decrypt data for state store %s: %w", storeName, err)
+		}
+		return val, nil
+	}
+
 	// extract the decryption key that should be appended to the value
 	ind := bytes.LastIndex(value, []byte(separator))
 	keyName := string(value[ind+len(separator):])
@@ -79,5 +129,9 @@ func TryDecryptValue(storeName string, value []byte) ([]byte, error) {
 		key = keys.Secondary
 	}
 
+	if key.cipherObj == nil {
+		return value, fmt.Errorf("could not decrypt data for state store %s: encryption key %s not found", storeName, keyName)
+	}
+
 	return decrypt(value[:ind], key)
 }
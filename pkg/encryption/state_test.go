@@ -14,6 +14,7 @@ limitations under the License.
 package encryption
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
@@ -87,12 +88,12 @@ func TestTryEncryptValue(t *testing.T) {
 		})
 
 		v := []byte("hello")
-		r, err := TryEncryptValue("test", v)
+		r, err := TryEncryptValue(context.Background(), "test", v)
 
 		assert.NoError(t, err)
 		assert.NotEqual(t, v, r)
 
-		dr, err := TryDecryptValue("test", r)
+		dr, err := TryDecryptValue(context.Background(), "test", r)
 		assert.NoError(t, err)
 		assert.Equal(t, v, dr)
 	})
@@ -119,7 +120,7 @@ func TestTryEncryptValue(t *testing.T) {
 		})
 
 		v := []byte("hello")
-		r, err := TryEncryptValue("test", v)
+		r, err := TryEncryptValue(context.Background(), "test", v)
 
 		assert.NoError(t, err)
 		assert.NotEqual(t, v, r)
@@ -129,7 +130,7 @@ func TestTryEncryptValue(t *testing.T) {
 			Secondary: pr,
 		})
 
-		dr, err := TryDecryptValue("test", r)
+		dr, err := TryDecryptValue(context.Background(), "test", r)
 		assert.NoError(t, err)
 		assert.Equal(t, v, dr)
 	})
@@ -157,12 +158,12 @@ func TestTryEncryptValue(t *testing.T) {
 
 		v := []byte("hello")
 		s := base64.StdEncoding.EncodeToString(v)
-		r, err := TryEncryptValue("test", []byte(s))
+		r, err := TryEncryptValue(context.Background(), "test", []byte(s))
 
 		assert.NoError(t, err)
 		assert.NotEqual(t, v, r)
 
-		dr, err := TryDecryptValue("test", r)
+		dr, err := TryDecryptValue(context.Background(), "test", r)
 		assert.NoError(t, err)
 		assert.Equal(t, []byte(s), dr)
 	})
@@ -189,12 +190,12 @@ func TestTryEncryptValue(t *testing.T) {
 		})
 
 		v := []byte("hello world")
-		r, err := TryEncryptValue("test", v)
+		r, err := TryEncryptValue(context.Background(), "test", v)
 
 		assert.NoError(t, err)
 		assert.NotEqual(t, v, r)
 
-		dr, err := TryDecryptValue("test", r)
+		dr, err := TryDecryptValue(context.Background(), "test", r)
 		assert.NoError(t, err)
 		assert.Equal(t, v, dr)
 	})
@@ -222,7 +223,7 @@ func TestTryDecryptValue(t *testing.T) {
 			Primary: pr,
 		})
 
-		dr, err := TryDecryptValue("test", nil)
+		dr, err := TryDecryptValue(context.Background(), "test", nil)
 		assert.NoError(t, err)
 		assert.Empty(t, dr)
 	})
@@ -55,6 +55,7 @@ import (
 	"github.com/dapr/dapr/pkg/resiliency/breaker"
 	"github.com/dapr/dapr/pkg/runtime/channels"
 	runtimePubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+	"github.com/dapr/dapr/pkg/statechanges"
 	"github.com/dapr/dapr/utils"
 )
 
@@ -208,6 +209,10 @@ func (a *api) PublishEvent(ctx context.Context, in *runtimev1pb.PublishEventRequ
 		if errors.As(err, &runtimePubsub.NotFoundError{}) {
 			nerr = status.Errorf(codes.NotFound, err.Error())
 		}
+
+		if errors.As(err, &runtimePubsub.SchemaValidationError{}) {
+			nerr = status.Errorf(codes.InvalidArgument, err.Error())
+		}
 		apiServerLogger.Debug(nerr)
 		return &emptypb.Empty{}, nerr
 	}
@@ -431,6 +436,10 @@ func (a *api) BulkPublishEventAlpha1(ctx context.Context, in *runtimev1pb.BulkPu
 		if errors.As(err, &runtimePubsub.NotFoundError{}) {
 			nerr = status.Errorf(codes.NotFound, err.Error())
 		}
+
+		if errors.As(err, &runtimePubsub.SchemaValidationError{}) {
+			nerr = status.Errorf(codes.InvalidArgument, err.Error())
+		}
 		apiServerLogger.Debug(nerr)
 		closeChildSpans(ctx, nerr)
 		return &bulkRes, nerr
@@ -532,7 +541,7 @@ func (a *api) GetBulkState(ctx context.Context, in *runtimev1pb.GetBulkStateRequ
 	bulkResp.Items = make([]*runtimev1pb.BulkStateItem, len(responses))
 	for i := 0; i < len(responses); i++ {
 		item := &runtimev1pb.BulkStateItem{
-			Key:      stateLoader.GetOriginalStateKey(responses[i].Key),
+			Key:      stateLoader.GetOriginalStateKeyForStore(responses[i].Key, in.StoreName, a.UniversalAPI.AppID),
 			Data:     responses[i].Data,
 			Etag:     stringValueOrEmpty(responses[i].ETag),
 			Metadata: responses[i].Metadata,
@@ -548,7 +557,7 @@ func (a *api) GetBulkState(ctx context.Context, in *runtimev1pb.GetBulkStateRequ
 				continue
 			}
 
-			val, err := encryption.TryDecryptValue(in.StoreName, bulkResp.Items[i].Data)
+			val, err := encryption.TryDecryptValue(ctx, in.StoreName, bulkResp.Items[i].Data)
 			if err != nil {
 				apiServerLogger.Debugf("Bulk get error: %v", err)
 				bulkResp.Items[i].Data = nil
@@ -581,17 +590,19 @@ func (a *api) GetState(ctx context.Context, in *runtimev1pb.GetStateRequest) (*r
 		},
 	}
 
-	start := time.Now()
-	policyRunner := resiliency.NewRunner[*state.GetResponse](ctx,
-		a.UniversalAPI.Resiliency.ComponentOutboundPolicy(in.StoreName, resiliency.Statestore),
-	)
-	getResponse, err := policyRunner(func(ctx context.Context) (*state.GetResponse, error) {
-		return store.Get(ctx, req)
-	})
-	elapsed := diag.ElapsedSince(start)
-
-	diag.DefaultComponentMonitoring.StateInvoked(ctx, in.StoreName, diag.Get, err == nil, elapsed)
+	getResponse, err := a.UniversalAPI.StateCache.Get(ctx, in.StoreName, req, func(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+		start := time.Now()
+		policyRunner := resiliency.NewRunner[*state.GetResponse](ctx,
+			a.UniversalAPI.Resiliency.ComponentOutboundPolicy(in.StoreName, resiliency.Statestore),
+		)
+		res, err := policyRunner(func(ctx context.Context) (*state.GetResponse, error) {
+			return store.Get(ctx, req)
+		})
+		elapsed := diag.ElapsedSince(start)
 
+		diag.DefaultComponentMonitoring.StateInvoked(ctx, in.StoreName, diag.Get, err == nil, elapsed)
+		return res, err
+	})
 	if err != nil {
 		err = status.Errorf(codes.Internal, messages.ErrStateGet, in.Key, in.StoreName, err.Error())
 		a.UniversalAPI.Logger.Debug(err)
@@ -602,7 +613,7 @@ func (a *api) GetState(ctx context.Context, in *runtimev1pb.GetStateRequest) (*r
 		getResponse = &state.GetResponse{}
 	}
 	if encryption.EncryptedStateStore(in.StoreName) {
-		val, err := encryption.TryDecryptValue(in.StoreName, getResponse.Data)
+		val, err := encryption.TryDecryptValue(ctx, in.StoreName, getResponse.Data)
 		if err != nil {
 			err = status.Errorf(codes.Internal, messages.ErrStateGet, in.Key, in.StoreName, err.Error())
 			a.UniversalAPI.Logger.Debug(err)
@@ -630,21 +641,47 @@ func (a *api) SaveState(ctx context.Context, in *runtimev1pb.SaveStateRequest) (
 		return empty, err
 	}
 
-	l := len(in.States)
-	if l == 0 {
+	if len(in.States) == 0 {
 		return empty, nil
 	}
 
-	reqs := make([]state.SetRequest, l)
+	reqs, err := a.stateSetRequests(ctx, in)
+	if err != nil {
+		return empty, err
+	}
+
+	start := time.Now()
+	err = stateLoader.PerformBulkStoreOperation(ctx, reqs,
+		a.UniversalAPI.Resiliency.ComponentOutboundPolicy(in.StoreName, resiliency.Statestore),
+		stateLoader.GetBulkStoreOpts(in.StoreName),
+		store.Set,
+		store.BulkSet,
+	)
+	elapsed := diag.ElapsedSince(start)
+
+	diag.DefaultComponentMonitoring.StateInvoked(ctx, in.StoreName, diag.Set, err == nil, elapsed)
+
+	if err != nil {
+		err = a.stateErrorResponse(err, messages.ErrStateSave, in.StoreName, err.Error())
+		a.UniversalAPI.Logger.Debug(err)
+		return empty, err
+	}
+	a.UniversalAPI.StateWritten(ctx, in.StoreName, statechanges.Operations(reqs))
+	return empty, nil
+}
+
+// stateSetRequests returns the requests to save the states, with the keys
+// and values to save.
+func (a *api) stateSetRequests(ctx context.Context, in *runtimev1pb.SaveStateRequest) ([]state.SetRequest, error) {
+	reqs := make([]state.SetRequest, len(in.States))
 	for i, s := range in.States {
 		if len(s.Key) == 0 {
-			return empty, status.Errorf(codes.InvalidArgument, "state key cannot be empty")
+			return nil, status.Errorf(codes.InvalidArgument, "state key cannot be empty")
 		}
 
-		var key string
-		key, err = stateLoader.GetModifiedStateKey(s.Key, in.StoreName, a.UniversalAPI.AppID)
+		key, err := stateLoader.GetModifiedStateKey(s.Key, in.StoreName, a.UniversalAPI.AppID)
 		if err != nil {
-			return empty, err
+			return nil, err
 		}
 		req := state.SetRequest{
 			Key:      key,
@@ -654,7 +691,7 @@ func (a *api) SaveState(ctx context.Context, in *runtimev1pb.SaveStateRequest) (
 		if req.Metadata[contribMetadata.ContentType] == contenttype.JSONContentType {
 			err = json.Unmarshal(s.Value, &req.Value)
 			if err != nil {
-				return empty, err
+				return nil, err
 			}
 		} else {
 			req.Value = s.Value
@@ -670,10 +707,10 @@ func (a *api) SaveState(ctx context.Context, in *runtimev1pb.SaveStateRequest) (
 			}
 		}
 		if encryption.EncryptedStateStore(in.StoreName) {
-			val, encErr := encryption.TryEncryptValue(in.StoreName, s.Value)
+			val, encErr := encryption.TryEncryptValue(ctx, in.StoreName, s.Value)
 			if encErr != nil {
 				a.UniversalAPI.Logger.Debug(encErr)
-				return empty, encErr
+				return nil, encErr
 			}
 
 			req.Value = val
@@ -681,24 +718,7 @@ func (a *api) SaveState(ctx context.Context, in *runtimev1pb.SaveStateRequest) (
 
 		reqs[i] = req
 	}
-
-	start := time.Now()
-	err = stateLoader.PerformBulkStoreOperation(ctx, reqs,
-		a.UniversalAPI.Resiliency.ComponentOutboundPolicy(in.StoreName, resiliency.Statestore),
-		state.BulkStoreOpts{},
-		store.Set,
-		store.BulkSet,
-	)
-	elapsed := diag.ElapsedSince(start)
-
-	diag.DefaultComponentMonitoring.StateInvoked(ctx, in.StoreName, diag.Set, err == nil, elapsed)
-
-	if err != nil {
-		err = a.stateErrorResponse(err, messages.ErrStateSave, in.StoreName, err.Error())
-		a.UniversalAPI.Logger.Debug(err)
-		return empty, err
-	}
-	return empty, nil
+	return reqs, nil
 }
 
 // stateErrorResponse takes a state store error, format and args and returns a status code encoded gRPC error.
@@ -759,6 +779,7 @@ func (a *api) DeleteState(ctx context.Context, in *runtimev1pb.DeleteStateReques
 		a.UniversalAPI.Logger.Debug(err)
 		return empty, err
 	}
+	a.UniversalAPI.StateWritten(ctx, in.StoreName, []state.TransactionalStateOperation{req})
 	return empty, nil
 }
 
@@ -771,32 +792,15 @@ func (a *api) DeleteBulkState(ctx context.Context, in *runtimev1pb.DeleteBulkSta
 		return empty, err
 	}
 
-	reqs := make([]state.DeleteRequest, len(in.States))
-	for i, item := range in.States {
-		key, err1 := stateLoader.GetModifiedStateKey(item.Key, in.StoreName, a.UniversalAPI.AppID)
-		if err1 != nil {
-			return empty, err1
-		}
-		req := state.DeleteRequest{
-			Key:      key,
-			Metadata: item.Metadata,
-		}
-		if item.Etag != nil {
-			req.ETag = &item.Etag.Value
-		}
-		if item.Options != nil {
-			req.Options = state.DeleteStateOption{
-				Concurrency: stateConcurrencyToString(item.Options.Concurrency),
-				Consistency: stateConsistencyToString(item.Options.Consistency),
-			}
-		}
-		reqs[i] = req
+	reqs, err := a.stateDeleteRequests(in)
+	if err != nil {
+		return empty, err
 	}
 
 	start := time.Now()
 	err = stateLoader.PerformBulkStoreOperation(ctx, reqs,
 		a.UniversalAPI.Resiliency.ComponentOutboundPolicy(in.StoreName, resiliency.Statestore),
-		state.BulkStoreOpts{},
+		stateLoader.GetBulkStoreOpts(in.StoreName),
 		store.Delete,
 		store.BulkDelete,
 	)
@@ -809,10 +813,38 @@ func (a *api) DeleteBulkState(ctx context.Context, in *runtimev1pb.DeleteBulkSta
 		a.UniversalAPI.Logger.Debug(err)
 		return empty, err
 	}
+	a.UniversalAPI.StateWritten(ctx, in.StoreName, statechanges.Operations(reqs))
 
 	return empty, nil
 }
 
+// stateDeleteRequests returns the requests to delete the states, with the
+// keys to delete.
+func (a *api) stateDeleteRequests(in *runtimev1pb.DeleteBulkStateRequest) ([]state.DeleteRequest, error) {
+	reqs := make([]state.DeleteRequest, len(in.States))
+	for i, item := range in.States {
+		key, err := stateLoader.GetModifiedStateKey(item.Key, in.StoreName, a.UniversalAPI.AppID)
+		if err != nil {
+			return nil, err
+		}
+		req := state.DeleteRequest{
+			Key:      key,
+			Metadata: item.Metadata,
+		}
+		if item.Etag != nil {
+			req.ETag = &item.Etag.Value
+		}
+		if item.Options != nil {
+			req.Options = state.DeleteStateOption{
+				Concurrency: stateConcurrencyToString(item.Options.Concurrency),
+				Consistency: stateConsistencyToString(item.Options.Consistency),
+			}
+		}
+		reqs[i] = req
+	}
+	return reqs, nil
+}
+
 func extractEtag(req *commonv1pb.StateItem) (bool, string) {
 	if req.Etag != nil {
 		return true, req.Etag.Value
@@ -834,6 +866,62 @@ func (a *api) ExecuteStateTransaction(ctx context.Context, in *runtimev1pb.Execu
 		return &emptypb.Empty{}, err
 	}
 
+	operations, err := a.stateTransactionOperations(ctx, in)
+	if err != nil {
+		return &emptypb.Empty{}, err
+	}
+
+	if maxMulti, ok := store.(state.TransactionalStoreMultiMaxSize); ok {
+		max := maxMulti.MultiMaxSize()
+		if max > 0 && len(operations) > max {
+			err := messages.ErrStateTooManyTransactionalOp.WithFormat(len(operations), max)
+			apiServerLogger.Debug(err)
+			return &emptypb.Empty{}, err
+		}
+	}
+
+	// The operations of the app, without the ones added by the outbox
+	changes := operations
+
+	outboxEnabled := a.pubsubAdapter.Outbox().Enabled(in.StoreName)
+	if outboxEnabled {
+		ops, err := a.pubsubAdapter.Outbox().PublishInternal(ctx, in.StoreName, operations, a.UniversalAPI.AppID)
+		if err != nil {
+			err = status.Errorf(codes.Internal, messages.ErrPublishOutbox, err.Error())
+			apiServerLogger.Debug(err)
+			return &emptypb.Empty{}, err
+		}
+
+		operations = ops
+	}
+
+	start := time.Now()
+	policyRunner := resiliency.NewRunner[struct{}](ctx,
+		a.UniversalAPI.Resiliency.ComponentOutboundPolicy(in.StoreName, resiliency.Statestore),
+	)
+	storeReq := &state.TransactionalStateRequest{
+		Operations: operations,
+		Metadata:   in.Metadata,
+	}
+	_, err = policyRunner(func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, transactionalStore.Multi(ctx, storeReq)
+	})
+	elapsed := diag.ElapsedSince(start)
+
+	diag.DefaultComponentMonitoring.StateInvoked(ctx, in.StoreName, diag.StateTransaction, err == nil, elapsed)
+
+	if err != nil {
+		err = status.Errorf(codes.Internal, messages.ErrStateTransaction, err.Error())
+		apiServerLogger.Debug(err)
+		return &emptypb.Empty{}, err
+	}
+	a.UniversalAPI.StateWritten(ctx, in.StoreName, changes)
+	return &emptypb.Empty{}, nil
+}
+
+// stateTransactionOperations returns the operations of a transaction, with the
+// keys and values to save.
+func (a *api) stateTransactionOperations(ctx context.Context, in *runtimev1pb.ExecuteStateTransactionRequest) ([]state.TransactionalStateOperation, error) {
 	operations := make([]state.TransactionalStateOperation, 0, len(in.Operations))
 	for _, inputReq := range in.Operations {
 		req := inputReq.Request
@@ -841,7 +929,7 @@ func (a *api) ExecuteStateTransaction(ctx context.Context, in *runtimev1pb.Execu
 		hasEtag, etag := extractEtag(req)
 		key, err := stateLoader.GetModifiedStateKey(req.Key, in.StoreName, a.UniversalAPI.AppID)
 		if err != nil {
-			return &emptypb.Empty{}, err
+			return nil, err
 		}
 		switch state.OperationType(inputReq.OperationType) {
 		case state.OperationUpsert:
@@ -887,16 +975,7 @@ func (a *api) ExecuteStateTransaction(ctx context.Context, in *runtimev1pb.Execu
 		default:
 			err := status.Errorf(codes.Unimplemented, messages.ErrNotSupportedStateOperation, inputReq.OperationType)
 			apiServerLogger.Debug(err)
-			return &emptypb.Empty{}, err
-		}
-	}
-
-	if maxMulti, ok := store.(state.TransactionalStoreMultiMaxSize); ok {
-		max := maxMulti.MultiMaxSize()
-		if max > 0 && len(operations) > max {
-			err := messages.ErrStateTooManyTransactionalOp.WithFormat(len(operations), max)
-			apiServerLogger.Debug(err)
-			return &emptypb.Empty{}, err
+			return nil, err
 		}
 	}
 
@@ -904,12 +983,11 @@ func (a *api) ExecuteStateTransaction(ctx context.Context, in *runtimev1pb.Execu
 		for i, op := range operations {
 			switch req := op.(type) {
 			case state.SetRequest:
-				data := []byte(fmt.Sprintf("%v", req.Value))
-				val, err := encryption.TryEncryptValue(in.StoreName, data)
+				val, err := encryption.TryEncryptStateValue(ctx, in.StoreName, req.Value)
 				if err != nil {
 					err = status.Errorf(codes.Internal, messages.ErrStateTransaction, err.Error())
 					apiServerLogger.Debug(err)
-					return &emptypb.Empty{}, err
+					return nil, err
 				}
 
 				req.Value = val
@@ -918,39 +996,7 @@ func (a *api) ExecuteStateTransaction(ctx context.Context, in *runtimev1pb.Execu
 		}
 	}
 
-	outboxEnabled := a.pubsubAdapter.Outbox().Enabled(in.StoreName)
-	if outboxEnabled {
-		trs, err := a.pubsubAdapter.Outbox().PublishInternal(ctx, in.StoreName, operations, a.UniversalAPI.AppID)
-		if err != nil {
-			err = status.Errorf(codes.Internal, messages.ErrPublishOutbox, err.Error())
-			apiServerLogger.Debug(err)
-			return &emptypb.Empty{}, err
-		}
-
-		operations = append(operations, trs...)
-	}
-
-	start := time.Now()
-	policyRunner := resiliency.NewRunner[struct{}](ctx,
-		a.UniversalAPI.Resiliency.ComponentOutboundPolicy(in.StoreName, resiliency.Statestore),
-	)
-	storeReq := &state.TransactionalStateRequest{
-		Operations: operations,
-		Metadata:   in.Metadata,
-	}
-	_, err := policyRunner(func(ctx context.Context) (struct{}, error) {
-		return struct{}{}, transactionalStore.Multi(ctx, storeReq)
-	})
-	elapsed := diag.ElapsedSince(start)
-
-	diag.DefaultComponentMonitoring.StateInvoked(ctx, in.StoreName, diag.StateTransaction, err == nil, elapsed)
-
-	if err != nil {
-		err = status.Errorf(codes.Internal, messages.ErrStateTransaction, err.Error())
-		apiServerLogger.Debug(err)
-		return &emptypb.Empty{}, err
-	}
-	return &emptypb.Empty{}, nil
+	return operations, nil
 }
 
 func (a *api) RegisterActorTimer(ctx context.Context, in *runtimev1pb.RegisterActorTimerRequest) (*emptypb.Empty, error) {
@@ -129,11 +129,16 @@ func (a *api) CallLocalStream(stream internalv1pb.ServiceInvocation_CallLocalStr
 	}()
 
 	// Check the ACL
+	// For upgrade requests, this happens once at handshake time
 	err = a.callLocalValidateACL(ctx, req)
 	if err != nil {
 		return err
 	}
 
+	if req.IsUpgrade() && !a.UniversalAPI.AppConnectionConfig.Protocol.IsHTTP() {
+		return status.Errorf(codes.InvalidArgument, messages.ErrInternalInvokeRequest, "upgrade requests are supported only by apps that use HTTP")
+	}
+
 	// Diagnostics
 	callerAppID := a.callLocalRecordRequest(req.Proto())
 
@@ -224,6 +229,20 @@ func (a *api) CallLocalStream(stream internalv1pb.ServiceInvocation_CallLocalStr
 	}
 
 	proto := &internalv1pb.InternalInvokeResponseStream{}
+
+	// Responses that are written over time, such as server-sent events or
+	// upgraded connections, are started right away rather than with their
+	// first chunk of data
+	if res.IsUpgrade() || invokev1.IsStreamingContentType(res.ContentType()) {
+		proto.Response = resProto
+		resProto = nil
+		err = stream.SendMsg(proto)
+		if err != nil {
+			return fmt.Errorf("error sending message: %w", err)
+		}
+		proto.Reset()
+	}
+
 	var (
 		n    int
 		seq  uint64
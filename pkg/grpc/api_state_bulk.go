@@ -0,0 +1,44 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+)
+
+func (a *api) SaveBulkStateAlpha1(ctx context.Context, in *runtimev1pb.SaveStateRequest) (*runtimev1pb.BulkStateWriteResponse, error) {
+	reqs, err := a.stateSetRequests(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(in.States))
+	for i, s := range in.States {
+		keys[i] = s.Key
+	}
+	return a.UniversalAPI.SaveBulkState(ctx, in.StoreName, keys, reqs)
+}
+
+func (a *api) DeleteBulkStateAlpha1(ctx context.Context, in *runtimev1pb.DeleteBulkStateRequest) (*runtimev1pb.BulkStateWriteResponse, error) {
+	reqs, err := a.stateDeleteRequests(in)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(in.States))
+	for i, s := range in.States {
+		keys[i] = s.Key
+	}
+	return a.UniversalAPI.DeleteBulkState(ctx, in.StoreName, keys, reqs)
+}
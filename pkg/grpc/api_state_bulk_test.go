@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/dapr/pkg/grpc/universalapi"
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	daprt "github.com/dapr/dapr/pkg/testing"
+	"github.com/dapr/kit/logger"
+)
+
+func TestBulkStateWriteAlpha1(t *testing.T) {
+	store := rawStateStore{daprt.NewFakeStateStore()}
+	compStore := compstore.New()
+	compStore.AddStateStore("store1", store)
+	server, lis := startDaprAPIServer(&api{
+		UniversalAPI: &universalapi.UniversalAPI{
+			AppID:      "fakeAPI",
+			Logger:     logger.NewLogger("grpc.api.test"),
+			CompStore:  compStore,
+			Resiliency: resiliency.New(nil),
+		},
+	}, "")
+	defer server.Stop()
+
+	clientConn := createTestClient(lis)
+	defer clientConn.Close()
+
+	client := runtimev1pb.NewDaprClient(clientConn)
+
+	resp, err := client.SaveBulkStateAlpha1(context.Background(), &runtimev1pb.SaveStateRequest{
+		StoreName: "store1",
+		States: []*commonv1pb.StateItem{
+			{Key: "a", Value: []byte("1")},
+			{Key: "b", Value: []byte("2")},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+	for _, r := range resp.Results {
+		assert.Equal(t, runtimev1pb.BulkStateWriteResult_SUCCESS, r.Status)
+	}
+	assert.Len(t, store.GetItems(), 2)
+
+	resp, err = client.DeleteBulkStateAlpha1(context.Background(), &runtimev1pb.DeleteBulkStateRequest{
+		StoreName: "store1",
+		States:    []*commonv1pb.StateItem{{Key: "a"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, "a", resp.Results[0].Key)
+	assert.Equal(t, runtimev1pb.BulkStateWriteResult_SUCCESS, resp.Results[0].Status)
+	assert.Len(t, store.GetItems(), 1)
+
+	_, err = client.SaveBulkStateAlpha1(context.Background(), &runtimev1pb.SaveStateRequest{
+		StoreName: "store1",
+		States:    []*commonv1pb.StateItem{{Value: []byte("1")}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
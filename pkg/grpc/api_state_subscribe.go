@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"github.com/dapr/dapr/pkg/messages"
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/dapr/pkg/statechanges"
+)
+
+func (a *api) SubscribeStateAlpha1(in *runtimev1pb.SubscribeStateRequest, stream runtimev1pb.Dapr_SubscribeStateAlpha1Server) error { //nolint:nosnakecase
+	_, err := a.UniversalAPI.GetStateStore(in.StoreName)
+	if err != nil {
+		// Error has already been logged
+		return err
+	}
+
+	if a.UniversalAPI.StateChanges == nil {
+		err = messages.ErrStateSubscribeUnavailable
+		apiServerLogger.Debug(err)
+		return err
+	}
+
+	sub := a.UniversalAPI.StateChanges.Subscribe(in.StoreName, in.Keys, in.KeyPrefixes)
+	defer sub.Close()
+
+	// An empty response tells the client the subscription is active
+	err = stream.Send(&runtimev1pb.SubscribeStateResponse{})
+	if err != nil {
+		apiServerLogger.Debug(err)
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case changes, ok := <-sub.C():
+			if !ok {
+				if err = sub.Err(); err != nil {
+					err = messages.ErrStateSubscriptionOverflow.WithFormat(in.StoreName, err)
+					apiServerLogger.Debug(err)
+					return err
+				}
+				return nil
+			}
+
+			err = stream.Send(&runtimev1pb.SubscribeStateResponse{
+				Changes: stateChangesToProto(changes),
+			})
+			if err != nil {
+				apiServerLogger.Debug(err)
+				return err
+			}
+		}
+	}
+}
+
+func stateChangesToProto(changes []statechanges.Change) []*runtimev1pb.StateChange {
+	res := make([]*runtimev1pb.StateChange, len(changes))
+	for i, c := range changes {
+		res[i] = &runtimev1pb.StateChange{
+			Key:       c.Key,
+			Operation: c.Operation,
+			Value:     c.Value,
+			Metadata:  c.Metadata,
+		}
+	}
+	return res
+}
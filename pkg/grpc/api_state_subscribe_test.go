@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/dapr/pkg/grpc/universalapi"
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	"github.com/dapr/dapr/pkg/statechanges"
+	daprt "github.com/dapr/dapr/pkg/testing"
+	"github.com/dapr/kit/logger"
+)
+
+func TestSubscribeStateAlpha1(t *testing.T) {
+	compStore := compstore.New()
+	compStore.AddStateStore("store1", daprt.NewFakeStateStore())
+	fakeAPI := &api{
+		UniversalAPI: &universalapi.UniversalAPI{
+			AppID:        "fakeAPI",
+			Logger:       logger.NewLogger("grpc.api.test"),
+			CompStore:    compStore,
+			Resiliency:   resiliency.New(nil),
+			StateChanges: statechanges.NewNotifier("fakeAPI", nil),
+		},
+		pubsubAdapter: &daprt.MockPubSubAdapter{},
+	}
+	server, lis := startDaprAPIServer(fakeAPI, "")
+	defer server.Stop()
+
+	clientConn := createTestClient(lis)
+	defer clientConn.Close()
+
+	client := runtimev1pb.NewDaprClient(clientConn)
+
+	t.Run("changes of the watched keys are streamed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream, err := client.SubscribeStateAlpha1(ctx, &runtimev1pb.SubscribeStateRequest{
+			StoreName:   "store1",
+			Keys:        []string{"a"},
+			KeyPrefixes: []string{"order-"},
+		})
+		require.NoError(t, err)
+
+		// Wait for the subscription to be active
+		res, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Empty(t, res.Changes)
+
+		_, err = client.SaveState(context.Background(), &runtimev1pb.SaveStateRequest{
+			StoreName: "store1",
+			States: []*commonv1pb.StateItem{
+				{Key: "a", Value: []byte(`1`)},
+				{Key: "b", Value: []byte(`2`)},
+			},
+		})
+		require.NoError(t, err)
+
+		res, err = stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, res.Changes, 1)
+		assert.Equal(t, "a", res.Changes[0].Key)
+		assert.Equal(t, statechanges.OperationUpsert, res.Changes[0].Operation)
+		assert.Equal(t, []byte(`1`), res.Changes[0].Value)
+
+		_, err = client.ExecuteStateTransaction(context.Background(), &runtimev1pb.ExecuteStateTransactionRequest{
+			StoreName: "store1",
+			Operations: []*runtimev1pb.TransactionalStateOperation{
+				{
+					OperationType: string(state.OperationUpsert),
+					Request:       &commonv1pb.StateItem{Key: "order-1", Value: []byte(`"new"`)},
+				},
+				{
+					OperationType: string(state.OperationDelete),
+					Request:       &commonv1pb.StateItem{Key: "a"},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		res, err = stream.Recv()
+		require.NoError(t, err)
+		require.Len(t, res.Changes, 2)
+		assert.Equal(t, "order-1", res.Changes[0].Key)
+		assert.Equal(t, []byte(`"new"`), res.Changes[0].Value)
+		assert.Equal(t, "a", res.Changes[1].Key)
+		assert.Equal(t, statechanges.OperationDelete, res.Changes[1].Operation)
+	})
+
+	t.Run("state store not found", func(t *testing.T) {
+		stream, err := client.SubscribeStateAlpha1(context.Background(), &runtimev1pb.SubscribeStateRequest{
+			StoreName: "nope",
+		})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
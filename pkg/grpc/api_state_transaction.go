@@ -0,0 +1,45 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/dapr/pkg/statetx"
+)
+
+func (a *api) ExecuteMultiStateTransactionAlpha1(ctx context.Context, in *runtimev1pb.ExecuteMultiStateTransactionRequest) (*emptypb.Empty, error) {
+	txs := make([]statetx.Transaction, len(in.Transactions))
+	for i, tx := range in.Transactions {
+		ops, err := a.stateTransactionOperations(ctx, tx)
+		if err != nil {
+			return &emptypb.Empty{}, err
+		}
+		txs[i] = statetx.Transaction{
+			StoreName:  tx.StoreName,
+			Operations: ops,
+			Metadata:   tx.Metadata,
+		}
+	}
+
+	err := a.UniversalAPI.ExecuteMultiStateTransaction(ctx, txs)
+	if err != nil {
+		// Error has already been logged
+		return &emptypb.Empty{}, err
+	}
+	return &emptypb.Empty{}, nil
+}
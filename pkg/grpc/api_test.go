@@ -70,6 +70,9 @@ import (
 	"github.com/dapr/dapr/pkg/resiliency"
 	"github.com/dapr/dapr/pkg/runtime/compstore"
 	runtimePubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+	"github.com/dapr/dapr/pkg/statecache"
+	"github.com/dapr/dapr/pkg/statechanges"
+	"github.com/dapr/dapr/pkg/stateindex"
 	daprt "github.com/dapr/dapr/pkg/testing"
 	testtrace "github.com/dapr/dapr/pkg/testing/trace"
 	"github.com/dapr/kit/logger"
@@ -3095,6 +3098,187 @@ func TestStateStoreQuerierEncrypted(t *testing.T) {
 	assert.Equal(t, codes.Internal, status.Code(err))
 }
 
+func TestStateStoreQuerierEncryptedFields(t *testing.T) {
+	storeName := "encrypted-fields-store1"
+	encryption.AddEncryptedStateStore(storeName, encryption.ComponentEncryptionKeys{Fields: []string{"ssn"}})
+	fakeStore := &mockStateStoreQuerier{}
+	fakeStore.MockQuerier.On("Query",
+		mock.MatchedBy(matchContextInterface),
+		mock.Anything).Return(
+		&state.QueryResponse{
+			Results: []state.QueryItem{
+				{
+					Key:  "1",
+					Data: []byte(`{"a":"b"}`),
+				},
+			},
+		}, nil)
+	compStore := compstore.New()
+	compStore.AddStateStore(storeName, fakeStore)
+	server, lis := startDaprAPIServer(&api{
+		UniversalAPI: &universalapi.UniversalAPI{
+			AppID:      "fakeAPI",
+			Logger:     logger.NewLogger("grpc.api.test"),
+			CompStore:  compStore,
+			Resiliency: resiliency.New(nil),
+		},
+	}, "")
+	defer server.Stop()
+
+	clientConn := createTestClient(lis)
+	defer clientConn.Close()
+
+	client := runtimev1pb.NewDaprClient(clientConn)
+	resp, err := client.QueryStateAlpha1(context.Background(), &runtimev1pb.QueryStateRequest{
+		StoreName: storeName,
+		Query:     queryTestRequestOK,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, `{"a":"b"}`, string(resp.Results[0].Data))
+
+	_, err = client.QueryStateAlpha1(context.Background(), &runtimev1pb.QueryStateRequest{
+		StoreName: storeName,
+		Query:     `{"filter":{"EQ":{"ssn":"123"}}}`,
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, err.Error(), "encrypted field ssn")
+}
+
+// rawStateStore saves the values as they are, like real state stores.
+type rawStateStore struct {
+	*daprt.FakeStateStore
+}
+
+func (s rawStateStore) Set(ctx context.Context, req *state.SetRequest) error {
+	return s.Multi(ctx, &state.TransactionalStateRequest{Operations: []state.TransactionalStateOperation{*req}})
+}
+
+func (s rawStateStore) BulkSet(ctx context.Context, req []state.SetRequest, _ state.BulkStoreOpts) error {
+	return s.Multi(ctx, &state.TransactionalStateRequest{Operations: statechanges.Operations(req)})
+}
+
+func (s rawStateStore) BulkDelete(ctx context.Context, req []state.DeleteRequest, _ state.BulkStoreOpts) error {
+	return s.Multi(ctx, &state.TransactionalStateRequest{Operations: statechanges.Operations(req)})
+}
+
+// ListKeys makes the store listable, so that it can be indexed.
+func (s rawStateStore) ListKeys(ctx context.Context, token string, limit int) ([]string, string, error) {
+	keys := make([]string, 0)
+	for k := range s.GetItems() {
+		keys = append(keys, k)
+	}
+	return keys, "", nil
+}
+
+func TestStateStoreQuerierIndexed(t *testing.T) {
+	compStore := compstore.New()
+	compStore.AddStateStore("indexed-store1", rawStateStore{daprt.NewFakeStateStore()})
+	compStore.AddStateStore("index", rawStateStore{daprt.NewFakeStateStore()})
+	index := stateindex.NewIndexer("fakeAPI", compStore, resiliency.New(nil))
+	index.AddOrUpdateStore(componentsV1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{Name: "indexed-store1"},
+		Spec: componentsV1alpha1.ComponentSpec{Metadata: []commonapi.NameValuePair{
+			{Name: "queryIndexStore", Value: commonapi.DynamicValue{JSON: v1.JSON{Raw: []byte("index")}}},
+			{Name: "queryIndexFields", Value: commonapi.DynamicValue{JSON: v1.JSON{Raw: []byte("city")}}},
+		}},
+	})
+	server, lis := startDaprAPIServer(&api{
+		UniversalAPI: &universalapi.UniversalAPI{
+			AppID:      "fakeAPI",
+			Logger:     logger.NewLogger("grpc.api.test"),
+			CompStore:  compStore,
+			Resiliency: resiliency.New(nil),
+			StateIndex: index,
+		},
+	}, "")
+	defer server.Stop()
+
+	clientConn := createTestClient(lis)
+	defer clientConn.Close()
+
+	client := runtimev1pb.NewDaprClient(clientConn)
+	_, err := client.SaveState(context.Background(), &runtimev1pb.SaveStateRequest{
+		StoreName: "indexed-store1",
+		States: []*commonv1pb.StateItem{
+			{Key: "a", Value: []byte(`{"city":"Rome"}`)},
+			{Key: "b", Value: []byte(`{"city":"Paris"}`)},
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err := client.QueryStateAlpha1(context.Background(), &runtimev1pb.QueryStateRequest{
+		StoreName: "indexed-store1",
+		Query:     `{"filter":{"EQ":{"city":"Rome"}}}`,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, "a", resp.Results[0].Key)
+	assert.Equal(t, `{"city":"Rome"}`, string(resp.Results[0].Data))
+
+	_, err = client.QueryStateAlpha1(context.Background(), &runtimev1pb.QueryStateRequest{
+		StoreName: "indexed-store1",
+		Query:     `{"sort":[{"key":"country"}]}`,
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, err.Error(), "field country is not indexed")
+}
+
+func TestGetStateCached(t *testing.T) {
+	fakeStore := rawStateStore{daprt.NewFakeStateStore()}
+	compStore := compstore.New()
+	compStore.AddStateStore("cached-store1", fakeStore)
+	cache := statecache.New()
+	cache.AddOrUpdateStore(componentsV1alpha1.Component{
+		ObjectMeta: metaV1.ObjectMeta{Name: "cached-store1"},
+		Spec: componentsV1alpha1.ComponentSpec{Metadata: []commonapi.NameValuePair{
+			{Name: "cacheTTL", Value: commonapi.DynamicValue{JSON: v1.JSON{Raw: []byte("1h")}}},
+		}},
+	})
+	server, lis := startDaprAPIServer(&api{
+		UniversalAPI: &universalapi.UniversalAPI{
+			AppID:      "fakeAPI",
+			Logger:     logger.NewLogger("grpc.api.test"),
+			CompStore:  compStore,
+			Resiliency: resiliency.New(nil),
+			StateCache: cache,
+		},
+	}, "")
+	defer server.Stop()
+
+	clientConn := createTestClient(lis)
+	defer clientConn.Close()
+
+	client := runtimev1pb.NewDaprClient(clientConn)
+	save := func(value string) {
+		_, err := client.SaveState(context.Background(), &runtimev1pb.SaveStateRequest{
+			StoreName: "cached-store1",
+			States:    []*commonv1pb.StateItem{{Key: "a", Value: []byte(value)}},
+		})
+		require.NoError(t, err)
+	}
+	get := func(consistency commonv1pb.StateOptions_StateConsistency) string {
+		resp, err := client.GetState(context.Background(), &runtimev1pb.GetStateRequest{
+			StoreName:   "cached-store1",
+			Key:         "a",
+			Consistency: consistency,
+		})
+		require.NoError(t, err)
+		return string(resp.Data)
+	}
+
+	save("1")
+	assert.Equal(t, "1", get(commonv1pb.StateOptions_CONSISTENCY_UNSPECIFIED))
+
+	// Writes that don't go through the API are only seen with strong consistency
+	require.NoError(t, fakeStore.Set(context.Background(), &state.SetRequest{Key: "fakeAPI||a", Value: []byte("2")}))
+	assert.Equal(t, "1", get(commonv1pb.StateOptions_CONSISTENCY_EVENTUAL))
+	assert.Equal(t, "2", get(commonv1pb.StateOptions_CONSISTENCY_STRONG))
+
+	save("3")
+	assert.Equal(t, "3", get(commonv1pb.StateOptions_CONSISTENCY_UNSPECIFIED))
+}
+
 // Interface that applies to both SubscribeConfigurationAlpha1 and SubscribeConfiguration
 type subscribeConfigurationFn func(ctx context.Context, in *runtimev1pb.SubscribeConfigurationRequest, opts ...grpc.CallOption) (interface {
 	Recv() (*runtimev1pb.SubscribeConfigurationResponse, error)
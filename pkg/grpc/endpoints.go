@@ -40,6 +40,10 @@ var endpoints = map[string][]string{
 	},
 	"state.v1alpha1": {
 		daprRuntimePrefix + "v1.Dapr/QueryStateAlpha1",
+		daprRuntimePrefix + "v1.Dapr/SubscribeStateAlpha1",
+		daprRuntimePrefix + "v1.Dapr/ExecuteMultiStateTransactionAlpha1",
+		daprRuntimePrefix + "v1.Dapr/SaveBulkStateAlpha1",
+		daprRuntimePrefix + "v1.Dapr/DeleteBulkStateAlpha1",
 	},
 	"publish.v1": {
 		daprRuntimePrefix + "v1.Dapr/PublishEvent",
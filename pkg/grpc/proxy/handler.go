@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -25,6 +26,9 @@ import (
 // Metadata header used to indicate if the call should be handled as a gRPC stream.
 const StreamMetadataKey = "dapr-stream"
 
+// Trailer used by servers to push back on retries.
+const retryPushbackKey = "grpc-retry-pushback-ms"
+
 var clientStreamDescForProxying = &grpc.StreamDesc{
 	ServerStreams: true,
 	ClientStreams: true,
@@ -194,6 +198,7 @@ func (s *handler) handler(srv any, serverStream grpc.ServerStream) error {
 					} else {
 						diagnostics.DefaultMonitoring.ServiceInvocationStreamingResponseReceived(grpcDestinationAppID, int32(code))
 					}
+					diagnostics.DefaultMonitoring.ServiceInvocationProxyResponseReceived(grpcDestinationAppID, fullMethodName, code.String(), isStream, requestStartedAt)
 				}
 			}()
 
@@ -254,12 +259,23 @@ func (s *handler) handler(srv any, serverStream grpc.ServerStream) error {
 		if grpcDestinationAppID != "" {
 			code := status.Code(err)
 			diagnostics.DefaultMonitoring.ServiceInvocationResponseReceived(grpcDestinationAppID, int32(code), requestStartedAt)
+			diagnostics.DefaultMonitoring.ServiceInvocationProxyResponseReceived(grpcDestinationAppID, fullMethodName, code.String(), false, requestStartedAt)
 		}
 
 		if err != nil {
-			// If the error is errRetryOnStreamingRPC, then that's permanent and should not cause the policy to retry
-			if errors.Is(err, errRetryOnStreamingRPC) {
+			switch {
+			case errors.Is(err, errRetryOnStreamingRPC):
+				// If the error is errRetryOnStreamingRPC, then that's permanent and should not cause the policy to retry
 				err = backoff.Permanent(errRetryOnStreamingRPC)
+			case replayBuffer != nil:
+				// Honor the server's pushback, if any
+				if delay, ok := retryPushback(pr.clientStream.Trailer()); ok {
+					if delay < 0 {
+						err = backoff.Permanent(err)
+					} else {
+						err = resiliency.RetryPushback(err, delay)
+					}
+				}
 			}
 			return nil, err
 		}
@@ -273,6 +289,9 @@ func (s *handler) handler(srv any, serverStream grpc.ServerStream) error {
 	// If the policy function returned a proxy runner, execute it here, outside of the policy function so it's not influenced by timeouts
 	if pr != nil {
 		cErr = pr.run()
+		if grpcDestinationAppID != "" {
+			diagnostics.DefaultMonitoring.ServiceInvocationProxyResponseReceived(grpcDestinationAppID, fullMethodName, status.Code(cErr).String(), true, requestStartedAt)
+		}
 		if cErr != nil {
 			return cErr
 		}
@@ -281,6 +300,25 @@ func (s *handler) handler(srv any, serverStream grpc.ServerStream) error {
 	return nil
 }
 
+// retryPushback returns the delay before the next attempt requested by the
+// server with the grpc-retry-pushback-ms trailer. A negative delay means that
+// the call must not be retried.
+func retryPushback(trailer metadata.MD) (time.Duration, bool) {
+	v := trailer.Get(retryPushbackKey)
+	switch len(v) {
+	case 0:
+		return 0, false
+	case 1:
+		ms, err := strconv.Atoi(v[0])
+		if err != nil || ms < 0 {
+			return -1, true
+		}
+		return time.Duration(ms) * time.Millisecond, true
+	default:
+		return -1, true
+	}
+}
+
 // Executes the proxying between client and server.
 type proxyRunner struct {
 	serverStream grpc.ServerStream
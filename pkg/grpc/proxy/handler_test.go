@@ -945,3 +945,27 @@ func createGrpcStreamingChaosInterceptor(interruptionsCounter *atomic.Int32, cod
 		return streamer(ctx, desc, cc, method, opts...)
 	}
 }
+
+func TestRetryPushback(t *testing.T) {
+	tests := []struct {
+		name    string
+		trailer metadata.MD
+		delay   time.Duration
+		ok      bool
+	}{
+		{name: "no pushback", trailer: metadata.MD{}, ok: false},
+		{name: "delay", trailer: metadata.Pairs(retryPushbackKey, "250"), delay: 250 * time.Millisecond, ok: true},
+		{name: "negative", trailer: metadata.Pairs(retryPushbackKey, "-1"), delay: -1, ok: true},
+		{name: "malformed", trailer: metadata.Pairs(retryPushbackKey, "soon"), delay: -1, ok: true},
+		{name: "multiple values", trailer: metadata.Pairs(retryPushbackKey, "1", retryPushbackKey, "2"), delay: -1, ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := retryPushback(tt.trailer)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, tt.delay, delay)
+			}
+		})
+	}
+}
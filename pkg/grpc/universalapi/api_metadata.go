@@ -100,6 +100,26 @@ func (a *UniversalAPI) GetMetadata(ctx context.Context, in *runtimev1pb.GetMetad
 		}
 	}
 
+	// Outbox
+	var outboxStores []*runtimev1pb.MetadataOutbox
+	if a.Outbox != nil && len(a.Outbox.Stores()) > 0 {
+		stores := a.Outbox.Stores()
+		outboxStores = make([]*runtimev1pb.MetadataOutbox, len(stores))
+		for i, v := range stores {
+			outboxStores[i] = &runtimev1pb.MetadataOutbox{
+				StateStore:              v.StateStore,
+				PublishPubsub:           v.PublishPubsub,
+				PublishTopic:            v.PublishTopic,
+				OutboxPubsub:            v.OutboxPubsub,
+				DiscardWhenMissingState: v.DiscardWhenMissingState,
+				DeadLetterTopic:         v.DeadLetterTopic,
+			}
+			if v.MaxVerificationAge > 0 {
+				outboxStores[i].MaxVerificationAge = v.MaxVerificationAge.String()
+			}
+		}
+	}
+
 	return &runtimev1pb.GetMetadataResponse{
 		Id:                      a.AppID,
 		ExtendedMetadata:        extendedMetadata,
@@ -110,6 +130,7 @@ func (a *UniversalAPI) GetMetadata(ctx context.Context, in *runtimev1pb.GetMetad
 		AppConnectionProperties: appConnectionProperties,
 		RuntimeVersion:          buildinfo.Version(),
 		EnabledFeatures:         a.GlobalConfig.EnabledFeatures(),
+		Outbox:                  outboxStores,
 	}, nil
 }
 
@@ -26,6 +26,7 @@ import (
 	componentsV1alpha "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	"github.com/dapr/dapr/pkg/config"
 	"github.com/dapr/dapr/pkg/expr"
+	"github.com/dapr/dapr/pkg/outbox"
 	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 	"github.com/dapr/dapr/pkg/runtime/compstore"
 	runtimePubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
@@ -131,6 +132,42 @@ func TestGetMetadata(t *testing.T) {
 	}
 }
 
+// fakeOutbox is an outbox that only reports its stores.
+type fakeOutbox struct {
+	outbox.Outbox
+	stores []outbox.StoreConfig
+}
+
+func (o fakeOutbox) Stores() []outbox.StoreConfig {
+	return o.stores
+}
+
+func TestGetMetadataOutbox(t *testing.T) {
+	fakeAPI := &UniversalAPI{
+		AppID:                       "fakeAPI",
+		CompStore:                   compstore.New(),
+		GetComponentsCapabilitiesFn: func() map[string][]string { return nil },
+		GlobalConfig:                &config.Configuration{},
+		Outbox: fakeOutbox{stores: []outbox.StoreConfig{
+			{StateStore: "store1", PublishPubsub: "pubsub1", PublishTopic: "orders", OutboxPubsub: "pubsub2"},
+			{StateStore: "store2", PublishPubsub: "pubsub1", PublishTopic: "invoices", OutboxPubsub: "pubsub2", MaxVerificationAge: time.Minute, DeadLetterTopic: "dead"},
+		}},
+	}
+
+	response, err := fakeAPI.GetMetadata(context.Background(), &runtimev1pb.GetMetadataRequest{})
+	require.NoError(t, err)
+	require.Len(t, response.Outbox, 2)
+	assert.Equal(t, "store1", response.Outbox[0].StateStore)
+	assert.Empty(t, response.Outbox[0].MaxVerificationAge)
+	assert.Equal(t, "1m0s", response.Outbox[1].MaxVerificationAge)
+	assert.Equal(t, "dead", response.Outbox[1].DeadLetterTopic)
+
+	fakeAPI.Outbox = fakeOutbox{}
+	response, err = fakeAPI.GetMetadata(context.Background(), &runtimev1pb.GetMetadataRequest{})
+	require.NoError(t, err)
+	assert.Nil(t, response.Outbox)
+}
+
 func TestSetMetadata(t *testing.T) {
 	fakeComponent := componentsV1alpha.Component{}
 	fakeComponent.Name = "testComponent"
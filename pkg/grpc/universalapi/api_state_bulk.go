@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package universalapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dapr/components-contrib/state"
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+	diag "github.com/dapr/dapr/pkg/diagnostics"
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/statechanges"
+)
+
+// SaveBulkState saves the values of the requests, which have the keys as
+// saved, and reports the result of each one. keys are the keys of the app,
+// in the same order.
+func (a *UniversalAPI) SaveBulkState(ctx context.Context, storeName string, keys []string, reqs []state.SetRequest) (*runtimev1pb.BulkStateWriteResponse, error) {
+	store, err := a.GetStateStore(storeName)
+	if err != nil {
+		// Error has already been logged
+		return nil, err
+	}
+	return writeBulkState(ctx, a, storeName, keys, reqs, diag.Set, store.Set, store.BulkSet), nil
+}
+
+// DeleteBulkState is like SaveBulkState, for deletes.
+func (a *UniversalAPI) DeleteBulkState(ctx context.Context, storeName string, keys []string, reqs []state.DeleteRequest) (*runtimev1pb.BulkStateWriteResponse, error) {
+	store, err := a.GetStateStore(storeName)
+	if err != nil {
+		// Error has already been logged
+		return nil, err
+	}
+	return writeBulkState(ctx, a, storeName, keys, reqs, diag.BulkDelete, store.Delete, store.BulkDelete), nil
+}
+
+func writeBulkState[T interface {
+	state.SetRequest | state.DeleteRequest
+	state.StateRequest
+}](
+	ctx context.Context, a *UniversalAPI, storeName string, keys []string, reqs []T, operation string,
+	execSingle func(ctx context.Context, req *T) error,
+	execMulti func(ctx context.Context, reqs []T, opts state.BulkStoreOpts) error,
+) *runtimev1pb.BulkStateWriteResponse {
+	res := &runtimev1pb.BulkStateWriteResponse{
+		Results: make([]*runtimev1pb.BulkStateWriteResult, len(reqs)),
+	}
+	if len(reqs) == 0 {
+		return res
+	}
+
+	start := time.Now()
+	results, err := stateLoader.PerformBulkStoreOperationWithResults(ctx, reqs,
+		a.Resiliency.ComponentOutboundPolicy(storeName, resiliency.Statestore),
+		stateLoader.GetBulkStoreOpts(storeName),
+		execSingle,
+		execMulti,
+	)
+	elapsed := diag.ElapsedSince(start)
+
+	diag.DefaultComponentMonitoring.StateInvoked(ctx, storeName, operation, err == nil, elapsed)
+
+	written := make([]T, 0, len(reqs))
+	for i, r := range results {
+		res.Results[i] = bulkStateWriteResult(keys[i], r.Error)
+		if r.Error == nil {
+			written = append(written, reqs[i])
+		} else {
+			a.Logger.Debugf("Failed to write key %s in state store %s: %v", keys[i], storeName, r.Error)
+		}
+	}
+	a.StateWritten(ctx, storeName, statechanges.Operations(written))
+	return res
+}
+
+func bulkStateWriteResult(key string, err error) *runtimev1pb.BulkStateWriteResult {
+	res := &runtimev1pb.BulkStateWriteResult{Key: key}
+	if err == nil {
+		return res
+	}
+
+	res.Error = err.Error()
+	var etagErr *state.ETagError
+	if errors.As(err, &etagErr) && etagErr.Kind() == state.ETagMismatch {
+		res.Status = runtimev1pb.BulkStateWriteResult_ETAG_MISMATCH
+	} else {
+		res.Status = runtimev1pb.BulkStateWriteResult_ERROR
+	}
+	return res
+}
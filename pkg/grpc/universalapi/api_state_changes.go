@@ -0,0 +1,34 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package universalapi
+
+import (
+	"context"
+
+	"github.com/dapr/components-contrib/state"
+)
+
+// StateWritten is called after operations are committed to a state store,
+// with the keys and values as saved. It invalidates the cached values,
+// notifies the subscribers of the changes and updates the query index of the
+// store.
+func (a *UniversalAPI) StateWritten(ctx context.Context, storeName string, ops []state.TransactionalStateOperation) {
+	a.StateCache.Invalidate(storeName, ops)
+	a.StateChanges.Notify(ctx, storeName, ops)
+
+	// The write succeeded, so a stale index is only logged
+	if err := a.StateIndex.Update(ctx, storeName, ops); err != nil {
+		a.Logger.Warnf("Failed to update the query index of state store %s: %v", storeName, err)
+	}
+}
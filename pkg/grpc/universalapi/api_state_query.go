@@ -51,14 +51,18 @@ func (a *UniversalAPI) QueryStateAlpha1(ctx context.Context, in *runtimev1pb.Que
 		return nil, err
 	}
 
+	// Stores that can't be queried natively may be indexed by Dapr
 	querier, ok := store.(state.Querier)
-	if !ok {
+	indexed := !ok && a.StateIndex.Indexed(in.StoreName)
+	if !ok && !indexed {
 		err = messages.ErrStateQueryUnsupported
 		a.Logger.Debug(err)
 		return nil, err
 	}
 
-	if encryption.EncryptedStateStore(in.StoreName) {
+	// Stores that encrypt only some fields of the values can be queried by the others
+	encrypted := encryption.EncryptedStateStore(in.StoreName)
+	if encrypted && !encryption.EncryptedFieldsStateStore(in.StoreName) {
 		err = messages.ErrStateQueryFailed.WithFormat(in.StoreName, "cannot query encrypted store")
 		a.Logger.Debug(err)
 		return nil, err
@@ -71,15 +75,26 @@ func (a *UniversalAPI) QueryStateAlpha1(ctx context.Context, in *runtimev1pb.Que
 		return nil, err
 	}
 
+	if field, ok := encryption.QueryEncryptedField(in.StoreName, req.Query); encrypted && ok {
+		err = messages.ErrStateQueryFailed.WithFormat(in.StoreName, "cannot filter or sort by encrypted field "+field)
+		a.Logger.Debug(err)
+		return nil, err
+	}
+
 	req.Metadata = in.GetMetadata()
 
 	start := time.Now()
-	policyRunner := resiliency.NewRunner[*state.QueryResponse](ctx,
-		a.Resiliency.ComponentOutboundPolicy(in.StoreName, resiliency.Statestore),
-	)
-	resp, err := policyRunner(func(ctx context.Context) (*state.QueryResponse, error) {
-		return querier.Query(ctx, &req)
-	})
+	var resp *state.QueryResponse
+	if indexed {
+		resp, err = a.StateIndex.Query(ctx, in.StoreName, store, &req)
+	} else {
+		policyRunner := resiliency.NewRunner[*state.QueryResponse](ctx,
+			a.Resiliency.ComponentOutboundPolicy(in.StoreName, resiliency.Statestore),
+		)
+		resp, err = policyRunner(func(ctx context.Context) (*state.QueryResponse, error) {
+			return querier.Query(ctx, &req)
+		})
+	}
 	elapsed := diag.ElapsedSince(start)
 
 	diag.DefaultComponentMonitoring.StateInvoked(ctx, in.StoreName, diag.StateQuery, err == nil, elapsed)
@@ -102,13 +117,23 @@ func (a *UniversalAPI) QueryStateAlpha1(ctx context.Context, in *runtimev1pb.Que
 
 	for i := range resp.Results {
 		row := &runtimev1pb.QueryStateItem{
-			Key:   stateLoader.GetOriginalStateKey(resp.Results[i].Key),
+			Key:   stateLoader.GetOriginalStateKeyForStore(resp.Results[i].Key, in.StoreName, a.AppID),
 			Data:  resp.Results[i].Data,
 			Error: resp.Results[i].Error,
 		}
 		if resp.Results[i].ETag != nil {
 			row.Etag = *resp.Results[i].ETag
 		}
+		if encrypted && row.Error == "" && len(row.Data) > 0 {
+			val, decErr := encryption.TryDecryptValue(ctx, in.StoreName, row.Data)
+			if decErr != nil {
+				a.Logger.Debugf("Query error: %v", decErr)
+				row.Data = nil
+				row.Error = decErr.Error()
+			} else {
+				row.Data = val
+			}
+		}
 		ret.Results[i] = row
 	}
 
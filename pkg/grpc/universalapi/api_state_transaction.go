@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package universalapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	diag "github.com/dapr/dapr/pkg/diagnostics"
+	"github.com/dapr/dapr/pkg/messages"
+	"github.com/dapr/dapr/pkg/statetx"
+)
+
+// ExecuteMultiStateTransaction commits transactions on multiple state stores
+// atomically. The keys and values of the operations are the ones saved.
+func (a *UniversalAPI) ExecuteMultiStateTransaction(ctx context.Context, txs []statetx.Transaction) error {
+	if a.StateTransactions == nil {
+		err := messages.ErrStateMultiTxNotSupported.WithFormat("transactions on multiple state stores are not enabled")
+		a.Logger.Debug(err)
+		return err
+	}
+
+	start := time.Now()
+	err := a.StateTransactions.Execute(ctx, txs)
+	elapsed := diag.ElapsedSince(start)
+	for _, tx := range txs {
+		diag.DefaultComponentMonitoring.StateInvoked(ctx, tx.StoreName, diag.StateTransaction, err == nil, elapsed)
+	}
+
+	switch {
+	case err == nil:
+		for _, tx := range txs {
+			a.StateWritten(ctx, tx.StoreName, tx.Operations)
+		}
+		return nil
+	case errors.Is(err, statetx.ErrInvalidTransaction):
+		err = messages.ErrStateMultiTxInvalid.WithFormat(err)
+	case errors.Is(err, statetx.ErrUnsupported):
+		err = messages.ErrStateMultiTxNotSupported.WithFormat(err)
+	case errors.Is(err, statetx.ErrConflict):
+		err = messages.ErrStateMultiTxConflict.WithFormat(err)
+	default:
+		err = messages.ErrStateMultiTx.WithFormat(err)
+	}
+	a.Logger.Debug(err)
+	return err
+}
@@ -21,8 +21,13 @@ import (
 
 	"github.com/dapr/dapr/pkg/actors"
 	"github.com/dapr/dapr/pkg/config"
+	"github.com/dapr/dapr/pkg/outbox"
 	"github.com/dapr/dapr/pkg/resiliency"
 	"github.com/dapr/dapr/pkg/runtime/compstore"
+	"github.com/dapr/dapr/pkg/statecache"
+	"github.com/dapr/dapr/pkg/statechanges"
+	"github.com/dapr/dapr/pkg/stateindex"
+	"github.com/dapr/dapr/pkg/statetx"
 	"github.com/dapr/kit/logger"
 )
 
@@ -38,6 +43,11 @@ type UniversalAPI struct {
 	ExtendedMetadata            map[string]string
 	AppConnectionConfig         config.AppConnectionConfig
 	GlobalConfig                *config.Configuration
+	StateChanges                *statechanges.Notifier
+	StateTransactions           *statetx.Coordinator
+	StateIndex                  *stateindex.Indexer
+	StateCache                  *statecache.Cache
+	Outbox                      outbox.Outbox
 
 	extendedMetadataLock sync.RWMutex
 	actorsReady          atomic.Bool
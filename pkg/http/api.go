@@ -53,6 +53,7 @@ import (
 	"github.com/dapr/dapr/pkg/resiliency"
 	"github.com/dapr/dapr/pkg/runtime/channels"
 	runtimePubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+	"github.com/dapr/dapr/pkg/statechanges"
 	"github.com/dapr/dapr/utils"
 )
 
@@ -255,6 +256,146 @@ func (a *api) constructStateEndpoints() []endpoints.Endpoint {
 				Name: "QueryStateAlpha1",
 			},
 		},
+		{
+			Methods: []string{nethttp.MethodPost},
+			Route:   "state/{storeName}/encryption/rotation",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onStartStateKeyRotation,
+			Settings: endpoints.EndpointSettings{
+				Name: "StartStateKeyRotationAlpha1",
+			},
+		},
+		{
+			Methods: []string{nethttp.MethodGet},
+			Route:   "state/{storeName}/encryption/rotation",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onGetStateKeyRotation,
+			Settings: endpoints.EndpointSettings{
+				Name: "GetStateKeyRotationAlpha1",
+			},
+		},
+		{
+			Methods: []string{nethttp.MethodPost},
+			Route:   "state/{storeName}/keys/migrate",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onMigrateStateKeys,
+			Settings: endpoints.EndpointSettings{
+				Name: "MigrateStateKeysAlpha1",
+			},
+		},
+		{
+			Methods: []string{nethttp.MethodGet},
+			Route:   "state/{storeName}/keys/migrate",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onGetStateKeyMigration,
+			Settings: endpoints.EndpointSettings{
+				Name: "GetStateKeyMigrationAlpha1",
+			},
+		},
+		{
+			Methods: []string{nethttp.MethodPost, nethttp.MethodPut},
+			Route:   "state/transaction",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onPostMultiStateTransaction,
+			Settings: endpoints.EndpointSettings{
+				Name: "ExecuteMultiStateTransactionAlpha1",
+			},
+		},
+		{
+			Methods: []string{nethttp.MethodPost, nethttp.MethodPut},
+			Route:   "state/{storeName}/bulk/save",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onSaveBulkState,
+			Settings: endpoints.EndpointSettings{
+				Name: "SaveBulkStateAlpha1",
+			},
+		},
+		{
+			Methods: []string{nethttp.MethodPost},
+			Route:   "state/{storeName}/bulk/delete",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onDeleteBulkState,
+			Settings: endpoints.EndpointSettings{
+				Name: "DeleteBulkStateAlpha1",
+			},
+		},
+		{
+			Methods: []string{nethttp.MethodPost},
+			Route:   "state/{storeName}/export",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onExportState,
+			Settings: endpoints.EndpointSettings{
+				Name: "ExportStateAlpha1",
+			},
+		},
+		{
+			Methods: []string{nethttp.MethodPost},
+			Route:   "state/{storeName}/import",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onImportState,
+			Settings: endpoints.EndpointSettings{
+				Name: "ImportStateAlpha1",
+			},
+		},
+		{
+			Methods: []string{nethttp.MethodPost},
+			Route:   "state/{storeName}/copy",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupState,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendStateSpanAttributes,
+			},
+			Handler: a.onCopyState,
+			Settings: endpoints.EndpointSettings{
+				Name: "CopyStateAlpha1",
+			},
+		},
 	}
 }
 
@@ -294,6 +435,20 @@ func (a *api) constructPubSubEndpoints() []endpoints.Endpoint {
 				Name: "BulkPublishEvent",
 			},
 		},
+		{
+			Methods: []string{nethttp.MethodPost},
+			Route:   "publish/redrive/{pubsubname}/*",
+			Version: apiVersionV1alpha1,
+			Group: &endpoints.EndpointGroup{
+				Name:                 endpoints.EndpointGroupPubsub,
+				Version:              endpoints.EndpointGroupVersion1alpha1,
+				AppendSpanAttributes: appendPubSubSpanAttributes,
+			},
+			FastHTTPHandler: a.onRedrive,
+			Settings: endpoints.EndpointSettings{
+				Name: "RedriveDeadLetter",
+			},
+		},
 	}
 }
 
@@ -658,7 +813,7 @@ func (a *api) onBulkGetState(reqCtx *fasthttp.RequestCtx) {
 	}
 
 	for i := 0; i < len(responses) && i < len(req.Keys); i++ {
-		bulkResp[i].Key = stateLoader.GetOriginalStateKey(responses[i].Key)
+		bulkResp[i].Key = stateLoader.GetOriginalStateKeyForStore(responses[i].Key, storeName, a.universal.AppID)
 		if responses[i].Error != "" {
 			log.Debugf("bulk get: error getting key %s: %s", bulkResp[i].Key, responses[i].Error)
 			bulkResp[i].Error = responses[i].Error
@@ -676,7 +831,7 @@ func (a *api) onBulkGetState(reqCtx *fasthttp.RequestCtx) {
 				continue
 			}
 
-			val, err := encryption.TryDecryptValue(storeName, bulkResp[i].Data)
+			val, err := encryption.TryDecryptValue(reqCtx, storeName, bulkResp[i].Data)
 			if err != nil {
 				log.Debugf("Bulk get error: %v", err)
 				bulkResp[i].Data = nil
@@ -738,17 +893,19 @@ func (a *api) onGetState(reqCtx *fasthttp.RequestCtx) {
 		Metadata: metadata,
 	}
 
-	start := time.Now()
-	policyRunner := resiliency.NewRunner[*state.GetResponse](reqCtx,
-		a.universal.Resiliency.ComponentOutboundPolicy(storeName, resiliency.Statestore),
-	)
-	resp, err := policyRunner(func(ctx context.Context) (*state.GetResponse, error) {
-		return store.Get(ctx, req)
-	})
-	elapsed := diag.ElapsedSince(start)
-
-	diag.DefaultComponentMonitoring.StateInvoked(context.Background(), storeName, diag.Get, err == nil, elapsed)
+	resp, err := a.universal.StateCache.Get(reqCtx, storeName, req, func(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+		start := time.Now()
+		policyRunner := resiliency.NewRunner[*state.GetResponse](ctx,
+			a.universal.Resiliency.ComponentOutboundPolicy(storeName, resiliency.Statestore),
+		)
+		res, err := policyRunner(func(ctx context.Context) (*state.GetResponse, error) {
+			return store.Get(ctx, req)
+		})
+		elapsed := diag.ElapsedSince(start)
 
+		diag.DefaultComponentMonitoring.StateInvoked(context.Background(), storeName, diag.Get, err == nil, elapsed)
+		return res, err
+	})
 	if err != nil {
 		msg := NewErrorResponse("ERR_STATE_GET", fmt.Sprintf(messages.ErrStateGet, key, storeName, err.Error()))
 		fasthttpRespond(reqCtx, fasthttpResponseWithError(nethttp.StatusInternalServerError, msg))
@@ -762,7 +919,7 @@ func (a *api) onGetState(reqCtx *fasthttp.RequestCtx) {
 	}
 
 	if encryption.EncryptedStateStore(storeName) {
-		val, err := encryption.TryDecryptValue(storeName, resp.Data)
+		val, err := encryption.TryDecryptValue(reqCtx, storeName, resp.Data)
 		if err != nil {
 			msg := NewErrorResponse("ERR_STATE_GET", fmt.Sprintf(messages.ErrStateGet, key, storeName, err.Error()))
 			fasthttpRespond(reqCtx, fasthttpResponseWithError(nethttp.StatusInternalServerError, msg))
@@ -1073,6 +1230,7 @@ func (a *api) onDeleteState(reqCtx *fasthttp.RequestCtx) {
 		log.Debug(resp.Message)
 		return
 	}
+	a.universal.StateWritten(reqCtx, storeName, []state.TransactionalStateOperation{req})
 	fasthttpRespond(reqCtx, fasthttpResponseWithEmpty())
 }
 
@@ -1124,8 +1282,7 @@ func (a *api) onPostState(reqCtx *fasthttp.RequestCtx) {
 		}
 
 		if encryption.EncryptedStateStore(storeName) {
-			data := []byte(fmt.Sprintf("%v", r.Value))
-			val, encErr := encryption.TryEncryptValue(storeName, data)
+			val, encErr := encryption.TryEncryptStateValue(reqCtx, storeName, r.Value)
 			if encErr != nil {
 				statusCode, errMsg, resp := a.stateErrorResponse(encErr, "ERR_STATE_SAVE")
 				resp.Message = fmt.Sprintf(messages.ErrStateSave, storeName, errMsg)
@@ -1142,7 +1299,7 @@ func (a *api) onPostState(reqCtx *fasthttp.RequestCtx) {
 	start := time.Now()
 	err = stateLoader.PerformBulkStoreOperation(reqCtx, reqs,
 		a.universal.Resiliency.ComponentOutboundPolicy(storeName, resiliency.Statestore),
-		state.BulkStoreOpts{},
+		stateLoader.GetBulkStoreOpts(storeName),
 		store.Set,
 		store.BulkSet,
 	)
@@ -1158,6 +1315,7 @@ func (a *api) onPostState(reqCtx *fasthttp.RequestCtx) {
 		log.Debug(resp.Message)
 		return
 	}
+	a.universal.StateWritten(reqCtx, storeName, statechanges.Operations(reqs))
 
 	fasthttpRespond(reqCtx, fasthttpResponseWithEmpty())
 }
@@ -1633,6 +1791,11 @@ func (a *api) onPublish(reqCtx *fasthttp.RequestCtx) {
 			status = nethttp.StatusBadRequest
 		}
 
+		if errors.As(err, &runtimePubsub.SchemaValidationError{}) {
+			msg = NewErrorResponse("ERR_PUBSUB_CLOUD_EVENT_SCHEMA", err.Error())
+			status = nethttp.StatusBadRequest
+		}
+
 		fasthttpRespond(reqCtx, fasthttpResponseWithError(status, msg))
 		log.Debug(msg)
 	} else {
@@ -1815,6 +1978,15 @@ func (a *api) onBulkPublish(reqCtx *fasthttp.RequestCtx) {
 			return
 		}
 
+		if errors.As(err, &runtimePubsub.SchemaValidationError{}) {
+			msg := NewErrorResponse("ERR_PUBSUB_CLOUD_EVENT_SCHEMA", err.Error())
+			status = nethttp.StatusBadRequest
+			fasthttpRespond(reqCtx, fasthttpResponseWithError(status, msg), closeChildSpans)
+			log.Debug(msg)
+
+			return
+		}
+
 		// Return the error along with the list of failed entries.
 		resData, _ := json.Marshal(bulkRes)
 		fasthttpRespond(reqCtx, fasthttpResponseWithJSON(status, resData, nil), closeChildSpans)
@@ -1825,6 +1997,92 @@ func (a *api) onBulkPublish(reqCtx *fasthttp.RequestCtx) {
 	fasthttpRespond(reqCtx, fasthttpResponseWithEmpty(), closeChildSpans)
 }
 
+type redriveRequestBody struct {
+	Target       string   `json:"target,omitempty"`
+	EventTypes   []string `json:"eventTypes,omitempty"`
+	StartTime    string   `json:"startTime,omitempty"`
+	EndTime      string   `json:"endTime,omitempty"`
+	MaxCount     int      `json:"maxCount,omitempty"`
+	DryRun       bool     `json:"dryRun,omitempty"`
+	WaitDuration string   `json:"waitDuration,omitempty"`
+}
+
+func (a *api) onRedrive(reqCtx *fasthttp.RequestCtx) {
+	_, pubsubName, topic, sc, errRes := a.validateAndGetPubsubAndTopic(reqCtx)
+	if errRes != nil {
+		fasthttpRespond(reqCtx, fasthttpResponseWithError(sc, errRes))
+
+		return
+	}
+
+	var body redriveRequestBody
+	if len(reqCtx.PostBody()) > 0 {
+		if err := json.Unmarshal(reqCtx.PostBody(), &body); err != nil {
+			msg := messages.ErrPubSubRedriveBadRequest.WithFormat(err)
+			universalFastHTTPErrorResponder(reqCtx, msg)
+			log.Debug(msg)
+			return
+		}
+	}
+
+	req := &runtimePubsub.RedriveRequest{
+		PubsubName: pubsubName,
+		Topic:      topic,
+		Target:     body.Target,
+		EventTypes: body.EventTypes,
+		MaxCount:   body.MaxCount,
+		DryRun:     body.DryRun,
+	}
+	var err error
+	if body.StartTime != "" {
+		req.StartTime, err = time.Parse(time.RFC3339, body.StartTime)
+	}
+	if err == nil && body.EndTime != "" {
+		req.EndTime, err = time.Parse(time.RFC3339, body.EndTime)
+	}
+	if err == nil && body.WaitDuration != "" {
+		req.WaitDuration, err = time.ParseDuration(body.WaitDuration)
+	}
+	if err == nil {
+		err = req.Validate()
+	}
+	if err != nil {
+		msg := messages.ErrPubSubRedriveBadRequest.WithFormat(err)
+		universalFastHTTPErrorResponder(reqCtx, msg)
+		log.Debug(msg)
+		return
+	}
+
+	redriver, ok := a.pubsubAdapter.(runtimePubsub.Redriver)
+	if !ok {
+		msg := messages.ErrPubSubRedrive.WithFormat(topic, pubsubName, "redrive is not supported")
+		universalFastHTTPErrorResponder(reqCtx, msg)
+		log.Debug(msg)
+		return
+	}
+
+	res, err := redriver.Redrive(reqCtx, req)
+	if err != nil {
+		if errors.As(err, &runtimePubsub.NotFoundError{}) {
+			msg := NewErrorResponse("ERR_PUBSUB_NOT_FOUND", err.Error())
+			fasthttpRespond(reqCtx, fasthttpResponseWithError(nethttp.StatusBadRequest, msg))
+			log.Debug(msg)
+			return
+		}
+
+		msg := messages.ErrPubSubRedrive.WithFormat(topic, pubsubName, err)
+		if errors.Is(err, runtimePubsub.ErrRedriveSubscriptionNotFound) || errors.Is(err, runtimePubsub.ErrRedriveNoDeadLetterTopic) {
+			msg = messages.ErrPubSubRedriveBadRequest.WithFormat(err)
+		}
+		universalFastHTTPErrorResponder(reqCtx, msg)
+		log.Debug(msg)
+		return
+	}
+
+	b, _ := json.Marshal(res)
+	fasthttpRespond(reqCtx, fasthttpResponseWithJSON(nethttp.StatusOK, b, nil))
+}
+
 // validateAndGetPubsubAndTopic takes input as request context and returns the pubsub interface, pubsub name, topic name,
 // or error status code and an ErrorResponse object.
 func (a *api) validateAndGetPubsubAndTopic(reqCtx *fasthttp.RequestCtx) (pubsub.PubSub, string, string, int, *ErrorResponse) {
@@ -2017,8 +2275,7 @@ func (a *api) onPostStateTransaction(reqCtx *fasthttp.RequestCtx) {
 		for i, op := range operations {
 			switch req := op.(type) {
 			case state.SetRequest:
-				data := []byte(fmt.Sprintf("%v", req.Value))
-				val, err := encryption.TryEncryptValue(storeName, data)
+				val, err := encryption.TryEncryptStateValue(reqCtx, storeName, req.Value)
 				if err != nil {
 					msg := NewErrorResponse(
 						"ERR_SAVE_STATE",
@@ -2034,9 +2291,12 @@ func (a *api) onPostStateTransaction(reqCtx *fasthttp.RequestCtx) {
 		}
 	}
 
+	// The operations of the app, without the ones added by the outbox
+	changes := operations
+
 	outboxEnabled := a.pubsubAdapter.Outbox().Enabled(storeName)
 	if outboxEnabled {
-		trs, err := a.pubsubAdapter.Outbox().PublishInternal(reqCtx, storeName, operations, a.universal.AppID)
+		ops, err := a.pubsubAdapter.Outbox().PublishInternal(reqCtx, storeName, operations, a.universal.AppID)
 		if err != nil {
 			msg := NewErrorResponse(
 				"ERR_PUBLISH_OUTBOX",
@@ -2046,7 +2306,7 @@ func (a *api) onPostStateTransaction(reqCtx *fasthttp.RequestCtx) {
 			return
 		}
 
-		operations = append(operations, trs...)
+		operations = ops
 	}
 
 	start := time.Now()
@@ -2069,6 +2329,7 @@ func (a *api) onPostStateTransaction(reqCtx *fasthttp.RequestCtx) {
 		fasthttpRespond(reqCtx, fasthttpResponseWithError(nethttp.StatusInternalServerError, msg))
 		log.Debug(msg)
 	} else {
+		a.universal.StateWritten(reqCtx, storeName, changes)
 		fasthttpRespond(reqCtx, fasthttpResponseWithEmpty())
 	}
 }
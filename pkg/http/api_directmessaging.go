@@ -23,6 +23,8 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -33,8 +35,21 @@ import (
 	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
 	"github.com/dapr/dapr/pkg/resiliency"
 	"github.com/dapr/dapr/pkg/resiliency/breaker"
+	"github.com/dapr/kit/utils"
 )
 
+const (
+	// Header used to request the streaming mode for service invocation.
+	// Requests that accept server-sent events or NDJSON use it too.
+	daprStreamHeader = "dapr-stream"
+	// Header that sets how long a streamed response can go without data before it's aborted.
+	daprStreamIdleTimeoutHeader = "dapr-stream-idle-timeout"
+
+	defaultStreamIdleTimeout = 5 * time.Minute
+)
+
+var errStreamIdleTimeout = errors.New("no data received from the target app within the idle timeout")
+
 // directMessagingSpanData is the data passed by the onDirectMessage endpoint to the tracing middleware
 type directMessagingSpanData struct {
 	// Target app ID
@@ -139,19 +154,44 @@ func (a *api) onDirectMessage(w http.ResponseWriter, r *http.Request) {
 		WithContentType(r.Header.Get("content-type")).
 		// Save headers to internal metadata
 		WithHTTPHeaders(r.Header)
+
+	// If the caller asks to upgrade the connection, e.g. to WebSocket, its data
+	// is sent once the target app accepts. Tunneled connections can't be
+	// replayed, so resiliency policies don't apply to them.
+	var upgradeData *io.PipeWriter
+	if req.IsUpgrade() {
+		var pr *io.PipeReader
+		pr, upgradeData = io.Pipe()
+		defer upgradeData.Close()
+		req.WithRawData(pr)
+		policyDef = nil
+	}
+
 	if policyDef != nil {
 		req.WithReplay(policyDef.HasRetries())
 	}
 	defer req.Close()
 
+	// In streaming mode, resiliency policies apply until the response headers
+	// are received; after that, the invocation lasts as long as the caller is
+	// connected and the target app keeps sending data.
+	streaming := upgradeData != nil || isStreamingRequest(r)
+	streamCtx, streamCancel := context.WithCancel(r.Context())
+	defer streamCancel()
+
 	policyRunner := resiliency.NewRunnerWithOptions(
-		r.Context(), policyDef,
+		streamCtx, policyDef,
 		resiliency.RunnerOpts[*invokev1.InvokeMethodResponse]{
 			Disposer: resiliency.DisposerCloser[*invokev1.InvokeMethodResponse],
 		},
 	)
 	// Since we don't want to return the actual error, we have to extract several things in order to construct our response.
 	resp, err := policyRunner(func(ctx context.Context) (*invokev1.InvokeMethodResponse, error) {
+		if streaming {
+			var detach func()
+			ctx, detach = detachAfterHeaders(ctx, streamCtx)
+			defer detach()
+		}
 		rResp, rErr := a.directMessaging.Invoke(ctx, targetID, req)
 		if rErr != nil {
 			// Allowlist policies that are applied on the callee side can return a Permission Denied error.
@@ -188,7 +228,7 @@ func (a *api) onDirectMessage(w http.ResponseWriter, r *http.Request) {
 			} else {
 				resStatus.Code = statusCode
 			}
-		} else if resStatus.Code < 200 || resStatus.Code > 399 {
+		} else if (resStatus.Code < 200 && !rResp.IsUpgrade()) || resStatus.Code > 399 {
 			msg, _ := rResp.RawDataFull()
 			// Returning a `codeError` here will cause Resiliency to retry the request (if retries are enabled), but if the request continues to fail, the response is sent to the user with whatever status code the app returned.
 			return rResp, codeError{
@@ -245,6 +285,19 @@ func (a *api) onDirectMessage(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Close()
 
+	if upgradeData != nil && resp.IsUpgrade() {
+		conn, brw, hErr := http.NewResponseController(w).Hijack()
+		if hErr != nil {
+			respondWithError(w, messages.ErrDirectInvoke.WithFormat(targetID, hErr))
+			return
+		}
+		err = tunnelUpgradedConn(conn, brw, w.Header(), r, resp, upgradeData, targetID, streamCancel)
+		if err != nil {
+			log.Debugf("Upgraded connection to %s interrupted: %v", targetID, err)
+		}
+		return
+	}
+
 	statusCode := int(resp.Status().Code)
 
 	if ct := resp.ContentType(); ct != "" {
@@ -253,6 +306,15 @@ func (a *api) onDirectMessage(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(statusCode)
 
+	if streaming || invokev1.IsStreamingContentType(resp.ContentType()) {
+		err = streamResponse(w, resp.RawData(), streamIdleTimeout(r.Header), streamCancel)
+		if err != nil {
+			// The status code was sent already, so all we can do is to stop
+			log.Debugf("Streamed response from %s interrupted: %v", targetID, err)
+		}
+		return
+	}
+
 	_, err = io.Copy(w, resp.RawData())
 	if err != nil {
 		respondWithError(w, messages.ErrDirectInvoke.WithFormat(targetID, err))
@@ -260,6 +322,120 @@ func (a *api) onDirectMessage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// detachAfterHeaders returns a context for an attempt to invoke a streaming
+// request. Until detach is called, once the response headers are received,
+// the context is canceled with attemptCtx, so that timeouts apply; after
+// that, it is only canceled with streamCtx.
+func detachAfterHeaders(attemptCtx, streamCtx context.Context) (ctx context.Context, detach func()) {
+	ctx, cancel := context.WithCancel(streamCtx)
+	var detached atomic.Bool
+	go func() {
+		select {
+		case <-attemptCtx.Done():
+			if !detached.Load() {
+				cancel()
+			}
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		detached.Store(true)
+	}
+}
+
+// isStreamingRequest returns true if the caller asked for the streaming mode,
+// explicitly or by accepting server-sent events or NDJSON.
+func isStreamingRequest(r *http.Request) bool {
+	if v := r.Header.Get(daprStreamHeader); v != "" {
+		return utils.IsTruthy(v)
+	}
+	for _, accept := range r.Header.Values("accept") {
+		for _, ct := range strings.Split(accept, ",") {
+			if invokev1.IsStreamingContentType(strings.TrimSpace(ct)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// streamIdleTimeout returns the idle timeout of streamed responses; zero means
+// no timeout.
+func streamIdleTimeout(header http.Header) time.Duration {
+	v := header.Get(daprStreamIdleTimeoutHeader)
+	if v == "" {
+		return defaultStreamIdleTimeout
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return defaultStreamIdleTimeout
+	}
+	return d
+}
+
+// streamResponse copies the body of a streamed response to the caller,
+// flushing every chunk as soon as it's read. If no data is read for
+// idleTimeout, cancel is invoked to abort the invocation, which cancels it in
+// the target app's sidecar too.
+func streamResponse(w http.ResponseWriter, body io.Reader, idleTimeout time.Duration, cancel context.CancelFunc) error {
+	rc := http.NewResponseController(w)
+
+	// Send the headers right away
+	err := rc.Flush()
+	if errors.Is(err, http.ErrNotSupported) {
+		_, err = io.Copy(w, body)
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	var idle atomic.Bool
+	if idleTimeout > 0 {
+		timer := time.AfterFunc(idleTimeout, func() {
+			idle.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+		body = &idleResetReader{r: body, reset: func() { timer.Reset(idleTimeout) }}
+	}
+
+	buf := invokev1.BufPool.Get().(*[]byte)
+	defer invokev1.BufPool.Put(buf)
+	for {
+		n, rErr := body.Read(*buf)
+		if n > 0 {
+			if _, err = w.Write((*buf)[:n]); err != nil {
+				return err
+			}
+			if err = rc.Flush(); err != nil {
+				return err
+			}
+		}
+		switch {
+		case errors.Is(rErr, io.EOF):
+			return nil
+		case rErr != nil && idle.Load():
+			return errStreamIdleTimeout
+		case rErr != nil:
+			return rErr
+		}
+	}
+}
+
+// idleResetReader invokes reset every time data is read.
+type idleResetReader struct {
+	r     io.Reader
+	reset func()
+}
+
+func (i *idleResetReader) Read(p []byte) (int, error) {
+	n, err := i.r.Read(p)
+	if n > 0 {
+		i.reset()
+	}
+	return n, err
+}
+
 // findTargetIDAndMethod finds ID of the target service and method from the following three places:
 // 1. HTTP header 'dapr-app-id' (path is method)
 // 2. Basic auth header: `http://dapr-app-id:<service-id>@localhost:3500/<method>`
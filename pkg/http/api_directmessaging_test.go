@@ -15,14 +15,18 @@ limitations under the License.
 package http
 
 import (
+	"context"
 	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	epb "google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -1087,6 +1091,152 @@ func TestFindTargetIDAndMethod(t *testing.T) {
 	}
 }
 
+func TestV1DirectMessagingEndpointsStreaming(t *testing.T) {
+	mockDirectMessaging := new(daprt.MockDirectMessaging)
+
+	fakeServer := newFakeHTTPServer()
+	testAPI := &api{
+		directMessaging: mockDirectMessaging,
+		universal: &universalapi.UniversalAPI{
+			CompStore:  compstore.New(),
+			Resiliency: resiliency.New(nil),
+		},
+	}
+	fakeServer.StartServer(testAPI.constructDirectMessagingEndpoints(), nil)
+	defer fakeServer.Shutdown()
+
+	t.Run("Server-sent events are streamed", func(t *testing.T) {
+		fakeResponse := invokev1.NewInvokeMethodResponse(200, "OK", nil).
+			WithRawDataString("data: one\n\ndata: two\n\n").
+			WithContentType(invokev1.EventStreamContentType)
+		defer fakeResponse.Close()
+
+		mockDirectMessaging.Calls = nil
+		mockDirectMessaging.
+			On(
+				"Invoke",
+				mock.MatchedBy(matchContextInterface),
+				mock.MatchedBy(func(b string) bool {
+					return b == "fakeAppID"
+				}),
+				mock.AnythingOfType("*v1.InvokeMethodRequest"),
+			).
+			Return(fakeResponse, nil).
+			Once()
+
+		resp := fakeServer.DoRequest("GET", "v1.0/invoke/fakeAppID/method/events", nil, nil, "Accept", invokev1.EventStreamContentType)
+
+		mockDirectMessaging.AssertNumberOfCalls(t, "Invoke", 1)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, invokev1.EventStreamContentType, resp.ContentType)
+		assert.Equal(t, "data: one\n\ndata: two\n\n", string(resp.RawBody))
+	})
+}
+
+func TestIsStreamingRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers http.Header
+		want    bool
+	}{
+		{name: "no headers", headers: http.Header{}, want: false},
+		{name: "dapr-stream header", headers: http.Header{"Dapr-Stream": []string{"true"}}, want: true},
+		{name: "dapr-stream header disabled", headers: http.Header{"Dapr-Stream": []string{"false"}, "Accept": []string{"text/event-stream"}}, want: false},
+		{name: "accept server-sent events", headers: http.Header{"Accept": []string{"text/event-stream"}}, want: true},
+		{name: "accept ndjson in list", headers: http.Header{"Accept": []string{"application/json, application/x-ndjson"}}, want: true},
+		{name: "accept json", headers: http.Header{"Accept": []string{"application/json"}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1.0/invoke/myapp/method/foo", nil)
+			r.Header = tt.headers
+			assert.Equal(t, tt.want, isStreamingRequest(r))
+		})
+	}
+}
+
+func TestDetachAfterHeaders(t *testing.T) {
+	t.Run("attempt timeout before headers", func(t *testing.T) {
+		streamCtx, streamCancel := context.WithCancel(context.Background())
+		defer streamCancel()
+		attemptCtx, attemptCancel := context.WithTimeout(streamCtx, 10*time.Millisecond)
+		defer attemptCancel()
+
+		ctx, _ := detachAfterHeaders(attemptCtx, streamCtx)
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("context not canceled by the attempt timeout")
+		}
+	})
+
+	t.Run("detached after headers", func(t *testing.T) {
+		streamCtx, streamCancel := context.WithCancel(context.Background())
+		defer streamCancel()
+		attemptCtx, attemptCancel := context.WithCancel(streamCtx)
+
+		ctx, detach := detachAfterHeaders(attemptCtx, streamCtx)
+		detach()
+		attemptCancel()
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, ctx.Err())
+
+		streamCancel()
+		<-ctx.Done()
+	})
+}
+
+func TestStreamIdleTimeout(t *testing.T) {
+	assert.Equal(t, defaultStreamIdleTimeout, streamIdleTimeout(http.Header{}))
+	assert.Equal(t, 10*time.Second, streamIdleTimeout(http.Header{"Dapr-Stream-Idle-Timeout": []string{"10s"}}))
+	assert.Equal(t, time.Duration(0), streamIdleTimeout(http.Header{"Dapr-Stream-Idle-Timeout": []string{"0"}}))
+	assert.Equal(t, defaultStreamIdleTimeout, streamIdleTimeout(http.Header{"Dapr-Stream-Idle-Timeout": []string{"-1s"}}))
+	assert.Equal(t, defaultStreamIdleTimeout, streamIdleTimeout(http.Header{"Dapr-Stream-Idle-Timeout": []string{"foo"}}))
+}
+
+func TestStreamResponse(t *testing.T) {
+	t.Run("chunks are flushed as they are read", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		w := httptest.NewRecorder()
+
+		done := make(chan error, 1)
+		go func() {
+			done <- streamResponse(w, pr, time.Minute, func() {})
+		}()
+
+		_, err := pw.Write([]byte("data: one\n\n"))
+		require.NoError(t, err)
+		_, err = pw.Write([]byte("data: two\n\n"))
+		require.NoError(t, err)
+		pw.Close()
+
+		select {
+		case err = <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("streamResponse did not return")
+		}
+		assert.True(t, w.Flushed)
+		assert.Equal(t, "data: one\n\ndata: two\n\n", w.Body.String())
+	})
+
+	t.Run("idle timeout cancels the invocation", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		w := httptest.NewRecorder()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			pw.Write([]byte("data: one\n\n"))
+			<-ctx.Done()
+			pw.CloseWithError(ctx.Err())
+		}()
+
+		err := streamResponse(w, pr, 50*time.Millisecond, cancel)
+		require.ErrorIs(t, err, errStreamIdleTimeout)
+		assert.Equal(t, "data: one\n\n", w.Body.String())
+	})
+}
+
 func getFakeDirectMessageResponse() *invokev1.InvokeMethodResponse {
 	return getFakeDirectMessageResponseWithStatusCode(http.StatusOK)
 }
@@ -115,6 +115,22 @@ func (a *api) onGetMetadata() http.HandlerFunc {
 					res.Subscriptions = subs
 				}
 
+				// Copy the outbox configuration into a custom struct
+				if len(out.Outbox) > 0 {
+					res.Outbox = make([]metadataResponseOutbox, len(out.Outbox))
+					for i, v := range out.Outbox {
+						res.Outbox[i] = metadataResponseOutbox{
+							StateStore:              v.StateStore,
+							PublishPubsub:           v.PublishPubsub,
+							PublishTopic:            v.PublishTopic,
+							OutboxPubsub:            v.OutboxPubsub,
+							DiscardWhenMissingState: v.DiscardWhenMissingState,
+							MaxVerificationAge:      v.MaxVerificationAge,
+							DeadLetterTopic:         v.DeadLetterTopic,
+						}
+					}
+				}
+
 				return res, nil
 			},
 		},
@@ -155,6 +171,17 @@ type metadataResponse struct {
 	Subscriptions           []metadataResponsePubsubSubscription    `json:"subscriptions,omitempty"`
 	HTTPEndpoints           []*runtimev1pb.MetadataHTTPEndpoint     `json:"httpEndpoints,omitempty"`
 	AppConnectionProperties metadataResponseAppConnectionProperties `json:"appConnectionProperties,omitempty"`
+	Outbox                  []metadataResponseOutbox                `json:"outbox,omitempty"`
+}
+
+type metadataResponseOutbox struct {
+	StateStore              string `json:"stateStore"`
+	PublishPubsub           string `json:"publishPubsub"`
+	PublishTopic            string `json:"publishTopic"`
+	OutboxPubsub            string `json:"outboxPubsub"`
+	DiscardWhenMissingState bool   `json:"discardWhenMissingState"`
+	MaxVerificationAge      string `json:"maxVerificationAge,omitempty"`
+	DeadLetterTopic         string `json:"deadLetterTopic,omitempty"`
 }
 
 type metadataResponsePubsubSubscription struct {
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/dapr/pkg/messages"
+	"github.com/dapr/dapr/pkg/statebackup"
+)
+
+// copyStateRequest is the body of the requests copying the records of the app
+// to another state store.
+type copyStateRequest struct {
+	statebackup.ExportOptions
+	To string `json:"to"`
+}
+
+// backupResponseWriter sends a backup, and records whether it started
+// sending it, after which errors can't be reported anymore.
+type backupResponseWriter struct {
+	nethttp.ResponseWriter
+	started bool
+}
+
+func (w *backupResponseWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.Header().Set(headerContentType, "application/x-ndjson")
+		w.started = true
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// onExportState streams a backup of the records of the app in the state store.
+func (a *api) onExportState(w nethttp.ResponseWriter, r *nethttp.Request) {
+	store, storeName, ok := a.getStateStore(w, r)
+	if !ok {
+		return
+	}
+
+	var opts statebackup.ExportOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		msg := messages.ErrStateExport.WithFormat(storeName, err)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	a.defaultBackupActorTypes(r.Context(), &opts)
+
+	rw := &backupResponseWriter{ResponseWriter: w}
+	src := statebackup.Store{Name: storeName, Store: store, AppID: a.universal.AppID}
+	_, err := statebackup.Export(r.Context(), rw, src, opts)
+	switch {
+	case err == nil:
+	case rw.started:
+		// The backup has no trailer, so it can't be imported
+		log.Warnf("Failed to export state store %s: %v", storeName, err)
+	case errors.Is(err, statebackup.ErrInvalidBackup):
+		msg := messages.ErrStateExport.WithFormat(storeName, err)
+		log.Debug(msg)
+		respondWithError(w, msg)
+	default:
+		msg := messages.ErrStateExportFailed.WithFormat(storeName, err)
+		log.Debug(msg)
+		respondWithError(w, msg)
+	}
+}
+
+// onImportState saves the records of the backup in the body in the state
+// store. Invalid backups aren't imported at all.
+func (a *api) onImportState(w nethttp.ResponseWriter, r *nethttp.Request) {
+	store, storeName, ok := a.getStateStore(w, r)
+	if !ok {
+		return
+	}
+
+	dst := statebackup.Store{Name: storeName, Store: store, AppID: a.universal.AppID}
+	res, err := statebackup.Import(r.Context(), r.Body, dst, a.backupWritten(storeName))
+	if err != nil {
+		var msg messages.APIError
+		if errors.Is(err, statebackup.ErrInvalidBackup) {
+			msg = messages.ErrStateImport.WithFormat(storeName, err)
+		} else {
+			// Imports aren't atomic: the records saved until then are kept
+			msg = messages.ErrStateImportFailed.WithFormat(storeName, res.State+res.ActorState+res.Reminders, err)
+		}
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	respondWithJSON(w, nethttp.StatusOK, res)
+}
+
+// onCopyState copies the records of the app to another state store.
+func (a *api) onCopyState(w nethttp.ResponseWriter, r *nethttp.Request) {
+	store, storeName, ok := a.getStateStore(w, r)
+	if !ok {
+		return
+	}
+
+	var req copyStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := messages.ErrStateExport.WithFormat(storeName, err)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	dstStore, ok := a.universal.CompStore.GetStateStore(req.To)
+	if !ok {
+		msg := messages.ErrStateStoreNotFound.WithFormat(req.To)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	a.defaultBackupActorTypes(r.Context(), &req.ExportOptions)
+
+	src := statebackup.Store{Name: storeName, Store: store, AppID: a.universal.AppID}
+	dst := statebackup.Store{Name: req.To, Store: dstStore, AppID: a.universal.AppID}
+	res, err := statebackup.Copy(r.Context(), src, dst, req.ExportOptions, a.backupWritten(req.To))
+	if err != nil {
+		var msg messages.APIError
+		if errors.Is(err, statebackup.ErrInvalidBackup) {
+			msg = messages.ErrStateExport.WithFormat(storeName, err)
+		} else {
+			msg = messages.ErrStateExportFailed.WithFormat(storeName, err)
+		}
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	respondWithJSON(w, nethttp.StatusOK, res)
+}
+
+// defaultBackupActorTypes exports the reminders of the actor types the app
+// hosts, unless others are requested.
+func (a *api) defaultBackupActorTypes(ctx context.Context, opts *statebackup.ExportOptions) {
+	if !opts.Actors || len(opts.ActorTypes) > 0 || a.universal.Actors == nil {
+		return
+	}
+	for _, c := range a.universal.Actors.GetActiveActorsCount(ctx) {
+		opts.ActorTypes = append(opts.ActorTypes, c.Type)
+	}
+}
+
+func (a *api) backupWritten(storeName string) statebackup.WrittenFn {
+	return func(ctx context.Context, ops []state.TransactionalStateOperation) {
+		a.universal.StateWritten(ctx, storeName, ops)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+	"github.com/dapr/dapr/pkg/grpc/universalapi"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+)
+
+func TestStateBackupEndpoints(t *testing.T) {
+	fakeServer := newFakeHTTPServer()
+	compStore := compstore.New()
+	compStore.AddStateStore("store1", newFakeStateStore())
+	compStore.AddStateStore("store2", newFakeStateStoreQuerier())
+	// Imported keys are saved as they are
+	compStore.AddStateStore("backup-store", newFakeStateStore())
+	require.NoError(t, stateLoader.SaveStateConfiguration("backup-store", map[string]string{"keyPrefix": "none"}))
+	testAPI := &api{
+		universal: &universalapi.UniversalAPI{
+			AppID:      "fakeAPI",
+			Logger:     log,
+			CompStore:  compStore,
+			Resiliency: resiliency.New(nil),
+		},
+	}
+	fakeServer.StartServer(testAPI.constructStateEndpoints(), nil)
+	defer fakeServer.Shutdown()
+
+	t.Run("export", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store2/export", nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.RawBody))
+		lines := strings.Split(strings.TrimSpace(string(resp.RawBody)), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], `"type":"header"`)
+		assert.JSONEq(t, `{"type":"end"}`, lines[1])
+	})
+
+	t.Run("export of a store that can't list keys - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store1/export", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_EXPORT", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("import", func(t *testing.T) {
+		body := []byte(`{"type":"header","version":1}
+{"type":"state","key":"good-key","value":{"a":1}}
+{"type":"end","count":1}
+`)
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/backup-store/import", body, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.RawBody))
+		assert.JSONEq(t, `{"state":1,"actorState":0,"reminders":0,"skipped":0,"failed":0}`, string(resp.RawBody))
+	})
+
+	t.Run("import of a truncated backup - 400", func(t *testing.T) {
+		body := []byte(`{"type":"header","version":1}
+{"type":"state","key":"good-key","value":{"a":1}}
+`)
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/backup-store/import", body, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_IMPORT", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store2/copy", []byte(`{"to":"backup-store"}`), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.RawBody))
+		assert.JSONEq(t, `{"state":0,"actorState":0,"reminders":0,"skipped":0,"failed":0}`, string(resp.RawBody))
+	})
+
+	t.Run("copy to an unknown store - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store2/copy", []byte(`{"to":"nope"}`), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_STORE_NOT_FOUND", resp.ErrorBody["errorCode"])
+	})
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/dapr/components-contrib/state"
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+	"github.com/dapr/dapr/pkg/encryption"
+	"github.com/dapr/dapr/pkg/messages"
+)
+
+// onSaveBulkState saves multiple states and responds with the result of
+// each one, in the order of the request.
+func (a *api) onSaveBulkState(w nethttp.ResponseWriter, r *nethttp.Request) {
+	storeName := chi.URLParam(r, storeNameParam)
+
+	var reqs []state.SetRequest
+	keys, ok := decodeBulkStateRequests(a, w, r, storeName, &reqs)
+	if !ok {
+		return
+	}
+
+	if encryption.EncryptedStateStore(storeName) {
+		for i := range reqs {
+			val, err := encryption.TryEncryptStateValue(r.Context(), storeName, reqs[i].Value)
+			if err != nil {
+				msg := messages.ErrStateBulkWrite.WithFormat(storeName, err)
+				log.Debug(msg)
+				respondWithError(w, msg)
+				return
+			}
+			reqs[i].Value = val
+		}
+	}
+
+	res, err := a.universal.SaveBulkState(r.Context(), storeName, keys, reqs)
+	if err != nil {
+		// Error has already been logged
+		respondWithError(w, err)
+		return
+	}
+	respondWithProto(w, res, nethttp.StatusOK, true)
+}
+
+// onDeleteBulkState deletes multiple states and responds with the result of
+// each one, in the order of the request.
+func (a *api) onDeleteBulkState(w nethttp.ResponseWriter, r *nethttp.Request) {
+	storeName := chi.URLParam(r, storeNameParam)
+
+	var reqs []state.DeleteRequest
+	keys, ok := decodeBulkStateRequests(a, w, r, storeName, &reqs)
+	if !ok {
+		return
+	}
+
+	res, err := a.universal.DeleteBulkState(r.Context(), storeName, keys, reqs)
+	if err != nil {
+		// Error has already been logged
+		respondWithError(w, err)
+		return
+	}
+	respondWithProto(w, res, nethttp.StatusOK, true)
+}
+
+// decodeBulkStateRequests decodes the requests in the body into reqs, and
+// replaces their keys with the ones to save, merging the metadata of the
+// query string. It returns the keys of the app.
+func decodeBulkStateRequests[T state.SetRequest | state.DeleteRequest](a *api, w nethttp.ResponseWriter, r *nethttp.Request, storeName string, reqs *[]T) ([]string, bool) {
+	if err := json.NewDecoder(r.Body).Decode(reqs); err != nil {
+		msg := messages.ErrMalformedRequest.WithFormat(err)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return nil, false
+	}
+
+	metadata := getMetadataFromRequest(r)
+	keys := make([]string, len(*reqs))
+	for i := range *reqs {
+		key, reqMetadata := bulkStateRequestFields(&(*reqs)[i])
+		if key == nil || *key == "" {
+			msg := messages.ErrMalformedRequest.WithFormat(errors.New(`"key" is a required field`))
+			log.Debug(msg)
+			respondWithError(w, msg)
+			return nil, false
+		}
+		keys[i] = *key
+
+		modified, err := stateLoader.GetModifiedStateKey(*key, storeName, a.universal.AppID)
+		if err != nil {
+			msg := messages.ErrMalformedRequest.WithFormat(err)
+			log.Debug(msg)
+			respondWithError(w, msg)
+			return nil, false
+		}
+		*key = modified
+
+		if *reqMetadata == nil {
+			*reqMetadata = map[string]string{}
+		}
+		for k, v := range metadata {
+			(*reqMetadata)[k] = v
+		}
+	}
+	return keys, true
+}
+
+func bulkStateRequestFields[T state.SetRequest | state.DeleteRequest](req *T) (*string, *map[string]string) {
+	switch r := any(req).(type) {
+	case *state.SetRequest:
+		return &r.Key, &r.Metadata
+	case *state.DeleteRequest:
+		return &r.Key, &r.Metadata
+	}
+	return nil, nil
+}
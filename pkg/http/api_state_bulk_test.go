@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/dapr/pkg/grpc/universalapi"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+)
+
+func TestBulkStateWriteEndpoints(t *testing.T) {
+	fakeServer := newFakeHTTPServer()
+	compStore := compstore.New()
+	compStore.AddStateStore("store1", newFakeStateStore())
+	testAPI := &api{
+		universal: &universalapi.UniversalAPI{
+			Logger:     log,
+			CompStore:  compStore,
+			Resiliency: resiliency.New(nil),
+		},
+	}
+	fakeServer.StartServer(testAPI.constructStateEndpoints(), nil)
+	defer fakeServer.Shutdown()
+
+	t.Run("save reports the result of each key", func(t *testing.T) {
+		body := []byte(`[
+			{"key":"good-key","value":1},
+			{"key":"good-key2","value":2,"etag":"wrong"},
+			{"key":"bad-key","value":3}
+		]`)
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store1/bulk/save", body, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.RawBody))
+		assert.JSONEq(t, `{"results":[
+			{"key":"good-key","status":"SUCCESS","error":""},
+			{"key":"good-key2","status":"ETAG_MISMATCH","error":"possible etag mismatch. error from state store: ETag mismatch"},
+			{"key":"bad-key","status":"ERROR","error":"NOT FOUND"}
+		]}`, string(resp.RawBody))
+	})
+
+	t.Run("delete reports the result of each key", func(t *testing.T) {
+		body := []byte(`[{"key":"good-key"},{"key":"bad-key"}]`)
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store1/bulk/delete", body, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.RawBody))
+		assert.JSONEq(t, `{"results":[
+			{"key":"good-key","status":"SUCCESS","error":""},
+			{"key":"bad-key","status":"ERROR","error":"NOT FOUND"}
+		]}`, string(resp.RawBody))
+	})
+
+	t.Run("missing key - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store1/bulk/save", []byte(`[{"value":1}]`), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_MALFORMED_REQUEST", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("unknown store - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/nope/bulk/delete", []byte(`[{"key":"a"}]`), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_STORE_NOT_FOUND", resp.ErrorBody["errorCode"])
+	})
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+	"github.com/dapr/dapr/pkg/messages"
+)
+
+// onMigrateStateKeys starts moving the records of the app to the keys created
+// by another key prefix strategy.
+func (a *api) onMigrateStateKeys(w nethttp.ResponseWriter, r *nethttp.Request) {
+	store, storeName, ok := a.getStateStore(w, r)
+	if !ok {
+		return
+	}
+
+	var opts stateLoader.KeyMigrationOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		msg := messages.ErrStateKeyMigration.WithFormat(storeName, err)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	opts.OnMigrated = func() {
+		a.universal.StateCache.Purge(storeName)
+	}
+
+	status, err := stateLoader.StartKeyMigration(store, storeName, a.universal.AppID, opts)
+	if err != nil {
+		var msg messages.APIError
+		if errors.Is(err, stateLoader.ErrKeyMigrationRunning) {
+			msg = messages.ErrStateKeyMigrationRunning.WithFormat(storeName)
+		} else {
+			msg = messages.ErrStateKeyMigration.WithFormat(storeName, err)
+		}
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	respondWithJSON(w, nethttp.StatusAccepted, status)
+}
+
+// onGetStateKeyMigration returns the progress of the key migration of a state
+// store.
+func (a *api) onGetStateKeyMigration(w nethttp.ResponseWriter, r *nethttp.Request) {
+	_, storeName, ok := a.getStateStore(w, r)
+	if !ok {
+		return
+	}
+
+	status, found := stateLoader.GetKeyMigrationStatus(storeName)
+	if !found {
+		msg := messages.ErrStateKeyMigrationNotFound.WithFormat(storeName)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	respondWithJSON(w, nethttp.StatusOK, status)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/dapr/pkg/grpc/universalapi"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+)
+
+func TestMigrateStateKeysEndpoint(t *testing.T) {
+	fakeServer := newFakeHTTPServer()
+	compStore := compstore.New()
+	compStore.AddStateStore("store1", newFakeStateStore())
+	compStore.AddStateStore("store2", newFakeStateStoreQuerier())
+	testAPI := &api{
+		universal: &universalapi.UniversalAPI{
+			AppID:      "fakeAPI",
+			Logger:     log,
+			CompStore:  compStore,
+			Resiliency: resiliency.New(nil),
+		},
+	}
+	fakeServer.StartServer(testAPI.constructStateEndpoints(), nil)
+	defer fakeServer.Shutdown()
+
+	t.Run("invalid body - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store2/keys/migrate", []byte(`{`), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_KEY_MIGRATION", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("invalid strategy - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store2/keys/migrate", []byte(`{"to":"{other}-{key}"}`), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_KEY_MIGRATION", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("no migration - 404", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodGet, "v1.0-alpha1/state/store1/keys/migrate", nil, nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_KEY_MIGRATION_NOT_FOUND", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("migration runs in the background - 202", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store2/keys/migrate", []byte(`{"to":"none","dryRun":true}`), nil)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		assert.Contains(t, string(resp.RawBody), `"state":"running"`)
+
+		assert.Eventually(t, func() bool {
+			resp = fakeServer.DoRequest(http.MethodGet, "v1.0-alpha1/state/store2/keys/migrate", nil, nil)
+			return resp.StatusCode == http.StatusOK && !strings.Contains(string(resp.RawBody), `"state":"running"`)
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Contains(t, string(resp.RawBody), `"dryRun":true`)
+	})
+
+	t.Run("store can't list keys - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store1/keys/migrate", []byte(`{"to":"none"}`), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_KEY_MIGRATION", resp.ErrorBody["errorCode"])
+	})
+}
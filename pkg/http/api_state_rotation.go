@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	nethttp "net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/dapr/pkg/encryption"
+	"github.com/dapr/dapr/pkg/messages"
+)
+
+// getStateStore returns the state store named in the request's path, or
+// responds with an error.
+func (a *api) getStateStore(w nethttp.ResponseWriter, r *nethttp.Request) (state.Store, string, bool) {
+	if a.universal.CompStore.StateStoresLen() == 0 {
+		err := messages.ErrStateStoresNotConfigured
+		log.Debug(err)
+		respondWithError(w, err)
+		return nil, "", false
+	}
+
+	storeName := chi.URLParam(r, storeNameParam)
+	store, ok := a.universal.CompStore.GetStateStore(storeName)
+	if !ok {
+		err := messages.ErrStateStoreNotFound.WithFormat(storeName)
+		log.Debug(err)
+		respondWithError(w, err)
+		return nil, "", false
+	}
+	return store, storeName, true
+}
+
+// onStartStateKeyRotation starts rewriting the records of an encrypted state
+// store with its current key.
+func (a *api) onStartStateKeyRotation(w nethttp.ResponseWriter, r *nethttp.Request) {
+	store, storeName, ok := a.getStateStore(w, r)
+	if !ok {
+		return
+	}
+
+	var opts encryption.RotationOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		msg := messages.ErrStateKeyRotation.WithFormat(storeName, err)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+
+	opts.OnRewritten = func(key string) {
+		a.universal.StateCache.Invalidate(storeName, []state.TransactionalStateOperation{state.DeleteRequest{Key: key}})
+	}
+
+	status, err := encryption.StartKeyRotation(storeName, a.universal.AppID, store, opts)
+	if err != nil {
+		var msg messages.APIError
+		if errors.Is(err, encryption.ErrRotationRunning) {
+			msg = messages.ErrStateKeyRotationRunning.WithFormat(storeName)
+		} else {
+			msg = messages.ErrStateKeyRotation.WithFormat(storeName, err)
+		}
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	respondWithJSON(w, nethttp.StatusAccepted, status)
+}
+
+// onGetStateKeyRotation returns the progress of the key rotation of a state
+// store.
+func (a *api) onGetStateKeyRotation(w nethttp.ResponseWriter, r *nethttp.Request) {
+	store, storeName, ok := a.getStateStore(w, r)
+	if !ok {
+		return
+	}
+
+	status, found, err := encryption.KeyRotationStatus(r.Context(), storeName, a.universal.AppID, store)
+	if err != nil {
+		msg := messages.ErrStateKeyRotationStatus.WithFormat(storeName, err)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	if !found {
+		msg := messages.ErrStateKeyRotationNotFound.WithFormat(storeName)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+	respondWithJSON(w, nethttp.StatusOK, status)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/dapr/pkg/grpc/universalapi"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+)
+
+func TestStateKeyRotationEndpoints(t *testing.T) {
+	fakeServer := newFakeHTTPServer()
+	compStore := compstore.New()
+	compStore.AddStateStore("store1", newFakeStateStore())
+	testAPI := &api{
+		universal: &universalapi.UniversalAPI{
+			Logger:     log,
+			CompStore:  compStore,
+			Resiliency: resiliency.New(nil),
+		},
+	}
+	fakeServer.StartServer(testAPI.constructStateEndpoints(), nil)
+	defer fakeServer.Shutdown()
+
+	t.Run("store not encrypted - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store1/encryption/rotation", []byte(`{"recordsPerSecond":10}`), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_KEY_ROTATION", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("invalid body - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/store1/encryption/rotation", []byte(`{`), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_KEY_ROTATION", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("store not found - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, "v1.0-alpha1/state/nope/encryption/rotation", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_STORE_NOT_FOUND", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("no rotation - 404", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodGet, "v1.0-alpha1/state/store1/encryption/rotation", nil, nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_KEY_ROTATION_NOT_FOUND", resp.ErrorBody["errorCode"])
+	})
+}
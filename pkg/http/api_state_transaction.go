@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	nethttp "net/http"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/dapr/components-contrib/state"
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+	"github.com/dapr/dapr/pkg/encryption"
+	"github.com/dapr/dapr/pkg/messages"
+	"github.com/dapr/dapr/pkg/statetx"
+)
+
+type multiStateTransactionRequestBody struct {
+	Transactions []multiStateTransactionRequestBodyStore `json:"transactions"`
+}
+
+type multiStateTransactionRequestBodyStore struct {
+	StoreName string `json:"storeName"`
+	stateTransactionRequestBody
+}
+
+// onPostMultiStateTransaction executes transactions on multiple state stores,
+// which are all committed or all rolled back.
+func (a *api) onPostMultiStateTransaction(w nethttp.ResponseWriter, r *nethttp.Request) {
+	var req multiStateTransactionRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg := messages.ErrMalformedRequest.WithFormat(err)
+		log.Debug(msg)
+		respondWithError(w, msg)
+		return
+	}
+
+	txs := make([]statetx.Transaction, len(req.Transactions))
+	for i, tx := range req.Transactions {
+		ops, err := a.multiStateTransactionOperations(r.Context(), tx)
+		if err != nil {
+			log.Debug(err)
+			respondWithError(w, err)
+			return
+		}
+		txs[i] = statetx.Transaction{
+			StoreName:  tx.StoreName,
+			Operations: ops,
+			Metadata:   tx.Metadata,
+		}
+	}
+
+	err := a.universal.ExecuteMultiStateTransaction(r.Context(), txs)
+	if err != nil {
+		// Error has already been logged
+		respondWithError(w, err)
+		return
+	}
+	respondWithEmpty(w)
+}
+
+// multiStateTransactionOperations returns the operations on a state store,
+// with the keys and values to save.
+func (a *api) multiStateTransactionOperations(ctx context.Context, tx multiStateTransactionRequestBodyStore) ([]state.TransactionalStateOperation, error) {
+	encrypted := encryption.EncryptedStateStore(tx.StoreName)
+	ops := make([]state.TransactionalStateOperation, len(tx.Operations))
+	for i, o := range tx.Operations {
+		switch o.Operation {
+		case string(state.OperationUpsert):
+			var upsertReq state.SetRequest
+			err := mapstructure.Decode(o.Request, &upsertReq)
+			if err != nil {
+				return nil, messages.ErrMalformedRequest.WithFormat(err)
+			}
+			upsertReq.Key, err = stateLoader.GetModifiedStateKey(upsertReq.Key, tx.StoreName, a.universal.AppID)
+			if err != nil {
+				return nil, messages.ErrMalformedRequest.WithFormat(err)
+			}
+			if encrypted {
+				upsertReq.Value, err = encryption.TryEncryptStateValue(ctx, tx.StoreName, upsertReq.Value)
+				if err != nil {
+					return nil, messages.ErrStateMultiTx.WithFormat(err)
+				}
+			}
+			ops[i] = upsertReq
+		case string(state.OperationDelete):
+			var delReq state.DeleteRequest
+			err := mapstructure.Decode(o.Request, &delReq)
+			if err != nil {
+				return nil, messages.ErrMalformedRequest.WithFormat(err)
+			}
+			delReq.Key, err = stateLoader.GetModifiedStateKey(delReq.Key, tx.StoreName, a.universal.AppID)
+			if err != nil {
+				return nil, messages.ErrMalformedRequest.WithFormat(err)
+			}
+			ops[i] = delReq
+		default:
+			return nil, messages.ErrStateMultiTxInvalid.WithFormat(fmt.Sprintf(messages.ErrNotSupportedStateOperation, o.Operation))
+		}
+	}
+	return ops, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dapr/dapr/pkg/grpc/universalapi"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	"github.com/dapr/dapr/pkg/statetx"
+)
+
+func TestMultiStateTransactionEndpoint(t *testing.T) {
+	fakeServer := newFakeHTTPServer()
+	compStore := compstore.New()
+	compStore.AddStateStore("store1", newFakeStateStore())
+	testAPI := &api{
+		universal: &universalapi.UniversalAPI{
+			AppID:             "fakeAPI",
+			Logger:            log,
+			CompStore:         compStore,
+			Resiliency:        resiliency.New(nil),
+			StateTransactions: statetx.NewCoordinator("fakeAPI", "", compStore, resiliency.New(nil), nil),
+		},
+	}
+	fakeServer.StartServer(testAPI.constructStateEndpoints(), nil)
+	defer fakeServer.Shutdown()
+
+	const apiPath = "v1.0-alpha1/state/transaction"
+
+	t.Run("invalid body - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(http.MethodPost, apiPath, []byte(`{`), nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_MALFORMED_REQUEST", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("unknown operation - 400", func(t *testing.T) {
+		body := []byte(`{"transactions":[{"storeName":"store1","operations":[{"operation":"merge","request":{"key":"a"}}]}]}`)
+		resp := fakeServer.DoRequest(http.MethodPost, apiPath, body, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_MULTI_TRANSACTION_INVALID", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("no transaction log - 400", func(t *testing.T) {
+		body := []byte(`{"transactions":[{"storeName":"store1","operations":[{"operation":"upsert","request":{"key":"a","value":1}}]}]}`)
+		resp := fakeServer.DoRequest(http.MethodPost, apiPath, body, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_STATE_MULTI_TRANSACTION_NOT_SUPPORTED", resp.ErrorBody["errorCode"])
+	})
+}
@@ -309,6 +309,72 @@ func TestPubSubEndpoints(t *testing.T) {
 	fakeServer.Shutdown()
 }
 
+// redriveAdapter is a pubsub adapter that supports redriving.
+type redriveAdapter struct {
+	daprt.MockPubSubAdapter
+	req *runtimePubsub.RedriveRequest
+}
+
+func (a *redriveAdapter) Redrive(_ context.Context, req *runtimePubsub.RedriveRequest) (*runtimePubsub.RedriveResponse, error) {
+	a.req = req
+	if req.Topic == "nodlq" {
+		return nil, runtimePubsub.ErrRedriveNoDeadLetterTopic
+	}
+	return &runtimePubsub.RedriveResponse{Matched: 2, Redriven: 1, Failed: 1}, nil
+}
+
+func TestRedriveEndpoint(t *testing.T) {
+	fakeServer := newFakeHTTPServer()
+	adapter := &redriveAdapter{}
+	testAPI := &api{
+		universal: &universalapi.UniversalAPI{
+			AppID:     "fakeAPI",
+			CompStore: compstore.New(),
+		},
+		pubsubAdapter: adapter,
+	}
+	mock := daprt.MockPubSub{}
+	mock.On("Features").Return([]pubsub.Feature{})
+	testAPI.universal.CompStore.AddPubSub("pubsubname", compstore.PubsubItem{Component: &mock})
+
+	fakeServer.StartServer(testAPI.constructPubSubEndpoints(), nil)
+	defer fakeServer.Shutdown()
+	apiPath := apiVersionV1alpha1 + "/publish/redrive/pubsubname/"
+
+	t.Run("Redrive successfully - 200 OK", func(t *testing.T) {
+		body := []byte(`{"target":"app","eventTypes":["order.created"],"startTime":"2023-01-01T00:00:00Z","maxCount":2,"waitDuration":"10s"}`)
+		resp := fakeServer.DoRequest(fasthttp.MethodPost, apiPath+"orders", body, nil)
+		require.Equal(t, gohttp.StatusOK, resp.StatusCode, string(resp.RawBody))
+		assert.JSONEq(t, `{"matched":2,"redriven":1,"skipped":0,"failed":1,"dryRun":false}`, string(resp.RawBody))
+		assert.Equal(t, "orders", adapter.req.Topic)
+		assert.Equal(t, runtimePubsub.RedriveTargetApp, adapter.req.Target)
+		assert.Equal(t, []string{"order.created"}, adapter.req.EventTypes)
+		assert.Equal(t, 10*time.Second, adapter.req.WaitDuration)
+	})
+
+	t.Run("Redrive with an invalid body - 400", func(t *testing.T) {
+		for _, body := range []string{`{`, `{"startTime":"yesterday"}`, `{"waitDuration":"1h"}`, `{"target":"queue"}`} {
+			resp := fakeServer.DoRequest(fasthttp.MethodPost, apiPath+"orders", []byte(body), nil)
+			assert.Equal(t, gohttp.StatusBadRequest, resp.StatusCode, body)
+			assert.Equal(t, "ERR_PUBSUB_REDRIVE_REQUEST", resp.ErrorBody["errorCode"], body)
+		}
+	})
+
+	t.Run("Redrive a subscription without dead letter topic - 400", func(t *testing.T) {
+		resp := fakeServer.DoRequest(fasthttp.MethodPost, apiPath+"nodlq", nil, nil)
+		assert.Equal(t, gohttp.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "ERR_PUBSUB_REDRIVE_REQUEST", resp.ErrorBody["errorCode"])
+	})
+
+	t.Run("Redrive not supported - 500", func(t *testing.T) {
+		testAPI.pubsubAdapter = &daprt.MockPubSubAdapter{}
+		defer func() { testAPI.pubsubAdapter = adapter }()
+		resp := fakeServer.DoRequest(fasthttp.MethodPost, apiPath+"orders", nil, nil)
+		assert.Equal(t, gohttp.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, "ERR_PUBSUB_REDRIVE", resp.ErrorBody["errorCode"])
+	})
+}
+
 func TestBulkPubSubEndpoints(t *testing.T) {
 	fakeServer := newFakeHTTPServer()
 	testAPI := &api{
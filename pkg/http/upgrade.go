@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	diag "github.com/dapr/dapr/pkg/diagnostics"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+)
+
+// tunnelUpgradedConn completes the handshake on the caller's connection after
+// the target app accepted to upgrade it, then copies the data in both
+// directions until either side is done. The caller's data is written to
+// reqData, which is the body of the invocation request.
+func tunnelUpgradedConn(conn net.Conn, brw *bufio.ReadWriter, header http.Header, r *http.Request, resp *invokev1.InvokeMethodResponse, reqData *io.PipeWriter, targetID string, cancel context.CancelFunc) error {
+	defer conn.Close()
+
+	// The server doesn't send responses with status code 101, so the
+	// response is written to the connection directly
+	res := &http.Response{
+		StatusCode: http.StatusSwitchingProtocols,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+	}
+	err := res.Write(brw)
+	if err == nil {
+		err = brw.Flush()
+	}
+	if err != nil {
+		reqData.CloseWithError(err)
+		return fmt.Errorf("failed to complete the handshake: %w", err)
+	}
+
+	var (
+		toApp    io.Writer = reqData
+		toCaller io.Writer = conn
+	)
+	if strings.EqualFold(r.Header.Get("upgrade"), "websocket") {
+		toApp = &webSocketMeter{w: toApp, appID: targetID, direction: diag.OutboundPolicyFlowDirection}
+		toCaller = &webSocketMeter{w: toCaller, appID: targetID, direction: diag.InboundPolicyFlowDirection}
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, cErr := io.Copy(toApp, brw.Reader)
+		reqData.CloseWithError(cErr)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(toCaller, resp.RawData())
+		done <- struct{}{}
+	}()
+
+	// Once either side is done, the whole tunnel is torn down
+	<-done
+	cancel()
+	conn.Close()
+	reqData.Close()
+	<-done
+	return nil
+}
+
+// webSocketMeter records the bytes and messages written in one direction of
+// a WebSocket connection.
+type webSocketMeter struct {
+	w         io.Writer
+	appID     string
+	direction diag.PolicyFlowDirection
+	frames    webSocketFrameCounter
+}
+
+func (m *webSocketMeter) Write(p []byte) (int, error) {
+	n, err := m.w.Write(p)
+	if n > 0 {
+		diag.DefaultMonitoring.ServiceInvocationWebSocketTraffic(m.appID, m.direction, int64(n), m.frames.count(p[:n]))
+	}
+	return n, err
+}
+
+// webSocketFrameCounter parses the headers of the WebSocket frames in a
+// stream of data, to count the messages in it.
+// See https://www.rfc-editor.org/rfc/rfc6455#section-5.2
+type webSocketFrameCounter struct {
+	header    []byte
+	remaining uint64
+}
+
+// count returns the number of messages that end in p, which continues the
+// data passed to the previous calls. Control frames are not counted.
+func (c *webSocketFrameCounter) count(p []byte) (messages int64) {
+	for len(p) > 0 {
+		// Skip the payload of the current frame
+		if c.remaining > 0 {
+			if uint64(len(p)) <= c.remaining {
+				c.remaining -= uint64(len(p))
+				return messages
+			}
+			p = p[c.remaining:]
+			c.remaining = 0
+		}
+
+		c.header = append(c.header, p[0])
+		p = p[1:]
+		size := webSocketHeaderSize(c.header)
+		if len(c.header) < size {
+			continue
+		}
+
+		// Messages end with a text, binary or continuation frame with FIN set
+		fin := c.header[0]&0x80 != 0
+		opcode := c.header[0] & 0x0F
+		if fin && opcode <= 0x2 {
+			messages++
+		}
+
+		switch length := c.header[1] & 0x7F; length {
+		case 126:
+			c.remaining = uint64(binary.BigEndian.Uint16(c.header[2:4]))
+		case 127:
+			c.remaining = binary.BigEndian.Uint64(c.header[2:10])
+		default:
+			c.remaining = uint64(length)
+		}
+		c.header = c.header[:0]
+	}
+	return messages
+}
+
+// webSocketHeaderSize returns the size of a frame header given its first
+// bytes, or 2 if not enough bytes are known yet.
+func webSocketHeaderSize(header []byte) int {
+	if len(header) < 2 {
+		return 2
+	}
+	size := 2
+	switch header[1] & 0x7F {
+	case 126:
+		size += 2
+	case 127:
+		size += 8
+	}
+	if header[1]&0x80 != 0 {
+		// Masking key
+		size += 4
+	}
+	return size
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/dapr/pkg/grpc/universalapi"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	daprt "github.com/dapr/dapr/pkg/testing"
+)
+
+func TestV1DirectMessagingEndpointsUpgrade(t *testing.T) {
+	mockDirectMessaging := new(daprt.MockDirectMessaging)
+
+	fakeServer := newFakeHTTPServer()
+	testAPI := &api{
+		directMessaging: mockDirectMessaging,
+		universal: &universalapi.UniversalAPI{
+			CompStore:  compstore.New(),
+			Resiliency: resiliency.New(nil),
+		},
+	}
+	fakeServer.StartServer(testAPI.constructDirectMessagingEndpoints(), nil)
+	defer fakeServer.Shutdown()
+
+	appData, appConn := io.Pipe()
+	fakeResponse := invokev1.NewInvokeMethodResponse(http.StatusSwitchingProtocols, "", nil).
+		WithHTTPHeaders(http.Header{
+			"Connection":           {"Upgrade"},
+			"Upgrade":              {"websocket"},
+			"Sec-Websocket-Accept": {"s3pPLMBiTxaQ9kYGzzhZRbK+xOo="},
+		}).
+		WithRawData(appData)
+	defer fakeResponse.Close()
+
+	invoked := make(chan *invokev1.InvokeMethodRequest, 1)
+	mockDirectMessaging.
+		On(
+			"Invoke",
+			mock.MatchedBy(matchContextInterface),
+			mock.MatchedBy(func(b string) bool {
+				return b == "fakeAppID"
+			}),
+			mock.AnythingOfType("*v1.InvokeMethodRequest"),
+		).
+		Run(func(args mock.Arguments) {
+			invoked <- args.Get(2).(*invokev1.InvokeMethodRequest)
+		}).
+		Return(fakeResponse, nil).
+		Once()
+
+	conn, err := fakeServer.ln.DialContext(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	_, err = conn.Write([]byte("GET /v1.0/invoke/fakeAppID/method/ws HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"))
+	require.NoError(t, err)
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal(t, "websocket", res.Header.Get("Upgrade"))
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get("Sec-Websocket-Accept"))
+
+	req := <-invoked
+	assert.True(t, req.IsUpgrade())
+
+	// Masked text frame from the caller
+	callerFrame := []byte{0x81, 0x82, 0x01, 0x02, 0x03, 0x04, 'h' ^ 0x01, 'i' ^ 0x02}
+	_, err = conn.Write(callerFrame)
+	require.NoError(t, err)
+	received := make([]byte, len(callerFrame))
+	_, err = io.ReadFull(req.RawData(), received)
+	require.NoError(t, err)
+	assert.Equal(t, callerFrame, received)
+
+	// Text frame from the app
+	appFrame := []byte{0x81, 0x02, 'y', 'o'}
+	_, err = appConn.Write(appFrame)
+	require.NoError(t, err)
+	received = make([]byte, len(appFrame))
+	_, err = io.ReadFull(br, received)
+	require.NoError(t, err)
+	assert.Equal(t, appFrame, received)
+
+	// The caller's connection is closed when the app closes its own
+	appConn.Close()
+	_, err = br.ReadByte()
+	assert.ErrorIs(t, err, io.EOF)
+	mockDirectMessaging.AssertNumberOfCalls(t, "Invoke", 1)
+}
+
+func TestWebSocketFrameCounter(t *testing.T) {
+	var stream bytes.Buffer
+	// Unfragmented text message
+	stream.Write([]byte{0x81, 0x03, 'f', 'o', 'o'})
+	// Ping, which is not counted
+	stream.Write([]byte{0x89, 0x00})
+	// Message fragmented in a text frame and a continuation frame
+	stream.Write([]byte{0x01, 0x01, 'a', 0x80, 0x01, 'b'})
+	// Masked binary message with a 16-bit length
+	stream.Write([]byte{0x82, 0xFE, 0x01, 0x00, 0x0A, 0x0B, 0x0C, 0x0D})
+	stream.Write(make([]byte, 256))
+	// Masked binary message with a 64-bit length
+	stream.Write([]byte{0x82, 0xFF, 0, 0, 0, 0, 0, 0, 0x01, 0x00, 0x0A, 0x0B, 0x0C, 0x0D})
+	stream.Write(make([]byte, 256))
+
+	t.Run("all at once", func(t *testing.T) {
+		var c webSocketFrameCounter
+		assert.Equal(t, int64(4), c.count(stream.Bytes()))
+	})
+
+	t.Run("byte by byte", func(t *testing.T) {
+		var (
+			c        webSocketFrameCounter
+			messages int64
+		)
+		for _, b := range stream.Bytes() {
+			messages += c.count([]byte{b})
+		}
+		assert.Equal(t, int64(4), messages)
+	})
+}
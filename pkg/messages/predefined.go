@@ -47,6 +47,7 @@ const (
 	ErrPubsubPublishMessage     = "error when publish to topic %s in pubsub %s: %s"
 	ErrPubsubForbidden          = "topic %s is not allowed for app id %s"
 	ErrPubsubCloudEventCreation = "cannot create cloudevent: %s"
+	ErrPubsubCloudEventSchema   = "cloud event data for topic %s in pubsub %s does not conform to the topic schema: %s"
 	ErrPubsubUnmarshal          = "error when unmarshaling the request for topic %s pubsub %s: %s"
 	ErrPubsubMarshal            = "error marshaling events to bytes for topic %s pubsub %s: %s"
 	ErrPubsubGetSubscriptions   = "unable to get app subscriptions %s"
@@ -104,9 +105,29 @@ var (
 	ErrStateQueryFailed            = APIError{"failed query in state store %s: %s", "ERR_STATE_QUERY", http.StatusInternalServerError, grpcCodes.Internal}
 	ErrStateQueryUnsupported       = APIError{"state store does not support querying", "ERR_STATE_STORE_NOT_SUPPORTED", http.StatusInternalServerError, grpcCodes.Internal}
 	ErrStateTooManyTransactionalOp = APIError{"the transaction contains %d operations, which is more than what the state store supports: %d", "ERR_STATE_STORE_TOO_MANY_TRANSACTIONS", http.StatusBadRequest, grpcCodes.InvalidArgument}
+	ErrStateKeyRotation            = APIError{"cannot rotate the encryption keys of state store %s: %v", "ERR_STATE_KEY_ROTATION", http.StatusBadRequest, grpcCodes.FailedPrecondition}
+	ErrStateKeyRotationRunning     = APIError{"the encryption keys of state store %s are already being rotated", "ERR_STATE_KEY_ROTATION_RUNNING", http.StatusConflict, grpcCodes.AlreadyExists}
+	ErrStateKeyRotationNotFound    = APIError{"no key rotation found for state store %s", "ERR_STATE_KEY_ROTATION_NOT_FOUND", http.StatusNotFound, grpcCodes.NotFound}
+	ErrStateKeyRotationStatus      = APIError{"failed to get the key rotation status of state store %s: %v", "ERR_STATE_KEY_ROTATION_STATUS", http.StatusInternalServerError, grpcCodes.Internal}
+	ErrStateMultiTxInvalid         = APIError{"invalid transaction on multiple state stores: %v", "ERR_STATE_MULTI_TRANSACTION_INVALID", http.StatusBadRequest, grpcCodes.InvalidArgument}
+	ErrStateMultiTxNotSupported    = APIError{"cannot execute the transaction on multiple state stores: %v", "ERR_STATE_MULTI_TRANSACTION_NOT_SUPPORTED", http.StatusBadRequest, grpcCodes.FailedPrecondition}
+	ErrStateMultiTxConflict        = APIError{"the transaction on multiple state stores was rolled back: %v", "ERR_STATE_MULTI_TRANSACTION_CONFLICT", http.StatusConflict, grpcCodes.Aborted}
+	ErrStateMultiTx                = APIError{"failed to execute the transaction on multiple state stores: %v", "ERR_STATE_MULTI_TRANSACTION", http.StatusInternalServerError, grpcCodes.Internal}
+	ErrStateSubscribeUnavailable   = APIError{"state change notifications are not available", "ERR_STATE_SUBSCRIBE", http.StatusInternalServerError, grpcCodes.Unavailable}
+	ErrStateSubscriptionOverflow   = APIError{"the subscription to the changes of state store %s has ended: %v", "ERR_STATE_SUBSCRIPTION_OVERFLOW", http.StatusInternalServerError, grpcCodes.ResourceExhausted}
+	ErrStateKeyMigration           = APIError{"cannot migrate the keys of state store %s: %v", "ERR_STATE_KEY_MIGRATION", http.StatusBadRequest, grpcCodes.InvalidArgument}
+	ErrStateKeyMigrationRunning    = APIError{"the keys of state store %s are already being migrated", "ERR_STATE_KEY_MIGRATION_RUNNING", http.StatusConflict, grpcCodes.AlreadyExists}
+	ErrStateKeyMigrationNotFound   = APIError{"no key migration found for state store %s", "ERR_STATE_KEY_MIGRATION_NOT_FOUND", http.StatusNotFound, grpcCodes.NotFound}
+	ErrStateBulkWrite              = APIError{"failed to prepare the bulk write on state store %s: %v", "ERR_STATE_BULK_WRITE", http.StatusInternalServerError, grpcCodes.Internal}
+	ErrStateExport                 = APIError{"cannot export state store %s: %v", "ERR_STATE_EXPORT", http.StatusBadRequest, grpcCodes.InvalidArgument}
+	ErrStateExportFailed           = APIError{"failed to export state store %s: %v", "ERR_STATE_EXPORT_FAILED", http.StatusInternalServerError, grpcCodes.Internal}
+	ErrStateImport                 = APIError{"cannot import into state store %s: %v", "ERR_STATE_IMPORT", http.StatusBadRequest, grpcCodes.InvalidArgument}
+	ErrStateImportFailed           = APIError{"failed to import into state store %s after saving %d records: %v", "ERR_STATE_IMPORT_FAILED", http.StatusInternalServerError, grpcCodes.Internal}
 
 	// PubSub.
 	ErrPubSubMetadataDeserialize = APIError{"failed deserializing metadata: %v", "ERR_PUBSUB_REQUEST_METADATA", http.StatusBadRequest, grpcCodes.InvalidArgument}
+	ErrPubSubRedriveBadRequest   = APIError{"invalid redrive request: %v", "ERR_PUBSUB_REDRIVE_REQUEST", http.StatusBadRequest, grpcCodes.InvalidArgument}
+	ErrPubSubRedrive             = APIError{"failed to redrive dead letter messages of topic %s in pubsub %s: %v", "ERR_PUBSUB_REDRIVE", http.StatusInternalServerError, grpcCodes.Internal}
 
 	// Secrets.
 	ErrSecretStoreNotConfigured = APIError{"secret store is not configured", "ERR_SECRET_STORES_NOT_CONFIGURED", http.StatusInternalServerError, grpcCodes.FailedPrecondition}
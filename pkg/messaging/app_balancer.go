@@ -0,0 +1,395 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	nr "github.com/dapr/components-contrib/nameresolution"
+	nrLoader "github.com/dapr/dapr/pkg/components/nameresolution"
+	diag "github.com/dapr/dapr/pkg/diagnostics"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+)
+
+const (
+	// appInstanceTTL is how long an instance returned by a name resolver that
+	// resolves a single address at a time is kept without being seen again.
+	appInstanceTTL = time.Minute
+
+	// appBalancerIdleTTL is how long the balancer of an app is kept without
+	// any request to the app.
+	appBalancerIdleTTL = 10 * time.Minute
+
+	// maxEjectionTimeMultiplier caps the ejection time of instances that keep
+	// failing after being restored.
+	maxEjectionTimeMultiplier = 10
+)
+
+// appInstance is an instance of an app known to a balancer.
+type appInstance struct {
+	address     string
+	outstanding atomic.Int64
+
+	// Protected by the balancer's lock.
+	lastSeen          time.Time
+	consecutiveErrors int
+	ejections         int
+	ejectedUntil      time.Time
+}
+
+// instancePicker is a load balancing strategy.
+type instancePicker interface {
+	// pick returns the instance to send the request to; instances is never empty.
+	pick(instances []*appInstance, req *invokev1.InvokeMethodRequest) *appInstance
+}
+
+// instancePickers contains the supported load balancing strategies.
+var instancePickers = map[string]func(policy *resiliency.LoadBalancingPolicy) instancePicker{
+	resiliency.LoadBalancingRoundRobin: func(*resiliency.LoadBalancingPolicy) instancePicker {
+		return &roundRobinPicker{}
+	},
+	resiliency.LoadBalancingLeastRequests: func(*resiliency.LoadBalancingPolicy) instancePicker {
+		return &leastRequestsPicker{}
+	},
+	resiliency.LoadBalancingConsistentHash: func(policy *resiliency.LoadBalancingPolicy) instancePicker {
+		return &consistentHashPicker{header: policy.HashHeader}
+	},
+}
+
+type roundRobinPicker struct {
+	next atomic.Uint64
+}
+
+func (p *roundRobinPicker) pick(instances []*appInstance, _ *invokev1.InvokeMethodRequest) *appInstance {
+	return instances[(p.next.Add(1)-1)%uint64(len(instances))]
+}
+
+type leastRequestsPicker struct {
+	next atomic.Uint64
+}
+
+func (p *leastRequestsPicker) pick(instances []*appInstance, _ *invokev1.InvokeMethodRequest) *appInstance {
+	// Start from a rotating offset so that ties are spread evenly.
+	offset := int(p.next.Add(1) % uint64(len(instances)))
+	var picked *appInstance
+	for i := range instances {
+		inst := instances[(offset+i)%len(instances)]
+		if picked == nil || inst.outstanding.Load() < picked.outstanding.Load() {
+			picked = inst
+		}
+	}
+	return picked
+}
+
+// consistentHashPicker uses rendezvous hashing on the value of a header, so
+// that only the requests sent to an instance that goes away are moved.
+// Requests without the header are balanced round robin.
+type consistentHashPicker struct {
+	header   string
+	fallback roundRobinPicker
+}
+
+func (p *consistentHashPicker) pick(instances []*appInstance, req *invokev1.InvokeMethodRequest) *appInstance {
+	var key string
+	for k, v := range req.Metadata() {
+		if strings.EqualFold(k, p.header) && len(v.GetValues()) > 0 {
+			key = v.GetValues()[0]
+			break
+		}
+	}
+	if key == "" {
+		return p.fallback.pick(instances, req)
+	}
+
+	var (
+		picked *appInstance
+		best   uint64
+	)
+	for _, inst := range instances {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(inst.address))
+		if sum := h.Sum64(); picked == nil || sum > best {
+			picked, best = inst, sum
+		}
+	}
+	return picked
+}
+
+// appBalancer balances the requests to an app across its instances, ejecting
+// the ones that fail too many requests in a row.
+type appBalancer struct {
+	appID     string
+	namespace string
+	policy    *resiliency.LoadBalancingPolicy
+	picker    instancePicker
+
+	lock      sync.Mutex
+	instances map[string]*appInstance
+
+	// Protected by the lock of appBalancers.
+	lastUsed time.Time
+}
+
+func newAppBalancer(appID, namespace string, policy *resiliency.LoadBalancingPolicy) *appBalancer {
+	newPicker, ok := instancePickers[policy.Strategy]
+	if !ok {
+		newPicker = instancePickers[resiliency.LoadBalancingRoundRobin]
+	}
+	return &appBalancer{
+		appID:     appID,
+		namespace: namespace,
+		policy:    policy,
+		picker:    newPicker(policy),
+		instances: make(map[string]*appInstance),
+	}
+}
+
+// appBalancers caches the balancers of the apps with a load balancing policy.
+// Balancers are evicted when their app can't be resolved, no longer has a
+// policy, or isn't invoked for appBalancerIdleTTL.
+type appBalancers struct {
+	lock      sync.Mutex
+	balancers map[string]*appBalancer
+	lastPrune time.Time
+}
+
+// get returns the balancer of an app, or nil if the app has no load balancing
+// policy.
+func (a *appBalancers) get(provider resiliency.Provider, appID, namespace string) *appBalancer {
+	if provider == nil {
+		return nil
+	}
+	policy := provider.EndpointLoadBalancingPolicy(appID)
+
+	key := appID + "." + namespace
+	now := time.Now()
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.prune(now)
+	if policy == nil {
+		delete(a.balancers, key)
+		return nil
+	}
+	b, ok := a.balancers[key]
+	if !ok || b.policy != policy {
+		b = newAppBalancer(appID, namespace, policy)
+		if a.balancers == nil {
+			a.balancers = make(map[string]*appBalancer)
+		}
+		a.balancers[key] = b
+	}
+	b.lastUsed = now
+	return b
+}
+
+// evict removes the balancer of an app that could not be resolved.
+func (a *appBalancers) evict(appID, namespace string) {
+	a.lock.Lock()
+	delete(a.balancers, appID+"."+namespace)
+	a.lock.Unlock()
+}
+
+// prune removes the balancers of the apps that were not invoked recently.
+// The caller must hold the lock.
+func (a *appBalancers) prune(now time.Time) {
+	if now.Sub(a.lastPrune) < appInstanceTTL {
+		return
+	}
+	a.lastPrune = now
+	for key, b := range a.balancers {
+		if now.Sub(b.lastUsed) > appBalancerIdleTTL {
+			delete(a.balancers, key)
+		}
+	}
+}
+
+// update refreshes the known instances. If all is true, addresses contains
+// all the instances of the app; otherwise, it contains one more instance.
+func (b *appBalancer) update(addresses []string, all bool, now time.Time) {
+	for _, addr := range addresses {
+		inst, ok := b.instances[addr]
+		if !ok {
+			inst = &appInstance{address: addr}
+			b.instances[addr] = inst
+		}
+		inst.lastSeen = now
+	}
+
+	for addr, inst := range b.instances {
+		if (all && !inst.lastSeen.Equal(now)) || (!all && now.Sub(inst.lastSeen) > appInstanceTTL) {
+			if !inst.ejectedUntil.IsZero() {
+				diag.DefaultMonitoring.ServiceInvocationInstanceRestored(b.appID, addr)
+			}
+			delete(b.instances, addr)
+		}
+	}
+}
+
+// available returns the instances that are not ejected, sorted by address.
+// If all instances are ejected, all of them are returned.
+func (b *appBalancer) available(now time.Time) []*appInstance {
+	all := make([]*appInstance, 0, len(b.instances))
+	available := make([]*appInstance, 0, len(b.instances))
+	for _, inst := range b.instances {
+		if !inst.ejectedUntil.IsZero() && !now.Before(inst.ejectedUntil) {
+			log.Infof("Instance %s of app %s is no longer ejected", inst.address, b.appID)
+			inst.ejectedUntil = time.Time{}
+			diag.DefaultMonitoring.ServiceInvocationInstanceRestored(b.appID, inst.address)
+		}
+		all = append(all, inst)
+		if inst.ejectedUntil.IsZero() {
+			available = append(available, inst)
+		}
+	}
+	if len(available) == 0 {
+		available = all
+	}
+	sort.Slice(available, func(i, j int) bool {
+		return available[i].address < available[j].address
+	})
+	return available
+}
+
+// pick returns the instance to send the request to. resolved is the address
+// returned by the name resolver for the request. The instances in exclude are
+// only picked if there is no other one available.
+func (b *appBalancer) pick(ctx context.Context, resolver nr.Resolver, grpcPort int, resolved string, req *invokev1.InvokeMethodRequest, exclude map[string]struct{}) *appInstance {
+	addresses, all := []string{resolved}, false
+	if multi, ok := resolver.(nrLoader.ResolverMulti); ok {
+		res, err := multi.ResolveIDMulti(ctx, nr.ResolveRequest{ID: b.appID, Namespace: b.namespace, Port: grpcPort})
+		if err != nil {
+			log.Warnf("Failed to resolve the instances of app %s: %v", b.appID, err)
+		} else if len(res) > 0 {
+			addresses, all = res, true
+		}
+	}
+
+	now := time.Now()
+	b.lock.Lock()
+	b.update(addresses, all, now)
+	instances := b.available(now)
+	if len(exclude) > 0 {
+		others := make([]*appInstance, 0, len(instances))
+		for _, inst := range instances {
+			if _, ok := exclude[inst.address]; !ok {
+				others = append(others, inst)
+			}
+		}
+		if len(others) > 0 {
+			instances = others
+		}
+	}
+	inst := b.picker.pick(instances, req)
+	b.lock.Unlock()
+
+	inst.outstanding.Add(1)
+	return inst
+}
+
+// done records the outcome of a request sent to an instance.
+func (b *appBalancer) done(inst *appInstance, failed bool) {
+	inst.outstanding.Add(-1)
+
+	od := b.policy.OutlierDetection
+	if od == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !failed {
+		inst.consecutiveErrors = 0
+		if inst.ejectedUntil.IsZero() {
+			inst.ejections = 0
+		}
+		return
+	}
+
+	inst.consecutiveErrors++
+	if inst.consecutiveErrors < od.ConsecutiveErrors || !inst.ejectedUntil.IsZero() {
+		return
+	}
+
+	ejected := 0
+	for _, i := range b.instances {
+		if !i.ejectedUntil.IsZero() {
+			ejected++
+		}
+	}
+	if (ejected+1)*100 > len(b.instances)*od.MaxEjectionPercent {
+		return
+	}
+
+	if inst.ejections < maxEjectionTimeMultiplier {
+		inst.ejections++
+	}
+	ejectionTime := od.BaseEjectionTime * time.Duration(inst.ejections)
+	inst.ejectedUntil = time.Now().Add(ejectionTime)
+	inst.consecutiveErrors = 0
+	log.Warnf("Ejecting instance %s of app %s for %v after %d consecutive errors", inst.address, b.appID, ejectionTime, od.ConsecutiveErrors)
+	diag.DefaultMonitoring.ServiceInvocationInstanceEjected(b.appID, inst.address)
+}
+
+// balance wraps fn so that every call is sent to the instance picked by the
+// balancer, and its outcome feeds outlier detection.
+func (d *directMessaging) balance(
+	b *appBalancer,
+	fn func(ctx context.Context, appID, namespace, appAddress string, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, func(destroy bool), error),
+) func(ctx context.Context, appID, namespace, appAddress string, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, func(destroy bool), error) {
+	return func(ctx context.Context, appID, namespace, appAddress string, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, func(destroy bool), error) {
+		inst := b.pick(ctx, d.resolver, d.grpcPort, appAddress, req, nil)
+		resp, teardown, err := fn(ctx, appID, namespace, inst.address, req)
+		b.done(inst, isInstanceFailure(resp, err))
+		return resp, teardown, err
+	}
+}
+
+// isInstanceFailure returns true if the outcome of a request counts as a
+// failure of the instance for outlier detection: an error reaching it, a 5xx
+// response from an HTTP app, or a gRPC status signaling an unhealthy app.
+func isInstanceFailure(resp *invokev1.InvokeMethodResponse, err error) bool {
+	if err != nil {
+		return isFailureCode(status.Code(err))
+	}
+	if resp == nil {
+		return false
+	}
+	if resp.IsHTTPResponse() {
+		return resp.Status().GetCode() >= 500
+	}
+	return isFailureCode(codes.Code(resp.Status().GetCode()))
+}
+
+func isFailureCode(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	nr "github.com/dapr/components-contrib/nameresolution"
+	resiliencyV1alpha "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/ptr"
+)
+
+type mockResolverMulti struct {
+	addresses []string
+}
+
+func (m *mockResolverMulti) Init(context.Context, nr.Metadata) error {
+	return nil
+}
+
+func (m *mockResolverMulti) ResolveID(context.Context, nr.ResolveRequest) (string, error) {
+	return m.addresses[0], nil
+}
+
+func (m *mockResolverMulti) ResolveIDMulti(context.Context, nr.ResolveRequest) ([]string, error) {
+	return m.addresses, nil
+}
+
+func newBalancerTestResiliency(lb resiliencyV1alpha.LoadBalancer) *resiliency.Resiliency {
+	return resiliency.FromConfigurations(logger.NewLogger("test"), &resiliencyV1alpha.Resiliency{
+		ObjectMeta: metav1.ObjectMeta{Name: "resiliency"},
+		Spec: resiliencyV1alpha.ResiliencySpec{
+			Policies: resiliencyV1alpha.Policies{
+				LoadBalancers: map[string]resiliencyV1alpha.LoadBalancer{"lb": lb},
+			},
+			Targets: resiliencyV1alpha.Targets{
+				Apps: map[string]resiliencyV1alpha.EndpointPolicyNames{
+					"app1": {LoadBalancer: "lb"},
+				},
+			},
+		},
+	})
+}
+
+func TestAppBalancerStrategies(t *testing.T) {
+	resolver := &mockResolverMulti{addresses: []string{"addr1", "addr2", "addr3"}}
+	pick := func(b *appBalancer, req *invokev1.InvokeMethodRequest) string {
+		inst := b.pick(context.Background(), resolver, 50002, "addr1", req, nil)
+		b.done(inst, false)
+		return inst.address
+	}
+
+	t.Run("round robin", func(t *testing.T) {
+		var balancers appBalancers
+		b := balancers.get(newBalancerTestResiliency(resiliencyV1alpha.LoadBalancer{}), "app1", "default")
+		require.NotNil(t, b)
+
+		req := invokev1.NewInvokeMethodRequest("method")
+		defer req.Close()
+		var picked []string
+		for i := 0; i < 4; i++ {
+			picked = append(picked, pick(b, req))
+		}
+		assert.Equal(t, []string{"addr1", "addr2", "addr3", "addr1"}, picked)
+	})
+
+	t.Run("least requests", func(t *testing.T) {
+		var balancers appBalancers
+		b := balancers.get(newBalancerTestResiliency(resiliencyV1alpha.LoadBalancer{
+			Strategy: resiliency.LoadBalancingLeastRequests,
+		}), "app1", "default")
+
+		req := invokev1.NewInvokeMethodRequest("method")
+		defer req.Close()
+		first := b.pick(context.Background(), resolver, 50002, "addr1", req, nil)
+		second := b.pick(context.Background(), resolver, 50002, "addr1", req, nil)
+		third := b.pick(context.Background(), resolver, 50002, "addr1", req, nil)
+		assert.ElementsMatch(t, []string{"addr1", "addr2", "addr3"}, []string{first.address, second.address, third.address})
+
+		b.done(second, false)
+		assert.Equal(t, second.address, pick(b, req))
+	})
+
+	t.Run("consistent hash", func(t *testing.T) {
+		var balancers appBalancers
+		b := balancers.get(newBalancerTestResiliency(resiliencyV1alpha.LoadBalancer{
+			Strategy:   resiliency.LoadBalancingConsistentHash,
+			HashHeader: "x-user",
+		}), "app1", "default")
+
+		req := invokev1.NewInvokeMethodRequest("method").
+			WithMetadata(map[string][]string{"X-User": {"alice"}})
+		defer req.Close()
+		sticky := pick(b, req)
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, sticky, pick(b, req))
+		}
+
+		// Removing another instance doesn't move the requests.
+		for _, addr := range []string{"addr1", "addr2", "addr3"} {
+			if addr != sticky {
+				resolver.addresses = []string{sticky, addr}
+				break
+			}
+		}
+		assert.Equal(t, sticky, pick(b, req))
+		resolver.addresses = []string{"addr1", "addr2", "addr3"}
+	})
+
+	t.Run("no policy", func(t *testing.T) {
+		var balancers appBalancers
+		assert.Nil(t, balancers.get(newBalancerTestResiliency(resiliencyV1alpha.LoadBalancer{}), "app2", "default"))
+		assert.Nil(t, balancers.get(resiliency.NoOp{}, "app1", "default"))
+	})
+}
+
+func TestAppBalancerInstancesFromSingleResolver(t *testing.T) {
+	var balancers appBalancers
+	b := balancers.get(newBalancerTestResiliency(resiliencyV1alpha.LoadBalancer{}), "app1", "default")
+
+	req := invokev1.NewInvokeMethodRequest("method")
+	defer req.Close()
+
+	now := time.Now()
+	b.update([]string{"addr1"}, false, now.Add(-2*appInstanceTTL))
+	b.update([]string{"addr2"}, false, now)
+	inst := b.pick(context.Background(), nil, 50002, "addr3", req, nil)
+	b.done(inst, false)
+
+	assert.Len(t, b.instances, 2)
+	assert.Contains(t, b.instances, "addr2")
+	assert.Contains(t, b.instances, "addr3")
+}
+
+func TestAppBalancerOutlierDetection(t *testing.T) {
+	resolver := &mockResolverMulti{addresses: []string{"addr1", "addr2", "addr3", "addr4"}}
+	var balancers appBalancers
+	b := balancers.get(newBalancerTestResiliency(resiliencyV1alpha.LoadBalancer{
+		OutlierDetection: &resiliencyV1alpha.OutlierDetection{
+			ConsecutiveErrors:  2,
+			BaseEjectionTime:   "1h",
+			MaxEjectionPercent: ptr.Of(50),
+		},
+	}), "app1", "default")
+
+	req := invokev1.NewInvokeMethodRequest("method")
+	defer req.Close()
+	b.pick(context.Background(), resolver, 50002, "addr1", req, nil).outstanding.Add(-1)
+
+	fail := func(addr string, n int) {
+		for i := 0; i < n; i++ {
+			b.instances[addr].outstanding.Add(1)
+			b.done(b.instances[addr], true)
+		}
+	}
+
+	// A success resets the consecutive errors.
+	fail("addr1", 1)
+	b.instances["addr1"].outstanding.Add(1)
+	b.done(b.instances["addr1"], false)
+	fail("addr1", 1)
+	assert.True(t, b.instances["addr1"].ejectedUntil.IsZero())
+
+	fail("addr1", 1)
+	assert.False(t, b.instances["addr1"].ejectedUntil.IsZero())
+	fail("addr2", 2)
+	assert.False(t, b.instances["addr2"].ejectedUntil.IsZero())
+
+	// No more than 50% of the instances are ejected.
+	fail("addr3", 2)
+	assert.True(t, b.instances["addr3"].ejectedUntil.IsZero())
+
+	for i := 0; i < 4; i++ {
+		inst := b.pick(context.Background(), resolver, 50002, "addr1", req, nil)
+		assert.Contains(t, []string{"addr3", "addr4"}, inst.address)
+		b.done(inst, false)
+	}
+
+	// Ejected instances are restored after the ejection time.
+	b.instances["addr1"].ejectedUntil = time.Now().Add(-time.Second)
+	b.lock.Lock()
+	available := b.available(time.Now())
+	b.lock.Unlock()
+	assert.Len(t, available, 3)
+}
+
+func TestBalanceInvoke(t *testing.T) {
+	resolver := &mockResolverMulti{addresses: []string{"addr1", "addr2"}}
+	d := &directMessaging{
+		resiliency: newBalancerTestResiliency(resiliencyV1alpha.LoadBalancer{
+			OutlierDetection: &resiliencyV1alpha.OutlierDetection{ConsecutiveErrors: 1},
+		}),
+		resolver: resolver,
+	}
+	b := d.appBalancers.get(d.resiliency, "app1", "default")
+
+	var called []string
+	fn := d.balance(b, func(ctx context.Context, appID, namespace, appAddress string, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, func(destroy bool), error) {
+		called = append(called, appAddress)
+		if appAddress == "addr1" {
+			return nil, nopTeardown, errors.New("unavailable")
+		}
+		return invokev1.NewInvokeMethodResponse(200, "OK", nil), nopTeardown, nil
+	})
+
+	req := invokev1.NewInvokeMethodRequest("method")
+	defer req.Close()
+	for i := 0; i < 4; i++ {
+		resp, teardown, _ := fn(context.Background(), "app1", "default", "addr1", req)
+		teardown(false)
+		if resp != nil {
+			resp.Close()
+		}
+	}
+
+	// addr1 is ejected after its first failure.
+	assert.Equal(t, []string{"addr1", "addr2", "addr2", "addr2"}, called)
+}
+
+func TestIsInstanceFailure(t *testing.T) {
+	assert.True(t, isInstanceFailure(nil, errors.New("unavailable")))
+	assert.True(t, isInstanceFailure(nil, status.Error(codes.Unavailable, "unavailable")))
+	assert.False(t, isInstanceFailure(nil, status.Error(codes.NotFound, "not found")))
+
+	assert.True(t, isInstanceFailure(invokev1.NewInvokeMethodResponse(503, "", nil), nil))
+	assert.False(t, isInstanceFailure(invokev1.NewInvokeMethodResponse(404, "", nil), nil))
+	assert.False(t, isInstanceFailure(invokev1.NewInvokeMethodResponse(200, "", nil), nil))
+
+	// gRPC codes are not compared as HTTP status codes.
+	assert.True(t, isInstanceFailure(invokev1.NewInvokeMethodResponse(int32(codes.Internal), "", nil), nil))
+	assert.False(t, isInstanceFailure(invokev1.NewInvokeMethodResponse(int32(codes.InvalidArgument), "", nil), nil))
+	assert.False(t, isInstanceFailure(invokev1.NewInvokeMethodResponse(int32(codes.OK), "", nil), nil))
+}
+
+func TestAppBalancersEviction(t *testing.T) {
+	res := newBalancerTestResiliency(resiliencyV1alpha.LoadBalancer{})
+
+	t.Run("app not resolved", func(t *testing.T) {
+		var balancers appBalancers
+		balancers.get(res, "app1", "default")
+		balancers.evict("app1", "default")
+		assert.Empty(t, balancers.balancers)
+	})
+
+	t.Run("app without policy", func(t *testing.T) {
+		var balancers appBalancers
+		balancers.get(res, "app1", "default")
+		assert.Nil(t, balancers.get(resiliency.New(logger.NewLogger("test")), "app1", "default"))
+		assert.Empty(t, balancers.balancers)
+	})
+
+	t.Run("idle app", func(t *testing.T) {
+		var balancers appBalancers
+		balancers.get(res, "app1", "default")
+		balancers.balancers["app1.default"].lastUsed = time.Now().Add(-2 * appBalancerIdleTTL)
+		balancers.lastPrune = time.Time{}
+		balancers.get(res, "app1", "other")
+		assert.Len(t, balancers.balancers, 1)
+		assert.Contains(t, balancers.balancers, "app1.other")
+	})
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/dapr/dapr/pkg/config"
+	diag "github.com/dapr/dapr/pkg/diagnostics"
+	diagConsts "github.com/dapr/dapr/pkg/diagnostics/consts"
+	diagUtils "github.com/dapr/dapr/pkg/diagnostics/utils"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+)
+
+// appRoute is a validated config.AppRoute.
+type appRoute struct {
+	matches     []appRouteMatch
+	targets     []config.WeightedAppTarget
+	totalWeight int
+}
+
+type appRouteMatch struct {
+	headers map[string]string
+	appID   string
+}
+
+// routeHeaders returns the values of a header of the request being routed.
+type routeHeaders func(name string) []string
+
+// invokeRequestHeaders returns the headers of an invocation request. Header
+// names are case-insensitive.
+func invokeRequestHeaders(req *invokev1.InvokeMethodRequest) routeHeaders {
+	return func(name string) []string {
+		var values []string
+		for k, v := range req.Metadata() {
+			if strings.EqualFold(k, name) {
+				values = append(values, v.GetValues()...)
+			}
+		}
+		return values
+	}
+}
+
+// appRouter maps logical app IDs to the backing app IDs the requests are sent to.
+type appRouter struct {
+	routes map[string]*appRoute
+}
+
+// newAppRouter returns the router for the given spec, or nil if there are no
+// routes. Invalid routes are logged and ignored.
+func newAppRouter(spec *config.AppRoutingSpec) *appRouter {
+	if spec == nil || len(spec.Routes) == 0 {
+		return nil
+	}
+
+	r := &appRouter{routes: make(map[string]*appRoute, len(spec.Routes))}
+	for _, route := range spec.Routes {
+		if _, ok := r.routes[route.AppID]; ok {
+			log.Warnf("Ignoring duplicate route for app %s", route.AppID)
+			continue
+		}
+		ar, err := newAppRoute(route)
+		if err != nil {
+			log.Warnf("Ignoring invalid route for app %s: %v", route.AppID, err)
+			continue
+		}
+		r.routes[route.AppID] = ar
+	}
+	if len(r.routes) == 0 {
+		return nil
+	}
+	return r
+}
+
+func newAppRoute(route config.AppRoute) (*appRoute, error) {
+	if route.AppID == "" || strings.Contains(route.AppID, ".") {
+		return nil, errors.New("appId must be an app ID without namespace")
+	}
+	if len(route.Matches) == 0 && len(route.Targets) == 0 {
+		return nil, errors.New("either matches or targets is required")
+	}
+
+	ar := &appRoute{
+		matches: make([]appRouteMatch, 0, len(route.Matches)),
+		targets: route.Targets,
+	}
+	for i, m := range route.Matches {
+		if m.AppID == "" || len(m.Headers) == 0 {
+			return nil, fmt.Errorf("match %d must have an appId and at least one header", i)
+		}
+		ar.matches = append(ar.matches, appRouteMatch{headers: m.Headers, appID: m.AppID})
+	}
+	for _, t := range route.Targets {
+		if t.AppID == "" || t.Weight < 0 {
+			return nil, fmt.Errorf("invalid target %q with weight %d", t.AppID, t.Weight)
+		}
+		ar.totalWeight += t.Weight
+	}
+	if len(ar.targets) > 0 && ar.totalWeight == 0 {
+		return nil, errors.New("the weights of the targets must not all be zero")
+	}
+	return ar, nil
+}
+
+// route returns the app ID to send the request to, and false if targetAppID
+// has no route. headers may be nil if the request has no headers to match.
+func (r *appRouter) route(targetAppID string, headers routeHeaders) (string, bool) {
+	if r == nil || strings.HasPrefix(targetAppID, "http://") || strings.HasPrefix(targetAppID, "https://") {
+		return targetAppID, false
+	}
+
+	// The namespace of the logical app ID, if any, applies to the backing one.
+	id, suffix := targetAppID, ""
+	if i := strings.IndexByte(targetAppID, '.'); i >= 0 {
+		id, suffix = targetAppID[:i], targetAppID[i:]
+	}
+	route, ok := r.routes[id]
+	if !ok {
+		return targetAppID, false
+	}
+
+	for _, m := range route.matches {
+		if m.matchesHeaders(headers) {
+			return m.appID + suffix, true
+		}
+	}
+	// Routes with only matches leave the other requests alone.
+	if route.totalWeight == 0 {
+		return targetAppID, false
+	}
+	n := rand.Intn(route.totalWeight) //nolint:gosec
+	for _, t := range route.targets {
+		if n < t.Weight {
+			return t.AppID + suffix, true
+		}
+		n -= t.Weight
+	}
+	return targetAppID, false
+}
+
+// matchesHeaders returns true if the request has all the headers of the
+// match.
+func (m appRouteMatch) matchesHeaders(headers routeHeaders) bool {
+	if headers == nil {
+		return false
+	}
+	for name, value := range m.headers {
+		found := false
+		for _, s := range headers(name) {
+			if s == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// routeAppID applies the traffic splitting routes to the target of a request,
+// recording the chosen app ID in the span and metrics.
+func (d *directMessaging) routeAppID(ctx context.Context, targetAppID string, headers routeHeaders) string {
+	routed, ok := d.router.route(targetAppID, headers)
+	if !ok {
+		return targetAppID
+	}
+
+	diag.DefaultMonitoring.ServiceInvocationRouted(targetAppID, routed)
+	diag.AddAttributesToSpan(diagUtils.SpanFromContext(ctx), map[string]string{
+		diagConsts.DaprAPIInvokeRouteTarget: routed,
+	})
+	return routed
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/dapr/dapr/pkg/config"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	daprt "github.com/dapr/dapr/pkg/testing"
+)
+
+func newTestAppRouter() *appRouter {
+	return newAppRouter(&config.AppRoutingSpec{
+		Routes: []config.AppRoute{
+			{
+				AppID: "checkout",
+				Matches: []config.AppRouteMatch{
+					{Headers: map[string]string{"x-canary": "true"}, AppID: "checkout-canary"},
+				},
+				Targets: []config.WeightedAppTarget{
+					{AppID: "checkout-v1", Weight: 3},
+					{AppID: "checkout-v2", Weight: 1},
+				},
+			},
+			{
+				AppID: "orders",
+				Matches: []config.AppRouteMatch{
+					{Headers: map[string]string{"x-canary": "true", "x-region": "eu"}, AppID: "orders-eu-canary"},
+				},
+			},
+			// Invalid routes are ignored.
+			{AppID: "invalid"},
+			{AppID: "zero", Targets: []config.WeightedAppTarget{{AppID: "zero-v1"}}},
+			{AppID: "ns.app", Targets: []config.WeightedAppTarget{{AppID: "app-v1", Weight: 1}}},
+		},
+	})
+}
+
+func TestNewAppRouter(t *testing.T) {
+	assert.Nil(t, newAppRouter(nil))
+	assert.Nil(t, newAppRouter(&config.AppRoutingSpec{}))
+	assert.Nil(t, newAppRouter(&config.AppRoutingSpec{Routes: []config.AppRoute{{AppID: "invalid"}}}))
+
+	r := newTestAppRouter()
+	require.NotNil(t, r)
+	assert.Len(t, r.routes, 2)
+	assert.Contains(t, r.routes, "checkout")
+	assert.Contains(t, r.routes, "orders")
+}
+
+func TestAppRouterRoute(t *testing.T) {
+	r := newTestAppRouter()
+
+	t.Run("header match", func(t *testing.T) {
+		req := invokev1.NewInvokeMethodRequest("method").
+			WithMetadata(map[string][]string{"X-Canary": {"true"}})
+		defer req.Close()
+
+		routed, ok := r.route("checkout", invokeRequestHeaders(req))
+		assert.True(t, ok)
+		assert.Equal(t, "checkout-canary", routed)
+
+		routed, ok = r.route("checkout.prod", invokeRequestHeaders(req))
+		assert.True(t, ok)
+		assert.Equal(t, "checkout-canary.prod", routed)
+	})
+
+	t.Run("all headers must match", func(t *testing.T) {
+		req := invokev1.NewInvokeMethodRequest("method").
+			WithMetadata(map[string][]string{"x-canary": {"true"}})
+		defer req.Close()
+		routed, ok := r.route("orders", invokeRequestHeaders(req))
+		assert.False(t, ok)
+		assert.Equal(t, "orders", routed)
+
+		both := invokev1.NewInvokeMethodRequest("method").
+			WithMetadata(map[string][]string{"x-canary": {"true"}, "x-region": {"eu"}})
+		defer both.Close()
+		routed, ok = r.route("orders", invokeRequestHeaders(both))
+		assert.True(t, ok)
+		assert.Equal(t, "orders-eu-canary", routed)
+	})
+
+	t.Run("weighted targets", func(t *testing.T) {
+		req := invokev1.NewInvokeMethodRequest("method")
+		defer req.Close()
+
+		counts := map[string]int{}
+		for i := 0; i < 4000; i++ {
+			routed, ok := r.route("checkout", invokeRequestHeaders(req))
+			require.True(t, ok)
+			counts[routed]++
+		}
+		assert.Len(t, counts, 2)
+		assert.InDelta(t, 3000, counts["checkout-v1"], 300)
+		assert.InDelta(t, 1000, counts["checkout-v2"], 300)
+	})
+
+	t.Run("no route", func(t *testing.T) {
+		req := invokev1.NewInvokeMethodRequest("method")
+		defer req.Close()
+
+		for _, id := range []string{"payments", "https://checkout", "invalid"} {
+			routed, ok := r.route(id, invokeRequestHeaders(req))
+			assert.False(t, ok)
+			assert.Equal(t, id, routed)
+		}
+
+		var nilRouter *appRouter
+		routed, ok := nilRouter.route("checkout", invokeRequestHeaders(req))
+		assert.False(t, ok)
+		assert.Equal(t, "checkout", routed)
+	})
+}
+
+func TestInvokeRouted(t *testing.T) {
+	resolver := &daprt.MockResolver{}
+	resolver.On("ResolveID", mock.Anything).Return("addr1", nil)
+
+	var invoked []string
+	d := &directMessaging{
+		appID:      "app1",
+		namespace:  "default",
+		resolver:   resolver,
+		resiliency: resiliency.New(nil),
+		compStore:  compstore.New(),
+		router:     newTestAppRouter(),
+	}
+	d.connectionCreatorFn = func(ctx context.Context, address, id, namespace string, customOpts ...grpc.DialOption) (*grpc.ClientConn, func(destroy bool), error) {
+		invoked = append(invoked, id+"."+namespace)
+		return nil, nopTeardown, errors.New("unavailable")
+	}
+
+	req := invokev1.NewInvokeMethodRequest("method").
+		WithMetadata(map[string][]string{"x-canary": {"true"}})
+	defer req.Close()
+	_, err := d.Invoke(context.Background(), "checkout", req)
+	require.Error(t, err)
+	assert.Equal(t, []string{"checkout-canary.default"}, invoked)
+}
+
+func TestGetRemoteAppRouted(t *testing.T) {
+	resolver := &daprt.MockResolver{}
+	resolver.On("ResolveID", mock.Anything).Return("addr1", nil)
+
+	d := &directMessaging{
+		appID:     "app1",
+		namespace: "default",
+		resolver:  resolver,
+		compStore: compstore.New(),
+		router:    newTestAppRouter(),
+	}
+
+	// The gRPC proxy routes with the metadata of the incoming call.
+	md := metadata.Pairs("x-canary", "true")
+	app, err := d.getRemoteApp(context.Background(), "checkout.prod", md.Get)
+	require.NoError(t, err)
+	assert.Equal(t, "checkout-canary", app.id)
+	assert.Equal(t, "prod", app.namespace)
+
+	app, err = d.getRemoteApp(context.Background(), "orders", md.Get)
+	require.NoError(t, err)
+	assert.Equal(t, "orders", app.id)
+}
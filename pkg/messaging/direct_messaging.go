@@ -30,6 +30,7 @@ import (
 
 	nr "github.com/dapr/components-contrib/nameresolution"
 	"github.com/dapr/dapr/pkg/channel"
+	"github.com/dapr/dapr/pkg/config"
 	diag "github.com/dapr/dapr/pkg/diagnostics"
 	diagUtils "github.com/dapr/dapr/pkg/diagnostics/utils"
 	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
@@ -68,6 +69,10 @@ type directMessaging struct {
 	readBufferSize               int
 	resiliency                   resiliency.Provider
 	compStore                    *compstore.ComponentStore
+	endpointTokens               endpointTokenSources
+	appBalancers                 appBalancers
+	router                       *appRouter
+	cache                        *responseCache
 }
 
 type remoteApp struct {
@@ -90,6 +95,8 @@ type NewDirectMessagingOpts struct {
 	Proxy              Proxy
 	ReadBufferSize     int
 	Resiliency         resiliency.Provider
+	AppRouting         *config.AppRoutingSpec
+	InvocationCache    *config.InvocationCacheSpec
 }
 
 // NewDirectMessaging returns a new direct messaging api.
@@ -113,6 +120,8 @@ func NewDirectMessaging(opts NewDirectMessagingOpts) invokev1.DirectMessaging {
 		hostName:                     hName,
 		compStore:                    opts.CompStore,
 		resourceHTTPEndpointChannels: map[string]channel.HTTPEndpointAppChannel{},
+		router:                       newAppRouter(opts.AppRouting),
+		cache:                        newResponseCache(opts.InvocationCache),
 	}
 
 	if dm.proxy != nil {
@@ -125,13 +134,21 @@ func NewDirectMessaging(opts NewDirectMessagingOpts) invokev1.DirectMessaging {
 
 // Invoke takes a message requests and invokes an app, either local or remote.
 func (d *directMessaging) Invoke(ctx context.Context, targetAppID string, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, error) {
-	app, err := d.getRemoteApp(targetAppID)
+	app, err := d.getRemoteApp(ctx, targetAppID, invokeRequestHeaders(req))
 	if err != nil {
 		return nil, err
 	}
 
 	// invoke external calls first if appID matches an httpEndpoint.Name or app.id == baseURL that is overwritten
 	if d.isHTTPEndpoint(app.id) || strings.HasPrefix(app.id, "http://") || strings.HasPrefix(app.id, "https://") {
+		// The data of upgraded connections can't be buffered nor replayed.
+		if req.IsUpgrade() {
+			resp, _, err := d.invokeHTTPEndpoint(ctx, app.id, app.namespace, app.address, req)
+			return resp, err
+		}
+		if h := d.hedgingPolicy(app.id, req); h != nil {
+			return d.invokeHedged(ctx, h, app, nil, d.invokeHTTPEndpoint, req)
+		}
 		return d.invokeWithRetry(ctx, retry.DefaultLinearRetryCount, retry.DefaultLinearBackoffInterval, app, d.invokeHTTPEndpoint, req)
 	}
 
@@ -139,14 +156,39 @@ func (d *directMessaging) Invoke(ctx context.Context, targetAppID string, req *i
 		return d.invokeLocal(ctx, req)
 	}
 
-	return d.invokeWithRetry(ctx, retry.DefaultLinearRetryCount, retry.DefaultLinearBackoffInterval, app, d.invokeRemote, req)
+	// Retries and hedged requests pick an instance each.
+	invokeRemote := d.invokeRemote
+	b := d.appBalancers.get(d.resiliency, app.id, app.namespace)
+	if b != nil {
+		invokeRemote = d.balance(b, invokeRemote)
+	}
+
+	// The data of upgraded connections can't be replayed.
+	if req.IsUpgrade() {
+		resp, teardown, err := invokeRemote(ctx, app.id, app.namespace, app.address, req)
+		teardown(false)
+		return resp, err
+	}
+
+	invoke := func(ctx context.Context, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, error) {
+		if h := d.hedgingPolicy(app.id, req); h != nil {
+			return d.invokeHedged(ctx, h, app, b, d.invokeRemote, req)
+		}
+		return d.invokeWithRetry(ctx, retry.DefaultLinearRetryCount, retry.DefaultLinearBackoffInterval, app, invokeRemote, req)
+	}
+	if d.cache.matches(app.id, req) {
+		return d.cache.invoke(ctx, app, req, invoke)
+	}
+	return invoke(ctx, req)
 }
 
 // requestAppIDAndNamespace takes an app id and returns the app id, namespace and error.
-func (d *directMessaging) requestAppIDAndNamespace(targetAppID string) (string, string, error) {
+// Logical app IDs are routed using the headers of the request first.
+func (d *directMessaging) requestAppIDAndNamespace(ctx context.Context, targetAppID string, headers routeHeaders) (string, string, error) {
 	if targetAppID == "" {
 		return "", "", errors.New("app id is empty")
 	}
+	targetAppID = d.routeAppID(ctx, targetAppID, headers)
 	// external invocation with targetAppID == baseURL
 	if strings.HasPrefix(targetAppID, "http://") || strings.HasPrefix(targetAppID, "https://") {
 		return targetAppID, "", nil
@@ -243,6 +285,12 @@ func (d *directMessaging) isHTTPEndpoint(appID string) bool {
 func (d *directMessaging) invokeHTTPEndpoint(ctx context.Context, appID, appNamespace, appAddress string, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, func(destroy bool), error) {
 	ctx = d.setContextSpan(ctx)
 
+	// Requests are retried with the same request object, so this gets a valid
+	// token on every attempt.
+	if err := d.addHTTPEndpointAuthorization(ctx, appID, req); err != nil {
+		return nil, nopTeardown, err
+	}
+
 	// Set up timers
 	start := time.Now()
 	diag.DefaultMonitoring.ServiceInvocationRequestSent(appID)
@@ -328,10 +376,6 @@ func (d *directMessaging) invokeRemoteStream(ctx context.Context, clientV1 inter
 	if err != nil {
 		return nil, err
 	}
-	buf := invokev1.BufPool.Get().(*[]byte)
-	defer func() {
-		invokev1.BufPool.Put(buf)
-	}()
 	r := req.RawData()
 	reqProto := req.Proto()
 
@@ -349,63 +393,23 @@ func (d *directMessaging) invokeRemoteStream(ctx context.Context, clientV1 inter
 		}()
 	}
 
-	proto := &internalv1pb.InternalInvokeRequestStream{}
-	var (
-		n    int
-		seq  uint64
-		done bool
-	)
-	for {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-
-		// First message only - add the request
-		if reqProto != nil {
-			proto.Request = reqProto
-			reqProto = nil
-		} else {
-			// Reset the object so we can re-use it
-			proto.Reset()
-		}
-
-		if r != nil {
-			n, err = r.Read(*buf)
-			if err == io.EOF {
-				done = true
-			} else if err != nil {
-				return nil, err
-			}
-			if n > 0 {
-				proto.Payload = &commonv1pb.StreamPayload{
-					Data: (*buf)[:n],
-					Seq:  seq,
-				}
-				seq++
-			}
-		} else {
-			done = true
-		}
-
-		// Send the chunk if there's anything to send
-		if proto.Request != nil || proto.Payload != nil {
-			err = stream.SendMsg(proto)
-			if errors.Is(err, io.EOF) {
-				// If SendMsg returns an io.EOF error, it usually means that there's a transport-level error
-				// The exact error can only be determined by RecvMsg, so if we encounter an EOF error here, just consider the stream done and let RecvMsg handle the error
-				done = true
-			} else if err != nil {
-				return nil, fmt.Errorf("error sending message: %w", err)
-			}
+	if req.IsUpgrade() {
+		// Data flows in both directions at once over upgraded connections, so
+		// the request's data is sent in background after the leading chunk
+		err = stream.SendMsg(&internalv1pb.InternalInvokeRequestStream{Request: reqProto})
+		if err != nil {
+			return nil, fmt.Errorf("error sending message: %w", err)
 		}
-
-		// Stop with the last chunk
-		if done {
-			err = stream.CloseSend()
-			if err != nil {
-				return nil, fmt.Errorf("failed to close the send direction of the stream: %w", err)
+		go func() {
+			sendErr := sendRequestStream(ctx, stream, nil, r)
+			if sendErr != nil {
+				log.Debugf("Failed to send data to the upgraded connection of app %s: %v", appID, sendErr)
 			}
-			break
+		}()
+	} else {
+		err = sendRequestStream(ctx, stream, reqProto, r)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -498,6 +502,78 @@ func (d *directMessaging) invokeRemoteStream(ctx context.Context, clientV1 inter
 	return res, nil
 }
 
+// sendRequestStream sends the leading chunk of a request, if reqProto is not
+// nil, followed by its data in r, then closes the send direction of the stream.
+func sendRequestStream(ctx context.Context, stream grpc.ClientStream, reqProto *internalv1pb.InternalInvokeRequest, r io.Reader) error {
+	buf := invokev1.BufPool.Get().(*[]byte)
+	defer func() {
+		invokev1.BufPool.Put(buf)
+	}()
+
+	proto := &internalv1pb.InternalInvokeRequestStream{}
+	var (
+		n    int
+		seq  uint64
+		done bool
+		err  error
+	)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// First message only - add the request
+		if reqProto != nil {
+			proto.Request = reqProto
+			reqProto = nil
+		} else {
+			// Reset the object so we can re-use it
+			proto.Reset()
+		}
+
+		if r != nil {
+			n, err = r.Read(*buf)
+			if err == io.EOF {
+				done = true
+			} else if err != nil {
+				return err
+			}
+			if n > 0 {
+				proto.Payload = &commonv1pb.StreamPayload{
+					Data: (*buf)[:n],
+					Seq:  seq,
+				}
+				seq++
+			}
+		} else {
+			done = true
+		}
+
+		// Send the chunk if there's anything to send
+		if proto.Request != nil || proto.Payload != nil {
+			err = stream.SendMsg(proto)
+			if errors.Is(err, io.EOF) {
+				// If SendMsg returns an io.EOF error, it usually means that there's a transport-level error
+				// The exact error can only be determined by RecvMsg, so if we encounter an EOF error here, just consider the stream done and let RecvMsg handle the error
+				done = true
+			} else if err != nil {
+				return fmt.Errorf("error sending message: %w", err)
+			}
+		}
+
+		// Stop with the last chunk
+		if done {
+			err = stream.CloseSend()
+			if err != nil {
+				return fmt.Errorf("failed to close the send direction of the stream: %w", err)
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
 func (d *directMessaging) addDestinationAppIDHeaderToMetadata(appID string, req *invokev1.InvokeMethodRequest) {
 	req.Metadata()[invokev1.DestinationIDHeader] = &internalv1pb.ListStringValue{
 		Values: []string{appID},
@@ -546,8 +622,8 @@ func (d *directMessaging) addForwardedHeadersToMetadata(req *invokev1.InvokeMeth
 	addOrCreate(fasthttp.HeaderForwarded, forwardedHeaderValue)
 }
 
-func (d *directMessaging) getRemoteApp(appID string) (remoteApp, error) {
-	id, namespace, err := d.requestAppIDAndNamespace(appID)
+func (d *directMessaging) getRemoteApp(ctx context.Context, appID string, headers routeHeaders) (remoteApp, error) {
+	id, namespace, err := d.requestAppIDAndNamespace(ctx, appID, headers)
 	if err != nil {
 		return remoteApp{}, err
 	}
@@ -568,6 +644,7 @@ func (d *directMessaging) getRemoteApp(appID string) (remoteApp, error) {
 		request := nr.ResolveRequest{ID: id, Namespace: namespace, Port: d.grpcPort}
 		address, err = d.resolver.ResolveID(context.TODO(), request)
 		if err != nil {
+			d.appBalancers.evict(id, namespace)
 			return remoteApp{}, err
 		}
 	}
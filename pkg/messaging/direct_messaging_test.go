@@ -126,7 +126,7 @@ func TestKubernetesNamespace(t *testing.T) {
 		appID := "app1"
 
 		dm := &directMessaging{}
-		id, ns, err := dm.requestAppIDAndNamespace(appID)
+		id, ns, err := dm.requestAppIDAndNamespace(context.Background(), appID, nil)
 
 		assert.NoError(t, err)
 		assert.Empty(t, ns)
@@ -137,7 +137,7 @@ func TestKubernetesNamespace(t *testing.T) {
 		appID := "app1.ns1"
 
 		dm := &directMessaging{}
-		id, ns, err := dm.requestAppIDAndNamespace(appID)
+		id, ns, err := dm.requestAppIDAndNamespace(context.Background(), appID, nil)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "ns1", ns)
@@ -148,7 +148,7 @@ func TestKubernetesNamespace(t *testing.T) {
 		appID := "app1.ns1.ns2"
 
 		dm := &directMessaging{}
-		_, _, err := dm.requestAppIDAndNamespace(appID)
+		_, _, err := dm.requestAppIDAndNamespace(context.Background(), appID, nil)
 
 		assert.Error(t, err)
 	})
@@ -17,6 +17,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -36,7 +37,7 @@ import (
 // Proxy is the interface for a gRPC transparent proxy.
 type Proxy interface {
 	Handler() grpc.StreamHandler
-	SetRemoteAppFn(func(string) (remoteApp, error))
+	SetRemoteAppFn(func(context.Context, string, routeHeaders) (remoteApp, error))
 	SetTelemetryFn(func(context.Context) context.Context)
 }
 
@@ -44,7 +45,7 @@ type proxy struct {
 	appID              string
 	appClientFn        func() (grpc.ClientConnInterface, error)
 	connectionFactory  messageClientConnection
-	remoteAppFn        func(appID string) (remoteApp, error)
+	remoteAppFn        func(ctx context.Context, appID string, headers routeHeaders) (remoteApp, error)
 	telemetryFn        func(context.Context) context.Context
 	acl                *config.AccessControlList
 	resiliency         resiliency.Provider
@@ -77,9 +78,9 @@ func NewProxy(opts ProxyOpts) Proxy {
 func (p *proxy) Handler() grpc.StreamHandler {
 	return grpcProxy.TransparentHandler(p.intercept,
 		func(appID, methodName string) *resiliency.PolicyDefinition {
-			_, isLocal, err := p.isLocal(appID)
+			_, isLocal, err := p.isLocal(context.Background(), appID, nil)
 			if err == nil && !isLocal {
-				return p.resiliency.EndpointPolicy(appID, appID+":"+methodName)
+				return p.resiliency.EndpointMethodPolicy(appID, strings.TrimPrefix(methodName, "/"))
 			}
 
 			return resiliency.NoOp{}.EndpointPolicy("", "")
@@ -108,7 +109,7 @@ func (p *proxy) intercept(ctx context.Context, fullName string) (context.Context
 		return ctx, nil, nil, nopTeardown, errors.New("failed to proxy request: proxy not initialized. daprd startup may be incomplete")
 	}
 
-	target, isLocal, err := p.isLocal(appID)
+	target, isLocal, err := p.isLocal(ctx, appID, md.Get)
 	if err != nil {
 		return ctx, nil, nil, nopTeardown, err
 	}
@@ -147,7 +148,7 @@ func (p *proxy) intercept(ctx context.Context, fullName string) (context.Context
 }
 
 // SetRemoteAppFn sets a function that helps the proxy resolve an app ID to an actual address.
-func (p *proxy) SetRemoteAppFn(remoteAppFn func(appID string) (remoteApp, error)) {
+func (p *proxy) SetRemoteAppFn(remoteAppFn func(ctx context.Context, appID string, headers routeHeaders) (remoteApp, error)) {
 	p.remoteAppFn = remoteAppFn
 }
 
@@ -156,12 +157,12 @@ func (p *proxy) SetTelemetryFn(spanFn func(context.Context) context.Context) {
 	p.telemetryFn = spanFn
 }
 
-func (p *proxy) isLocal(appID string) (remoteApp, bool, error) {
+func (p *proxy) isLocal(ctx context.Context, appID string, headers routeHeaders) (remoteApp, bool, error) {
 	if p.remoteAppFn == nil {
 		return remoteApp{}, false, errors.New("failed to proxy request: proxy not initialized; daprd startup may be incomplete")
 	}
 
-	target, err := p.remoteAppFn(appID)
+	target, err := p.remoteAppFn(ctx, appID, headers)
 	if err != nil {
 		return remoteApp{}, false, err
 	}
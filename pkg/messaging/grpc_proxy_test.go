@@ -73,14 +73,14 @@ func TestSetRemoteAppFn(t *testing.T) {
 		ACL:               nil,
 		Resiliency:        resiliency.New(nil),
 	})
-	p.SetRemoteAppFn(func(s string) (remoteApp, error) {
+	p.SetRemoteAppFn(func(_ context.Context, s string, _ routeHeaders) (remoteApp, error) {
 		return remoteApp{
 			id: "a",
 		}, nil
 	})
 
 	proxy := p.(*proxy)
-	app, err := proxy.remoteAppFn("a")
+	app, err := proxy.remoteAppFn(context.Background(), "a", nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "a", app.id)
@@ -130,7 +130,7 @@ func TestIntercept(t *testing.T) {
 			return ctx
 		})
 
-		p.SetRemoteAppFn(func(s string) (remoteApp, error) {
+		p.SetRemoteAppFn(func(_ context.Context, s string, _ routeHeaders) (remoteApp, error) {
 			return remoteApp{
 				id: "a",
 			}, nil
@@ -156,7 +156,7 @@ func TestIntercept(t *testing.T) {
 			return ctx
 		})
 
-		p.SetRemoteAppFn(func(s string) (remoteApp, error) {
+		p.SetRemoteAppFn(func(_ context.Context, s string, _ routeHeaders) (remoteApp, error) {
 			return remoteApp{
 				id: "a",
 			}, nil
@@ -180,7 +180,7 @@ func TestIntercept(t *testing.T) {
 			return ctx
 		})
 
-		p.SetRemoteAppFn(func(s string) (remoteApp, error) {
+		p.SetRemoteAppFn(func(_ context.Context, s string, _ routeHeaders) (remoteApp, error) {
 			return remoteApp{
 				id: "a",
 			}, nil
@@ -208,7 +208,7 @@ func TestIntercept(t *testing.T) {
 			return ctx
 		})
 
-		p.SetRemoteAppFn(func(s string) (remoteApp, error) {
+		p.SetRemoteAppFn(func(_ context.Context, s string, _ routeHeaders) (remoteApp, error) {
 			return remoteApp{
 				id: "b",
 			}, nil
@@ -242,7 +242,7 @@ func TestIntercept(t *testing.T) {
 			ACL:               acl,
 			Resiliency:        resiliency.New(nil),
 		})
-		p.SetRemoteAppFn(func(s string) (remoteApp, error) {
+		p.SetRemoteAppFn(func(_ context.Context, s string, _ routeHeaders) (remoteApp, error) {
 			return remoteApp{
 				id:      "a",
 				address: "a:123",
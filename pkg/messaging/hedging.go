@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	nr "github.com/dapr/components-contrib/nameresolution"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	internalv1pb "github.com/dapr/dapr/pkg/proto/internals/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+	streamutils "github.com/dapr/kit/streams"
+)
+
+// hedgeResolveAttempts is how many times the name resolver is queried for an
+// instance that hasn't been sent the request yet.
+const hedgeResolveAttempts = 3
+
+// maxHedgedBodySize is the size of the largest request body that is hedged.
+// Every attempt needs the whole body, so it is buffered in memory.
+const maxHedgedBodySize = 4 << 20
+
+// hedgingPolicy returns the hedging policy to use for the request, if any.
+func (d *directMessaging) hedgingPolicy(appID string, req *invokev1.InvokeMethodRequest) *resiliency.HedgingPolicy {
+	h := d.resiliency.EndpointHedgingPolicy(appID)
+	if h == nil {
+		return nil
+	}
+
+	var verb string
+	if v := req.Message().GetHttpExtension().GetVerb(); v != commonv1pb.HTTPExtension_NONE { //nolint:nosnakecase
+		verb = v.String()
+	}
+	if !h.IsIdempotent(req.Message().GetMethod(), verb) {
+		return nil
+	}
+	if !bufferHedgedBody(req) {
+		log.Debugf("Not hedging request to app %s: body is larger than %d bytes", appID, maxHedgedBodySize)
+		return nil
+	}
+	return h
+}
+
+// bufferHedgedBody reads the streamed body of the request, up to
+// maxHedgedBodySize bytes, and returns false if it is larger than that.
+// The request's data is left unchanged either way.
+func bufferHedgedBody(req *invokev1.InvokeMethodRequest) bool {
+	if req.HasMessageData() {
+		return true
+	}
+
+	r := req.RawData()
+	buf, err := io.ReadAll(io.LimitReader(r, maxHedgedBodySize+1))
+	if !req.CanReplay() {
+		// Put back what was read
+		req.WithRawData(streamutils.NewMultiReaderCloser(bytes.NewReader(buf), r))
+	}
+	return err == nil && len(buf) <= maxHedgedBodySize
+}
+
+// invokeHedged invokes the target with a hedging policy. Every attempt gets
+// its own copy of the request; attempts after the first one are sent to a
+// different instance of the app when the name resolver, or the balancer b if
+// not nil, returns one.
+func (d *directMessaging) invokeHedged(
+	ctx context.Context,
+	h *resiliency.HedgingPolicy,
+	app remoteApp,
+	b *appBalancer,
+	fn func(ctx context.Context, appID, namespace, appAddress string, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, func(destroy bool), error),
+	req *invokev1.InvokeMethodRequest,
+) (*invokev1.InvokeMethodResponse, error) {
+	pd, err := req.ProtoWithData()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data from request object: %w", err)
+	}
+
+	var lock sync.Mutex
+	used := map[string]struct{}{}
+	if b == nil {
+		used[app.address] = struct{}{}
+	}
+
+	return resiliency.Hedge(ctx, h, func(ctx context.Context) (*invokev1.InvokeMethodResponse, error) {
+		attemptReq, err := invokev1.InternalInvokeRequest(proto.Clone(pd).(*internalv1pb.InternalInvokeRequest))
+		if err != nil {
+			return nil, err
+		}
+		defer attemptReq.Close()
+
+		target := app
+		var inst *appInstance
+		lock.Lock()
+		switch {
+		case b != nil:
+			inst = b.pick(ctx, d.resolver, d.grpcPort, app.address, attemptReq, used)
+			target.address = inst.address
+		case resiliency.GetAttempt(ctx) > 1 && !d.isHTTPEndpoint(app.id):
+			target.address = d.resolveOtherInstance(ctx, app, used)
+		}
+		used[target.address] = struct{}{}
+		lock.Unlock()
+
+		resp, teardown, err := fn(ctx, target.id, target.namespace, target.address, attemptReq)
+		if inst != nil {
+			b.done(inst, isInstanceFailure(resp, err))
+		}
+		teardown(err != nil && status.Code(err) == codes.Unavailable)
+		return resp, err
+	}, resiliency.DisposerCloser[*invokev1.InvokeMethodResponse])
+}
+
+// resolveOtherInstance returns the address of an instance of the app that is
+// not in used, falling back to the address that was first resolved.
+func (d *directMessaging) resolveOtherInstance(ctx context.Context, app remoteApp, used map[string]struct{}) string {
+	request := nr.ResolveRequest{ID: app.id, Namespace: app.namespace, Port: d.grpcPort}
+	for i := 0; i < hedgeResolveAttempts; i++ {
+		address, err := d.resolver.ResolveID(ctx, request)
+		if err != nil {
+			log.Debugf("Failed to resolve another instance of app %s for a hedged request: %v", app.id, err)
+			break
+		}
+		if _, ok := used[address]; !ok {
+			return address
+		}
+	}
+	return app.address
+}
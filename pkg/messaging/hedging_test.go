@@ -0,0 +1,260 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	httpEndpointV1alpha1 "github.com/dapr/dapr/pkg/apis/httpEndpoint/v1alpha1"
+	resiliencyV1alpha "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
+	"github.com/dapr/dapr/pkg/channel"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/channels"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	daprt "github.com/dapr/dapr/pkg/testing"
+	"github.com/dapr/kit/logger"
+)
+
+func newHedgingTestResiliency() *resiliency.Resiliency {
+	return resiliency.FromConfigurations(logger.NewLogger("test"), &resiliencyV1alpha.Resiliency{
+		ObjectMeta: metav1.ObjectMeta{Name: "resiliency"},
+		Spec: resiliencyV1alpha.ResiliencySpec{
+			Policies: resiliencyV1alpha.Policies{
+				Hedging: map[string]resiliencyV1alpha.Hedging{
+					"hedge": {Delay: "20ms", MaxAttempts: 2, Methods: []string{"getOrder"}},
+				},
+			},
+			Targets: resiliencyV1alpha.Targets{
+				Apps: map[string]resiliencyV1alpha.EndpointPolicyNames{
+					"app1": {Hedging: "hedge"},
+				},
+			},
+		},
+	})
+}
+
+func TestHedgingPolicy(t *testing.T) {
+	d := &directMessaging{resiliency: newHedgingTestResiliency()}
+
+	get := invokev1.NewInvokeMethodRequest("orders").WithHTTPExtension("GET", "")
+	defer get.Close()
+	post := invokev1.NewInvokeMethodRequest("orders").WithHTTPExtension("POST", "")
+	defer post.Close()
+	grpcListed := invokev1.NewInvokeMethodRequest("getOrder")
+	defer grpcListed.Close()
+	grpcUnlisted := invokev1.NewInvokeMethodRequest("createOrder")
+	defer grpcUnlisted.Close()
+
+	assert.NotNil(t, d.hedgingPolicy("app1", get))
+	assert.Nil(t, d.hedgingPolicy("app1", post))
+	assert.NotNil(t, d.hedgingPolicy("app1", grpcListed))
+	assert.Nil(t, d.hedgingPolicy("app1", grpcUnlisted))
+	assert.Nil(t, d.hedgingPolicy("app2", get))
+
+	t.Run("streamed bodies", func(t *testing.T) {
+		for _, replay := range []bool{false, true} {
+			small := invokev1.NewInvokeMethodRequest("orders").WithHTTPExtension("GET", "").
+				WithRawData(io.NopCloser(strings.NewReader("hello"))).WithReplay(replay)
+			defer small.Close()
+			assert.NotNil(t, d.hedgingPolicy("app1", small))
+			body, err := small.RawDataFull()
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(body))
+
+			large := bytes.Repeat([]byte("a"), maxHedgedBodySize+10)
+			big := invokev1.NewInvokeMethodRequest("orders").WithHTTPExtension("GET", "").
+				WithRawData(io.NopCloser(bytes.NewReader(large))).WithReplay(replay)
+			defer big.Close()
+			assert.Nil(t, d.hedgingPolicy("app1", big))
+			body, err = big.RawDataFull()
+			require.NoError(t, err)
+			assert.Equal(t, large, body)
+		}
+	})
+}
+
+func TestInvokeHedged(t *testing.T) {
+	resolver := &daprt.MockResolver{}
+	resolver.On("ResolveID", mock.Anything).Return("addr1", nil).Once()
+	resolver.On("ResolveID", mock.Anything).Return("addr2", nil)
+
+	d := &directMessaging{
+		resiliency: newHedgingTestResiliency(),
+		resolver:   resolver,
+		compStore:  compstore.New(),
+	}
+
+	var (
+		lock      sync.Mutex
+		addresses []string
+		bodies    []string
+	)
+	slowCanceled := make(chan struct{})
+	fn := func(ctx context.Context, appID, namespace, appAddress string, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, func(destroy bool), error) {
+		body, err := req.RawDataFull()
+		require.NoError(t, err)
+		lock.Lock()
+		addresses = append(addresses, appAddress)
+		bodies = append(bodies, string(body))
+		lock.Unlock()
+
+		if appAddress == "addr1" {
+			<-ctx.Done()
+			close(slowCanceled)
+			return nil, nopTeardown, ctx.Err()
+		}
+		return invokev1.NewInvokeMethodResponse(200, "OK", nil).WithRawDataString(appAddress), nopTeardown, nil
+	}
+
+	req := invokev1.NewInvokeMethodRequest("orders").
+		WithHTTPExtension("GET", "").
+		WithRawDataString("hello")
+	defer req.Close()
+
+	app := remoteApp{id: "app1", namespace: "default", address: "addr1"}
+	resp, err := d.invokeHedged(context.Background(), d.hedgingPolicy("app1", req), app, nil, fn, req)
+	require.NoError(t, err)
+	defer resp.Close()
+
+	data, err := resp.RawDataFull()
+	require.NoError(t, err)
+	assert.Equal(t, "addr2", string(data))
+
+	select {
+	case <-slowCanceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow attempt was not canceled")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, []string{"addr1", "addr2"}, addresses)
+	assert.Equal(t, []string{"hello", "hello"}, bodies)
+}
+
+func TestInvokeHedgedBalanced(t *testing.T) {
+	d := &directMessaging{
+		resiliency: newHedgingTestResiliency(),
+		resolver:   &mockResolverMulti{addresses: []string{"addr1", "addr2", "addr3"}},
+		compStore:  compstore.New(),
+	}
+	b := newAppBalancer("app1", "default", &resiliency.LoadBalancingPolicy{
+		Strategy:   resiliency.LoadBalancingConsistentHash,
+		HashHeader: "x-user",
+	})
+
+	var (
+		lock      sync.Mutex
+		addresses []string
+	)
+	fn := func(ctx context.Context, appID, namespace, appAddress string, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, func(destroy bool), error) {
+		lock.Lock()
+		addresses = append(addresses, appAddress)
+		first := len(addresses) == 1
+		lock.Unlock()
+
+		if first {
+			<-ctx.Done()
+			return nil, nopTeardown, ctx.Err()
+		}
+		return invokev1.NewInvokeMethodResponse(200, "OK", nil).WithRawDataString(appAddress), nopTeardown, nil
+	}
+
+	req := invokev1.NewInvokeMethodRequest("orders").
+		WithHTTPExtension("GET", "").
+		WithMetadata(map[string][]string{"X-User": {"alice"}})
+	defer req.Close()
+
+	app := remoteApp{id: "app1", namespace: "default", address: "addr1"}
+	resp, err := d.invokeHedged(context.Background(), d.hedgingPolicy("app1", req), app, b, fn, req)
+	require.NoError(t, err)
+	defer resp.Close()
+
+	// The hedged attempt is not sent to the instance the key hashes to.
+	lock.Lock()
+	defer lock.Unlock()
+	require.Len(t, addresses, 2)
+	assert.NotEqual(t, addresses[0], addresses[1])
+	data, err := resp.RawDataFull()
+	require.NoError(t, err)
+	assert.Equal(t, addresses[1], string(data))
+}
+
+// upgradeChannel accepts every upgrade request without reading its body.
+type upgradeChannel struct {
+	calls atomic.Int32
+}
+
+func (c *upgradeChannel) InvokeMethod(ctx context.Context, req *invokev1.InvokeMethodRequest, appID string) (*invokev1.InvokeMethodResponse, error) {
+	c.calls.Add(1)
+	return invokev1.NewInvokeMethodResponse(http.StatusSwitchingProtocols, "Switching Protocols", nil), nil
+}
+
+func TestInvokeHTTPEndpointUpgradeNotHedged(t *testing.T) {
+	store := compstore.New()
+	store.AddHTTPEndpoint(httpEndpointV1alpha1.HTTPEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "app1"},
+		Spec:       httpEndpointV1alpha1.HTTPEndpointSpec{BaseURL: "http://localhost:1234"},
+	})
+	ch := &upgradeChannel{}
+	d := &directMessaging{
+		resiliency: newHedgingTestResiliency(),
+		resolver:   &daprt.MockResolver{},
+		compStore:  store,
+		channels:   (new(channels.Channels)).WithEndpointChannels(map[string]channel.HTTPEndpointAppChannel{"app1": ch}),
+	}
+
+	// The body of an upgraded connection is only closed when the connection is.
+	body, w := io.Pipe()
+	defer w.Close()
+	req := invokev1.NewInvokeMethodRequest("orders").
+		WithHTTPExtension("GET", "").
+		WithHTTPHeaders(http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}}).
+		WithRawData(body)
+	defer req.Close()
+
+	type result struct {
+		resp *invokev1.InvokeMethodResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := d.Invoke(context.Background(), "app1", req)
+		done <- result{resp, err}
+	}()
+
+	select {
+	case res := <-done:
+		require.NoError(t, res.err)
+		defer res.resp.Close()
+		assert.True(t, res.resp.IsUpgrade())
+	case <-time.After(5 * time.Second):
+		t.Fatal("upgrade request to http endpoint did not complete")
+	}
+	assert.Equal(t, int32(1), ch.calls.Load())
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	httpEndpointV1alpha1 "github.com/dapr/dapr/pkg/apis/httpEndpoint/v1alpha1"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+)
+
+const (
+	authorizationHeader = "Authorization"
+
+	defaultOAuth2RefreshBefore = 30 * time.Second
+	oauth2TokenTimeout         = 10 * time.Second
+)
+
+// oauth2HTTPClient fetches the OAuth2 tokens.
+var oauth2HTTPClient = &http.Client{Timeout: oauth2TokenTimeout}
+
+// endpointTokenSource is the cached OAuth2 token of an HTTP endpoint.
+type endpointTokenSource struct {
+	// config is the configuration the token source was built from.
+	config        string
+	ccConfig      *clientcredentials.Config
+	refreshBefore time.Duration
+
+	lock  sync.Mutex
+	token *oauth2.Token
+}
+
+// Token returns the cached token, or fetches a new one if it expires within
+// the refresh window. Concurrent callers wait for the same fetch.
+func (s *endpointTokenSource) Token(ctx context.Context) (*oauth2.Token, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.token != nil && (s.token.Expiry.IsZero() || time.Until(s.token.Expiry) > s.refreshBefore) {
+		return s.token, nil
+	}
+
+	token, err := s.ccConfig.Token(context.WithValue(ctx, oauth2.HTTPClient, oauth2HTTPClient))
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+// endpointTokenSources caches the OAuth2 token sources of HTTP endpoints, so
+// that tokens are shared by all requests and refreshed before they expire.
+type endpointTokenSources struct {
+	lock    sync.Mutex
+	sources map[string]*endpointTokenSource
+}
+
+// get returns the token source of an HTTP endpoint, creating it if needed or
+// if the endpoint's OAuth2 configuration changed.
+func (e *endpointTokenSources) get(endpoint httpEndpointV1alpha1.HTTPEndpoint) (*endpointTokenSource, error) {
+	cfg := endpoint.Spec.Authentication.OAuth2
+	refreshBefore := defaultOAuth2RefreshBefore
+	if cfg.RefreshBefore != "" {
+		var err error
+		refreshBefore, err = time.ParseDuration(cfg.RefreshBefore)
+		if err != nil || refreshBefore < 0 {
+			return nil, fmt.Errorf("invalid oauth2 refreshBefore %s for http endpoint %s", cfg.RefreshBefore, endpoint.Name)
+		}
+	}
+	if cfg.TokenURL == "" {
+		return nil, fmt.Errorf("missing oauth2 tokenUrl for http endpoint %s", endpoint.Name)
+	}
+
+	ccConfig := &clientcredentials.Config{
+		ClientID:     cfg.ClientID.String(),
+		ClientSecret: cfg.ClientSecret.String(),
+		TokenURL:     cfg.TokenURL,
+		Scopes:       cfg.Scopes,
+	}
+	if cfg.Audience != "" {
+		ccConfig.EndpointParams = map[string][]string{"audience": {cfg.Audience}}
+	}
+	key := fmt.Sprintf("%s|%s|%s|%v|%s|%s", ccConfig.TokenURL, ccConfig.ClientID, ccConfig.ClientSecret, ccConfig.Scopes, cfg.Audience, refreshBefore)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if ts, ok := e.sources[endpoint.Name]; ok && ts.config == key {
+		return ts, nil
+	}
+
+	ts := &endpointTokenSource{
+		config:        key,
+		ccConfig:      ccConfig,
+		refreshBefore: refreshBefore,
+	}
+	if e.sources == nil {
+		e.sources = make(map[string]*endpointTokenSource)
+	}
+	e.sources[endpoint.Name] = ts
+	return ts, nil
+}
+
+// addHTTPEndpointAuthorization sets the Authorization header of a request to
+// an HTTP endpoint that uses OAuth2 client credentials, replacing any
+// Authorization header sent by the caller.
+func (d *directMessaging) addHTTPEndpointAuthorization(ctx context.Context, appID string, req *invokev1.InvokeMethodRequest) error {
+	endpoint, ok := d.compStore.GetHTTPEndpoint(appID)
+	if !ok || !endpoint.HasOAuth2() {
+		return nil
+	}
+
+	ts, err := d.endpointTokens.get(endpoint)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	token, err := ts.Token(ctx)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "failed to get oauth2 token for http endpoint %s: %v", appID, err)
+	}
+
+	md := req.Metadata()
+	for k := range md {
+		if strings.EqualFold(k, authorizationHeader) {
+			delete(md, k)
+		}
+	}
+	req.WithCustomHTTPMetadata(map[string]string{
+		authorizationHeader: token.Type() + " " + token.AccessToken,
+	})
+	return nil
+}
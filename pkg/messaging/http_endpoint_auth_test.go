@@ -0,0 +1,171 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apiextensionsV1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonapi "github.com/dapr/dapr/pkg/apis/common"
+	httpEndpointV1alpha1 "github.com/dapr/dapr/pkg/apis/httpEndpoint/v1alpha1"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+)
+
+func newTokenServer(t *testing.T, expiresIn int, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		user, pass, _ := r.BasicAuth()
+		if user != "myclient" || pass != "mysecret" || r.Form.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+	}))
+}
+
+func newOAuth2Endpoint(tokenURL, clientSecret string) httpEndpointV1alpha1.HTTPEndpoint {
+	return httpEndpointV1alpha1.HTTPEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "myendpoint"},
+		Spec: httpEndpointV1alpha1.HTTPEndpointSpec{
+			BaseURL: "http://localhost:1234",
+			Authentication: &httpEndpointV1alpha1.Authentication{
+				OAuth2: &httpEndpointV1alpha1.OAuth2{
+					TokenURL: tokenURL,
+					ClientID: httpEndpointV1alpha1.SecretValue{
+						Value: &commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte(`"myclient"`)}},
+					},
+					ClientSecret: httpEndpointV1alpha1.SecretValue{
+						Value: &commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte(clientSecret)}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAddHTTPEndpointAuthorization(t *testing.T) {
+	authorization := func(req *invokev1.InvokeMethodRequest) []string {
+		var values []string
+		for k, v := range req.Metadata() {
+			if k == authorizationHeader || k == "authorization" {
+				values = append(values, v.GetValues()...)
+			}
+		}
+		return values
+	}
+
+	t.Run("token is cached and replaces the caller's header", func(t *testing.T) {
+		var calls atomic.Int32
+		server := newTokenServer(t, 3600, &calls)
+		defer server.Close()
+
+		store := compstore.New()
+		store.AddHTTPEndpoint(newOAuth2Endpoint(server.URL, "mysecret"))
+		dm := &directMessaging{compStore: store}
+
+		for i := 0; i < 2; i++ {
+			req := invokev1.NewInvokeMethodRequest("method").
+				WithHTTPHeaders(http.Header{"authorization": []string{"Bearer fromcaller"}})
+			require.NoError(t, dm.addHTTPEndpointAuthorization(context.Background(), "myendpoint", req))
+			assert.Equal(t, []string{"Bearer token1"}, authorization(req))
+			req.Close()
+		}
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("token is refreshed before it expires", func(t *testing.T) {
+		var calls atomic.Int32
+		// Tokens expire in 10s, which is within the default 30s refresh window.
+		server := newTokenServer(t, 10, &calls)
+		defer server.Close()
+
+		store := compstore.New()
+		store.AddHTTPEndpoint(newOAuth2Endpoint(server.URL, "mysecret"))
+		dm := &directMessaging{compStore: store}
+
+		for i := 1; i <= 2; i++ {
+			req := invokev1.NewInvokeMethodRequest("method")
+			require.NoError(t, dm.addHTTPEndpointAuthorization(context.Background(), "myendpoint", req))
+			assert.Equal(t, []string{fmt.Sprintf("Bearer token%d", i)}, authorization(req))
+			req.Close()
+		}
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		var calls atomic.Int32
+		server := newTokenServer(t, 3600, &calls)
+		defer server.Close()
+
+		store := compstore.New()
+		store.AddHTTPEndpoint(newOAuth2Endpoint(server.URL, "wrong"))
+		dm := &directMessaging{compStore: store}
+
+		req := invokev1.NewInvokeMethodRequest("method")
+		defer req.Close()
+		err := dm.addHTTPEndpointAuthorization(context.Background(), "myendpoint", req)
+		require.Error(t, err)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Empty(t, authorization(req))
+	})
+
+	t.Run("token fetch uses the request context", func(t *testing.T) {
+		var calls atomic.Int32
+		server := newTokenServer(t, 3600, &calls)
+		defer server.Close()
+
+		store := compstore.New()
+		store.AddHTTPEndpoint(newOAuth2Endpoint(server.URL, "mysecret"))
+		dm := &directMessaging{compStore: store}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := invokev1.NewInvokeMethodRequest("method")
+		defer req.Close()
+		err := dm.addHTTPEndpointAuthorization(ctx, "myendpoint", req)
+		require.Error(t, err)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Equal(t, int32(0), calls.Load())
+	})
+
+	t.Run("endpoint without oauth2", func(t *testing.T) {
+		store := compstore.New()
+		store.AddHTTPEndpoint(httpEndpointV1alpha1.HTTPEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Name: "myendpoint"},
+			Spec:       httpEndpointV1alpha1.HTTPEndpointSpec{BaseURL: "http://localhost:1234"},
+		})
+		dm := &directMessaging{compStore: store}
+
+		req := invokev1.NewInvokeMethodRequest("method").
+			WithHTTPHeaders(http.Header{"Authorization": []string{"Bearer fromcaller"}})
+		defer req.Close()
+		require.NoError(t, dm.addHTTPEndpointAuthorization(context.Background(), "myendpoint", req))
+		assert.Equal(t, []string{"Bearer fromcaller"}, authorization(req))
+	})
+}
@@ -0,0 +1,418 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kclock "k8s.io/utils/clock"
+
+	"github.com/dapr/dapr/pkg/config"
+	diag "github.com/dapr/dapr/pkg/diagnostics"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+)
+
+const (
+	defaultInvocationCacheSizeMB = 64
+
+	cacheResultHit         = "hit"
+	cacheResultMiss        = "miss"
+	cacheResultRevalidated = "revalidated"
+)
+
+type invokeFn func(ctx context.Context, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, error)
+
+// cacheRule is the set of methods of an app whose responses are cached.
+type cacheRule struct {
+	allMethods bool
+	methods    []string
+}
+
+// responseCache keeps the responses to GET requests sent to other apps, as
+// allowed by their Cache-Control, Expires, ETag and Last-Modified headers.
+// Entries are evicted in LRU order once the size budget is exceeded.
+type responseCache struct {
+	rules        map[string]*cacheRule
+	maxSize      int
+	maxEntrySize int
+	clock        kclock.Clock
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	size    int
+}
+
+// cachedResponse is a response in the cache.
+type cachedResponse struct {
+	key         string
+	contentType string
+	header      http.Header
+	body        []byte
+	// Request header values the response varies on, keyed by lowercase name.
+	vary map[string]string
+	// When the response was received or last revalidated.
+	date    time.Time
+	expires time.Time
+	size    int
+}
+
+// newResponseCache returns the cache for the given spec, or nil if there are
+// no rules. Invalid rules are logged and ignored.
+func newResponseCache(spec *config.InvocationCacheSpec) *responseCache {
+	if spec == nil || len(spec.Rules) == 0 {
+		return nil
+	}
+
+	maxSizeMB := spec.MaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultInvocationCacheSizeMB
+	}
+	c := &responseCache{
+		rules:   make(map[string]*cacheRule, len(spec.Rules)),
+		maxSize: maxSizeMB << 20,
+		clock:   &kclock.RealClock{},
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+	// A single response can't take more than a fraction of the cache.
+	c.maxEntrySize = c.maxSize / 8
+
+rules:
+	for _, r := range spec.Rules {
+		if r.AppID == "" {
+			log.Warn("Ignoring invocation cache rule without appId")
+			continue
+		}
+		for _, m := range r.Methods {
+			if _, err := path.Match(m, ""); err != nil {
+				log.Warnf("Ignoring invocation cache rule for app %s: invalid method pattern %q", r.AppID, m)
+				continue rules
+			}
+		}
+		rule, ok := c.rules[r.AppID]
+		if !ok {
+			rule = &cacheRule{}
+			c.rules[r.AppID] = rule
+		}
+		rule.allMethods = rule.allMethods || len(r.Methods) == 0
+		rule.methods = append(rule.methods, r.Methods...)
+	}
+	if len(c.rules) == 0 {
+		return nil
+	}
+	return c
+}
+
+// matches returns true if the response to the request may be cached.
+func (c *responseCache) matches(appID string, req *invokev1.InvokeMethodRequest) bool {
+	if c == nil {
+		return false
+	}
+	rule, ok := c.rules[appID]
+	if !ok || req.Message().GetHttpExtension().GetVerb() != commonv1pb.HTTPExtension_GET { //nolint:nosnakecase
+		return false
+	}
+	if rule.allMethods {
+		return true
+	}
+	method := strings.TrimPrefix(req.Message().GetMethod(), "/")
+	for _, m := range rule.methods {
+		if ok, _ := path.Match(strings.TrimPrefix(m, "/"), method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// invoke serves the request from the cache if there's a fresh response to it,
+// otherwise it invokes fn, revalidating the cached response if possible.
+func (c *responseCache) invoke(ctx context.Context, app remoteApp, req *invokev1.InvokeMethodRequest, fn invokeFn) (*invokev1.InvokeMethodResponse, error) {
+	md := req.Metadata()
+	reqCC := parseCacheControl(metadataValue(md, "cache-control"))
+	// Conditional requests from the app are its own business.
+	if reqCC.has("no-store") || metadataValue(md, "if-none-match") != "" || metadataValue(md, "if-modified-since") != "" {
+		return fn(ctx, req)
+	}
+
+	key := app.id + "." + app.namespace + "/" + req.Message().GetMethod() + "?" + req.EncodeHTTPQueryString()
+	now := c.clock.Now()
+	entry := c.get(key, md)
+	if entry != nil {
+		maxAge, hasMaxAge := reqCC.seconds("max-age")
+		if !reqCC.has("no-cache") && now.Before(entry.expires) && (!hasMaxAge || now.Sub(entry.date) <= maxAge) {
+			diag.DefaultMonitoring.ServiceInvocationCacheRequest(app.id, cacheResultHit)
+			return entry.response(now), nil
+		}
+
+		validators := map[string][]string{}
+		if etag := entry.header.Get("etag"); etag != "" {
+			validators["If-None-Match"] = []string{etag}
+		}
+		if lastModified := entry.header.Get("last-modified"); lastModified != "" {
+			validators["If-Modified-Since"] = []string{lastModified}
+		}
+		req.AddMetadata(validators)
+	}
+
+	resp, err := fn(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if entry != nil && resp.Status().GetCode() == http.StatusNotModified {
+		_ = resp.Close()
+		entry = c.refresh(entry, resp, now)
+		diag.DefaultMonitoring.ServiceInvocationCacheRequest(app.id, cacheResultRevalidated)
+		return entry.response(now), nil
+	}
+
+	diag.DefaultMonitoring.ServiceInvocationCacheRequest(app.id, cacheResultMiss)
+	return c.store(key, md, resp, now)
+}
+
+// get returns the entry for key if it matches the request's headers.
+func (c *responseCache) get(key string, md invokev1.DaprInternalMetadata) *cachedResponse {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := el.Value.(*cachedResponse)
+	for name, value := range entry.vary {
+		if metadataValue(md, name) != value {
+			return nil
+		}
+	}
+	c.lru.MoveToFront(el)
+	return entry
+}
+
+// store caches the response if it's allowed to, and returns the response to
+// pass on to the caller.
+func (c *responseCache) store(key string, md invokev1.DaprInternalMetadata, resp *invokev1.InvokeMethodResponse, now time.Time) (*invokev1.InvokeMethodResponse, error) {
+	if resp.Status().GetCode() != http.StatusOK || invokev1.IsStreamingContentType(resp.ContentType()) {
+		return resp, nil
+	}
+	header := make(http.Header, len(resp.Headers()))
+	for k, v := range resp.Headers() {
+		header[http.CanonicalHeaderKey(k)] = v.GetValues()
+	}
+	expires, ok := freshUntil(header, now)
+	if !ok {
+		return resp, nil
+	}
+	vary := map[string]string{}
+	for _, v := range header.Values("vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "*" {
+				return resp, nil
+			}
+			if name != "" {
+				vary[name] = metadataValue(md, name)
+			}
+		}
+	}
+
+	data := resp.RawData()
+	body, err := io.ReadAll(io.LimitReader(data, int64(c.maxEntrySize)+1))
+	if err != nil {
+		_ = resp.Close()
+		return nil, err
+	}
+	res, _ := invokev1.InternalInvokeResponse(resp.Proto())
+	if len(body) > c.maxEntrySize {
+		// Too large to be cached: the caller gets the rest of the stream, and
+		// closes the original response with it.
+		return res.WithRawData(&uncachedBody{
+			Reader: io.MultiReader(bytes.NewReader(body), data),
+			resp:   resp,
+		}), nil
+	}
+	_ = resp.Close()
+
+	entry := &cachedResponse{
+		key:         key,
+		contentType: resp.ContentType(),
+		header:      header,
+		body:        body,
+		vary:        vary,
+		date:        now,
+		expires:     expires,
+	}
+	entry.size = len(key) + len(body) + len(entry.contentType)
+	for k, v := range header {
+		entry.size += len(k)
+		for _, s := range v {
+			entry.size += len(s)
+		}
+	}
+	c.add(entry)
+
+	return res.WithRawDataBytes(body), nil
+}
+
+// uncachedBody is the body of a response that was not cached. Closing it
+// closes the response it is read from.
+type uncachedBody struct {
+	io.Reader
+	resp *invokev1.InvokeMethodResponse
+}
+
+func (b *uncachedBody) Close() error {
+	return b.resp.Close()
+}
+
+// refresh updates an entry with the headers of a 304 response, and returns
+// the entry to serve.
+func (c *responseCache) refresh(entry *cachedResponse, resp *invokev1.InvokeMethodResponse, now time.Time) *cachedResponse {
+	updated := *entry
+	updated.header = entry.header.Clone()
+	for k, v := range resp.Headers() {
+		updated.header[http.CanonicalHeaderKey(k)] = v.GetValues()
+	}
+	updated.date = now
+
+	expires, ok := freshUntil(updated.header, now)
+	updated.expires = expires
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if el, found := c.entries[entry.key]; found && el.Value == entry {
+		if ok {
+			el.Value = &updated
+		} else {
+			c.remove(el)
+		}
+	}
+	return &updated
+}
+
+// add inserts the entry in the cache, evicting the least recently used ones
+// to stay within the size budget.
+func (c *responseCache) add(entry *cachedResponse) {
+	if entry.size > c.maxEntrySize {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if el, ok := c.entries[entry.key]; ok {
+		c.remove(el)
+	}
+	for c.size+entry.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.size += entry.size
+}
+
+func (c *responseCache) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*cachedResponse)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// response returns a new response with the cached data.
+func (e *cachedResponse) response(now time.Time) *invokev1.InvokeMethodResponse {
+	header := e.header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(now.Sub(e.date)/time.Second), 10))
+	return invokev1.NewInvokeMethodResponse(http.StatusOK, http.StatusText(http.StatusOK), nil).
+		WithHTTPHeaders(header).
+		WithContentType(e.contentType).
+		WithRawDataBytes(e.body)
+}
+
+// freshUntil returns when a response with the given headers becomes stale,
+// and false if the response can't be cached.
+// Responses that must always be revalidated are stale right away.
+func freshUntil(header http.Header, now time.Time) (time.Time, bool) {
+	cc := parseCacheControl(strings.Join(header.Values("cache-control"), ","))
+	if cc.has("no-store") {
+		return time.Time{}, false
+	}
+
+	var expires time.Time
+	if maxAge, ok := cc.seconds("max-age"); ok && !cc.has("no-cache") {
+		expires = now.Add(maxAge)
+	} else if exp := header.Get("expires"); exp != "" && !cc.has("no-cache") {
+		// Invalid dates, such as "0", mean the response is already expired.
+		if t, err := http.ParseTime(exp); err == nil {
+			if date, err := http.ParseTime(header.Get("date")); err == nil {
+				expires = now.Add(t.Sub(date))
+			} else {
+				expires = t
+			}
+		}
+	}
+
+	validated := header.Get("etag") != "" || header.Get("last-modified") != ""
+	if !expires.After(now) && !validated {
+		return time.Time{}, false
+	}
+	return expires, true
+}
+
+// cacheControl holds the directives of a Cache-Control header.
+type cacheControl map[string]string
+
+func parseCacheControl(value string) cacheControl {
+	cc := cacheControl{}
+	for _, d := range strings.Split(value, ",") {
+		name, val, _ := strings.Cut(strings.TrimSpace(d), "=")
+		if name != "" {
+			cc[strings.ToLower(name)] = strings.Trim(val, `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	n, err := strconv.ParseInt(cc[directive], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// metadataValue returns the values of a header, joined by commas.
+// Header names are case-insensitive.
+func metadataValue(md invokev1.DaprInternalMetadata, name string) string {
+	var values []string
+	for k, v := range md {
+		if strings.EqualFold(k, name) {
+			values = append(values, v.GetValues()...)
+		}
+	}
+	return strings.Join(values, ", ")
+}
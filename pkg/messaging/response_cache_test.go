@@ -0,0 +1,260 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package messaging
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/dapr/pkg/config"
+	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
+)
+
+func newTestResponseCache(t *testing.T) (*responseCache, *clocktesting.FakeClock) {
+	c := newResponseCache(&config.InvocationCacheSpec{
+		MaxSizeMB: 1,
+		Rules: []config.InvocationCacheRule{
+			{AppID: "catalog", Methods: []string{"items/*"}},
+			{AppID: "prices"},
+			{AppID: "invalid", Methods: []string{"["}},
+		},
+	})
+	require.NotNil(t, c)
+	clock := clocktesting.NewFakeClock(time.Now())
+	c.clock = clock
+	return c, clock
+}
+
+func newCacheTestRequest(method string, md map[string][]string) *invokev1.InvokeMethodRequest {
+	return invokev1.NewInvokeMethodRequest(method).
+		WithHTTPExtension(http.MethodGet, "").
+		WithMetadata(md)
+}
+
+// cacheTestApp is an invokeFn that counts the requests, and replies with the
+// given response, or with 304 if the request has a matching If-None-Match.
+type cacheTestApp struct {
+	calls  int
+	header http.Header
+	body   string
+	lastMD invokev1.DaprInternalMetadata
+	// bodiesClosed counts the response bodies that were closed.
+	bodiesClosed int
+}
+
+type cacheTestBody struct {
+	*strings.Reader
+	app *cacheTestApp
+}
+
+func (b *cacheTestBody) Close() error {
+	b.app.bodiesClosed++
+	return nil
+}
+
+func (a *cacheTestApp) invoke(ctx context.Context, req *invokev1.InvokeMethodRequest) (*invokev1.InvokeMethodResponse, error) {
+	a.calls++
+	a.lastMD = req.Metadata()
+	if etag := a.header.Get("ETag"); etag != "" && metadataValue(req.Metadata(), "if-none-match") == etag {
+		return invokev1.NewInvokeMethodResponse(http.StatusNotModified, "", nil).
+			WithHTTPHeaders(http.Header{"Cache-Control": a.header.Values("Cache-Control")}), nil
+	}
+	return invokev1.NewInvokeMethodResponse(http.StatusOK, "", nil).
+		WithHTTPHeaders(a.header).
+		WithContentType("text/plain").
+		WithRawData(&cacheTestBody{Reader: strings.NewReader(a.body), app: a}), nil
+}
+
+func readCachedResponse(t *testing.T, resp *invokev1.InvokeMethodResponse) string {
+	t.Helper()
+	defer resp.Close()
+	assert.Equal(t, int32(http.StatusOK), resp.Status().GetCode())
+	data, err := resp.RawDataFull()
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestNewResponseCache(t *testing.T) {
+	assert.Nil(t, newResponseCache(nil))
+	assert.Nil(t, newResponseCache(&config.InvocationCacheSpec{}))
+	assert.Nil(t, newResponseCache(&config.InvocationCacheSpec{Rules: []config.InvocationCacheRule{{}}}))
+
+	c := newResponseCache(&config.InvocationCacheSpec{Rules: []config.InvocationCacheRule{{AppID: "app"}}})
+	require.NotNil(t, c)
+	assert.Equal(t, defaultInvocationCacheSizeMB<<20, c.maxSize)
+
+	c, _ = newTestResponseCache(t)
+	assert.Len(t, c.rules, 2)
+}
+
+func TestResponseCacheMatches(t *testing.T) {
+	c, _ := newTestResponseCache(t)
+
+	assert.True(t, c.matches("catalog", newCacheTestRequest("items/42", nil)))
+	assert.True(t, c.matches("catalog", newCacheTestRequest("/items/42", nil)))
+	assert.False(t, c.matches("catalog", newCacheTestRequest("orders/42", nil)))
+	assert.True(t, c.matches("prices", newCacheTestRequest("anything", nil)))
+	assert.False(t, c.matches("invalid", newCacheTestRequest("anything", nil)))
+	assert.False(t, c.matches("prices", invokev1.NewInvokeMethodRequest("anything").WithHTTPExtension(http.MethodPost, "")))
+
+	var nilCache *responseCache
+	assert.False(t, nilCache.matches("prices", newCacheTestRequest("anything", nil)))
+}
+
+func TestResponseCacheInvoke(t *testing.T) {
+	app := remoteApp{id: "prices", namespace: "default"}
+
+	t.Run("fresh responses are served from the cache", func(t *testing.T) {
+		c, clock := newTestResponseCache(t)
+		target := &cacheTestApp{header: http.Header{"Cache-Control": {"max-age=60"}}, body: "v1"}
+
+		for i := 0; i < 3; i++ {
+			resp, err := c.invoke(context.Background(), app, newCacheTestRequest("price", nil), target.invoke)
+			require.NoError(t, err)
+			assert.Equal(t, "v1", readCachedResponse(t, resp))
+		}
+		assert.Equal(t, 1, target.calls)
+
+		clock.Step(10 * time.Second)
+		resp, err := c.invoke(context.Background(), app, newCacheTestRequest("price", nil), target.invoke)
+		require.NoError(t, err)
+		assert.Equal(t, "10", resp.Headers()["Age"].GetValues()[0])
+		assert.Equal(t, "text/plain", resp.ContentType())
+		assert.Equal(t, "v1", readCachedResponse(t, resp))
+
+		clock.Step(time.Minute)
+		target.body = "v2"
+		resp, err = c.invoke(context.Background(), app, newCacheTestRequest("price", nil), target.invoke)
+		require.NoError(t, err)
+		assert.Equal(t, "v2", readCachedResponse(t, resp))
+		assert.Equal(t, 2, target.calls)
+	})
+
+	t.Run("stale responses are revalidated", func(t *testing.T) {
+		c, _ := newTestResponseCache(t)
+		target := &cacheTestApp{header: http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"abc"`}}, body: "v1"}
+
+		resp, err := c.invoke(context.Background(), app, newCacheTestRequest("price", nil), target.invoke)
+		require.NoError(t, err)
+		assert.Equal(t, "v1", readCachedResponse(t, resp))
+
+		resp, err = c.invoke(context.Background(), app, newCacheTestRequest("price", nil), target.invoke)
+		require.NoError(t, err)
+		assert.Equal(t, "v1", readCachedResponse(t, resp))
+		assert.Equal(t, 2, target.calls)
+		assert.Equal(t, `"abc"`, metadataValue(target.lastMD, "if-none-match"))
+
+		// A new version replaces the cached one
+		target.header.Set("ETag", `"def"`)
+		target.body = "v2"
+		resp, err = c.invoke(context.Background(), app, newCacheTestRequest("price", nil), target.invoke)
+		require.NoError(t, err)
+		assert.Equal(t, "v2", readCachedResponse(t, resp))
+		assert.Len(t, c.entries, 1)
+	})
+
+	t.Run("request directives", func(t *testing.T) {
+		c, _ := newTestResponseCache(t)
+		target := &cacheTestApp{header: http.Header{"Cache-Control": {"max-age=60"}, "Etag": {`"abc"`}}, body: "v1"}
+
+		resp, err := c.invoke(context.Background(), app, newCacheTestRequest("price", nil), target.invoke)
+		require.NoError(t, err)
+		readCachedResponse(t, resp)
+
+		// no-cache forces a revalidation
+		resp, err = c.invoke(context.Background(), app, newCacheTestRequest("price", map[string][]string{"Cache-Control": {"no-cache"}}), target.invoke)
+		require.NoError(t, err)
+		readCachedResponse(t, resp)
+		assert.Equal(t, 2, target.calls)
+
+		// no-store and the app's own conditional requests bypass the cache
+		resp, err = c.invoke(context.Background(), app, newCacheTestRequest("price", map[string][]string{"Cache-Control": {"no-store"}}), target.invoke)
+		require.NoError(t, err)
+		readCachedResponse(t, resp)
+		resp, err = c.invoke(context.Background(), app, newCacheTestRequest("price", map[string][]string{"If-None-Match": {`"abc"`}}), target.invoke)
+		require.NoError(t, err)
+		assert.Equal(t, int32(http.StatusNotModified), resp.Status().GetCode())
+		assert.Equal(t, 4, target.calls)
+	})
+
+	t.Run("uncacheable responses", func(t *testing.T) {
+		c, _ := newTestResponseCache(t)
+		for _, h := range []http.Header{
+			{},
+			{"Cache-Control": {"no-store, max-age=60"}},
+			{"Cache-Control": {"max-age=60"}, "Vary": {"*"}},
+			{"Cache-Control": {"no-cache"}},
+			{"Expires": {"0"}},
+		} {
+			target := &cacheTestApp{header: h, body: "v1"}
+			for i := 0; i < 2; i++ {
+				resp, err := c.invoke(context.Background(), app, newCacheTestRequest("price", nil), target.invoke)
+				require.NoError(t, err)
+				assert.Equal(t, "v1", readCachedResponse(t, resp))
+			}
+			assert.Equal(t, 2, target.calls, h)
+		}
+		assert.Empty(t, c.entries)
+	})
+
+	t.Run("vary", func(t *testing.T) {
+		c, _ := newTestResponseCache(t)
+		target := &cacheTestApp{header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept-Language"}}, body: "hello"}
+
+		en := map[string][]string{"accept-language": {"en"}}
+		resp, err := c.invoke(context.Background(), app, newCacheTestRequest("price", en), target.invoke)
+		require.NoError(t, err)
+		readCachedResponse(t, resp)
+		resp, err = c.invoke(context.Background(), app, newCacheTestRequest("price", en), target.invoke)
+		require.NoError(t, err)
+		readCachedResponse(t, resp)
+		assert.Equal(t, 1, target.calls)
+
+		resp, err = c.invoke(context.Background(), app, newCacheTestRequest("price", map[string][]string{"accept-language": {"it"}}), target.invoke)
+		require.NoError(t, err)
+		readCachedResponse(t, resp)
+		assert.Equal(t, 2, target.calls)
+	})
+
+	t.Run("size budget", func(t *testing.T) {
+		c, _ := newTestResponseCache(t)
+		// Large responses are passed through in full without being cached
+		large := &cacheTestApp{header: http.Header{"Cache-Control": {"max-age=60"}}, body: strings.Repeat("x", c.maxEntrySize+1)}
+		resp, err := c.invoke(context.Background(), app, newCacheTestRequest("large", nil), large.invoke)
+		require.NoError(t, err)
+		assert.Equal(t, large.body, readCachedResponse(t, resp))
+		assert.Equal(t, 1, large.bodiesClosed)
+		assert.Empty(t, c.entries)
+
+		// The least recently used entries are evicted
+		body := strings.Repeat("x", c.maxEntrySize-1000)
+		target := &cacheTestApp{header: http.Header{"Cache-Control": {"max-age=60"}}, body: body}
+		for i := 0; i < 9; i++ {
+			resp, err = c.invoke(context.Background(), app, newCacheTestRequest("item", nil).WithHTTPExtension(http.MethodGet, "id="+string(rune('0'+i))), target.invoke)
+			require.NoError(t, err)
+			readCachedResponse(t, resp)
+		}
+		assert.Len(t, c.entries, 8)
+		assert.LessOrEqual(t, c.size, c.maxSize)
+		assert.NotContains(t, c.entries, "prices.default/item?id=0")
+		assert.Contains(t, c.entries, "prices.default/item?id=8")
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/valyala/fasthttp"
+	"golang.org/x/net/http/httpguts"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 
@@ -282,6 +283,22 @@ func (imr *InvokeMethodRequest) ContentType() string {
 	return m.GetContentType()
 }
 
+// IsUpgrade returns true if the request asks to switch to another protocol,
+// such as WebSocket, with the HTTP "Upgrade" mechanism.
+// The request's data then flows over the upgraded connection.
+func (imr *InvokeMethodRequest) IsUpgrade() bool {
+	var connection, upgrade []string
+	for k, v := range imr.r.Metadata {
+		switch {
+		case strings.EqualFold(k, "connection"):
+			connection = append(connection, v.GetValues()...)
+		case strings.EqualFold(k, "upgrade"):
+			upgrade = append(upgrade, v.GetValues()...)
+		}
+	}
+	return len(upgrade) > 0 && httpguts.HeaderValuesContainsToken(connection, "upgrade")
+}
+
 // RawData returns the stream body.
 // Note: this method is not safe for concurrent use.
 func (imr *InvokeMethodRequest) RawData() (r io.Reader) {
@@ -443,6 +443,27 @@ func TestAddHeaders(t *testing.T) {
 	})
 }
 
+func TestIsUpgrade(t *testing.T) {
+	tests := []struct {
+		name string
+		md   map[string][]string
+		want bool
+	}{
+		{name: "no headers", md: nil, want: false},
+		{name: "websocket", md: map[string][]string{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}}, want: true},
+		{name: "lowercase keys and token list", md: map[string][]string{"connection": {"keep-alive, upgrade"}, "upgrade": {"websocket"}}, want: true},
+		{name: "missing upgrade header", md: map[string][]string{"Connection": {"Upgrade"}}, want: false},
+		{name: "missing connection token", md: map[string][]string{"Connection": {"keep-alive"}, "Upgrade": {"websocket"}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := NewInvokeMethodRequest("test_method").WithMetadata(tt.md)
+			defer req.Close()
+			assert.Equal(t, tt.want, req.IsUpgrade())
+		})
+	}
+}
+
 func TestWithCustomHTTPMetadata(t *testing.T) {
 	customMetadataKey := func(i int) string {
 		return fmt.Sprintf("customMetadataKey%d", i)
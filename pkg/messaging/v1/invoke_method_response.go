@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"net/http"
 	"strings"
 
 	"github.com/valyala/fasthttp"
@@ -157,6 +158,12 @@ func (imr *InvokeMethodResponse) IsHTTPResponse() bool {
 	return imr.r.Status.Code >= 100
 }
 
+// IsUpgrade returns true if the app accepted to switch protocols, so the
+// response's data is what the app sends over the upgraded connection.
+func (imr *InvokeMethodResponse) IsUpgrade() bool {
+	return imr.r != nil && imr.r.Status.Code == http.StatusSwitchingProtocols
+}
+
 // Proto returns the internal InvokeMethodResponse Proto object.
 func (imr *InvokeMethodResponse) Proto() *internalv1pb.InternalInvokeResponse {
 	return imr.r
@@ -407,6 +407,16 @@ func TestIsHTTPResponse(t *testing.T) {
 	})
 }
 
+func TestResponseIsUpgrade(t *testing.T) {
+	imr := NewInvokeMethodResponse(http.StatusSwitchingProtocols, "", nil)
+	defer imr.Close()
+	assert.True(t, imr.IsUpgrade())
+
+	ok := NewInvokeMethodResponse(http.StatusOK, "OK", nil)
+	defer ok.Close()
+	assert.False(t, ok.IsUpgrade())
+}
+
 func TestResponseReplayable(t *testing.T) {
 	const message = "Nel mezzo del cammin di nostra vita mi ritrovai per una selva oscura, che' la diritta via era smarrita."
 	newReplayable := func() *InvokeMethodResponse {
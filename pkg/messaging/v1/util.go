@@ -47,6 +47,10 @@ const (
 	ProtobufContentType = "application/x-protobuf"
 	// OctetStreamContentType is the MIME media type for arbitrary binary data.
 	OctetStreamContentType = "application/octet-stream"
+	// EventStreamContentType is the MIME media type for server-sent events.
+	EventStreamContentType = "text/event-stream"
+	// NDJSONContentType is the MIME media type for newline-delimited JSON.
+	NDJSONContentType = "application/x-ndjson"
 
 	// ContentTypeHeader is the header key of content-type.
 	ContentTypeHeader = "content-type"
@@ -97,6 +101,15 @@ func IsJSONContentType(contentType string) bool {
 	return strings.HasPrefix(strings.ToLower(contentType), JSONContentType)
 }
 
+// IsStreamingContentType returns true if the content type is used for
+// responses that are written over time, such as server-sent events, and which
+// must be forwarded chunk by chunk.
+func IsStreamingContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.HasPrefix(contentType, EventStreamContentType) ||
+		strings.HasPrefix(contentType, NDJSONContentType)
+}
+
 // metadataToInternalMetadata converts metadata to Dapr internal metadata map.
 func metadataToInternalMetadata(md map[string][]string) DaprInternalMetadata {
 	internalMD := make(DaprInternalMetadata, len(md))
@@ -98,6 +98,14 @@ func TestIsJSONContentType(t *testing.T) {
 	}
 }
 
+func TestIsStreamingContentType(t *testing.T) {
+	assert.True(t, IsStreamingContentType("text/event-stream"))
+	assert.True(t, IsStreamingContentType("Text/Event-Stream; charset=utf-8"))
+	assert.True(t, IsStreamingContentType("application/x-ndjson"))
+	assert.False(t, IsStreamingContentType("application/json"))
+	assert.False(t, IsStreamingContentType(""))
+}
+
 func TestInternalMetadataToGrpcMetadata(t *testing.T) {
 	httpHeaders := map[string]*internalv1pb.ListStringValue{
 		"Host": {
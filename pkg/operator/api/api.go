@@ -309,6 +309,20 @@ func processHTTPEndpointSecrets(ctx context.Context, endpoint *httpendpointsapi.
 		endpoint.Spec.ClientTLS.RootCA.Value = &v
 	}
 
+	if endpoint.HasOAuth2() {
+		oauth2 := endpoint.Spec.Authentication.OAuth2
+		for _, sv := range []*httpendpointsapi.SecretValue{&oauth2.ClientID, &oauth2.ClientSecret} {
+			if sv.HasSecret() && pairNeedsSecretExtraction(*sv.SecretKeyRef, endpoint.Auth) {
+				v, err := getSecret(ctx, sv.SecretKeyRef.Name, namespace, *sv.SecretKeyRef, kubeClient)
+				if err != nil {
+					return err
+				}
+
+				sv.Value = &v
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -527,10 +541,13 @@ func (a *apiServer) HTTPEndpointUpdate(in *operatorv1pb.HTTPEndpointUpdateReques
 			return
 		}
 
-		err := processHTTPEndpointSecrets(ctx, e, in.Namespace, a.Client)
-		if err != nil {
-			log.Warnf("error processing http endpoint %s secrets from pod %s/%s: %s", e.Name, in.Namespace, in.PodName, err)
-			return
+		// Secrets of deleted endpoints may be gone already, and are not needed.
+		if e.DeletionTimestamp == nil {
+			err := processHTTPEndpointSecrets(ctx, e, in.Namespace, a.Client)
+			if err != nil {
+				log.Warnf("error processing http endpoint %s secrets from pod %s/%s: %s", e.Name, in.Namespace, in.PodName, err)
+				return
+			}
 		}
 		b, err := json.Marshal(&e)
 		if err != nil {
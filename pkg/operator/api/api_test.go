@@ -24,6 +24,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsV1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -636,6 +637,52 @@ func TestProcessHTTPEndpointSecrets(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, jsonEnc, e.Spec.Headers[0].Value.Raw)
 	})
+
+	t.Run("oauth2 client secret ref, kubernetes secret store, secret extracted", func(t *testing.T) {
+		oe := httpendpointapi.HTTPEndpoint{
+			Spec: httpendpointapi.HTTPEndpointSpec{
+				BaseURL: "http://test.com/",
+				Authentication: &httpendpointapi.Authentication{
+					OAuth2: &httpendpointapi.OAuth2{
+						TokenURL: "http://test.com/token",
+						ClientID: httpendpointapi.SecretValue{
+							Value: &commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte(`"myclient"`)}},
+						},
+						ClientSecret: httpendpointapi.SecretValue{
+							SecretKeyRef: &commonapi.SecretKeyRef{Name: "secret1", Key: "key1"},
+						},
+					},
+				},
+			},
+		}
+		s := runtime.NewScheme()
+		err := scheme.AddToScheme(s)
+		assert.NoError(t, err)
+
+		err = corev1.AddToScheme(s)
+		assert.NoError(t, err)
+
+		client := fake.NewClientBuilder().
+			WithScheme(s).
+			WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret1",
+					Namespace: "default",
+				},
+				Data: map[string][]byte{
+					"key1": []byte("value1"),
+				},
+			}).
+			Build()
+
+		assert.NoError(t, processHTTPEndpointSecrets(context.Background(), &oe, "default", client))
+
+		enc := base64.StdEncoding.EncodeToString([]byte("value1"))
+		jsonEnc, err := json.Marshal(enc)
+		assert.NoError(t, err)
+		assert.Equal(t, jsonEnc, oe.Spec.Authentication.OAuth2.ClientSecret.Value.Raw)
+		assert.Equal(t, "myclient", oe.Spec.Authentication.OAuth2.ClientID.String())
+	})
 }
 
 func Test_Ready(t *testing.T) {
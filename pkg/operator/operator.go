@@ -207,6 +207,27 @@ func (o *operator) syncHTTPEndpoint(ctx context.Context) func(obj interface{}) {
 	}
 }
 
+// deleteHTTPEndpoint notifies the sidecars of a deleted http endpoint, which
+// is sent to them with its deletion timestamp set.
+func (o *operator) deleteHTTPEndpoint(ctx context.Context) func(obj interface{}) {
+	return func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		e, ok := obj.(*httpendpointsapi.HTTPEndpoint)
+		if !ok {
+			return
+		}
+		log.Debugf("Observed http endpoint to be deleted: %s/%s", e.Namespace, e.Name)
+		e = e.DeepCopy()
+		if e.DeletionTimestamp == nil {
+			now := v1.Now()
+			e.DeletionTimestamp = &now
+		}
+		o.apiServer.OnHTTPEndpointUpdated(ctx, e)
+	}
+}
+
 func (o *operator) Run(ctx context.Context) error {
 	log.Info("Dapr Operator is starting")
 	healthzServer := health.NewServer(log)
@@ -339,6 +360,7 @@ func (o *operator) Run(ctx context.Context) error {
 				UpdateFunc: func(_, newObj interface{}) {
 					o.syncHTTPEndpoint(ctx)(newObj)
 				},
+				DeleteFunc: o.deleteHTTPEndpoint(ctx),
 			})
 			if rErr != nil {
 				return fmt.Errorf("unable to add http endpoint informer event handler: %w", rErr)
@@ -15,6 +15,7 @@ package outbox
 
 import (
 	"context"
+	"time"
 
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
@@ -24,6 +25,25 @@ import (
 type Outbox interface {
 	AddOrUpdateOutbox(stateStore v1alpha1.Component)
 	Enabled(stateStore string) bool
+	// PublishInternal publishes the set operations to the internal outbox topic
+	// and returns the full list of operations the caller must execute in the
+	// state transaction in place of the given ones: the operations without the
+	// projections, followed by the outbox transaction markers.
 	PublishInternal(ctx context.Context, stateStore string, states []state.TransactionalStateOperation, source string) ([]state.TransactionalStateOperation, error)
 	SubscribeToInternalTopics(ctx context.Context, appID string) error
+	Stores() []StoreConfig
+}
+
+// StoreConfig is the outbox configuration of a state store.
+type StoreConfig struct {
+	StateStore              string
+	PublishPubsub           string
+	PublishTopic            string
+	OutboxPubsub            string
+	DiscardWhenMissingState bool
+	// MaxVerificationAge is the age after which a message whose transaction
+	// cannot be verified is sent to DeadLetterTopic, or discarded if no
+	// dead-letter topic is configured. Zero means messages are retried forever.
+	MaxVerificationAge time.Duration
+	DeadLetterTopic    string
 }
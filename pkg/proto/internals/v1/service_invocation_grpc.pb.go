@@ -35,6 +35,7 @@ type ServiceInvocationClient interface {
 	// - Each message with a `payload` MUST contain a sequence number in `seq`, which is a counter that starts from 0 and MUST be incremented by 1 in each chunk. The `seq` counter MUST NOT be included if the message does not have a `payload`.
 	// - When the sender has completed sending the data, it MUST call `CloseSend` on the stream.
 	// The caller and callee must send at least one message in the stream. If only 1 message is sent in each direction, that message must contain both a `request`/`response` (the `payload` may be empty).
+	// Requests that upgrade the connection (for example, to WebSocket) are an exception: after the leading `request` message, the caller keeps sending the data it writes to the upgraded connection while it reads the response, until either side closes the stream.
 	CallLocalStream(ctx context.Context, opts ...grpc.CallOption) (ServiceInvocation_CallLocalStreamClient, error)
 }
 
@@ -112,6 +113,7 @@ type ServiceInvocationServer interface {
 	// - Each message with a `payload` MUST contain a sequence number in `seq`, which is a counter that starts from 0 and MUST be incremented by 1 in each chunk. The `seq` counter MUST NOT be included if the message does not have a `payload`.
 	// - When the sender has completed sending the data, it MUST call `CloseSend` on the stream.
 	// The caller and callee must send at least one message in the stream. If only 1 message is sent in each direction, that message must contain both a `request`/`response` (the `payload` may be empty).
+	// Requests that upgrade the connection (for example, to WebSocket) are an exception: after the leading `request` message, the caller keeps sending the data it writes to the upgraded connection while it reads the response, until either side closes the stream.
 	CallLocalStream(ServiceInvocation_CallLocalStreamServer) error
 }
 
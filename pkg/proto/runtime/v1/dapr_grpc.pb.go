@@ -33,14 +33,22 @@ type DaprClient interface {
 	GetBulkState(ctx context.Context, in *GetBulkStateRequest, opts ...grpc.CallOption) (*GetBulkStateResponse, error)
 	// Saves the state for a specific key.
 	SaveState(ctx context.Context, in *SaveStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Saves multiple states, reporting the result of each one.
+	SaveBulkStateAlpha1(ctx context.Context, in *SaveStateRequest, opts ...grpc.CallOption) (*BulkStateWriteResponse, error)
 	// Queries the state.
 	QueryStateAlpha1(ctx context.Context, in *QueryStateRequest, opts ...grpc.CallOption) (*QueryStateResponse, error)
+	// Streams the changes of keys in a state store.
+	SubscribeStateAlpha1(ctx context.Context, in *SubscribeStateRequest, opts ...grpc.CallOption) (Dapr_SubscribeStateAlpha1Client, error)
 	// Deletes the state for a specific key.
 	DeleteState(ctx context.Context, in *DeleteStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Deletes a bulk of state items for a list of keys
 	DeleteBulkState(ctx context.Context, in *DeleteBulkStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Deletes multiple states, reporting the result of each one.
+	DeleteBulkStateAlpha1(ctx context.Context, in *DeleteBulkStateRequest, opts ...grpc.CallOption) (*BulkStateWriteResponse, error)
 	// Executes transactions for a specified store
 	ExecuteStateTransaction(ctx context.Context, in *ExecuteStateTransactionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Executes transactions on multiple state stores, which are all committed or all rolled back.
+	ExecuteMultiStateTransactionAlpha1(ctx context.Context, in *ExecuteMultiStateTransactionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Publishes events to the specific topic.
 	PublishEvent(ctx context.Context, in *PublishEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Bulk Publishes multiple events to the specified topic.
@@ -179,6 +187,15 @@ func (c *daprClient) SaveState(ctx context.Context, in *SaveStateRequest, opts .
 	return out, nil
 }
 
+func (c *daprClient) SaveBulkStateAlpha1(ctx context.Context, in *SaveStateRequest, opts ...grpc.CallOption) (*BulkStateWriteResponse, error) {
+	out := new(BulkStateWriteResponse)
+	err := c.cc.Invoke(ctx, "/dapr.proto.runtime.v1.Dapr/SaveBulkStateAlpha1", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *daprClient) QueryStateAlpha1(ctx context.Context, in *QueryStateRequest, opts ...grpc.CallOption) (*QueryStateResponse, error) {
 	out := new(QueryStateResponse)
 	err := c.cc.Invoke(ctx, "/dapr.proto.runtime.v1.Dapr/QueryStateAlpha1", in, out, opts...)
@@ -188,6 +205,38 @@ func (c *daprClient) QueryStateAlpha1(ctx context.Context, in *QueryStateRequest
 	return out, nil
 }
 
+func (c *daprClient) SubscribeStateAlpha1(ctx context.Context, in *SubscribeStateRequest, opts ...grpc.CallOption) (Dapr_SubscribeStateAlpha1Client, error) {
+	stream, err := c.cc.NewStream(ctx, &Dapr_ServiceDesc.Streams[0], "/dapr.proto.runtime.v1.Dapr/SubscribeStateAlpha1", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daprSubscribeStateAlpha1Client{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Dapr_SubscribeStateAlpha1Client interface {
+	Recv() (*SubscribeStateResponse, error)
+	grpc.ClientStream
+}
+
+type daprSubscribeStateAlpha1Client struct {
+	grpc.ClientStream
+}
+
+func (x *daprSubscribeStateAlpha1Client) Recv() (*SubscribeStateResponse, error) {
+	m := new(SubscribeStateResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *daprClient) DeleteState(ctx context.Context, in *DeleteStateRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, "/dapr.proto.runtime.v1.Dapr/DeleteState", in, out, opts...)
@@ -206,6 +255,15 @@ func (c *daprClient) DeleteBulkState(ctx context.Context, in *DeleteBulkStateReq
 	return out, nil
 }
 
+func (c *daprClient) DeleteBulkStateAlpha1(ctx context.Context, in *DeleteBulkStateRequest, opts ...grpc.CallOption) (*BulkStateWriteResponse, error) {
+	out := new(BulkStateWriteResponse)
+	err := c.cc.Invoke(ctx, "/dapr.proto.runtime.v1.Dapr/DeleteBulkStateAlpha1", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *daprClient) ExecuteStateTransaction(ctx context.Context, in *ExecuteStateTransactionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, "/dapr.proto.runtime.v1.Dapr/ExecuteStateTransaction", in, out, opts...)
@@ -215,6 +273,15 @@ func (c *daprClient) ExecuteStateTransaction(ctx context.Context, in *ExecuteSta
 	return out, nil
 }
 
+func (c *daprClient) ExecuteMultiStateTransactionAlpha1(ctx context.Context, in *ExecuteMultiStateTransactionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dapr.proto.runtime.v1.Dapr/ExecuteMultiStateTransactionAlpha1", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *daprClient) PublishEvent(ctx context.Context, in *PublishEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, "/dapr.proto.runtime.v1.Dapr/PublishEvent", in, out, opts...)
@@ -342,7 +409,7 @@ func (c *daprClient) GetConfiguration(ctx context.Context, in *GetConfigurationR
 }
 
 func (c *daprClient) SubscribeConfigurationAlpha1(ctx context.Context, in *SubscribeConfigurationRequest, opts ...grpc.CallOption) (Dapr_SubscribeConfigurationAlpha1Client, error) {
-	stream, err := c.cc.NewStream(ctx, &Dapr_ServiceDesc.Streams[0], "/dapr.proto.runtime.v1.Dapr/SubscribeConfigurationAlpha1", opts...)
+	stream, err := c.cc.NewStream(ctx, &Dapr_ServiceDesc.Streams[1], "/dapr.proto.runtime.v1.Dapr/SubscribeConfigurationAlpha1", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -374,7 +441,7 @@ func (x *daprSubscribeConfigurationAlpha1Client) Recv() (*SubscribeConfiguration
 }
 
 func (c *daprClient) SubscribeConfiguration(ctx context.Context, in *SubscribeConfigurationRequest, opts ...grpc.CallOption) (Dapr_SubscribeConfigurationClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Dapr_ServiceDesc.Streams[1], "/dapr.proto.runtime.v1.Dapr/SubscribeConfiguration", opts...)
+	stream, err := c.cc.NewStream(ctx, &Dapr_ServiceDesc.Streams[2], "/dapr.proto.runtime.v1.Dapr/SubscribeConfiguration", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -442,7 +509,7 @@ func (c *daprClient) UnlockAlpha1(ctx context.Context, in *UnlockRequest, opts .
 }
 
 func (c *daprClient) EncryptAlpha1(ctx context.Context, opts ...grpc.CallOption) (Dapr_EncryptAlpha1Client, error) {
-	stream, err := c.cc.NewStream(ctx, &Dapr_ServiceDesc.Streams[2], "/dapr.proto.runtime.v1.Dapr/EncryptAlpha1", opts...)
+	stream, err := c.cc.NewStream(ctx, &Dapr_ServiceDesc.Streams[3], "/dapr.proto.runtime.v1.Dapr/EncryptAlpha1", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -473,7 +540,7 @@ func (x *daprEncryptAlpha1Client) Recv() (*EncryptResponse, error) {
 }
 
 func (c *daprClient) DecryptAlpha1(ctx context.Context, opts ...grpc.CallOption) (Dapr_DecryptAlpha1Client, error) {
-	stream, err := c.cc.NewStream(ctx, &Dapr_ServiceDesc.Streams[3], "/dapr.proto.runtime.v1.Dapr/DecryptAlpha1", opts...)
+	stream, err := c.cc.NewStream(ctx, &Dapr_ServiceDesc.Streams[4], "/dapr.proto.runtime.v1.Dapr/DecryptAlpha1", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -732,14 +799,22 @@ type DaprServer interface {
 	GetBulkState(context.Context, *GetBulkStateRequest) (*GetBulkStateResponse, error)
 	// Saves the state for a specific key.
 	SaveState(context.Context, *SaveStateRequest) (*emptypb.Empty, error)
+	// Saves multiple states, reporting the result of each one.
+	SaveBulkStateAlpha1(context.Context, *SaveStateRequest) (*BulkStateWriteResponse, error)
 	// Queries the state.
 	QueryStateAlpha1(context.Context, *QueryStateRequest) (*QueryStateResponse, error)
+	// Streams the changes of keys in a state store.
+	SubscribeStateAlpha1(*SubscribeStateRequest, Dapr_SubscribeStateAlpha1Server) error
 	// Deletes the state for a specific key.
 	DeleteState(context.Context, *DeleteStateRequest) (*emptypb.Empty, error)
 	// Deletes a bulk of state items for a list of keys
 	DeleteBulkState(context.Context, *DeleteBulkStateRequest) (*emptypb.Empty, error)
+	// Deletes multiple states, reporting the result of each one.
+	DeleteBulkStateAlpha1(context.Context, *DeleteBulkStateRequest) (*BulkStateWriteResponse, error)
 	// Executes transactions for a specified store
 	ExecuteStateTransaction(context.Context, *ExecuteStateTransactionRequest) (*emptypb.Empty, error)
+	// Executes transactions on multiple state stores, which are all committed or all rolled back.
+	ExecuteMultiStateTransactionAlpha1(context.Context, *ExecuteMultiStateTransactionRequest) (*emptypb.Empty, error)
 	// Publishes events to the specific topic.
 	PublishEvent(context.Context, *PublishEventRequest) (*emptypb.Empty, error)
 	// Bulk Publishes multiple events to the specified topic.
@@ -850,18 +925,30 @@ func (UnimplementedDaprServer) GetBulkState(context.Context, *GetBulkStateReques
 func (UnimplementedDaprServer) SaveState(context.Context, *SaveStateRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SaveState not implemented")
 }
+func (UnimplementedDaprServer) SaveBulkStateAlpha1(context.Context, *SaveStateRequest) (*BulkStateWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveBulkStateAlpha1 not implemented")
+}
 func (UnimplementedDaprServer) QueryStateAlpha1(context.Context, *QueryStateRequest) (*QueryStateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method QueryStateAlpha1 not implemented")
 }
+func (UnimplementedDaprServer) SubscribeStateAlpha1(*SubscribeStateRequest, Dapr_SubscribeStateAlpha1Server) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeStateAlpha1 not implemented")
+}
 func (UnimplementedDaprServer) DeleteState(context.Context, *DeleteStateRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteState not implemented")
 }
 func (UnimplementedDaprServer) DeleteBulkState(context.Context, *DeleteBulkStateRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteBulkState not implemented")
 }
+func (UnimplementedDaprServer) DeleteBulkStateAlpha1(context.Context, *DeleteBulkStateRequest) (*BulkStateWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBulkStateAlpha1 not implemented")
+}
 func (UnimplementedDaprServer) ExecuteStateTransaction(context.Context, *ExecuteStateTransactionRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ExecuteStateTransaction not implemented")
 }
+func (UnimplementedDaprServer) ExecuteMultiStateTransactionAlpha1(context.Context, *ExecuteMultiStateTransactionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExecuteMultiStateTransactionAlpha1 not implemented")
+}
 func (UnimplementedDaprServer) PublishEvent(context.Context, *PublishEventRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PublishEvent not implemented")
 }
@@ -1084,6 +1171,24 @@ func _Dapr_SaveState_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Dapr_SaveBulkStateAlpha1_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaprServer).SaveBulkStateAlpha1(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dapr.proto.runtime.v1.Dapr/SaveBulkStateAlpha1",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaprServer).SaveBulkStateAlpha1(ctx, req.(*SaveStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Dapr_QueryStateAlpha1_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(QueryStateRequest)
 	if err := dec(in); err != nil {
@@ -1102,6 +1207,27 @@ func _Dapr_QueryStateAlpha1_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Dapr_SubscribeStateAlpha1_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeStateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaprServer).SubscribeStateAlpha1(m, &daprSubscribeStateAlpha1Server{stream})
+}
+
+type Dapr_SubscribeStateAlpha1Server interface {
+	Send(*SubscribeStateResponse) error
+	grpc.ServerStream
+}
+
+type daprSubscribeStateAlpha1Server struct {
+	grpc.ServerStream
+}
+
+func (x *daprSubscribeStateAlpha1Server) Send(m *SubscribeStateResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Dapr_DeleteState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteStateRequest)
 	if err := dec(in); err != nil {
@@ -1138,6 +1264,24 @@ func _Dapr_DeleteBulkState_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Dapr_DeleteBulkStateAlpha1_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBulkStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaprServer).DeleteBulkStateAlpha1(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dapr.proto.runtime.v1.Dapr/DeleteBulkStateAlpha1",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaprServer).DeleteBulkStateAlpha1(ctx, req.(*DeleteBulkStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Dapr_ExecuteStateTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ExecuteStateTransactionRequest)
 	if err := dec(in); err != nil {
@@ -1156,6 +1300,24 @@ func _Dapr_ExecuteStateTransaction_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Dapr_ExecuteMultiStateTransactionAlpha1_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecuteMultiStateTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaprServer).ExecuteMultiStateTransactionAlpha1(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dapr.proto.runtime.v1.Dapr/ExecuteMultiStateTransactionAlpha1",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaprServer).ExecuteMultiStateTransactionAlpha1(ctx, req.(*ExecuteMultiStateTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Dapr_PublishEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PublishEventRequest)
 	if err := dec(in); err != nil {
@@ -2029,6 +2191,10 @@ var Dapr_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SaveState",
 			Handler:    _Dapr_SaveState_Handler,
 		},
+		{
+			MethodName: "SaveBulkStateAlpha1",
+			Handler:    _Dapr_SaveBulkStateAlpha1_Handler,
+		},
 		{
 			MethodName: "QueryStateAlpha1",
 			Handler:    _Dapr_QueryStateAlpha1_Handler,
@@ -2041,10 +2207,18 @@ var Dapr_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteBulkState",
 			Handler:    _Dapr_DeleteBulkState_Handler,
 		},
+		{
+			MethodName: "DeleteBulkStateAlpha1",
+			Handler:    _Dapr_DeleteBulkStateAlpha1_Handler,
+		},
 		{
 			MethodName: "ExecuteStateTransaction",
 			Handler:    _Dapr_ExecuteStateTransaction_Handler,
 		},
+		{
+			MethodName: "ExecuteMultiStateTransactionAlpha1",
+			Handler:    _Dapr_ExecuteMultiStateTransactionAlpha1_Handler,
+		},
 		{
 			MethodName: "PublishEvent",
 			Handler:    _Dapr_PublishEvent_Handler,
@@ -2215,6 +2389,11 @@ var Dapr_ServiceDesc = grpc.ServiceDesc{
 		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeStateAlpha1",
+			Handler:       _Dapr_SubscribeStateAlpha1_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "SubscribeConfigurationAlpha1",
 			Handler:       _Dapr_SubscribeConfigurationAlpha1_Handler,
@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	resiliencyV1alpha "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
+)
+
+const defaultHedgingMaxAttempts = 2
+
+// HedgingPolicy configures hedged requests: if an attempt has not completed
+// after Delay, another one is started concurrently, up to MaxAttempts in total.
+type HedgingPolicy struct {
+	Delay       time.Duration
+	MaxAttempts int
+	// Methods that are idempotent regardless of the HTTP verb they are invoked with.
+	Methods map[string]struct{}
+
+	// Requests that aren't idempotent don't use the policy, so it is only
+	// reported as executed once a request is hedged.
+	addPolicyExecutedMetric func()
+	addHedgeActivatedMetric func()
+}
+
+func decodeHedgingPolicy(h resiliencyV1alpha.Hedging) (*HedgingPolicy, error) {
+	hp := &HedgingPolicy{
+		MaxAttempts: h.MaxAttempts,
+		Methods:     make(map[string]struct{}, len(h.Methods)),
+	}
+
+	var err error
+	if hp.Delay, err = parseDuration(h.Delay); err != nil {
+		return nil, fmt.Errorf("invalid delay %q: %w", h.Delay, err)
+	}
+	if hp.Delay <= 0 {
+		return nil, errors.New("delay must be greater than zero")
+	}
+	if hp.MaxAttempts == 0 {
+		hp.MaxAttempts = defaultHedgingMaxAttempts
+	} else if hp.MaxAttempts < 2 {
+		return nil, errors.New("maxAttempts must be at least 2")
+	}
+	for _, m := range h.Methods {
+		hp.Methods[strings.TrimPrefix(m, "/")] = struct{}{}
+	}
+
+	return hp, nil
+}
+
+// IsIdempotent returns true if a method invoked with the given HTTP verb can
+// be hedged. The verb is empty for gRPC invocations.
+func (h *HedgingPolicy) IsIdempotent(method, verb string) bool {
+	if _, ok := h.Methods[strings.TrimPrefix(method, "/")]; ok {
+		return true
+	}
+
+	switch strings.ToUpper(verb) {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	default:
+		return false
+	}
+}
+
+// Hedge invokes oper and, each time the hedging delay passes without a
+// successful result, invokes it again concurrently until MaxAttempts attempts
+// are in flight. An attempt that fails starts the next one right away.
+// The first successful result is returned and the other attempts are
+// canceled; their results are passed to disposer, if set. The context of the
+// successful attempt is left alone, so results that are still being read
+// (such as streamed responses) are not interrupted.
+// If all attempts fail, the error of the last one is returned.
+// The attempt number can be retrieved from the context with GetAttempt.
+func Hedge[T any](ctx context.Context, h *HedgingPolicy, oper Operation[T], disposer func(T)) (T, error) {
+	type attemptResult struct {
+		doneCh[T]
+		attempt int
+	}
+
+	if h.addPolicyExecutedMetric != nil {
+		h.addPolicyExecutedMetric()
+	}
+
+	results := make(chan attemptResult, h.MaxAttempts)
+	cancels := make([]context.CancelFunc, 0, h.MaxAttempts)
+	start := func() {
+		attempt := len(cancels) + 1
+		if attempt > 1 && h.addHedgeActivatedMetric != nil {
+			h.addHedgeActivatedMetric()
+		}
+		attemptCtx, cancel := context.WithCancel(context.WithValue(ctx, attemptsCtxKey{}, int32(attempt)))
+		cancels = append(cancels, cancel)
+		go func() {
+			res, err := oper(attemptCtx)
+			results <- attemptResult{doneCh[T]{res, err}, attempt}
+		}()
+	}
+
+	// Cancels all attempts but the winner, if any, and disposes of the results
+	// of the ones still in flight in the background.
+	completed := 0
+	abandon := func(winner int) {
+		for i, cancel := range cancels {
+			if i+1 != winner {
+				cancel()
+			}
+		}
+		pending := len(cancels) - completed
+		if pending == 0 {
+			return
+		}
+		go func() {
+			for i := 0; i < pending; i++ {
+				v := <-results
+				if disposer != nil && !isZero(v.res) {
+					disposer(v.res)
+				}
+				cancels[v.attempt-1]()
+			}
+		}()
+	}
+
+	timer := time.NewTimer(h.Delay)
+	defer timer.Stop()
+
+	start()
+	var (
+		zero    T
+		lastErr error
+	)
+	for {
+		select {
+		case v := <-results:
+			completed++
+			if v.err == nil {
+				abandon(v.attempt)
+				return v.res, nil
+			}
+			cancels[v.attempt-1]()
+			lastErr = v.err
+			if disposer != nil && !isZero(v.res) {
+				disposer(v.res)
+			}
+			if len(cancels) < h.MaxAttempts && ctx.Err() == nil {
+				start()
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(h.Delay)
+			} else if completed == len(cancels) {
+				return zero, lastErr
+			}
+		case <-timer.C:
+			if len(cancels) < h.MaxAttempts {
+				start()
+				timer.Reset(h.Delay)
+			}
+		case <-ctx.Done():
+			abandon(0)
+			return zero, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	resiliencyV1alpha "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
+)
+
+func TestHedge(t *testing.T) {
+	policy := &HedgingPolicy{Delay: 20 * time.Millisecond, MaxAttempts: 3}
+
+	t.Run("fast first attempt is not hedged", func(t *testing.T) {
+		var calls atomic.Int32
+		res, err := Hedge(context.Background(), policy, func(ctx context.Context) (string, error) {
+			calls.Add(1)
+			return "ok", nil
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", res)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("slow attempt is hedged and canceled", func(t *testing.T) {
+		canceled := make(chan struct{})
+		var disposed atomic.Int32
+		res, err := Hedge(context.Background(), policy, func(ctx context.Context) (string, error) {
+			if GetAttempt(ctx) == 1 {
+				<-ctx.Done()
+				close(canceled)
+				return "slow", ctx.Err()
+			}
+			return "fast", nil
+		}, func(string) { disposed.Add(1) })
+		require.NoError(t, err)
+		assert.Equal(t, "fast", res)
+
+		select {
+		case <-canceled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("slow attempt was not canceled")
+		}
+		assert.Eventually(t, func() bool {
+			return disposed.Load() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("winner context is not canceled", func(t *testing.T) {
+		var winnerCtx context.Context
+		_, err := Hedge(context.Background(), policy, func(ctx context.Context) (string, error) {
+			winnerCtx = ctx
+			return "ok", nil
+		}, nil)
+		require.NoError(t, err)
+		assert.NoError(t, winnerCtx.Err())
+	})
+
+	t.Run("failed attempt starts the next one right away", func(t *testing.T) {
+		slow := &HedgingPolicy{Delay: time.Hour, MaxAttempts: 2}
+		res, err := Hedge(context.Background(), slow, func(ctx context.Context) (int32, error) {
+			if GetAttempt(ctx) == 1 {
+				return 0, errors.New("failed")
+			}
+			return GetAttempt(ctx), nil
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), res)
+	})
+
+	t.Run("all attempts fail", func(t *testing.T) {
+		var calls atomic.Int32
+		_, err := Hedge(context.Background(), policy, func(ctx context.Context) (string, error) {
+			calls.Add(1)
+			return "", errors.New("failed")
+		}, nil)
+		require.Error(t, err)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("parent context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := Hedge(ctx, policy, func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}, nil)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestHedgingPolicyIsIdempotent(t *testing.T) {
+	h, err := decodeHedgingPolicy(resiliencyV1alpha.Hedging{
+		Delay:   "100ms",
+		Methods: []string{"/getOrder", "mypackage.MyService/Get"},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, h.IsIdempotent("orders", "GET"))
+	assert.True(t, h.IsIdempotent("orders", "delete"))
+	assert.False(t, h.IsIdempotent("orders", "POST"))
+	assert.False(t, h.IsIdempotent("orders", ""))
+	assert.True(t, h.IsIdempotent("getOrder", "POST"))
+	assert.True(t, h.IsIdempotent("mypackage.MyService/Get", ""))
+}
+
+func TestDecodeHedgingPolicy(t *testing.T) {
+	h, err := decodeHedgingPolicy(resiliencyV1alpha.Hedging{Delay: "50"})
+	require.NoError(t, err)
+	assert.Equal(t, 50*time.Millisecond, h.Delay)
+	assert.Equal(t, defaultHedgingMaxAttempts, h.MaxAttempts)
+
+	_, err = decodeHedgingPolicy(resiliencyV1alpha.Hedging{})
+	require.Error(t, err)
+	_, err = decodeHedgingPolicy(resiliencyV1alpha.Hedging{Delay: "1s", MaxAttempts: 1})
+	require.Error(t, err)
+}
+
+func TestEndpointHedgingPolicy(t *testing.T) {
+	r := FromConfigurations(log, &resiliencyV1alpha.Resiliency{
+		ObjectMeta: v1.ObjectMeta{Name: "resiliency"},
+		Spec: resiliencyV1alpha.ResiliencySpec{
+			Policies: resiliencyV1alpha.Policies{
+				Hedging: map[string]resiliencyV1alpha.Hedging{
+					"hedge": {Delay: "100ms", MaxAttempts: 3},
+				},
+			},
+			Targets: resiliencyV1alpha.Targets{
+				Apps: map[string]resiliencyV1alpha.EndpointPolicyNames{
+					"appA": {Hedging: "hedge"},
+					"appB": {Hedging: "missing"},
+				},
+			},
+		},
+	})
+
+	h := r.EndpointHedgingPolicy("appA")
+	require.NotNil(t, h)
+	assert.Equal(t, 100*time.Millisecond, h.Delay)
+	assert.Equal(t, 3, h.MaxAttempts)
+	assert.Nil(t, r.EndpointHedgingPolicy("appB"))
+	assert.Nil(t, r.EndpointHedgingPolicy("appC"))
+	assert.Nil(t, NoOp{}.EndpointHedgingPolicy("appA"))
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	resiliencyV1alpha "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
+)
+
+const (
+	LoadBalancingRoundRobin     = "roundRobin"
+	LoadBalancingLeastRequests  = "leastRequests"
+	LoadBalancingConsistentHash = "consistentHash"
+
+	defaultOutlierConsecutiveErrors  = 5
+	defaultOutlierBaseEjectionTime   = 30 * time.Second
+	defaultOutlierMaxEjectionPercent = 50
+)
+
+// LoadBalancingPolicy configures client-side load balancing across the
+// instances of an app.
+type LoadBalancingPolicy struct {
+	Strategy   string
+	HashHeader string
+	// OutlierDetection is nil if instances are never ejected.
+	OutlierDetection *OutlierDetectionPolicy
+}
+
+// OutlierDetectionPolicy ejects an instance after ConsecutiveErrors failed
+// requests in a row. Instances are ejected for BaseEjectionTime multiplied by
+// the number of times they were ejected, and at most MaxEjectionPercent of
+// the instances are ejected at any time.
+type OutlierDetectionPolicy struct {
+	ConsecutiveErrors  int
+	BaseEjectionTime   time.Duration
+	MaxEjectionPercent int
+}
+
+func decodeLoadBalancingPolicy(lb resiliencyV1alpha.LoadBalancer) (*LoadBalancingPolicy, error) {
+	p := &LoadBalancingPolicy{
+		Strategy:   lb.Strategy,
+		HashHeader: lb.HashHeader,
+	}
+
+	switch p.Strategy {
+	case "":
+		p.Strategy = LoadBalancingRoundRobin
+	case LoadBalancingRoundRobin, LoadBalancingLeastRequests:
+	case LoadBalancingConsistentHash:
+		if p.HashHeader == "" {
+			return nil, errors.New("hashHeader is required by the consistentHash strategy")
+		}
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", p.Strategy)
+	}
+
+	od := lb.OutlierDetection
+	if od == nil {
+		return p, nil
+	}
+	p.OutlierDetection = &OutlierDetectionPolicy{
+		ConsecutiveErrors:  od.ConsecutiveErrors,
+		BaseEjectionTime:   defaultOutlierBaseEjectionTime,
+		MaxEjectionPercent: defaultOutlierMaxEjectionPercent,
+	}
+	if p.OutlierDetection.ConsecutiveErrors <= 0 {
+		p.OutlierDetection.ConsecutiveErrors = defaultOutlierConsecutiveErrors
+	}
+	if od.BaseEjectionTime != "" {
+		d, err := parseDuration(od.BaseEjectionTime)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid baseEjectionTime %q", od.BaseEjectionTime)
+		}
+		p.OutlierDetection.BaseEjectionTime = d
+	}
+	if od.MaxEjectionPercent != nil {
+		if *od.MaxEjectionPercent < 0 || *od.MaxEjectionPercent > 100 {
+			return nil, fmt.Errorf("invalid maxEjectionPercent %d", *od.MaxEjectionPercent)
+		}
+		p.OutlierDetection.MaxEjectionPercent = *od.MaxEjectionPercent
+	}
+
+	return p, nil
+}
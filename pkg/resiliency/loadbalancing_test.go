@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	resiliencyV1alpha "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
+	"github.com/dapr/kit/ptr"
+)
+
+func TestDecodeLoadBalancingPolicy(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		p, err := decodeLoadBalancingPolicy(resiliencyV1alpha.LoadBalancer{
+			OutlierDetection: &resiliencyV1alpha.OutlierDetection{},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, LoadBalancingRoundRobin, p.Strategy)
+		assert.Equal(t, &OutlierDetectionPolicy{
+			ConsecutiveErrors:  defaultOutlierConsecutiveErrors,
+			BaseEjectionTime:   defaultOutlierBaseEjectionTime,
+			MaxEjectionPercent: defaultOutlierMaxEjectionPercent,
+		}, p.OutlierDetection)
+	})
+
+	t.Run("outlier detection", func(t *testing.T) {
+		p, err := decodeLoadBalancingPolicy(resiliencyV1alpha.LoadBalancer{
+			Strategy: LoadBalancingLeastRequests,
+			OutlierDetection: &resiliencyV1alpha.OutlierDetection{
+				ConsecutiveErrors:  3,
+				BaseEjectionTime:   "10s",
+				MaxEjectionPercent: ptr.Of(0),
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &OutlierDetectionPolicy{
+			ConsecutiveErrors:  3,
+			BaseEjectionTime:   10 * time.Second,
+			MaxEjectionPercent: 0,
+		}, p.OutlierDetection)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, lb := range []resiliencyV1alpha.LoadBalancer{
+			{Strategy: "random"},
+			{Strategy: LoadBalancingConsistentHash},
+			{OutlierDetection: &resiliencyV1alpha.OutlierDetection{BaseEjectionTime: "soon"}},
+			{OutlierDetection: &resiliencyV1alpha.OutlierDetection{MaxEjectionPercent: ptr.Of(101)}},
+		} {
+			_, err := decodeLoadBalancingPolicy(lb)
+			assert.Error(t, err, "%+v", lb)
+		}
+	})
+}
+
+func TestEndpointLoadBalancingPolicy(t *testing.T) {
+	r := FromConfigurations(log, &resiliencyV1alpha.Resiliency{
+		ObjectMeta: v1.ObjectMeta{Name: "resiliency"},
+		Spec: resiliencyV1alpha.ResiliencySpec{
+			Policies: resiliencyV1alpha.Policies{
+				LoadBalancers: map[string]resiliencyV1alpha.LoadBalancer{
+					"sticky": {Strategy: LoadBalancingConsistentHash, HashHeader: "x-user"},
+				},
+			},
+			Targets: resiliencyV1alpha.Targets{
+				Apps: map[string]resiliencyV1alpha.EndpointPolicyNames{
+					"appA": {LoadBalancer: "sticky"},
+				},
+			},
+		},
+	})
+
+	p := r.EndpointLoadBalancingPolicy("appA")
+	require.NotNil(t, p)
+	assert.Equal(t, "x-user", p.HashHeader)
+	assert.Same(t, p, r.EndpointLoadBalancingPolicy("appA"))
+	assert.Nil(t, r.EndpointLoadBalancingPolicy("appB"))
+}
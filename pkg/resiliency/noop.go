@@ -26,6 +26,21 @@ func (NoOp) EndpointPolicy(service string, endpoint string) *PolicyDefinition {
 	return nil
 }
 
+// EndpointMethodPolicy returns a NoOp policy definition for a gRPC method of a service.
+func (NoOp) EndpointMethodPolicy(service string, method string) *PolicyDefinition {
+	return nil
+}
+
+// EndpointHedgingPolicy returns no hedging policy for a service.
+func (NoOp) EndpointHedgingPolicy(service string) *HedgingPolicy {
+	return nil
+}
+
+// EndpointLoadBalancingPolicy returns no load balancing policy for a service.
+func (NoOp) EndpointLoadBalancingPolicy(service string) *LoadBalancingPolicy {
+	return nil
+}
+
 // ActorPreLockPolicy returns a NoOp policy definition for an actor instance.
 func (NoOp) ActorPreLockPolicy(actorType string, id string) *PolicyDefinition {
 	return nil
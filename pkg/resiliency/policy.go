@@ -183,17 +183,20 @@ func NewRunnerWithOptions[T any](ctx context.Context, def *PolicyDefinition, opt
 		}
 
 		if def.r == nil {
-			return operation(ctx)
+			rRes, rErr := operation(ctx)
+			_, rErr = unwrapPushback(rErr)
+			return rRes, rErr
 		}
 
 		// Use retry/back off
-		b := def.r.NewBackOffWithContext(ctx)
+		b := newPushbackBackOff(def.r.NewBackOffWithContext(ctx).(backoff.BackOffContext))
 		attempts := atomic.Int32{}
 		return retry.NotifyRecoverWithData(
 			func() (T, error) {
 				attempt := attempts.Add(1)
 				opCtx := context.WithValue(ctx, attemptsCtxKey{}, attempt)
 				rRes, rErr := operation(opCtx)
+				b.next, rErr = unwrapPushback(rErr)
 				// In case of an error, if we have a disposer we invoke it with the return value, then reset the return value
 				if rErr != nil && opts.Disposer != nil && !isZero(rRes) {
 					opts.Disposer(rRes)
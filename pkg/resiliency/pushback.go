@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// RetryPushback wraps an error returned by an operation so that, if the
+// operation is retried, the next attempt starts after delay instead of the
+// delay of the retry policy. This is how servers push back on retries, for
+// example with the "grpc-retry-pushback-ms" trailer.
+// The runner returns the wrapped error as-is.
+func RetryPushback(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &pushbackError{err: err, delay: delay}
+}
+
+type pushbackError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *pushbackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *pushbackError) Unwrap() error {
+	return e.err
+}
+
+// unwrapPushback returns the delay requested for the next attempt, or -1, and
+// the error wrapped by RetryPushback, if any.
+func unwrapPushback(err error) (time.Duration, error) {
+	if pe, ok := err.(*pushbackError); ok { //nolint:errorlint
+		return pe.delay, pe.err
+	}
+	return -1, err
+}
+
+// pushbackBackOff is a backoff that uses the delay requested by the last
+// failed attempt, if any. The retry policy still decides when to stop.
+type pushbackBackOff struct {
+	backoff.BackOffContext
+	next time.Duration
+}
+
+func newPushbackBackOff(b backoff.BackOffContext) *pushbackBackOff {
+	return &pushbackBackOff{BackOffContext: b, next: -1}
+}
+
+func (b *pushbackBackOff) NextBackOff() time.Duration {
+	next := b.BackOffContext.NextBackOff()
+	if next != backoff.Stop && b.next >= 0 {
+		next = b.next
+	}
+	b.next = -1
+	return next
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resiliency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/kit/retry"
+)
+
+func TestRetryPushback(t *testing.T) {
+	def := &PolicyDefinition{
+		log:  testLog,
+		name: "pushback",
+		r: &retry.Config{
+			Policy:     retry.PolicyConstant,
+			Duration:   time.Hour,
+			MaxRetries: 2,
+		},
+	}
+	errFailed := errors.New("failed")
+
+	t.Run("pushback replaces the retry delay", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		start := time.Now()
+		res, err := NewRunner[int32](ctx, def)(func(ctx context.Context) (int32, error) {
+			if GetAttempt(ctx) == 1 {
+				return 0, RetryPushback(errFailed, 20*time.Millisecond)
+			}
+			return GetAttempt(ctx), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), res)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+
+	t.Run("the retry policy decides when to stop", func(t *testing.T) {
+		var attempts int32
+		_, err := NewRunner[struct{}](context.Background(), def)(func(ctx context.Context) (struct{}, error) {
+			attempts = GetAttempt(ctx)
+			return struct{}{}, RetryPushback(errFailed, time.Millisecond)
+		})
+		require.Error(t, err)
+		assert.Equal(t, int32(3), attempts)
+
+		// The error is returned unwrapped.
+		assert.Equal(t, errFailed, err) //nolint:errorlint
+	})
+
+	t.Run("without retries", func(t *testing.T) {
+		_, err := NewRunner[struct{}](context.Background(), &PolicyDefinition{log: testLog})(func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, RetryPushback(errFailed, time.Millisecond)
+		})
+		assert.Equal(t, errFailed, err) //nolint:errorlint
+	})
+
+	assert.NoError(t, RetryPushback(nil, time.Second))
+}
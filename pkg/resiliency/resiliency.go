@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"sync"
@@ -88,6 +89,12 @@ type (
 	Provider interface {
 		// EndpointPolicy returns the policy for a service endpoint.
 		EndpointPolicy(service string, endpoint string) *PolicyDefinition
+		// EndpointMethodPolicy returns the policy for a gRPC method of a service, such as "mypackage.Orders/Get".
+		EndpointMethodPolicy(service string, method string) *PolicyDefinition
+		// EndpointHedgingPolicy returns the hedging policy for a service, or nil if requests to it are not hedged.
+		EndpointHedgingPolicy(service string) *HedgingPolicy
+		// EndpointLoadBalancingPolicy returns the client-side load balancing policy for a service, or nil if the name resolver picks the instance.
+		EndpointLoadBalancingPolicy(service string) *LoadBalancingPolicy
 		// ActorPolicy returns the policy for an actor instance to be used before the lock is acquired.
 		ActorPreLockPolicy(actorType string, id string) *PolicyDefinition
 		// ActorPolicy returns the policy for an actor instance to be used after the lock is acquired.
@@ -113,6 +120,8 @@ type (
 		timeouts        map[string]time.Duration
 		retries         map[string]*retry.Config
 		circuitBreakers map[string]*breaker.CircuitBreaker
+		hedging         map[string]*HedgingPolicy
+		loadBalancers   map[string]*LoadBalancingPolicy
 
 		actorCBCaches    map[string]*lru.Cache[string, *breaker.CircuitBreaker]
 		actorCBsCachesMu sync.RWMutex
@@ -139,12 +148,23 @@ type (
 		Outbound PolicyNames
 	}
 
-	// PolicyNames contains the policy names for a timeout, retry, and circuit breaker.
+	// PolicyNames contains the policy names for a timeout, retry, circuit breaker, and hedging.
 	// Empty values mean that no policy is configured.
 	PolicyNames struct {
 		Timeout        string
 		Retry          string
 		CircuitBreaker string
+		Hedging        string
+		LoadBalancer   string
+		Methods        []MethodPolicyNames
+	}
+
+	// MethodPolicyNames contains the policy names for the gRPC methods matching a glob.
+	MethodPolicyNames struct {
+		Match          string
+		Timeout        string
+		Retry          string
+		CircuitBreaker string
 	}
 
 	// Actors have different behavior before and after locking.
@@ -307,6 +327,8 @@ func New(log logger.Logger) *Resiliency {
 		timeouts:        make(map[string]time.Duration),
 		retries:         make(map[string]*retry.Config),
 		circuitBreakers: make(map[string]*breaker.CircuitBreaker),
+		hedging:         make(map[string]*HedgingPolicy),
+		loadBalancers:   make(map[string]*LoadBalancingPolicy),
 		actorCBCaches:   make(map[string]*lru.Cache[string, *breaker.CircuitBreaker]),
 		serviceCBs:      make(map[string]*lru.Cache[string, *breaker.CircuitBreaker]),
 		componentCBs: &circuitBreakerInstances{
@@ -433,6 +455,18 @@ func (r *Resiliency) decodePolicies(c *resiliencyV1alpha.Resiliency) (err error)
 		r.circuitBreakers[name] = &cb
 	}
 
+	for name, h := range policies.Hedging {
+		if r.hedging[name], err = decodeHedgingPolicy(h); err != nil {
+			return fmt.Errorf("invalid hedging configuration %q: %w", name, err)
+		}
+	}
+
+	for name, lb := range policies.LoadBalancers {
+		if r.loadBalancers[name], err = decodeLoadBalancingPolicy(lb); err != nil {
+			return fmt.Errorf("invalid load balancer configuration %q: %w", name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -440,11 +474,25 @@ func (r *Resiliency) decodeTargets(c *resiliencyV1alpha.Resiliency) (err error)
 	targets := c.Spec.Targets
 
 	for name, t := range targets.Apps {
-		r.apps[name] = PolicyNames{
+		policyNames := PolicyNames{
 			Timeout:        t.Timeout,
 			Retry:          t.Retry,
 			CircuitBreaker: t.CircuitBreaker,
+			Hedging:        t.Hedging,
+			LoadBalancer:   t.LoadBalancer,
+		}
+		for _, m := range t.Methods {
+			if _, err = path.Match(m.Match, ""); err != nil || m.Match == "" {
+				return fmt.Errorf("invalid method match %q for app %s", m.Match, name)
+			}
+			policyNames.Methods = append(policyNames.Methods, MethodPolicyNames{
+				Match:          m.Match,
+				Timeout:        m.Timeout,
+				Retry:          m.Retry,
+				CircuitBreaker: m.CircuitBreaker,
+			})
 		}
+		r.apps[name] = policyNames
 		if t.CircuitBreakerCacheSize == 0 {
 			t.CircuitBreakerCacheSize = defaultEndpointCacheSize
 		}
@@ -571,25 +619,7 @@ func (r *Resiliency) EndpointPolicy(app string, endpoint string) *PolicyDefiniti
 	policyNames, ok := r.apps[app]
 	if ok {
 		r.log.Debugf("Found Endpoint Policy for %s: %+v", app, policyNames)
-		if policyNames.Timeout != "" {
-			policyDef.t = r.timeouts[policyNames.Timeout]
-		}
-		if policyNames.Retry != "" {
-			policyDef.r = r.retries[policyNames.Retry]
-		}
-		if policyNames.CircuitBreaker != "" {
-			template, ok := r.circuitBreakers[policyNames.CircuitBreaker]
-			if ok {
-				cache, ok := r.serviceCBs[app]
-				if ok {
-					policyDef.cb, ok = cache.Get(endpoint)
-					if !ok || policyDef.cb == nil {
-						policyDef.cb = newCB(endpoint, template, r.log)
-						cache.Add(endpoint, policyDef.cb)
-					}
-				}
-			}
-		}
+		r.setEndpointPolicies(policyDef, app, endpoint, policyNames)
 	} else {
 		if defaultNames, ok := r.getDefaultPolicy(EndpointPolicy{}); ok {
 			r.log.Debugf("Found Default Policy for Endpoint %s: %+v", app, defaultNames)
@@ -617,6 +647,97 @@ func (r *Resiliency) EndpointPolicy(app string, endpoint string) *PolicyDefiniti
 	return policyDef
 }
 
+// EndpointMethodPolicy returns the policy for a gRPC method of a service. The
+// policies of the first method glob matching the method override the ones of
+// the service; without a match, this is the same as the endpoint policy.
+func (r *Resiliency) EndpointMethodPolicy(app string, method string) *PolicyDefinition {
+	endpoint := app + ":" + method
+	policyNames, ok := r.apps[app]
+	if !ok {
+		return r.EndpointPolicy(app, endpoint)
+	}
+
+	for _, m := range policyNames.Methods {
+		if matched, _ := path.Match(m.Match, method); !matched {
+			continue
+		}
+		r.log.Debugf("Found Method Policy %s for %s: %+v", m.Match, endpoint, m)
+		if m.Timeout != "" {
+			policyNames.Timeout = m.Timeout
+		}
+		if m.Retry != "" {
+			policyNames.Retry = m.Retry
+		}
+		if m.CircuitBreaker != "" {
+			policyNames.CircuitBreaker = m.CircuitBreaker
+		}
+
+		policyDef := &PolicyDefinition{
+			log:  r.log,
+			name: "endpoint[" + app + ", " + endpoint + "]",
+		}
+		r.setEndpointPolicies(policyDef, app, endpoint, policyNames)
+		r.addMetricsToPolicy(policyDef, diag.ResiliencyAppTarget(app), diag.OutboundPolicyFlowDirection)
+		return policyDef
+	}
+
+	return r.EndpointPolicy(app, endpoint)
+}
+
+func (r *Resiliency) setEndpointPolicies(policyDef *PolicyDefinition, app string, endpoint string, policyNames PolicyNames) {
+	if policyNames.Timeout != "" {
+		policyDef.t = r.timeouts[policyNames.Timeout]
+	}
+	if policyNames.Retry != "" {
+		policyDef.r = r.retries[policyNames.Retry]
+	}
+	if policyNames.CircuitBreaker != "" {
+		template, ok := r.circuitBreakers[policyNames.CircuitBreaker]
+		if ok {
+			cache, ok := r.serviceCBs[app]
+			if ok {
+				policyDef.cb, ok = cache.Get(endpoint)
+				if !ok || policyDef.cb == nil {
+					policyDef.cb = newCB(endpoint, template, r.log)
+					cache.Add(endpoint, policyDef.cb)
+				}
+			}
+		}
+	}
+}
+
+// EndpointHedgingPolicy returns the hedging policy for a service, or nil if none is configured.
+func (r *Resiliency) EndpointHedgingPolicy(app string) *HedgingPolicy {
+	policyNames, ok := r.apps[app]
+	if !ok || policyNames.Hedging == "" {
+		return nil
+	}
+	template, ok := r.hedging[policyNames.Hedging]
+	if !ok {
+		return nil
+	}
+
+	target := diag.ResiliencyAppTarget(app)
+	h := *template
+	h.addPolicyExecutedMetric = func() {
+		diag.DefaultResiliencyMonitoring.PolicyExecuted(r.name, r.namespace, diag.HedgingPolicy, diag.OutboundPolicyFlowDirection, target)
+	}
+	h.addHedgeActivatedMetric = func() {
+		diag.DefaultResiliencyMonitoring.PolicyActivated(r.name, r.namespace, diag.HedgingPolicy, diag.OutboundPolicyFlowDirection, target)
+	}
+	return &h
+}
+
+// EndpointLoadBalancingPolicy returns the client-side load balancing policy for a service, or nil if none is configured.
+// The same object is returned for all the calls, so callers can keep state per policy.
+func (r *Resiliency) EndpointLoadBalancingPolicy(app string) *LoadBalancingPolicy {
+	policyNames, ok := r.apps[app]
+	if !ok || policyNames.LoadBalancer == "" {
+		return nil
+	}
+	return r.loadBalancers[policyNames.LoadBalancer]
+}
+
 func newCB(cbName string, template *breaker.CircuitBreaker, l logger.Logger) *breaker.CircuitBreaker {
 	cb := &breaker.CircuitBreaker{
 		Name:        cbName,
@@ -703,3 +703,65 @@ func concurrentPolicyExec(t *testing.T, policyDefFn func(idx int) *PolicyDefinit
 	}
 	wg.Wait()
 }
+
+func TestEndpointMethodPolicy(t *testing.T) {
+	config := &resiliencyV1alpha.Resiliency{
+		ObjectMeta: v1.ObjectMeta{Name: "resiliency"},
+		Spec: resiliencyV1alpha.ResiliencySpec{
+			Policies: resiliencyV1alpha.Policies{
+				Timeouts: map[string]string{
+					"fast": "100ms",
+					"slow": "10s",
+				},
+				Retries: map[string]resiliencyV1alpha.Retry{
+					"none": {Policy: "constant", Duration: "10ms", MaxRetries: ptr.Of(0)},
+					"some": {Policy: "constant", Duration: "10ms", MaxRetries: ptr.Of(3)},
+				},
+			},
+			Targets: resiliencyV1alpha.Targets{
+				Apps: map[string]resiliencyV1alpha.EndpointPolicyNames{
+					"appA": {
+						Timeout: "fast",
+						Retry:   "some",
+						Methods: []resiliencyV1alpha.MethodPolicyNames{
+							{Match: "mypackage.Orders/Create*", Retry: "none"},
+							{Match: "mypackage.Reports/*", Timeout: "slow"},
+							{Match: "mypackage.*/*", Timeout: "slow", Retry: "none"},
+						},
+					},
+				},
+			},
+		},
+	}
+	r := FromConfigurations(log, config)
+
+	tests := []struct {
+		app     string
+		method  string
+		timeout time.Duration
+		retries bool
+	}{
+		{"appA", "mypackage.Orders/CreateOrder", 100 * time.Millisecond, false},
+		{"appA", "mypackage.Reports/Get", 10 * time.Second, true},
+		{"appA", "mypackage.Orders/Get", 10 * time.Second, false},
+		{"appA", "other.Orders/Get", 100 * time.Millisecond, true},
+		{"appB", "mypackage.Orders/Get", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.app+"/"+tt.method, func(t *testing.T) {
+			policy := r.EndpointMethodPolicy(tt.app, tt.method)
+			require.NotNil(t, policy)
+			assert.Equal(t, tt.timeout, policy.t)
+			assert.Equal(t, tt.retries, policy.HasRetries())
+		})
+	}
+
+	assert.Nil(t, NoOp{}.EndpointMethodPolicy("appA", "mypackage.Orders/Get"))
+
+	t.Run("invalid glob", func(t *testing.T) {
+		invalid := config.DeepCopy()
+		invalid.Spec.Targets.Apps["appA"].Methods[0].Match = "mypackage.Orders/["
+		r := FromConfigurations(log, invalid)
+		assert.False(t, r.PolicyDefined("appA", EndpointPolicy{}))
+	})
+}
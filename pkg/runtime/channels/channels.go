@@ -17,6 +17,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strconv"
@@ -122,6 +123,9 @@ func (c *Channels) Refresh() error {
 		return fmt.Errorf("failed to create HTTP endpoints channels: %w", err)
 	}
 
+	for _, ch := range c.endpChannels {
+		closeChannel(ch)
+	}
 	c.httpEndpChannel = httpEndpChannel
 	c.endpChannels = endpChannels
 
@@ -152,6 +156,45 @@ func (c *Channels) Refresh() error {
 	return nil
 }
 
+// RefreshHTTPEndpoint rebuilds the channel of an HTTP endpoint after it was
+// added, updated or removed in the component store.
+// The previous channel is closed, but requests already using it complete.
+// It is a no-op until the channels are first refreshed.
+func (c *Channels) RefreshHTTPEndpoint(name string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.httpEndpChannel == nil {
+		return nil
+	}
+
+	endpChannels := make(map[string]channel.HTTPEndpointAppChannel, len(c.endpChannels)+1)
+	for k, v := range c.endpChannels {
+		if k != name {
+			endpChannels[k] = v
+		}
+	}
+
+	if endpoint, ok := c.compStore.GetHTTPEndpoint(name); ok {
+		pipeline, err := c.buildHTTPPipeline(c.appHTTPPipelineSpec)
+		if err != nil {
+			return err
+		}
+
+		ch, err := c.createHTTPEndpointChannel(pipeline, endpoint)
+		if err != nil {
+			return err
+		}
+		endpChannels[name] = ch
+	}
+
+	closeChannel(c.endpChannels[name])
+	c.endpChannels = endpChannels
+	log.Debugf("Refreshed channel of http endpoint %s", name)
+
+	return nil
+}
+
 func (c *Channels) BuildHTTPPipeline(spec *config.PipelineSpec) (middlehttp.Pipeline, error) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -265,13 +308,11 @@ func (c *Channels) initEndpointChannels() (map[string]channel.HTTPEndpointAppCha
 		}
 
 		for _, e := range endpoints {
-			conf, err := c.getHTTPEndpointAppChannel(pipeline, e)
-			if err != nil {
-				return nil, err
-			}
-
-			ch, err := channelhttp.CreateHTTPChannel(conf)
+			ch, err := c.createHTTPEndpointChannel(pipeline, e)
 			if err != nil {
+				for _, prev := range channels {
+					closeChannel(prev)
+				}
 				return nil, err
 			}
 
@@ -282,6 +323,32 @@ func (c *Channels) initEndpointChannels() (map[string]channel.HTTPEndpointAppCha
 	return channels, nil
 }
 
+// createHTTPEndpointChannel creates the channel of an HTTP endpoint, and
+// starts the balancing and health probes of its base URLs.
+func (c *Channels) createHTTPEndpointChannel(pipeline middlehttp.Pipeline, endpoint httpendpapi.HTTPEndpoint) (channel.HTTPEndpointAppChannel, error) {
+	conf, err := c.getHTTPEndpointAppChannel(pipeline, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := channelhttp.CreateHTTPChannel(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	httpCh, ok := ch.(*channelhttp.Channel)
+	if !ok {
+		closeChannel(ch)
+		return nil, fmt.Errorf("unexpected channel type %T for http endpoint %s", ch, endpoint.Name)
+	}
+
+	// Requests to the endpoint report the error if its balancing is invalid.
+	if err = httpCh.StartEndpoint(endpoint); err != nil {
+		log.Errorf("Failed to start load balancing of http endpoint %s: %v", endpoint.Name, err)
+	}
+	return ch, nil
+}
+
 func (c *Channels) getHTTPEndpointAppChannel(pipeline middlehttp.Pipeline, endpoint httpendpapi.HTTPEndpoint) (channelhttp.ChannelConfiguration, error) {
 	conf := channelhttp.ChannelConfiguration{
 		CompStore:            c.compStore,
@@ -349,6 +416,15 @@ func (c *Channels) getHTTPEndpointAppChannel(pipeline middlehttp.Pipeline, endpo
 	return conf, nil
 }
 
+// closeChannel releases the resources of a channel, if it holds any.
+func closeChannel(ch channel.HTTPEndpointAppChannel) {
+	if closer, ok := ch.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Warnf("Failed to close http endpoint channel: %v", err)
+		}
+	}
+}
+
 // appHTTPClient Initializes the appHTTPClient property.
 func appHTTPClient(connConfig config.AppConnectionConfig, globalConfig *config.Configuration, readBufferSize int) *http.Client {
 	var transport http.RoundTripper
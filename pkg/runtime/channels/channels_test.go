@@ -23,6 +23,8 @@ import (
 	"errors"
 	"math/big"
 	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -408,3 +410,96 @@ func TestGetHTTPEndpointAppChannel(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestRefreshHTTPEndpoint(t *testing.T) {
+	store := compstore.New()
+	ch := New(Options{
+		Registry: registry.New(registry.NewOptions().WithHTTPMiddlewares(
+			httpMiddlewareLoader.NewRegistry(),
+		)),
+		ComponentStore: store,
+		Meta:           meta.New(meta.Options{Mode: modes.StandaloneMode}),
+		AppConnectionConfig: config.AppConnectionConfig{
+			ChannelAddress: "my.app",
+			Protocol:       "http",
+		},
+		GlobalConfig: new(config.Configuration),
+	})
+
+	endpoint := httpendpapi.HTTPEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec:       httpendpapi.HTTPEndpointSpec{BaseURL: "http://localhost:1234"},
+	}
+	store.AddHTTPEndpoint(endpoint)
+
+	t.Run("no-op before the channels are refreshed", func(t *testing.T) {
+		require.NoError(t, ch.RefreshHTTPEndpoint("test"))
+		assert.Empty(t, ch.EndpointChannels())
+	})
+
+	require.NoError(t, ch.Refresh())
+	first := ch.EndpointChannels()["test"]
+	require.NotNil(t, first)
+
+	t.Run("updated endpoint gets a new channel", func(t *testing.T) {
+		store.AddHTTPEndpoint(endpoint)
+		require.NoError(t, ch.RefreshHTTPEndpoint("test"))
+		second := ch.EndpointChannels()["test"]
+		require.NotNil(t, second)
+		assert.NotSame(t, first, second)
+	})
+
+	t.Run("removed endpoint has no channel", func(t *testing.T) {
+		store.DeleteHTTPEndpoint("test")
+		require.NoError(t, ch.RefreshHTTPEndpoint("test"))
+		assert.NotContains(t, ch.EndpointChannels(), "test")
+	})
+}
+
+func TestHTTPEndpointHealthProbes(t *testing.T) {
+	var probes atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+	}))
+	defer srv.Close()
+
+	store := compstore.New()
+	ch := New(Options{
+		Registry: registry.New(registry.NewOptions().WithHTTPMiddlewares(
+			httpMiddlewareLoader.NewRegistry(),
+		)),
+		ComponentStore: store,
+		Meta:           meta.New(meta.Options{Mode: modes.StandaloneMode}),
+		AppConnectionConfig: config.AppConnectionConfig{
+			ChannelAddress: "my.app",
+			Protocol:       "http",
+		},
+		GlobalConfig: new(config.Configuration),
+	})
+	require.NoError(t, ch.Refresh())
+
+	store.AddHTTPEndpoint(httpendpapi.HTTPEndpoint{
+		ObjectMeta: metav1.ObjectMeta{Name: "probed"},
+		Spec: httpendpapi.HTTPEndpointSpec{
+			BaseURL:     srv.URL,
+			HealthCheck: &httpendpapi.HealthCheck{Path: "/healthz", Interval: "10ms"},
+		},
+	})
+	require.NoError(t, ch.RefreshHTTPEndpoint("probed"))
+
+	// Probes start without waiting for a request to the endpoint
+	assert.Eventually(t, func() bool {
+		return probes.Load() >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	store.DeleteHTTPEndpoint("probed")
+	require.NoError(t, ch.RefreshHTTPEndpoint("probed"))
+
+	var last int64
+	assert.Eventually(t, func() bool {
+		n := probes.Load()
+		stopped := n == last
+		last = n
+		return stopped
+	}, 5*time.Second, 100*time.Millisecond)
+}
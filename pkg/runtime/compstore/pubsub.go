@@ -27,6 +27,9 @@ type PubsubItem struct {
 	AllowedTopics       []string
 	ProtectedTopics     []string
 	NamespaceScoped     bool
+	// TopicSchemas are the JSON Schemas declared for topics in the metadata
+	// of the component.
+	TopicSchemas map[string]rtpubsub.SchemaDefinition
 }
 
 type TopicRoutes map[string]TopicRouteElem
@@ -36,6 +39,7 @@ type TopicRouteElem struct {
 	Rules           []*rtpubsub.Rule
 	DeadLetterTopic string
 	BulkSubscribe   *rtpubsub.BulkSubscribe
+	DeliveryLimits  *rtpubsub.DeliveryLimits
 }
 
 func (c *ComponentStore) AddPubSub(name string, item PubsubItem) {
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	httpEndpointV1alpha1 "github.com/dapr/dapr/pkg/apis/httpEndpoint/v1alpha1"
+	"github.com/dapr/dapr/pkg/httpendpoint"
+)
+
+// httpEndpointsReloadDelay is how long to wait after a change in the
+// resources path before reloading, so that bursts of file events (such as an
+// editor saving a file) cause a single reload.
+const httpEndpointsReloadDelay = 500 * time.Millisecond
+
+// watchLocalHTTPEndpoints reloads the HTTP endpoints whenever a file in the
+// resources path changes.
+func (a *DaprRuntime) watchLocalHTTPEndpoints(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	for _, path := range a.runtimeConfig.standalone.ResourcesPath {
+		if err = watcher.Add(path); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch resources path %s: %w", path, err)
+		}
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer watcher.Close()
+
+		var reload <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				log.Debugf("resources path changed: %s", event)
+				reload = time.After(httpEndpointsReloadDelay)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warnf("error watching resources path: %s", err)
+			case <-reload:
+				reload = nil
+				a.reloadLocalHTTPEndpoints(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+// reloadLocalHTTPEndpoints loads the HTTP endpoints from the resources path
+// and applies the differences with the loaded ones.
+func (a *DaprRuntime) reloadLocalHTTPEndpoints(ctx context.Context) {
+	endpoints, err := httpendpoint.NewLocalHTTPEndpoints(a.runtimeConfig.standalone.ResourcesPath...).LoadHTTPEndpoints()
+	if err != nil {
+		log.Warnf("failed to reload http endpoints: %s", err)
+		return
+	}
+
+	authorized := a.getAuthorizedObjects(endpoints, a.isObjectAuthorized).([]httpEndpointV1alpha1.HTTPEndpoint)
+	a.syncHTTPEndpoints(ctx, authorized)
+}
+
+// syncHTTPEndpoints makes the loaded HTTP endpoints match the given ones:
+// new and changed endpoints are updated, and endpoints that are not in the
+// list are removed.
+func (a *DaprRuntime) syncHTTPEndpoints(ctx context.Context, endpoints []httpEndpointV1alpha1.HTTPEndpoint) {
+	names := make(map[string]struct{}, len(endpoints))
+	for _, e := range endpoints {
+		names[e.Name] = struct{}{}
+		if a.onHTTPEndpointUpdated(ctx, e) {
+			log.Infof("http endpoint updated: %s", e.Name)
+		}
+	}
+
+	for _, e := range a.compStore.ListHTTPEndpoints() {
+		if _, ok := names[e.Name]; ok {
+			continue
+		}
+		now := metav1.Now()
+		e.DeletionTimestamp = &now
+		if !a.addPendingEndpoint(ctx, e) {
+			return
+		}
+	}
+}
+
+// syncListedHTTPEndpoints syncs the HTTP endpoints listed by the operator.
+// An endpoint that fails to deserialize keeps its loaded version; if its name
+// can't be read either, no endpoint is removed.
+func (a *DaprRuntime) syncListedHTTPEndpoints(ctx context.Context, listed [][]byte) {
+	endpoints := make([]httpEndpointV1alpha1.HTTPEndpoint, 0, len(listed))
+	complete := true
+	for _, raw := range listed {
+		var endpoint httpEndpointV1alpha1.HTTPEndpoint
+		err := json.Unmarshal(raw, &endpoint)
+		if err == nil {
+			endpoints = append(endpoints, endpoint)
+			continue
+		}
+
+		var named struct {
+			metav1.ObjectMeta `json:"metadata"`
+		}
+		if jsonErr := json.Unmarshal(raw, &named); jsonErr != nil || named.Name == "" {
+			log.Errorf("error deserializing http endpoint, keeping all loaded http endpoints: %s", err)
+			complete = false
+			continue
+		}
+		log.Errorf("error deserializing http endpoint %s, keeping its previous version: %s", named.Name, err)
+		if prev, ok := a.compStore.GetHTTPEndpoint(named.Name); ok {
+			endpoints = append(endpoints, prev)
+		}
+	}
+
+	if complete {
+		a.syncHTTPEndpoints(ctx, endpoints)
+		return
+	}
+	for _, e := range endpoints {
+		if a.onHTTPEndpointUpdated(ctx, e) {
+			log.Infof("http endpoint updated: %s", e.Name)
+		}
+	}
+}
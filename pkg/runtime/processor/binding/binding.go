@@ -33,6 +33,7 @@ import (
 	"github.com/dapr/dapr/pkg/runtime/compstore"
 	rterrors "github.com/dapr/dapr/pkg/runtime/errors"
 	"github.com/dapr/dapr/pkg/runtime/meta"
+	"github.com/dapr/dapr/pkg/statetx"
 	"github.com/dapr/kit/logger"
 )
 
@@ -58,6 +59,8 @@ type Options struct {
 	GRPC           *manager.Manager
 	TracingSpec    *config.TracingSpec
 	Channels       *channels.Channels
+	// Called after the state returned by the app is saved; may be nil.
+	StateWritten statetx.WrittenFn
 }
 
 type binding struct {
@@ -71,6 +74,8 @@ type binding struct {
 	tracingSpec *config.TracingSpec
 	grpc        *manager.Manager
 
+	stateWritten statetx.WrittenFn
+
 	lock sync.Mutex
 
 	subscribeBindingList []string
@@ -88,6 +93,8 @@ func New(opts Options) *binding {
 		tracingSpec: opts.TracingSpec,
 		grpc:        opts.GRPC,
 		channels:    opts.Channels,
+
+		stateWritten: opts.StateWritten,
 	}
 }
 
@@ -37,6 +37,7 @@ import (
 	invokev1 "github.com/dapr/dapr/pkg/messaging/v1"
 	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
 	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/statechanges"
 )
 
 func (b *binding) StartReadingFromBindings(ctx context.Context) error {
@@ -178,12 +179,16 @@ func (b *binding) onAppResponse(ctx context.Context, response *bindings.AppRespo
 
 			err := stateLoader.PerformBulkStoreOperation(ctx, reqs,
 				b.resiliency.ComponentOutboundPolicy(response.StoreName, resiliency.Statestore),
-				state.BulkStoreOpts{},
+				stateLoader.GetBulkStoreOpts(response.StoreName),
 				store.Set,
 				store.BulkSet,
 			)
 			if err != nil {
 				log.Errorf("error saving state from app response: %v", err)
+				return
+			}
+			if b.stateWritten != nil {
+				b.stateWritten(ctx, response.StoreName, statechanges.Operations(reqs))
 			}
 		}(response.State)
 	}
@@ -29,6 +29,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/dapr/components-contrib/bindings"
+	"github.com/dapr/components-contrib/state"
 	commonapi "github.com/dapr/dapr/pkg/apis/common"
 	componentsV1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	channelt "github.com/dapr/dapr/pkg/channel/testing"
@@ -461,6 +462,33 @@ func TestInvokeOutputBindings(t *testing.T) {
 	})
 }
 
+func TestOnAppResponseState(t *testing.T) {
+	var written []string
+	b := New(Options{
+		IsHTTP:         true,
+		Resiliency:     resiliency.New(log),
+		ComponentStore: compstore.New(),
+		Meta:           meta.New(meta.Options{}),
+		StateWritten: func(_ context.Context, storeName string, ops []state.TransactionalStateOperation) {
+			for _, op := range ops {
+				written = append(written, storeName+"/"+op.GetKey())
+			}
+		},
+	})
+	store := daprt.NewFakeStateStore()
+	b.compStore.AddStateStore("store1", store)
+
+	err := b.onAppResponse(context.Background(), &bindings.AppResponse{
+		StoreName: "store1",
+		State:     []state.SetRequest{{Key: "a", Value: "1"}},
+	})
+	require.NoError(t, err)
+	b.wg.Wait()
+
+	assert.Len(t, store.GetItems(), 1)
+	assert.Equal(t, []string{"store1/a"}, written)
+}
+
 func TestBindingTracingHttp(t *testing.T) {
 	b := New(Options{
 		IsHTTP:         true,
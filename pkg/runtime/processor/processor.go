@@ -32,6 +32,11 @@ import (
 	"github.com/dapr/dapr/pkg/runtime/channels"
 	"github.com/dapr/dapr/pkg/runtime/compstore"
 	"github.com/dapr/dapr/pkg/runtime/meta"
+	rtpubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+	"github.com/dapr/dapr/pkg/statecache"
+	"github.com/dapr/dapr/pkg/statechanges"
+	"github.com/dapr/dapr/pkg/stateindex"
+	"github.com/dapr/dapr/pkg/statetx"
 
 	grpcmanager "github.com/dapr/dapr/pkg/grpc/manager"
 	"github.com/dapr/dapr/pkg/runtime/processor/binding"
@@ -98,6 +103,10 @@ type manager interface {
 
 type StateManager interface {
 	ActorStateStoreName() (string, bool)
+	Changes() *statechanges.Notifier
+	Transactions() *statetx.Coordinator
+	Index() *stateindex.Indexer
+	Cache() *statecache.Cache
 	manager
 }
 
@@ -108,6 +117,7 @@ type PubsubManager interface {
 	StartSubscriptions(context.Context) error
 	StopSubscriptions()
 	Outbox() outbox.Outbox
+	Redrive(context.Context, *rtpubsub.RedriveRequest) (*rtpubsub.RedriveResponse, error)
 	manager
 }
 
@@ -148,12 +158,22 @@ func New(opts Options) *Processor {
 		ResourcesPath:  opts.Standalone.ResourcesPath,
 	})
 
+	changes := statechanges.NewNotifier(opts.ID, ps.Publish)
+	index := stateindex.NewIndexer(opts.ID, opts.ComponentStore, opts.Resiliency)
+	cache := statecache.New()
+	stateWritten := state.StateWritten(cache, changes, index)
+
 	state := state.New(state.Options{
+		AppID:            opts.ID,
 		PlacementEnabled: opts.PlacementEnabled,
 		Registry:         opts.Registry.StateStores(),
 		ComponentStore:   opts.ComponentStore,
 		Meta:             opts.Meta,
 		Outbox:           ps.Outbox(),
+		Changes:          changes,
+		Transactions:     statetx.NewCoordinator(opts.ID, opts.PodName, opts.ComponentStore, opts.Resiliency, stateWritten),
+		Index:            index,
+		Cache:            cache,
 	})
 
 	binding := binding.New(binding.Options{
@@ -165,6 +185,7 @@ func New(opts Options) *Processor {
 		GRPC:           opts.GRPC,
 		TracingSpec:    opts.GlobalConfig.Spec.TracingSpec,
 		Channels:       opts.Channels,
+		StateWritten:   stateWritten,
 	})
 
 	return &Processor{
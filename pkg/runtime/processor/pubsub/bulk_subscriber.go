@@ -121,6 +121,13 @@ func (p *pubsub) bulkSubscribeTopic(ctx context.Context, policyDef *resiliency.P
 		},
 	}
 
+	schema, err := p.topicSchema(ctx, ps, topic, route.Metadata)
+	if err != nil {
+		return err
+	}
+
+	limiter := newDeliveryLimiter(route.DeliveryLimits)
+
 	bulkHandler := func(ctx context.Context, msg *contribpubsub.BulkMessage) ([]contribpubsub.BulkSubscribeResponseEntry, error) {
 		if msg.Metadata == nil {
 			msg.Metadata = make(map[string]string, 1)
@@ -158,6 +165,13 @@ func (p *pubsub) bulkSubscribeTopic(ctx context.Context, policyDef *resiliency.P
 			}
 			entryIdIndexMap[message.EntryId] = i
 			if rawPayload {
+				if schema != nil {
+					if vErr := schema.ValidateData(psName, topic, message.Event); vErr != nil {
+						log.Warnf("dropping pub/sub event %s not conforming to the schema: %s", message.EntryId, vErr)
+						p.dropBulkEntry(ctx, &bulkSubCallData, route, message, i)
+						continue
+					}
+				}
 				rPath, routeErr := p.getRouteIfProcessable(ctx, &bulkSubCallData, route, &(msg.Entries[i]), i, string(message.Event))
 				if routeErr != nil {
 					hasAnyError = true
@@ -197,6 +211,13 @@ func (p *pubsub) bulkSubscribeTopic(ctx context.Context, policyDef *resiliency.P
 					bulkResponses[i].Error = nil
 					continue
 				}
+				if schema != nil {
+					if vErr := schema.ValidateCloudEvent(psName, topic, cloudEvent); vErr != nil {
+						log.Warnf("dropping pub/sub event %v not conforming to the schema: %s", cloudEvent[contribpubsub.IDField], vErr)
+						p.dropBulkEntry(ctx, &bulkSubCallData, route, message, i)
+						continue
+					}
+				}
 				rPath, routeErr := p.getRouteIfProcessable(ctx, &bulkSubCallData, route, &(msg.Entries[i]), i, cloudEvent)
 				if routeErr != nil {
 					hasAnyError = true
@@ -214,7 +235,14 @@ func (p *pubsub) bulkSubscribeTopic(ctx context.Context, policyDef *resiliency.P
 		}
 		var overallInvokeErr error
 		for path, psm := range routePathBulkMessageMap {
-			invokeErr := p.createEnvelopeAndInvokeSubscriber(ctx, &bulkSubCallData, psm, msg, route, path, policyDef, rawPayload)
+			release, throttled, invokeErr := limiter.acquire(ctx, psm.length)
+			if throttled {
+				diag.DefaultComponentMonitoring.PubsubIngressThrottled(ctx, psName, topic, int64(psm.length))
+			}
+			if invokeErr == nil {
+				invokeErr = p.createEnvelopeAndInvokeSubscriber(ctx, &bulkSubCallData, psm, msg, route, path, policyDef, rawPayload)
+				release()
+			}
 			if invokeErr != nil {
 				hasAnyError = true
 				err = invokeErr
@@ -667,6 +695,24 @@ func endSpans(spans []trace.Span) {
 	}
 }
 
+// dropBulkEntry drops an entry of a bulk message without delivering it to the
+// app, sending it to the deadletter topic if configured.
+func (p *pubsub) dropBulkEntry(ctx context.Context, bscData *bulkSubscribeCallData, route compstore.TopicRouteElem,
+	message contribpubsub.BulkMessageEntry, i int,
+) {
+	bscData.bulkSubDiag.statusWiseDiag[string(contribpubsub.Drop)]++
+	if route.DeadLetterTopic != "" {
+		_ = p.sendToDeadLetter(ctx, bscData.psName, &contribpubsub.NewMessage{
+			Data:        message.Event,
+			Topic:       bscData.topic,
+			Metadata:    message.Metadata,
+			ContentType: &message.ContentType,
+		}, route.DeadLetterTopic)
+	}
+	(*bscData.bulkResponses)[i].EntryId = message.EntryId
+	(*bscData.bulkResponses)[i].Error = nil
+}
+
 // sendBulkToDeadLetter sends the bulk message to deadletter topic.
 func (p *pubsub) sendBulkToDeadLetter(ctx context.Context,
 	bulkSubCallData *bulkSubscribeCallData, msg *contribpubsub.BulkMessage, deadLetterTopic string,
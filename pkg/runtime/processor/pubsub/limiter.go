@@ -0,0 +1,118 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	rtpubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+)
+
+// deliveryLimiter enforces the per-subscription limits on the deliveries to
+// the app: the maximum number of concurrent deliveries and the maximum number
+// of messages delivered per second.
+type deliveryLimiter struct {
+	sem     chan struct{}
+	limiter *rate.Limiter
+}
+
+// newDeliveryLimiter returns a deliveryLimiter for the given limits, or nil if
+// no limit is configured.
+func newDeliveryLimiter(limits *rtpubsub.DeliveryLimits) *deliveryLimiter {
+	if limits == nil || (limits.MaxConcurrency <= 0 && limits.MaxMessagesPerSecond <= 0) {
+		return nil
+	}
+
+	l := &deliveryLimiter{}
+	if limits.MaxConcurrency > 0 {
+		l.sem = make(chan struct{}, limits.MaxConcurrency)
+	}
+	if limits.MaxMessagesPerSecond > 0 {
+		l.limiter = rate.NewLimiter(rate.Limit(limits.MaxMessagesPerSecond), int(limits.MaxMessagesPerSecond))
+	}
+	return l
+}
+
+// acquire blocks until a delivery of count messages is allowed by the limits,
+// or until the context is canceled.
+// It returns a function that must be invoked once the delivery has completed,
+// and whether the delivery had to wait because of the limits.
+// A nil deliveryLimiter never blocks.
+func (l *deliveryLimiter) acquire(ctx context.Context, count int) (release func(), throttled bool, err error) {
+	if l == nil {
+		return func() {}, false, nil
+	}
+
+	if l.limiter != nil {
+		throttled, err = l.waitRate(ctx, count)
+		if err != nil {
+			return nil, throttled, err
+		}
+	}
+
+	if l.sem == nil {
+		return func() {}, throttled, nil
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+	default:
+		throttled = true
+		select {
+		case l.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, throttled, ctx.Err()
+		}
+	}
+
+	return func() { <-l.sem }, throttled, nil
+}
+
+// waitRate waits until count tokens are available from the rate limiter.
+// Requests larger than the burst size are satisfied in several steps.
+func (l *deliveryLimiter) waitRate(ctx context.Context, count int) (bool, error) {
+	if count < 1 {
+		count = 1
+	}
+
+	var throttled bool
+	for count > 0 {
+		n := count
+		if burst := l.limiter.Burst(); n > burst {
+			n = burst
+		}
+		count -= n
+
+		r := l.limiter.ReserveN(time.Now(), n)
+		delay := r.Delay()
+		if delay == 0 {
+			continue
+		}
+
+		throttled = true
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			r.Cancel()
+			return throttled, ctx.Err()
+		}
+	}
+
+	return throttled, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rtpubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+)
+
+func TestDeliveryLimiter(t *testing.T) {
+	t.Run("no limits returns nil limiter", func(t *testing.T) {
+		assert.Nil(t, newDeliveryLimiter(nil))
+		assert.Nil(t, newDeliveryLimiter(&rtpubsub.DeliveryLimits{}))
+
+		var l *deliveryLimiter
+		release, throttled, err := l.acquire(context.Background(), 10)
+		require.NoError(t, err)
+		assert.False(t, throttled)
+		release()
+	})
+
+	t.Run("max concurrency blocks until released", func(t *testing.T) {
+		l := newDeliveryLimiter(&rtpubsub.DeliveryLimits{MaxConcurrency: 1})
+
+		release1, throttled, err := l.acquire(context.Background(), 1)
+		require.NoError(t, err)
+		assert.False(t, throttled)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, throttled, err = l.acquire(ctx, 1)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, throttled)
+
+		release1()
+		release2, throttled, err := l.acquire(context.Background(), 1)
+		require.NoError(t, err)
+		assert.False(t, throttled)
+		release2()
+	})
+
+	t.Run("max messages per second delays deliveries", func(t *testing.T) {
+		l := newDeliveryLimiter(&rtpubsub.DeliveryLimits{MaxMessagesPerSecond: 10})
+
+		// The first second's worth of messages is delivered immediately.
+		release, throttled, err := l.acquire(context.Background(), 10)
+		require.NoError(t, err)
+		assert.False(t, throttled)
+		release()
+
+		start := time.Now()
+		release, throttled, err = l.acquire(context.Background(), 1)
+		require.NoError(t, err)
+		assert.True(t, throttled)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		release()
+	})
+
+	t.Run("rate wait is interrupted by context cancellation", func(t *testing.T) {
+		l := newDeliveryLimiter(&rtpubsub.DeliveryLimits{MaxMessagesPerSecond: 1})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, throttled, err := l.acquire(ctx, 5)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, throttled)
+	})
+}
@@ -55,6 +55,10 @@ func (p *pubsub) Publish(ctx context.Context, req *contribpubsub.PublishRequest)
 		return rtpubsub.NotAllowedError{Topic: req.Topic, ID: p.id}
 	}
 
+	if err := p.validatePublishedMessage(ctx, ps, req.PubsubName, req.Topic, req.Data, req.Metadata); err != nil {
+		return err
+	}
+
 	if ps.NamespaceScoped {
 		req.Topic = p.namespace + req.Topic
 	}
@@ -81,6 +85,16 @@ func (p *pubsub) BulkPublish(ctx context.Context, req *contribpubsub.BulkPublish
 		return contribpubsub.BulkPublishResponse{}, rtpubsub.NotAllowedError{Topic: req.Topic, ID: p.id}
 	}
 
+	for _, entry := range req.Entries {
+		md := req.Metadata
+		if len(entry.Metadata) > 0 {
+			md = entry.Metadata
+		}
+		if err := p.validatePublishedMessage(ctx, ps, req.PubsubName, req.Topic, entry.Event, md); err != nil {
+			return contribpubsub.BulkPublishResponse{}, fmt.Errorf("entry %s: %w", entry.EntryId, err)
+		}
+	}
+
 	policyDef := p.resiliency.ComponentOutboundPolicy(req.PubsubName, resiliency.Pubsub)
 
 	if contribpubsub.FeatureBulkPublish.IsPresent(ps.Component.Features()) {
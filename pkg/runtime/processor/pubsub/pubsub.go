@@ -101,6 +101,9 @@ type pubsub struct {
 
 	topicCancels map[string]context.CancelFunc
 	outbox       outbox.Outbox
+
+	schemaLock sync.Mutex
+	schemas    map[string]*rtpubsub.Schema
 }
 
 type subscribedMessage struct {
@@ -129,6 +132,7 @@ func New(opts Options) *pubsub {
 		channels:       opts.Channels,
 		operatorClient: opts.OperatorClient,
 		topicCancels:   make(map[string]context.CancelFunc),
+		schemas:        make(map[string]*rtpubsub.Schema),
 	}
 
 	ps.outbox = rtpubsub.NewOutbox(ps.Publish, opts.ComponentStore.GetPubSubComponent, opts.ComponentStore.GetStateStore, ExtractCloudEventProperty, opts.Namespace)
@@ -174,6 +178,7 @@ func (p *pubsub) Init(ctx context.Context, comp compapi.Component) error {
 		AllowedTopics:       scopes.GetAllowedTopics(properties),
 		ProtectedTopics:     scopes.GetProtectedTopics(properties),
 		NamespaceScoped:     meta.ContainsNamespace(comp.Spec.Metadata),
+		TopicSchemas:        rtpubsub.GetTopicSchemas(properties),
 	})
 	diag.DefaultMonitoring.ComponentInitialized(comp.Spec.Type)
 
@@ -0,0 +1,291 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/contenttype"
+	"github.com/dapr/components-contrib/metadata"
+	contribpubsub "github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	rtpubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+)
+
+// errRedriveSkipped is returned to the pubsub component for messages which
+// are neither redriven nor kept by the redrive.
+var errRedriveSkipped = errors.New("message not redriven")
+
+// Redrive moves messages from the dead-letter topic of a subscription back to
+// the subscription's topic, or delivers them directly to the app.
+// The dead-letter topic is consumed for at most req.WaitDuration, and until
+// it stops delivering new messages, or delivers again a message it delivered
+// already. Messages that don't match the filters, or that exceed
+// req.MaxCount, are published again to the dead-letter topic and
+// acknowledged, so they are kept whatever the broker does with rejected
+// messages.
+// Dry runs don't settle the messages they are delivered until they end, so
+// they inspect at most as many messages as the component delivers
+// concurrently.
+func (p *pubsub) Redrive(ctx context.Context, req *rtpubsub.RedriveRequest) (*rtpubsub.RedriveResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	ps, ok := p.compStore.GetPubSub(req.PubsubName)
+	if !ok {
+		return nil, rtpubsub.NotFoundError{PubsubName: req.PubsubName}
+	}
+
+	route, ok := p.compStore.GetTopicRoutes()[req.PubsubName][req.Topic]
+	if !ok {
+		return nil, fmt.Errorf("%w: topic '%s' on pubsub '%s'", rtpubsub.ErrRedriveSubscriptionNotFound, req.Topic, req.PubsubName)
+	}
+	if route.DeadLetterTopic == "" {
+		return nil, fmt.Errorf("%w: topic '%s' on pubsub '%s'", rtpubsub.ErrRedriveNoDeadLetterTopic, req.Topic, req.PubsubName)
+	}
+
+	rawPayload, err := metadata.IsRawPayload(route.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing pubsub metadata: %w", err)
+	}
+
+	deadLetterTopic := route.DeadLetterTopic
+	if ps.NamespaceScoped {
+		deadLetterTopic = p.namespace + deadLetterTopic
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, req.WaitDuration)
+	defer cancel()
+
+	var (
+		lock   sync.Mutex
+		wg     sync.WaitGroup
+		closed bool
+		idle   *time.Timer
+		seen   = make(map[string]struct{})
+		res    = &rtpubsub.RedriveResponse{DryRun: req.DryRun}
+	)
+
+	// skip keeps a message in the dead-letter topic: dry runs hold it until
+	// they end, the others publish it again.
+	skip := func(msg *contribpubsub.NewMessage) error {
+		if req.DryRun {
+			<-waitCtx.Done()
+			return errRedriveSkipped
+		}
+		if err := p.republishDeadLetter(ctx, req.PubsubName, route.DeadLetterTopic, msg); err != nil {
+			log.Warnf("failed to keep message in dead letter topic %s: %v", route.DeadLetterTopic, err)
+			return err
+		}
+		return nil
+	}
+
+	handler := func(_ context.Context, msg *contribpubsub.NewMessage) error {
+		lock.Lock()
+		if closed {
+			lock.Unlock()
+			return errRedriveSkipped
+		}
+		wg.Add(1)
+		lock.Unlock()
+		defer wg.Done()
+
+		var cloudEvent map[string]any
+		if rawPayload {
+			cloudEvent = contribpubsub.FromRawPayload(msg.Data, req.Topic, req.PubsubName)
+		} else if err := json.Unmarshal(msg.Data, &cloudEvent); err != nil {
+			log.Debugf("skipping message in dead letter topic %s that is not a cloud event: %v", route.DeadLetterTopic, err)
+			lock.Lock()
+			res.Skipped++
+			lock.Unlock()
+			return skip(msg)
+		}
+
+		lock.Lock()
+		if waitCtx.Err() != nil {
+			lock.Unlock()
+			return skip(msg)
+		}
+
+		// A message delivered again was published again by this redrive, or
+		// rejected: the whole dead-letter topic was read.
+		id, _ := cloudEvent[contribpubsub.IDField].(string)
+		if id != "" {
+			if _, ok := seen[id]; ok {
+				cancel()
+				lock.Unlock()
+				return skip(msg)
+			}
+			seen[id] = struct{}{}
+		}
+
+		// Messages keep coming while the dead-letter topic isn't drained.
+		if idle == nil {
+			idle = time.AfterFunc(rtpubsub.RedriveIdleDuration, cancel)
+		} else {
+			idle.Reset(rtpubsub.RedriveIdleDuration)
+		}
+
+		if !req.Matches(cloudEvent) || (req.MaxCount > 0 && res.Matched >= req.MaxCount) {
+			res.Skipped++
+			lock.Unlock()
+			return skip(msg)
+		}
+		res.Matched++
+		if req.DryRun {
+			if req.MaxCount > 0 && res.Matched >= req.MaxCount {
+				cancel()
+			}
+			lock.Unlock()
+			return skip(msg)
+		}
+		lock.Unlock()
+
+		// The redrive itself uses the parent context so it is not interrupted
+		// when the wait duration elapses or the max count is reached.
+		rErr := p.redriveMessage(ctx, req, route, msg, cloudEvent, rawPayload)
+
+		lock.Lock()
+		defer lock.Unlock()
+		if rErr != nil {
+			log.Warnf("failed to redrive message %s from dead letter topic %s: %v", id, route.DeadLetterTopic, rErr)
+			res.Failed++
+		} else {
+			res.Redriven++
+		}
+		// Redriving may take longer than the idle duration
+		idle.Reset(rtpubsub.RedriveIdleDuration)
+		if req.MaxCount > 0 && res.Redriven+res.Failed >= req.MaxCount {
+			cancel()
+		}
+		return rErr
+	}
+
+	err = ps.Component.Subscribe(waitCtx, contribpubsub.SubscribeRequest{
+		Topic:    deadLetterTopic,
+		Metadata: route.Metadata,
+	}, handler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to dead letter topic %s: %w", route.DeadLetterTopic, err)
+	}
+
+	<-waitCtx.Done()
+	lock.Lock()
+	closed = true
+	if idle != nil {
+		idle.Stop()
+	}
+	lock.Unlock()
+	wg.Wait()
+
+	log.Infof("redrive of dead letter topic %s to topic %s on pubsub %s completed: matched=%d redriven=%d skipped=%d failed=%d dryRun=%v",
+		route.DeadLetterTopic, req.Topic, req.PubsubName, res.Matched, res.Redriven, res.Skipped, res.Failed, req.DryRun)
+	return res, nil
+}
+
+// republishDeadLetter publishes a message of the dead-letter topic to it
+// again, unchanged.
+func (p *pubsub) republishDeadLetter(ctx context.Context, pubsubName, deadLetterTopic string, msg *contribpubsub.NewMessage) error {
+	md := make(map[string]string, len(msg.Metadata))
+	for k, v := range msg.Metadata {
+		if k != metadataKeyPubSub {
+			md[k] = v
+		}
+	}
+	return p.Publish(ctx, &contribpubsub.PublishRequest{
+		PubsubName:  pubsubName,
+		Topic:       deadLetterTopic,
+		Data:        msg.Data,
+		ContentType: msg.ContentType,
+		Metadata:    md,
+	})
+}
+
+// redriveMessage sends a single message from the dead-letter topic to the
+// redrive target.
+// Cloud events get the redrive extensions added; messages of raw payload
+// subscriptions are re-published unchanged.
+func (p *pubsub) redriveMessage(ctx context.Context, req *rtpubsub.RedriveRequest, route compstore.TopicRouteElem,
+	msg *contribpubsub.NewMessage, cloudEvent map[string]any, rawPayload bool,
+) error {
+	md := make(map[string]string, len(msg.Metadata))
+	for k, v := range msg.Metadata {
+		if k != metadataKeyPubSub {
+			md[k] = v
+		}
+	}
+
+	rtpubsub.MarkRedriven(cloudEvent, time.Now())
+	data, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return fmt.Errorf("error serializing cloud event: %w", err)
+	}
+
+	if req.Target == rtpubsub.RedriveTargetTopic {
+		pubReq := &contribpubsub.PublishRequest{
+			PubsubName: req.PubsubName,
+			Topic:      req.Topic,
+			Metadata:   md,
+		}
+		if rawPayload {
+			pubReq.Data = msg.Data
+			pubReq.ContentType = msg.ContentType
+		} else {
+			ct := contenttype.CloudEventContentType
+			pubReq.Data = data
+			pubReq.ContentType = &ct
+		}
+		return p.Publish(ctx, pubReq)
+	}
+
+	routePath, shouldProcess, err := findMatchingRoute(route.Rules, cloudEvent)
+	if err != nil {
+		return fmt.Errorf("error finding matching route: %w", err)
+	}
+	if !shouldProcess {
+		return errors.New("no matching route for event")
+	}
+
+	md[metadataKeyPubSub] = req.PubsubName
+	sm := &subscribedMessage{
+		cloudEvent: cloudEvent,
+		data:       data,
+		topic:      req.Topic,
+		metadata:   md,
+		path:       routePath,
+		pubsub:     req.PubsubName,
+	}
+	policyRunner := resiliency.NewRunner[any](ctx, p.resiliency.ComponentInboundPolicy(req.PubsubName, resiliency.Pubsub))
+	_, err = policyRunner(func(ctx context.Context) (any, error) {
+		var pErr error
+		if p.isHTTP {
+			pErr = p.publishMessageHTTP(ctx, sm)
+		} else {
+			pErr = p.publishMessageGRPC(ctx, sm)
+		}
+		if errors.Is(pErr, rtpubsub.ErrMessageDropped) {
+			// The app has received the message and decided to drop it.
+			return nil, nil
+		}
+		return nil, pErr
+	})
+	return err
+}
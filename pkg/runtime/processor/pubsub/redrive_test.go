@@ -0,0 +1,238 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribpubsub "github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	"github.com/dapr/dapr/pkg/runtime/meta"
+	runtimePubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+	"github.com/dapr/dapr/pkg/runtime/registry"
+	"github.com/dapr/kit/logger"
+)
+
+// redrivePubSub is a pubsub component that delivers a fixed list of messages
+// concurrently to subscribers of the dead-letter topic and records publishes.
+type redrivePubSub struct {
+	deadLetters []*contribpubsub.NewMessage
+
+	lock      sync.Mutex
+	published []*contribpubsub.PublishRequest
+	rejected  int
+}
+
+func (r *redrivePubSub) Init(context.Context, contribpubsub.Metadata) error { return nil }
+func (r *redrivePubSub) Features() []contribpubsub.Feature                  { return nil }
+func (r *redrivePubSub) Close() error                                       { return nil }
+
+func (r *redrivePubSub) Publish(_ context.Context, req *contribpubsub.PublishRequest) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.published = append(r.published, req)
+	return nil
+}
+
+func (r *redrivePubSub) Subscribe(ctx context.Context, req contribpubsub.SubscribeRequest, handler contribpubsub.Handler) error {
+	for _, msg := range r.deadLetters {
+		go func(msg *contribpubsub.NewMessage) {
+			if err := handler(ctx, msg); err != nil {
+				r.lock.Lock()
+				r.rejected++
+				r.lock.Unlock()
+			}
+		}(msg)
+	}
+	return nil
+}
+
+// publishedTo returns the messages published to topic.
+func (r *redrivePubSub) publishedTo(topic string) []*contribpubsub.PublishRequest {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var res []*contribpubsub.PublishRequest
+	for _, pub := range r.published {
+		if pub.Topic == topic {
+			res = append(res, pub)
+		}
+	}
+	return res
+}
+
+func newRedriveTestEvent(t *testing.T, id, eventType, eventTime string) *contribpubsub.NewMessage {
+	t.Helper()
+	b, err := json.Marshal(map[string]any{
+		contribpubsub.IDField:          id,
+		contribpubsub.TypeField:        eventType,
+		contribpubsub.TimeField:        eventTime,
+		contribpubsub.SpecVersionField: "1.0",
+		contribpubsub.TopicField:       "orders",
+		contribpubsub.DataField:        "hello",
+		"myextension":                  "keep",
+	})
+	require.NoError(t, err)
+	return &contribpubsub.NewMessage{
+		Topic:    "orders-dlq",
+		Data:     b,
+		Metadata: map[string]string{metadataKeyPubSub: "redrivepubsub"},
+	}
+}
+
+func TestRedrive(t *testing.T) {
+	newPubSub := func(t *testing.T, comp *redrivePubSub, deadLetterTopic string) *pubsub {
+		ps := New(Options{
+			Registry:       registry.New(registry.NewOptions()).PubSubs(),
+			Resiliency:     resiliency.New(logger.NewLogger("test")),
+			ComponentStore: compstore.New(),
+			Meta:           meta.New(meta.Options{}),
+			ID:             TestRuntimeConfigID,
+		})
+		ps.compStore.AddPubSub("redrivepubsub", compstore.PubsubItem{Component: comp})
+		ps.compStore.SetTopicRoutes(map[string]compstore.TopicRoutes{
+			"redrivepubsub": {
+				"orders": {
+					Rules:           []*runtimePubsub.Rule{{Path: "orders"}},
+					DeadLetterTopic: deadLetterTopic,
+				},
+			},
+		})
+		return ps
+	}
+
+	dlq := func(t *testing.T) []*contribpubsub.NewMessage {
+		return []*contribpubsub.NewMessage{
+			newRedriveTestEvent(t, "1", "order.created", "2023-01-01T10:00:00Z"),
+			newRedriveTestEvent(t, "2", "order.deleted", "2023-01-01T11:00:00Z"),
+			newRedriveTestEvent(t, "3", "order.created", "2023-01-02T10:00:00Z"),
+			newRedriveTestEvent(t, "4", "order.created", "2023-01-03T10:00:00Z"),
+		}
+	}
+
+	t.Run("subscription without dead letter topic", func(t *testing.T) {
+		ps := newPubSub(t, &redrivePubSub{}, "")
+		_, err := ps.Redrive(context.Background(), &runtimePubsub.RedriveRequest{PubsubName: "redrivepubsub", Topic: "orders"})
+		require.ErrorIs(t, err, runtimePubsub.ErrRedriveNoDeadLetterTopic)
+	})
+
+	t.Run("unknown subscription", func(t *testing.T) {
+		ps := newPubSub(t, &redrivePubSub{}, "orders-dlq")
+		_, err := ps.Redrive(context.Background(), &runtimePubsub.RedriveRequest{PubsubName: "redrivepubsub", Topic: "invoices"})
+		require.ErrorIs(t, err, runtimePubsub.ErrRedriveSubscriptionNotFound)
+	})
+
+	t.Run("redrive matching messages to topic", func(t *testing.T) {
+		comp := &redrivePubSub{deadLetters: dlq(t)}
+		ps := newPubSub(t, comp, "orders-dlq")
+
+		res, err := ps.Redrive(context.Background(), &runtimePubsub.RedriveRequest{
+			PubsubName:   "redrivepubsub",
+			Topic:        "orders",
+			EventTypes:   []string{"order.created"},
+			EndTime:      time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC),
+			WaitDuration: 200 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &runtimePubsub.RedriveResponse{Matched: 2, Redriven: 2, Skipped: 2}, res)
+
+		comp.lock.Lock()
+		assert.Zero(t, comp.rejected)
+		comp.lock.Unlock()
+
+		// Skipped messages are kept in the dead-letter topic as they were.
+		kept := comp.publishedTo("orders-dlq")
+		require.Len(t, kept, 2)
+		for _, pub := range kept {
+			assert.NotContains(t, pub.Metadata, metadataKeyPubSub)
+
+			var ce map[string]any
+			require.NoError(t, json.Unmarshal(pub.Data, &ce))
+			assert.Contains(t, []string{"2", "4"}, ce[contribpubsub.IDField])
+			assert.NotContains(t, ce, runtimePubsub.RedriveCountExtension)
+		}
+
+		redriven := comp.publishedTo("orders")
+		require.Len(t, redriven, 2)
+		for _, pub := range redriven {
+			assert.NotContains(t, pub.Metadata, metadataKeyPubSub)
+
+			var ce map[string]any
+			require.NoError(t, json.Unmarshal(pub.Data, &ce))
+			assert.Equal(t, "order.created", ce[contribpubsub.TypeField])
+			assert.Equal(t, "keep", ce["myextension"])
+			assert.Equal(t, float64(1), ce[runtimePubsub.RedriveCountExtension])
+			assert.NotEmpty(t, ce[runtimePubsub.RedriveTimeExtension])
+		}
+	})
+
+	t.Run("max count stops the redrive", func(t *testing.T) {
+		comp := &redrivePubSub{deadLetters: dlq(t)}
+		ps := newPubSub(t, comp, "orders-dlq")
+
+		start := time.Now()
+		res, err := ps.Redrive(context.Background(), &runtimePubsub.RedriveRequest{
+			PubsubName:   "redrivepubsub",
+			Topic:        "orders",
+			MaxCount:     1,
+			WaitDuration: 10 * time.Second,
+		})
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.Equal(t, 1, res.Redriven)
+		assert.Len(t, comp.publishedTo("orders"), 1)
+	})
+
+	t.Run("redrive ends once the dead letter topic is drained", func(t *testing.T) {
+		comp := &redrivePubSub{deadLetters: dlq(t)}
+		ps := newPubSub(t, comp, "orders-dlq")
+
+		start := time.Now()
+		res, err := ps.Redrive(context.Background(), &runtimePubsub.RedriveRequest{
+			PubsubName:   "redrivepubsub",
+			Topic:        "orders",
+			DryRun:       true,
+			WaitDuration: time.Minute,
+		})
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 30*time.Second)
+		assert.Equal(t, 4, res.Matched)
+	})
+
+	t.Run("dry run does not consume messages", func(t *testing.T) {
+		comp := &redrivePubSub{deadLetters: dlq(t)}
+		ps := newPubSub(t, comp, "orders-dlq")
+
+		res, err := ps.Redrive(context.Background(), &runtimePubsub.RedriveRequest{
+			PubsubName:   "redrivepubsub",
+			Topic:        "orders",
+			DryRun:       true,
+			WaitDuration: 200 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, &runtimePubsub.RedriveResponse{Matched: 4, DryRun: true}, res)
+
+		comp.lock.Lock()
+		defer comp.lock.Unlock()
+		assert.Empty(t, comp.published)
+		assert.Equal(t, 4, comp.rejected)
+	})
+}
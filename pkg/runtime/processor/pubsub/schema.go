@@ -0,0 +1,139 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dapr/components-contrib/configuration"
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	rtpubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+)
+
+// topicSchema returns the JSON Schema of a topic, or nil if the topic has no
+// schema. A schema declared in the metadata of a subscription takes
+// precedence over the one declared in the metadata of the pubsub component.
+func (p *pubsub) topicSchema(ctx context.Context, ps compstore.PubsubItem, topic string, subscriptionMetadata map[string]string) (*rtpubsub.Schema, error) {
+	def := rtpubsub.GetSubscriptionSchema(subscriptionMetadata)
+	if def.IsEmpty() {
+		def = ps.TopicSchemas[topic]
+	}
+	if def.IsEmpty() {
+		return nil, nil
+	}
+	if def.Inline != "" && def.Ref != "" {
+		return nil, fmt.Errorf("schema of topic %s cannot be both inline and a reference", topic)
+	}
+
+	cacheKey := "inline:" + def.Inline
+	if def.Ref != "" {
+		cacheKey = "ref:" + def.Ref
+	}
+
+	p.schemaLock.Lock()
+	schema, ok := p.schemas[cacheKey]
+	p.schemaLock.Unlock()
+	if ok {
+		return schema, nil
+	}
+
+	raw := def.Inline
+	if def.Ref != "" {
+		var err error
+		raw, err = p.resolveSchemaRef(ctx, def.Ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve schema of topic %s: %w", topic, err)
+		}
+	}
+
+	schema, err := rtpubsub.NewSchema(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema of topic %s: %w", topic, err)
+	}
+
+	p.schemaLock.Lock()
+	p.schemas[cacheKey] = schema
+	p.schemaLock.Unlock()
+	return schema, nil
+}
+
+// resolveSchemaRef reads a JSON Schema from a secret or configuration store.
+func (p *pubsub) resolveSchemaRef(ctx context.Context, ref string) (string, error) {
+	r, err := rtpubsub.ParseSchemaRef(ref)
+	if err != nil {
+		return "", err
+	}
+
+	switch r.StoreType {
+	case rtpubsub.SchemaRefSecretStore:
+		store, ok := p.compStore.GetSecretStore(r.StoreName)
+		if !ok {
+			return "", fmt.Errorf("secret store %s not found", r.StoreName)
+		}
+		resp, err := store.GetSecret(ctx, secretstores.GetSecretRequest{Name: r.Name})
+		if err != nil {
+			return "", fmt.Errorf("failed to get secret %s from secret store %s: %w", r.Name, r.StoreName, err)
+		}
+		v, ok := resp.Data[r.Key]
+		if !ok {
+			return "", fmt.Errorf("key %s not found in secret %s of secret store %s", r.Key, r.Name, r.StoreName)
+		}
+		return v, nil
+	default:
+		store, ok := p.compStore.GetConfiguration(r.StoreName)
+		if !ok {
+			return "", fmt.Errorf("configuration store %s not found", r.StoreName)
+		}
+		resp, err := store.Get(ctx, &configuration.GetRequest{Keys: []string{r.Name}})
+		if err != nil {
+			return "", fmt.Errorf("failed to get key %s from configuration store %s: %w", r.Name, r.StoreName, err)
+		}
+		item, ok := resp.Items[r.Name]
+		if !ok || item == nil {
+			return "", fmt.Errorf("key %s not found in configuration store %s", r.Name, r.StoreName)
+		}
+		return item.Value, nil
+	}
+}
+
+// validatePublishedMessage validates the data of a message published to a
+// topic against the schema of the topic declared by the pubsub component.
+func (p *pubsub) validatePublishedMessage(ctx context.Context, ps compstore.PubsubItem, pubsubName, topic string, data []byte, md map[string]string) error {
+	schema, err := p.topicSchema(ctx, ps, topic, nil)
+	if err != nil || schema == nil {
+		return err
+	}
+
+	rawPayload, err := metadata.IsRawPayload(md)
+	if err != nil {
+		return fmt.Errorf("error deserializing pubsub metadata: %w", err)
+	}
+	if rawPayload {
+		return schema.ValidateData(pubsubName, topic, data)
+	}
+
+	var cloudEvent map[string]any
+	if err := json.Unmarshal(data, &cloudEvent); err != nil {
+		return rtpubsub.SchemaValidationError{
+			PubsubName: pubsubName,
+			Topic:      topic,
+			Errors:     []string{"message is not a valid cloud event: " + err.Error()},
+		}
+	}
+	return schema.ValidateCloudEvent(pubsubName, topic, cloudEvent)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	contribpubsub "github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	"github.com/dapr/dapr/pkg/runtime/meta"
+	rtpubsub "github.com/dapr/dapr/pkg/runtime/pubsub"
+	"github.com/dapr/dapr/pkg/runtime/registry"
+	daprt "github.com/dapr/dapr/pkg/testing"
+	"github.com/dapr/kit/logger"
+)
+
+const testOrderSchema = `{"type": "object", "required": ["orderId"]}`
+
+type schemaSecretStore struct {
+	daprt.FakeSecretStore
+}
+
+func (schemaSecretStore) GetSecret(context.Context, secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	return secretstores.GetSecretResponse{Data: map[string]string{"orders": testOrderSchema}}, nil
+}
+
+func TestTopicSchema(t *testing.T) {
+	newPubSub := func(comp *redrivePubSub, schemas map[string]rtpubsub.SchemaDefinition) *pubsub {
+		ps := New(Options{
+			Registry:       registry.New(registry.NewOptions()).PubSubs(),
+			Resiliency:     resiliency.New(logger.NewLogger("test")),
+			ComponentStore: compstore.New(),
+			Meta:           meta.New(meta.Options{}),
+			ID:             TestRuntimeConfigID,
+		})
+		ps.compStore.AddPubSub("schemapubsub", compstore.PubsubItem{Component: comp, TopicSchemas: schemas})
+		ps.compStore.AddSecretStore("secrets", schemaSecretStore{})
+		return ps
+	}
+
+	publish := func(ps *pubsub, topic string, data []byte) error {
+		ce, err := rtpubsub.NewCloudEvent(&rtpubsub.CloudEvent{
+			ID:              "1",
+			Topic:           topic,
+			Pubsub:          "schemapubsub",
+			DataContentType: "application/json",
+			Data:            data,
+		}, nil)
+		require.NoError(t, err)
+		b, err := json.Marshal(ce)
+		require.NoError(t, err)
+		return ps.Publish(context.Background(), &contribpubsub.PublishRequest{
+			PubsubName: "schemapubsub",
+			Topic:      topic,
+			Data:       b,
+		})
+	}
+
+	t.Run("publish is validated against the component schema", func(t *testing.T) {
+		comp := &redrivePubSub{}
+		ps := newPubSub(comp, map[string]rtpubsub.SchemaDefinition{"orders": {Inline: testOrderSchema}})
+
+		require.NoError(t, publish(ps, "orders", []byte(`{"orderId":1}`)))
+		require.ErrorAs(t, publish(ps, "orders", []byte(`{"id":1}`)), &rtpubsub.SchemaValidationError{})
+		// Topics without a schema are not validated.
+		require.NoError(t, publish(ps, "invoices", []byte(`{"id":1}`)))
+
+		comp.lock.Lock()
+		defer comp.lock.Unlock()
+		assert.Len(t, comp.published, 2)
+	})
+
+	t.Run("schema referenced from a secret store", func(t *testing.T) {
+		ps := newPubSub(&redrivePubSub{}, map[string]rtpubsub.SchemaDefinition{"orders": {Ref: "secretstore/secrets/schemas/orders"}})
+
+		require.NoError(t, publish(ps, "orders", []byte(`{"orderId":1}`)))
+		require.ErrorAs(t, publish(ps, "orders", []byte(`{"id":1}`)), &rtpubsub.SchemaValidationError{})
+	})
+
+	t.Run("unresolvable schema reference", func(t *testing.T) {
+		ps := newPubSub(&redrivePubSub{}, map[string]rtpubsub.SchemaDefinition{"orders": {Ref: "secretstore/missing/schemas/orders"}})
+
+		err := publish(ps, "orders", []byte(`{"orderId":1}`))
+		require.Error(t, err)
+		assert.False(t, errors.As(err, &rtpubsub.SchemaValidationError{}))
+	})
+
+	t.Run("non-conforming inbound messages are dead-lettered", func(t *testing.T) {
+		b, err := json.Marshal(map[string]any{
+			contribpubsub.IDField:          "1",
+			contribpubsub.SpecVersionField: "1.0",
+			contribpubsub.DataField:        map[string]any{"id": 1},
+		})
+		require.NoError(t, err)
+		comp := &redrivePubSub{deadLetters: []*contribpubsub.NewMessage{{Topic: "orders", Data: b}}}
+		ps := newPubSub(comp, nil)
+
+		err = ps.subscribeTopic(context.Background(), "schemapubsub", "orders", compstore.TopicRouteElem{
+			Metadata:        map[string]string{rtpubsub.SchemaMetadataKey: testOrderSchema},
+			Rules:           []*rtpubsub.Rule{{Path: "orders"}},
+			DeadLetterTopic: "orders-dlq",
+		})
+		require.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			comp.lock.Lock()
+			defer comp.lock.Unlock()
+			return len(comp.published) == 1 && comp.published[0].Topic == "orders-dlq"
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
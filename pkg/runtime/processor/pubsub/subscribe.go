@@ -105,6 +105,7 @@ func (p *pubsub) topicRoutes(ctx context.Context) (map[string]compstore.TopicRou
 			Rules:           s.Rules,
 			DeadLetterTopic: s.DeadLetterTopic,
 			BulkSubscribe:   s.BulkSubscribe,
+			DeliveryLimits:  s.DeliveryLimits,
 		}
 	}
 
@@ -73,7 +73,15 @@ func (p *pubsub) subscribeTopic(ctx context.Context, name, topic string, route c
 		subscribeTopic = p.namespace + topic
 	}
 
-	err := pubSub.Component.Subscribe(ctx, contribpubsub.SubscribeRequest{
+	schema, err := p.topicSchema(ctx, pubSub, topic, routeMetadata)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to subscribe to topic %s: %w", topic, err)
+	}
+
+	limiter := newDeliveryLimiter(route.DeliveryLimits)
+
+	err = pubSub.Component.Subscribe(ctx, contribpubsub.SubscribeRequest{
 		Topic:    subscribeTopic,
 		Metadata: routeMetadata,
 	}, func(ctx context.Context, msg *contribpubsub.NewMessage) error {
@@ -145,6 +153,18 @@ func (p *pubsub) subscribeTopic(ctx context.Context, name, topic string, route c
 			return nil
 		}
 
+		if schema != nil {
+			if vErr := schema.ValidateCloudEvent(name, msgTopic, cloudEvent); vErr != nil {
+				log.Warnf("dropping pub/sub event %v not conforming to the schema: %s", cloudEvent[contribpubsub.IDField], vErr)
+				diag.DefaultComponentMonitoring.PubsubIngressEvent(ctx, name, strings.ToLower(string(contribpubsub.Drop)), msgTopic, 0)
+
+				if route.DeadLetterTopic != "" {
+					_ = p.sendToDeadLetter(ctx, name, msg, route.DeadLetterTopic)
+				}
+				return nil
+			}
+		}
+
 		routePath, shouldProcess, err := findMatchingRoute(route.Rules, cloudEvent)
 		if err != nil {
 			log.Errorf("error finding matching route for event %v in pubsub %s and topic %s: %s", cloudEvent[contribpubsub.IDField], name, msgTopic, err)
@@ -176,6 +196,16 @@ func (p *pubsub) subscribeTopic(ctx context.Context, name, topic string, route c
 			path:       routePath,
 			pubsub:     name,
 		}
+
+		release, throttled, err := limiter.acquire(ctx, 1)
+		if throttled {
+			diag.DefaultComponentMonitoring.PubsubIngressThrottled(ctx, name, msgTopic, 1)
+		}
+		if err != nil {
+			return err
+		}
+		defer release()
+
 		policyRunner := resiliency.NewRunner[any](ctx, policyDef)
 		_, err = policyRunner(func(ctx context.Context) (any, error) {
 			var pErr error
@@ -29,6 +29,10 @@ import (
 	"github.com/dapr/dapr/pkg/runtime/compstore"
 	rterrors "github.com/dapr/dapr/pkg/runtime/errors"
 	"github.com/dapr/dapr/pkg/runtime/meta"
+	"github.com/dapr/dapr/pkg/statecache"
+	"github.com/dapr/dapr/pkg/statechanges"
+	"github.com/dapr/dapr/pkg/stateindex"
+	"github.com/dapr/dapr/pkg/statetx"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/utils"
 )
@@ -40,14 +44,20 @@ const (
 var log = logger.NewLogger("dapr.runtime.processor.state")
 
 type Options struct {
+	AppID            string
 	Registry         *compstate.Registry
 	ComponentStore   *compstore.ComponentStore
 	Meta             *meta.Meta
 	PlacementEnabled bool
 	Outbox           outbox.Outbox
+	Changes          *statechanges.Notifier
+	Transactions     *statetx.Coordinator
+	Index            *stateindex.Indexer
+	Cache            *statecache.Cache
 }
 
 type state struct {
+	appID     string
 	registry  *compstate.Registry
 	compStore *compstore.ComponentStore
 	meta      *meta.Meta
@@ -56,15 +66,24 @@ type state struct {
 	actorStateStoreName *string
 	placementEnabled    bool
 	outbox              outbox.Outbox
+	changes             *statechanges.Notifier
+	transactions        *statetx.Coordinator
+	index               *stateindex.Indexer
+	cache               *statecache.Cache
 }
 
 func New(opts Options) *state {
 	return &state{
+		appID:            opts.AppID,
 		registry:         opts.Registry,
 		compStore:        opts.ComponentStore,
 		meta:             opts.Meta,
 		placementEnabled: opts.PlacementEnabled,
 		outbox:           opts.Outbox,
+		changes:          opts.Changes,
+		transactions:     opts.Transactions,
+		index:            opts.Index,
+		cache:            opts.Cache,
 	}
 }
 
@@ -89,11 +108,22 @@ func (s *state) Init(ctx context.Context, comp compapi.Component) error {
 			return rterrors.NewInit(rterrors.CreateComponentFailure, fName, err)
 		}
 
-		if encKeys.Primary.Key != "" {
+		encKeys.Envelope, encErr = encryption.ComponentEnvelopeKeys(comp, s.compStore.GetCryptoProvider)
+		if encErr != nil {
+			diag.DefaultMonitoring.ComponentInitFailed(comp.Spec.Type, "creation", comp.ObjectMeta.Name)
+			return rterrors.NewInit(rterrors.CreateComponentFailure, fName, encErr)
+		}
+
+		encKeys.Fields = encryption.ComponentEncryptedFields(comp)
+		if encKeys.Primary.Key != "" || encKeys.Envelope != nil {
 			ok := encryption.AddEncryptedStateStore(comp.ObjectMeta.Name, encKeys)
-			if ok {
+			if ok && len(encKeys.Fields) > 0 {
+				log.Infof("automatic encryption enabled for fields %v of state store %s", encKeys.Fields, comp.ObjectMeta.Name)
+			} else if ok {
 				log.Infof("automatic encryption enabled for state store %s", comp.ObjectMeta.Name)
 			}
+		} else if len(encKeys.Fields) > 0 {
+			log.Warnf("encrypted fields are set for state store %s, but no encryption keys are configured", comp.ObjectMeta.Name)
 		}
 
 		meta, err := s.meta.ToBaseMetadata(comp)
@@ -109,6 +139,19 @@ func (s *state) Init(ctx context.Context, comp compapi.Component) error {
 			return rterrors.NewInit(rterrors.InitComponentFailure, fName, err)
 		}
 
+		// The store isn't used yet if it can't be the transaction log.
+		if s.transactions != nil {
+			if err = s.transactions.AddOrUpdateStore(comp); err != nil {
+				if closer, ok := store.(io.Closer); ok {
+					if closeErr := closer.Close(); closeErr != nil {
+						log.Warnf("Failed to close state store %s: %v", comp.ObjectMeta.Name, closeErr)
+					}
+				}
+				diag.DefaultMonitoring.ComponentInitFailed(comp.Spec.Type, "init", comp.ObjectMeta.Name)
+				return rterrors.NewInit(rterrors.InitComponentFailure, fName, err)
+			}
+		}
+
 		s.compStore.AddStateStore(comp.ObjectMeta.Name, store)
 		err = compstate.SaveStateConfiguration(comp.ObjectMeta.Name, props)
 		if err != nil {
@@ -117,7 +160,26 @@ func (s *state) Init(ctx context.Context, comp compapi.Component) error {
 			return rterrors.NewInit(rterrors.InitComponentFailure, fName, wrapError)
 		}
 
+		if opts, ok := encryption.RotationOnStartup(comp); ok && encryption.EncryptedStateStore(comp.ObjectMeta.Name) {
+			name := comp.ObjectMeta.Name
+			opts.OnRewritten = func(key string) {
+				s.cache.Invalidate(name, []contribstate.TransactionalStateOperation{contribstate.DeleteRequest{Key: key}})
+			}
+			if _, err = encryption.StartKeyRotation(comp.ObjectMeta.Name, s.appID, store, opts); err != nil {
+				log.Warnf("Failed to start rotating the encryption keys of state store %s: %v", comp.ObjectMeta.Name, err)
+			}
+		}
+
 		s.outbox.AddOrUpdateOutbox(comp)
+		if s.changes != nil {
+			s.changes.AddOrUpdateStore(comp)
+		}
+		if s.index != nil {
+			s.index.AddOrUpdateStore(comp)
+		}
+		if s.cache != nil {
+			s.cache.AddOrUpdateStore(comp)
+		}
 
 		// when placement address list is not empty, set specified actor store.
 		if s.placementEnabled {
@@ -156,6 +218,9 @@ func (s *state) Close(comp compapi.Component) error {
 		return nil
 	}
 
+	encryption.StopKeyRotation(comp.Name)
+	compstate.StopKeyMigration(comp.Name)
+
 	closer, ok := ss.(io.Closer)
 	if ok && closer != nil {
 		if err := closer.Close(); err != nil {
@@ -164,10 +229,56 @@ func (s *state) Close(comp compapi.Component) error {
 	}
 
 	s.compStore.DeleteStateStore(comp.Name)
+	if s.transactions != nil {
+		s.transactions.RemoveStore(comp.Name)
+	}
+	if s.index != nil {
+		s.index.RemoveStore(comp.Name)
+	}
+	if s.cache != nil {
+		s.cache.RemoveStore(comp.Name)
+	}
 
 	return nil
 }
 
+// Changes returns the notifier of the changes made to the state stores.
+func (s *state) Changes() *statechanges.Notifier {
+	return s.changes
+}
+
+// Transactions returns the coordinator of the transactions spanning multiple
+// state stores.
+func (s *state) Transactions() *statetx.Coordinator {
+	return s.transactions
+}
+
+// Index returns the indexer of the state stores that can't be queried
+// natively.
+func (s *state) Index() *stateindex.Indexer {
+	return s.index
+}
+
+// StateWritten returns the function called after the runtime commits
+// operations to a state store outside of the state API, with the keys and
+// values as saved. Like the state API, it invalidates the cached values,
+// notifies the subscribers of the changes and updates the query index of the
+// store.
+func StateWritten(cache *statecache.Cache, changes *statechanges.Notifier, index *stateindex.Indexer) statetx.WrittenFn {
+	return func(ctx context.Context, storeName string, ops []contribstate.TransactionalStateOperation) {
+		cache.Invalidate(storeName, ops)
+		changes.Notify(ctx, storeName, ops)
+		if err := index.Update(ctx, storeName, ops); err != nil {
+			log.Warnf("Failed to update the query index of state store %s: %v", storeName, err)
+		}
+	}
+}
+
+// Cache returns the cache of the values read from the state stores.
+func (s *state) Cache() *statecache.Cache {
+	return s.cache
+}
+
 func (s *state) ActorStateStoreName() (string, bool) {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
@@ -134,6 +134,63 @@ func TestInitState(t *testing.T) {
 	})
 }
 
+// closingStateStore records whether it was closed.
+type closingStateStore struct {
+	*daprt.FakeStateStore
+	closed bool
+}
+
+func (s *closingStateStore) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestInitStateDuplicateTransactionLog(t *testing.T) {
+	reg := registry.New(registry.NewOptions().WithStateStores(stateLoader.NewRegistry()))
+	compStore := compstore.New()
+	proc := processor.New(processor.Options{
+		Registry:       reg,
+		ComponentStore: compStore,
+		GlobalConfig:   new(config.Configuration),
+		Meta:           meta.New(meta.Options{Mode: modes.StandaloneMode}),
+	})
+
+	var stores []*closingStateStore
+	reg.StateStores().RegisterComponent(
+		func(_ logger.Logger) contribstate.Store {
+			store := &closingStateStore{FakeStateStore: daprt.NewFakeStateStore()}
+			stores = append(stores, store)
+			return store
+		},
+		"fakeState",
+	)
+	logStore := func(name string) compapi.Component {
+		return compapi.Component{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: compapi.ComponentSpec{
+				Type:    "state.fakeState",
+				Version: "v1",
+				Metadata: []common.NameValuePair{{
+					Name:  "transactionLogStore",
+					Value: common.DynamicValue{JSON: apiextv1.JSON{Raw: []byte("true")}},
+				}},
+			},
+		}
+	}
+
+	assert.NoError(t, proc.Init(context.TODO(), logStore("log1")))
+
+	err := proc.Init(context.TODO(), logStore("log2"))
+	var initErr *rterrors.InitError
+	assert.ErrorAs(t, err, &initErr)
+	_, ok := compStore.GetStateStore("log2")
+	assert.False(t, ok)
+	if assert.Len(t, stores, 2) {
+		assert.False(t, stores[0].closed)
+		assert.True(t, stores[1].closed)
+	}
+}
+
 func initMockStateStoreForRegistry(reg *registry.Registry, encryptKey string, e error) *daprt.MockStateStore {
 	mockStateStore := new(daprt.MockStateStore)
 
@@ -26,3 +26,9 @@ type Adapter interface {
 	BulkPublish(context.Context, *contribPubsub.BulkPublishRequest) (contribPubsub.BulkPublishResponse, error)
 	Outbox() outbox.Outbox
 }
+
+// Redriver is implemented by adapters that can move messages from the
+// dead-letter topic of a subscription back to the subscription.
+type Redriver interface {
+	Redrive(context.Context, *RedriveRequest) (*RedriveResponse, error)
+}
@@ -16,6 +16,7 @@ package pubsub
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/dapr/dapr/pkg/messages"
 )
@@ -40,3 +41,15 @@ type NotAllowedError struct {
 func (e NotAllowedError) Error() string {
 	return fmt.Sprintf(messages.ErrPubsubForbidden, e.Topic, e.ID)
 }
+
+// pubsub.SchemaValidationError is returned by the runtime when the data of a
+// cloud event does not conform to the JSON Schema of its topic.
+type SchemaValidationError struct {
+	PubsubName string
+	Topic      string
+	Errors     []string
+}
+
+func (e SchemaValidationError) Error() string {
+	return fmt.Sprintf(messages.ErrPubsubCloudEventSchema, e.Topic, e.PubsubName, strings.Join(e.Errors, "; "))
+}
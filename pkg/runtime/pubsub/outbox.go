@@ -17,6 +17,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,6 +28,7 @@ import (
 	contribPubsub "github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/components-contrib/state"
 	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	diag "github.com/dapr/dapr/pkg/diagnostics"
 	"github.com/dapr/dapr/pkg/outbox"
 	"github.com/dapr/kit/logger"
 	"github.com/dapr/kit/utils"
@@ -36,10 +39,52 @@ const (
 	outboxPublishTopicKey            = "outboxPublishTopic"
 	outboxPubsubKey                  = "outboxPubsub"
 	outboxDiscardWhenMissingStateKey = "outboxDiscardWhenMissingState"
+	outboxMaxVerificationAgeKey      = "outboxMaxVerificationAge"
+	outboxDeadLetterTopicKey         = "outboxDeadLetterTopic"
 	outboxStatePrefix                = "outbox"
 	defaultStateScanDelay            = time.Second * 1
+
+	// outboxProjectionKey is the metadata key that marks a set operation of a
+	// transaction as the outbox projection of the set operation with the same
+	// key: its value is published instead of the saved state, and it is not
+	// saved itself.
+	outboxProjectionKey = "outbox.projection"
+	// cloudEventMetadataPrefix is the prefix of the metadata keys of a
+	// projection that set attributes of the published cloud event.
+	cloudEventMetadataPrefix = "cloudevent."
 )
 
+// outboxCloudEventOverrides are the cloud event attributes which are set by
+// NewCloudEvent from metadata.
+var outboxCloudEventOverrides = map[string]struct{}{
+	contribPubsub.IDField:          {},
+	contribPubsub.SourceField:      {},
+	contribPubsub.TypeField:        {},
+	contribPubsub.TraceIDField:     {},
+	contribPubsub.TraceParentField: {},
+	contribPubsub.TraceStateField:  {},
+}
+
+// outboxCloudEventReservedAttributes are the cloud event attributes which are
+// set by the outbox and are not carried from the internal outbox message to
+// the published message.
+var outboxCloudEventReservedAttributes = map[string]struct{}{
+	contribPubsub.IDField:              {},
+	contribPubsub.SourceField:          {},
+	contribPubsub.TypeField:            {},
+	contribPubsub.SpecVersionField:     {},
+	contribPubsub.DataContentTypeField: {},
+	contribPubsub.DataField:            {},
+	contribPubsub.DataBase64Field:      {},
+	contribPubsub.TopicField:           {},
+	contribPubsub.PubsubField:          {},
+	contribPubsub.TraceIDField:         {},
+	contribPubsub.TraceParentField:     {},
+	contribPubsub.TraceStateField:      {},
+	contribPubsub.ExpirationField:      {},
+	contribPubsub.TimeField:            {},
+}
+
 var outboxLogger = logger.NewLogger("dapr.outbox")
 
 type outboxConfig struct {
@@ -47,6 +92,8 @@ type outboxConfig struct {
 	publishTopic                  string
 	outboxPubsub                  string
 	outboxDiscardWhenMissingState bool
+	maxVerificationAge            time.Duration
+	deadLetterTopic               string
 }
 
 type outboxImpl struct {
@@ -57,6 +104,11 @@ type outboxImpl struct {
 	outboxStores          map[string]outboxConfig
 	lock                  sync.RWMutex
 	namespace             string
+
+	// pending is the number of internal outbox messages each state store has
+	// in this instance, waiting to be verified and published.
+	pending     map[string]int64
+	pendingLock sync.Mutex
 }
 
 // NewOutbox returns an instance of an Outbox.
@@ -76,6 +128,8 @@ func NewOutbox(publishFn func(context.Context, *contribPubsub.PublishRequest) er
 func (o *outboxImpl) AddOrUpdateOutbox(stateStore v1alpha1.Component) {
 	var publishPubSub, publishTopicKey, outboxPubsub string
 	var outboxDiscardWhenMissingState bool
+	var maxVerificationAge time.Duration
+	var deadLetterTopic string
 
 	for _, v := range stateStore.Spec.Metadata {
 		switch v.Name {
@@ -87,6 +141,15 @@ func (o *outboxImpl) AddOrUpdateOutbox(stateStore v1alpha1.Component) {
 			outboxPubsub = v.Value.String()
 		case outboxDiscardWhenMissingStateKey:
 			outboxDiscardWhenMissingState = utils.IsTruthy(v.Value.String())
+		case outboxMaxVerificationAgeKey:
+			d, err := time.ParseDuration(v.Value.String())
+			if err != nil || d < 0 {
+				outboxLogger.Warnf("invalid %s value '%s' for state store %s, messages will be retried until verified", outboxMaxVerificationAgeKey, v.Value.String(), stateStore.Name)
+				continue
+			}
+			maxVerificationAge = d
+		case outboxDeadLetterTopicKey:
+			deadLetterTopic = v.Value.String()
 		}
 	}
 
@@ -103,10 +166,36 @@ func (o *outboxImpl) AddOrUpdateOutbox(stateStore v1alpha1.Component) {
 			publishTopic:                  publishTopicKey,
 			outboxPubsub:                  outboxPubsub,
 			outboxDiscardWhenMissingState: outboxDiscardWhenMissingState,
+			maxVerificationAge:            maxVerificationAge,
+			deadLetterTopic:               deadLetterTopic,
 		}
 	}
 }
 
+// Stores returns the outbox configuration of the state stores with outbox
+// enabled, sorted by state store name.
+func (o *outboxImpl) Stores() []outbox.StoreConfig {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	stores := make([]outbox.StoreConfig, 0, len(o.outboxStores))
+	for name, c := range o.outboxStores {
+		stores = append(stores, outbox.StoreConfig{
+			StateStore:              name,
+			PublishPubsub:           c.publishPubSub,
+			PublishTopic:            c.publishTopic,
+			OutboxPubsub:            c.outboxPubsub,
+			DiscardWhenMissingState: c.outboxDiscardWhenMissingState,
+			MaxVerificationAge:      c.maxVerificationAge,
+			DeadLetterTopic:         c.deadLetterTopic,
+		})
+	}
+	sort.Slice(stores, func(i, j int) bool {
+		return stores[i].StateStore < stores[j].StateStore
+	})
+	return stores
+}
+
 // Enabled returns a bool to indicate if a state store has outbox configured
 func (o *outboxImpl) Enabled(stateStore string) bool {
 	o.lock.RLock()
@@ -128,7 +217,13 @@ func transaction() (state.TransactionalStateOperation, error) {
 	}, nil
 }
 
-// PublishInternal publishes the state to an internal topic for outbox processing
+// PublishInternal publishes the state to an internal topic for outbox processing.
+// Set operations with the "outbox.projection" metadata set to true are
+// projections: their value is published in place of the value of the set
+// operation with the same key, and they are not saved.
+// It returns the operations to execute in the state transaction: the given
+// operations without the projections, followed by the outbox transaction
+// markers.
 func (o *outboxImpl) PublishInternal(ctx context.Context, stateStore string, operations []state.TransactionalStateOperation, source string) ([]state.TransactionalStateOperation, error) {
 	o.lock.RLock()
 	c, ok := o.outboxStores[stateStore]
@@ -138,8 +233,13 @@ func (o *outboxImpl) PublishInternal(ctx context.Context, stateStore string, ope
 		return nil, fmt.Errorf("error publishing internal outbox message: could not find outbox configuration on state store %s", stateStore)
 	}
 
-	trs := make([]state.TransactionalStateOperation, 0, len(operations))
-	for _, op := range operations {
+	ops, projections, err := splitOutboxProjections(operations)
+	if err != nil {
+		return nil, err
+	}
+
+	trs := make([]state.TransactionalStateOperation, 0, len(ops))
+	for _, op := range ops {
 		sr, ok := op.(state.SetRequest)
 		if ok {
 			tr, err := transaction()
@@ -147,6 +247,10 @@ func (o *outboxImpl) PublishInternal(ctx context.Context, stateStore string, ope
 				return nil, err
 			}
 
+			if projection, ok := projections[sr.Key]; ok {
+				sr = projection
+			}
+
 			var ceData []byte
 			bt, ok := sr.Value.([]byte)
 			if ok {
@@ -166,10 +270,14 @@ func (o *outboxImpl) PublishInternal(ctx context.Context, stateStore string, ope
 				ce.DataContentType = *sr.ContentType
 			}
 
-			msg, err := NewCloudEvent(ce, nil)
+			md, extensions := outboxCloudEventMetadata(sr.Metadata)
+			msg, err := NewCloudEvent(ce, md)
 			if err != nil {
 				return nil, err
 			}
+			for k, v := range extensions {
+				msg[k] = v
+			}
 
 			data, err := json.Marshal(msg)
 			if err != nil {
@@ -189,7 +297,86 @@ func (o *outboxImpl) PublishInternal(ctx context.Context, stateStore string, ope
 		}
 	}
 
-	return trs, nil
+	return append(ops, trs...), nil
+}
+
+// splitOutboxProjections separates the projections from the operations to
+// save. Each projection must match a set operation with the same key.
+func splitOutboxProjections(operations []state.TransactionalStateOperation) ([]state.TransactionalStateOperation, map[string]state.SetRequest, error) {
+	ops := make([]state.TransactionalStateOperation, 0, len(operations))
+	projections := make(map[string]state.SetRequest)
+	for _, op := range operations {
+		sr, ok := op.(state.SetRequest)
+		if ok && utils.IsTruthy(sr.Metadata[outboxProjectionKey]) {
+			if _, ok := projections[sr.Key]; ok {
+				return nil, nil, fmt.Errorf("found more than one outbox projection for key %s", sr.Key)
+			}
+			projections[sr.Key] = sr
+			continue
+		}
+		ops = append(ops, op)
+	}
+
+	for key := range projections {
+		found := false
+		for _, op := range ops {
+			if sr, ok := op.(state.SetRequest); ok && sr.Key == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("outbox projection for key %s has no matching set operation", key)
+		}
+	}
+
+	return ops, projections, nil
+}
+
+// outboxCloudEventMetadata splits the "cloudevent." metadata of an operation
+// into the overrides understood by NewCloudEvent and the extra attributes to
+// add to the cloud event.
+// The ID of the cloud event cannot be overridden as it identifies the
+// outbox transaction marker.
+func outboxCloudEventMetadata(metadata map[string]string) (map[string]string, map[string]string) {
+	var md, extensions map[string]string
+	for k, v := range metadata {
+		if !strings.HasPrefix(k, cloudEventMetadataPrefix) {
+			continue
+		}
+		attr := strings.ToLower(strings.TrimPrefix(k, cloudEventMetadataPrefix))
+		if attr == contribPubsub.IDField {
+			continue
+		}
+		if _, ok := outboxCloudEventOverrides[attr]; ok {
+			if md == nil {
+				md = make(map[string]string)
+			}
+			md[k] = v
+			continue
+		}
+		if _, ok := outboxCloudEventReservedAttributes[attr]; ok {
+			continue
+		}
+		if extensions == nil {
+			extensions = make(map[string]string)
+		}
+		extensions[attr] = v
+	}
+	return md, extensions
+}
+
+// addPending updates the number of outbox messages of the state store this
+// instance is processing, and records it.
+func (o *outboxImpl) addPending(ctx context.Context, stateStore string, delta int64) {
+	o.pendingLock.Lock()
+	defer o.pendingLock.Unlock()
+
+	if o.pending == nil {
+		o.pending = make(map[string]int64)
+	}
+	o.pending[stateStore] += delta
+	diag.DefaultComponentMonitoring.StateOutboxPending(ctx, stateStore, o.pending[stateStore])
 }
 
 func outboxTopic(appID, topic, namespace string) string {
@@ -217,9 +404,25 @@ func (o *outboxImpl) SubscribeToInternalTopics(ctx context.Context, appID string
 				return err
 			}
 
+			o.addPending(ctx, stateStore, 1)
+			defer o.addPending(ctx, stateStore, -1)
+
 			stateKey := o.cloudEventExtractorFn(cloudEvent, contribPubsub.IDField)
-			data := []byte(o.cloudEventExtractorFn(cloudEvent, contribPubsub.DataField))
 			contentType := o.cloudEventExtractorFn(cloudEvent, contribPubsub.DataContentTypeField)
+			eventType := o.cloudEventExtractorFn(cloudEvent, contribPubsub.TypeField)
+
+			var data []byte
+			switch v := cloudEvent[contribPubsub.DataField].(type) {
+			case nil:
+			case string:
+				data = []byte(v)
+			default:
+				// JSON data is decoded into the cloud event.
+				data, err = json.Marshal(v)
+				if err != nil {
+					return err
+				}
+			}
 
 			store, ok := o.getStateFn(stateStore)
 			if !ok {
@@ -235,6 +438,7 @@ func (o *outboxImpl) SubscribeToInternalTopics(ctx context.Context, appID string
 				Multiplier:          3,
 				Clock:               backoff.SystemClock,
 				RandomizationFactor: 0.1,
+				Stop:                backoff.Stop,
 			}
 
 			err = backoff.Retry(func() error {
@@ -252,41 +456,40 @@ func (o *outboxImpl) SubscribeToInternalTopics(ctx context.Context, appID string
 				return fmt.Errorf("cannot publish outbox message to topic %s with pubsub %s: outbox state not found", c.publishTopic, c.publishPubSub)
 			}, bo)
 			if err != nil {
+				diag.DefaultComponentMonitoring.StateOutboxEvent(ctx, stateStore, diag.OutboxVerificationFailed)
+
 				if c.outboxDiscardWhenMissingState {
 					outboxLogger.Errorf("failed to publish outbox topic to pubsub %s: %s, discarding message", c.publishPubSub, err)
+					diag.DefaultComponentMonitoring.StateOutboxEvent(ctx, stateStore, diag.OutboxDiscarded)
 					//lint:ignore nilerr dropping message
 					return nil
 				}
 
-				outboxLogger.Errorf("failed to publish outbox topic to pubsub %s: %s, rejecting for later processing", c.publishPubSub, err)
-				return err
-			}
-
-			ce, err := NewCloudEvent(&CloudEvent{
-				Data:            data,
-				DataContentType: contentType,
-				Pubsub:          c.publishPubSub,
-				Source:          appID,
-				Topic:           c.publishTopic,
-			}, nil)
-			if err != nil {
-				return err
-			}
+				if c.maxVerificationAge > 0 && outboxMessageAge(cloudEvent) > c.maxVerificationAge {
+					if c.deadLetterTopic == "" {
+						outboxLogger.Errorf("failed to publish outbox topic to pubsub %s: %s, discarding message older than %v", c.publishPubSub, err, c.maxVerificationAge)
+						diag.DefaultComponentMonitoring.StateOutboxEvent(ctx, stateStore, diag.OutboxDiscarded)
+						return nil
+					}
+
+					outboxLogger.Errorf("failed to publish outbox topic to pubsub %s: %s, sending message older than %v to dead letter topic %s", c.publishPubSub, err, c.maxVerificationAge, c.deadLetterTopic)
+					err = o.publishOutboxMessage(ctx, appID, c.publishPubSub, c.deadLetterTopic, cloudEvent, data, contentType, eventType)
+					if err != nil {
+						return err
+					}
+					diag.DefaultComponentMonitoring.StateOutboxEvent(ctx, stateStore, diag.OutboxDeadLettered)
+					return nil
+				}
 
-			b, err := json.Marshal(ce)
-			if err != nil {
+				outboxLogger.Errorf("failed to publish outbox topic to pubsub %s: %s, rejecting for later processing", c.publishPubSub, err)
 				return err
 			}
 
-			err = o.publishFn(ctx, &contribPubsub.PublishRequest{
-				PubsubName:  c.publishPubSub,
-				Data:        b,
-				Topic:       c.publishTopic,
-				ContentType: &contentType,
-			})
+			err = o.publishOutboxMessage(ctx, appID, c.publishPubSub, c.publishTopic, cloudEvent, data, contentType, eventType)
 			if err != nil {
 				return err
 			}
+			diag.DefaultComponentMonitoring.StateOutboxEvent(ctx, stateStore, diag.OutboxPublished)
 
 			err = backoff.Retry(func() error {
 				err = store.Delete(ctx, &state.DeleteRequest{
@@ -304,3 +507,49 @@ func (o *outboxImpl) SubscribeToInternalTopics(ctx context.Context, appID string
 
 	return nil
 }
+
+// publishOutboxMessage publishes the message of the internal outbox cloud
+// event to the given topic, carrying the extension attributes set by the
+// projection.
+func (o *outboxImpl) publishOutboxMessage(ctx context.Context, appID, pubsubName, topic string, cloudEvent map[string]any, data []byte, contentType, eventType string) error {
+	ce, err := NewCloudEvent(&CloudEvent{
+		Data:            data,
+		DataContentType: contentType,
+		Pubsub:          pubsubName,
+		Source:          appID,
+		Topic:           topic,
+		Type:            eventType,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range cloudEvent {
+		if _, ok := outboxCloudEventReservedAttributes[k]; !ok {
+			ce[k] = v
+		}
+	}
+
+	b, err := json.Marshal(ce)
+	if err != nil {
+		return err
+	}
+
+	return o.publishFn(ctx, &contribPubsub.PublishRequest{
+		PubsubName:  pubsubName,
+		Data:        b,
+		Topic:       topic,
+		ContentType: &contentType,
+	})
+}
+
+// outboxMessageAge returns the time elapsed since the internal outbox cloud
+// event was created, or zero if the event has no valid time attribute.
+func outboxMessageAge(cloudEvent map[string]any) time.Duration {
+	rawTime, _ := cloudEvent[contribPubsub.TimeField].(string)
+	t, err := time.Parse(time.RFC3339, rawTime)
+	if err != nil {
+		return 0
+	}
+	return time.Since(t)
+}
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -202,9 +203,98 @@ func TestAddOrUpdateOutbox(t *testing.T) {
 		assert.Equal(t, c.outboxPubsub, "a")
 		assert.Equal(t, c.publishPubSub, "a")
 		assert.Equal(t, c.publishTopic, "1")
+		assert.Zero(t, c.maxVerificationAge)
+		assert.Empty(t, c.deadLetterTopic)
+	})
+
+	t.Run("max verification age and dead letter topic", func(t *testing.T) {
+		o := newTestOutbox().(*outboxImpl)
+		o.AddOrUpdateOutbox(v1alpha1.Component{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test",
+			},
+			Spec: v1alpha1.ComponentSpec{
+				Metadata: []common.NameValuePair{
+					{
+						Name: outboxPublishPubsubKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("a"),
+							},
+						},
+					},
+					{
+						Name: outboxPublishTopicKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("1"),
+							},
+						},
+					},
+					{
+						Name: outboxMaxVerificationAgeKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("10m"),
+							},
+						},
+					},
+					{
+						Name: outboxDeadLetterTopicKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("dlq"),
+							},
+						},
+					},
+				},
+			},
+		})
+
+		c := o.outboxStores["test"]
+		assert.Equal(t, 10*time.Minute, c.maxVerificationAge)
+		assert.Equal(t, "dlq", c.deadLetterTopic)
 	})
 }
 
+func TestStores(t *testing.T) {
+	o := newTestOutbox().(*outboxImpl)
+	assert.Empty(t, o.Stores())
+
+	for _, name := range []string{"b", "a"} {
+		o.AddOrUpdateOutbox(v1alpha1.Component{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: v1alpha1.ComponentSpec{
+				Metadata: []common.NameValuePair{
+					{
+						Name: outboxPublishPubsubKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("ps"),
+							},
+						},
+					},
+					{
+						Name: outboxPublishTopicKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("topic-" + name),
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	assert.Equal(t, []outbox.StoreConfig{
+		{StateStore: "a", PublishPubsub: "ps", PublishTopic: "topic-a", OutboxPubsub: "ps"},
+		{StateStore: "b", PublishPubsub: "ps", PublishTopic: "topic-b", OutboxPubsub: "ps"},
+	}, o.Stores())
+}
+
 func TestPublishInternal(t *testing.T) {
 	t.Run("valid operation, correct default parameters", func(t *testing.T) {
 		o := newTestOutbox().(*outboxImpl)
@@ -405,6 +495,122 @@ func TestPublishInternal(t *testing.T) {
 
 		assert.Error(t, err)
 	})
+
+	t.Run("projection replaces the published payload", func(t *testing.T) {
+		o := newTestOutbox().(*outboxImpl)
+		o.publishFn = func(ctx context.Context, pr *contribPubsub.PublishRequest) error {
+			var cloudEvent map[string]interface{}
+			err := json.Unmarshal(pr.Data, &cloudEvent)
+			assert.NoError(t, err)
+
+			assert.Equal(t, map[string]interface{}{"id": "1"}, cloudEvent["data"])
+			assert.Equal(t, "application/json", cloudEvent["datacontenttype"])
+			assert.Equal(t, "order.created", cloudEvent["type"])
+			assert.Equal(t, "eu", cloudEvent["region"])
+			assert.NotEqual(t, "myid", cloudEvent["id"])
+
+			return nil
+		}
+
+		o.AddOrUpdateOutbox(v1alpha1.Component{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test",
+			},
+			Spec: v1alpha1.ComponentSpec{
+				Metadata: []common.NameValuePair{
+					{
+						Name: outboxPublishPubsubKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("a"),
+							},
+						},
+					},
+					{
+						Name: outboxPublishTopicKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("1"),
+							},
+						},
+					},
+				},
+			},
+		})
+
+		contentType := "application/json"
+		ops, err := o.PublishInternal(context.Background(), "test", []state.TransactionalStateOperation{
+			state.SetRequest{
+				Key:   "1",
+				Value: "full state",
+			},
+			state.SetRequest{
+				Key:         "1",
+				Value:       `{"id":"1"}`,
+				ContentType: &contentType,
+				Metadata: map[string]string{
+					outboxProjectionKey: "true",
+					"cloudevent.type":   "order.created",
+					"cloudevent.id":     "myid",
+					"cloudevent.region": "eu",
+				},
+			},
+		}, "testapp")
+		require.NoError(t, err)
+
+		require.Len(t, ops, 2)
+		sr, ok := ops[0].(state.SetRequest)
+		require.True(t, ok)
+		assert.Equal(t, "full state", sr.Value)
+		assert.True(t, strings.HasPrefix(ops[1].GetKey(), outboxStatePrefix))
+	})
+
+	t.Run("projection without matching set operation", func(t *testing.T) {
+		o := newTestOutbox().(*outboxImpl)
+		o.publishFn = func(ctx context.Context, pr *contribPubsub.PublishRequest) error {
+			assert.Fail(t, "unexptected message received")
+			return nil
+		}
+
+		o.AddOrUpdateOutbox(v1alpha1.Component{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test",
+			},
+			Spec: v1alpha1.ComponentSpec{
+				Metadata: []common.NameValuePair{
+					{
+						Name: outboxPublishPubsubKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("a"),
+							},
+						},
+					},
+					{
+						Name: outboxPublishTopicKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("1"),
+							},
+						},
+					},
+				},
+			},
+		})
+
+		_, err := o.PublishInternal(context.Background(), "test", []state.TransactionalStateOperation{
+			state.SetRequest{
+				Key:   "1",
+				Value: "full state",
+			},
+			state.SetRequest{
+				Key:      "2",
+				Value:    "projection",
+				Metadata: map[string]string{outboxProjectionKey: "true"},
+			},
+		}, "testapp")
+		assert.Error(t, err)
+	})
 }
 
 func TestSubscribeToInternalTopics(t *testing.T) {
@@ -486,13 +692,13 @@ func TestSubscribeToInternalTopics(t *testing.T) {
 				errCh <- pErr
 				return
 			}
-			if len(trs) != 1 {
-				errCh <- fmt.Errorf("expected trs to have len(1), but got: %d", len(trs))
+			if len(trs) != 2 {
+				errCh <- fmt.Errorf("expected trs to have len(2), but got: %d", len(trs))
 				return
 			}
 
 			errCh <- nil
-			stateMock.expectedKey.Store(ptr.Of(trs[0].GetKey()))
+			stateMock.expectedKey.Store(ptr.Of(trs[1].GetKey()))
 		}()
 
 		d, err := time.ParseDuration(stateScan)
@@ -640,8 +846,8 @@ func TestSubscribeToInternalTopics(t *testing.T) {
 				errCh <- pErr
 				return
 			}
-			if len(trs) != 1 {
-				errCh <- fmt.Errorf("expected trs to have len(1), but got: %d", len(trs))
+			if len(trs) != 2 {
+				errCh <- fmt.Errorf("expected trs to have len(2), but got: %d", len(trs))
 				return
 			}
 			errCh <- nil
@@ -765,8 +971,8 @@ func TestSubscribeToInternalTopics(t *testing.T) {
 				errCh <- pErr
 				return
 			}
-			if len(trs) != 1 {
-				errCh <- fmt.Errorf("expected trs to have len(1), but got: %d", len(trs))
+			if len(trs) != 2 {
+				errCh <- fmt.Errorf("expected trs to have len(2), but got: %d", len(trs))
 				return
 			}
 			errCh <- nil
@@ -805,6 +1011,112 @@ func TestSubscribeToInternalTopics(t *testing.T) {
 		// Publishing should not have errored
 		require.NoError(t, <-errCh)
 	})
+
+	t.Run("outbox state not present with dead letter topic", func(t *testing.T) {
+		o := newTestOutbox().(*outboxImpl)
+		o.cloudEventExtractorFn = extractCloudEventProperty
+
+		const outboxTopic = "test1outbox"
+
+		psMock := &outboxPubsubMock{
+			expectedOutboxTopic: outboxTopic,
+			t:                   t,
+			validateNoError:     true,
+		}
+		stateMock := &outboxStateMock{
+			returnEmptyOnGet: true,
+		}
+
+		externalCalledCh := make(chan struct{})
+		deadLetterCh := make(chan *contribPubsub.PublishRequest, 1)
+
+		o.publishFn = func(ctx context.Context, pr *contribPubsub.PublishRequest) error {
+			switch pr.Topic {
+			case outboxTopic:
+				return psMock.Publish(ctx, pr)
+			case "1":
+				close(externalCalledCh)
+			case "dlq":
+				deadLetterCh <- pr
+			}
+			return nil
+		}
+		o.getPubsubFn = func(s string) (contribPubsub.PubSub, bool) {
+			return psMock, true
+		}
+		o.getStateFn = func(s string) (state.Store, bool) {
+			return stateMock, true
+		}
+
+		o.AddOrUpdateOutbox(v1alpha1.Component{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test",
+			},
+			Spec: v1alpha1.ComponentSpec{
+				Metadata: []common.NameValuePair{
+					{
+						Name: outboxPublishPubsubKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("a"),
+							},
+						},
+					},
+					{
+						Name: outboxPublishTopicKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("1"),
+							},
+						},
+					},
+					{
+						Name: outboxMaxVerificationAgeKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("1ms"),
+							},
+						},
+					},
+					{
+						Name: outboxDeadLetterTopicKey,
+						Value: common.DynamicValue{
+							JSON: v1.JSON{
+								Raw: []byte("dlq"),
+							},
+						},
+					},
+				},
+			},
+		})
+
+		const appID = "test"
+		err := o.SubscribeToInternalTopics(context.Background(), appID)
+		require.NoError(t, err)
+
+		_, err = o.PublishInternal(context.Background(), "test", []state.TransactionalStateOperation{
+			state.SetRequest{
+				Key:   "1",
+				Value: "hello",
+			},
+		}, appID)
+		require.NoError(t, err)
+
+		// account for max retry time
+		select {
+		case pr := <-deadLetterCh:
+			assert.Equal(t, "a", pr.PubsubName)
+
+			var cloudEvent map[string]interface{}
+			require.NoError(t, json.Unmarshal(pr.Data, &cloudEvent))
+			assert.Equal(t, "hello", cloudEvent["data"])
+			assert.Equal(t, "dlq", cloudEvent["topic"])
+		case <-externalCalledCh:
+			require.Fail(t, "message was published to the topic")
+		case <-time.After(15 * time.Second):
+			require.Fail(t, "timeout waiting for the message on the dead letter topic")
+		}
+	})
 }
 
 type outboxPubsubMock struct {
@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	contribPubsub "github.com/dapr/components-contrib/pubsub"
+)
+
+const (
+	// RedriveCountExtension is the CloudEvent extension attribute holding the
+	// number of times an event was redriven from a dead-letter topic.
+	RedriveCountExtension = "daprredrivecount"
+	// RedriveTimeExtension is the CloudEvent extension attribute holding the
+	// time of the last redrive of an event, in RFC3339 format.
+	RedriveTimeExtension = "daprredrivetime"
+
+	// RedriveTargetTopic redrives messages by re-publishing them to the
+	// subscription's topic.
+	RedriveTargetTopic = "topic"
+	// RedriveTargetApp redrives messages by delivering them directly to the
+	// app route matching the event.
+	RedriveTargetApp = "app"
+
+	// DefaultRedriveWaitDuration is the default time a redrive waits for
+	// messages from the dead-letter topic.
+	DefaultRedriveWaitDuration = 5 * time.Second
+	// MaxRedriveWaitDuration is the maximum time a redrive can wait for
+	// messages from the dead-letter topic.
+	MaxRedriveWaitDuration = 5 * time.Minute
+	// RedriveIdleDuration is the time after which a redrive ends once the
+	// dead-letter topic stopped delivering new messages.
+	RedriveIdleDuration = 2 * time.Second
+)
+
+var (
+	// ErrRedriveNoDeadLetterTopic is returned when redriving a subscription
+	// that has no dead-letter topic configured.
+	ErrRedriveNoDeadLetterTopic = errors.New("subscription has no dead letter topic configured")
+	// ErrRedriveSubscriptionNotFound is returned when redriving a
+	// subscription that the app does not have.
+	ErrRedriveSubscriptionNotFound = errors.New("subscription not found")
+)
+
+// RedriveRequest is the request to move messages from the dead-letter topic
+// of a subscription back to the subscription.
+type RedriveRequest struct {
+	PubsubName string
+	// Topic is the topic of the subscription whose dead-letter topic is drained.
+	Topic string
+	// Target is either RedriveTargetTopic (default) or RedriveTargetApp.
+	Target string
+	// EventTypes, if not empty, restricts the redrive to events of these types.
+	EventTypes []string
+	// StartTime and EndTime, if not zero, restrict the redrive to events whose
+	// "time" attribute falls in the window. Events without a "time" attribute
+	// are not matched when a window is set.
+	StartTime time.Time
+	EndTime   time.Time
+	// MaxCount is the maximum number of messages to redrive. Zero means no limit.
+	MaxCount int
+	// DryRun reports what would be redriven without redriving any message.
+	// The messages read are held, neither acknowledged nor rejected, until
+	// the dry run ends, so a dry run inspects at most as many messages as the
+	// component delivers concurrently.
+	DryRun bool
+	// WaitDuration is the maximum time the redrive listens on the dead-letter
+	// topic. The redrive ends earlier when MaxCount is reached, or when no new
+	// message was received for RedriveIdleDuration after the first one.
+	WaitDuration time.Duration
+}
+
+// RedriveResponse reports the outcome of a redrive.
+type RedriveResponse struct {
+	// Matched is the number of messages that matched the filters.
+	Matched int `json:"matched"`
+	// Redriven is the number of messages that were successfully redriven.
+	Redriven int `json:"redriven"`
+	// Skipped is the number of messages that did not match the filters, or
+	// that exceeded MaxCount. Unless in a dry run, they are published again
+	// to the dead-letter topic.
+	Skipped int `json:"skipped"`
+	// Failed is the number of matching messages that could not be redriven
+	// and were left in the dead-letter topic.
+	Failed int `json:"failed"`
+	// DryRun is true if no message was consumed.
+	DryRun bool `json:"dryRun"`
+}
+
+// Validate validates the request and sets the defaults.
+func (r *RedriveRequest) Validate() error {
+	if r.PubsubName == "" {
+		return errors.New("pubsub name is empty")
+	}
+	if r.Topic == "" {
+		return errors.New("topic is empty")
+	}
+	switch r.Target {
+	case "":
+		r.Target = RedriveTargetTopic
+	case RedriveTargetTopic, RedriveTargetApp:
+	default:
+		return fmt.Errorf("invalid redrive target '%s': must be '%s' or '%s'", r.Target, RedriveTargetTopic, RedriveTargetApp)
+	}
+	if r.MaxCount < 0 {
+		return errors.New("max count must not be negative")
+	}
+	if !r.StartTime.IsZero() && !r.EndTime.IsZero() && r.EndTime.Before(r.StartTime) {
+		return errors.New("end time is before start time")
+	}
+	switch {
+	case r.WaitDuration <= 0:
+		r.WaitDuration = DefaultRedriveWaitDuration
+	case r.WaitDuration > MaxRedriveWaitDuration:
+		return fmt.Errorf("wait duration must not be greater than %v", MaxRedriveWaitDuration)
+	}
+	return nil
+}
+
+// Matches returns true if the cloud event matches the filters of the request.
+func (r *RedriveRequest) Matches(cloudEvent map[string]any) bool {
+	if len(r.EventTypes) > 0 {
+		eventType, _ := cloudEvent[contribPubsub.TypeField].(string)
+		found := false
+		for _, t := range r.EventTypes {
+			if t == eventType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if r.StartTime.IsZero() && r.EndTime.IsZero() {
+		return true
+	}
+
+	rawTime, _ := cloudEvent[contribPubsub.TimeField].(string)
+	eventTime, err := time.Parse(time.RFC3339, rawTime)
+	if err != nil {
+		return false
+	}
+	if !r.StartTime.IsZero() && eventTime.Before(r.StartTime) {
+		return false
+	}
+	if !r.EndTime.IsZero() && eventTime.After(r.EndTime) {
+		return false
+	}
+	return true
+}
+
+// MarkRedriven increments the redrive count extension of the cloud event and
+// records the redrive time. All the other attributes are preserved.
+func MarkRedriven(cloudEvent map[string]any, now time.Time) {
+	var count int
+	switch v := cloudEvent[RedriveCountExtension].(type) {
+	case float64:
+		count = int(v)
+	case int:
+		count = v
+	case string:
+		count, _ = strconv.Atoi(v)
+	}
+	cloudEvent[RedriveCountExtension] = count + 1
+	cloudEvent[RedriveTimeExtension] = now.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedriveRequestValidate(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		req := &RedriveRequest{PubsubName: "ps", Topic: "t"}
+		require.NoError(t, req.Validate())
+		assert.Equal(t, RedriveTargetTopic, req.Target)
+		assert.Equal(t, DefaultRedriveWaitDuration, req.WaitDuration)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		now := time.Now()
+		for name, req := range map[string]*RedriveRequest{
+			"no pubsub":      {Topic: "t"},
+			"no topic":       {PubsubName: "ps"},
+			"invalid target": {PubsubName: "ps", Topic: "t", Target: "foo"},
+			"negative count": {PubsubName: "ps", Topic: "t", MaxCount: -1},
+			"invalid window": {PubsubName: "ps", Topic: "t", StartTime: now, EndTime: now.Add(-time.Hour)},
+			"wait too long":  {PubsubName: "ps", Topic: "t", WaitDuration: time.Hour},
+		} {
+			assert.Error(t, req.Validate(), name)
+		}
+	})
+}
+
+func TestRedriveRequestMatches(t *testing.T) {
+	event := map[string]any{
+		"type": "order.created",
+		"time": "2023-01-02T10:00:00Z",
+	}
+
+	assert.True(t, (&RedriveRequest{}).Matches(event))
+	assert.True(t, (&RedriveRequest{EventTypes: []string{"a", "order.created"}}).Matches(event))
+	assert.False(t, (&RedriveRequest{EventTypes: []string{"order.deleted"}}).Matches(event))
+	assert.True(t, (&RedriveRequest{
+		StartTime: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC),
+	}).Matches(event))
+	assert.False(t, (&RedriveRequest{StartTime: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)}).Matches(event))
+	assert.False(t, (&RedriveRequest{EndTime: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}).Matches(event))
+	assert.False(t, (&RedriveRequest{EndTime: time.Now()}).Matches(map[string]any{}))
+}
+
+func TestMarkRedriven(t *testing.T) {
+	now := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	event := map[string]any{"id": "1"}
+	MarkRedriven(event, now)
+	assert.Equal(t, 1, event[RedriveCountExtension])
+	assert.Equal(t, "2023-01-02T10:00:00Z", event[RedriveTimeExtension])
+	assert.Equal(t, "1", event["id"])
+
+	// Values decoded from JSON are float64.
+	event[RedriveCountExtension] = float64(2)
+	MarkRedriven(event, now)
+	assert.Equal(t, 3, event[RedriveCountExtension])
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	contribPubsub "github.com/dapr/components-contrib/pubsub"
+)
+
+const (
+	// SchemaMetadataKey is the metadata key of an inline JSON Schema that the
+	// data of the cloud events of a topic must conform to.
+	// In the metadata of a pubsub component it is suffixed with the topic, as
+	// in "cloudEventSchema.orders".
+	SchemaMetadataKey = "cloudEventSchema"
+	// SchemaRefMetadataKey is the metadata key of a reference to the JSON
+	// Schema of a topic, in the format "secretstore/<store>/<secret>/<key>" or
+	// "configstore/<store>/<key>".
+	// In the metadata of a pubsub component it is suffixed with the topic, as
+	// in "cloudEventSchemaRef.orders".
+	SchemaRefMetadataKey = "cloudEventSchemaRef"
+
+	// SchemaRefSecretStore is the type of a schema reference to a secret store.
+	SchemaRefSecretStore = "secretstore"
+	// SchemaRefConfigStore is the type of a schema reference to a configuration store.
+	SchemaRefConfigStore = "configstore"
+)
+
+// SchemaDefinition is the JSON Schema declared for a topic, either inline or
+// as a reference to a secret or configuration store.
+type SchemaDefinition struct {
+	Inline string
+	Ref    string
+}
+
+// IsEmpty returns true if no schema is declared.
+func (d SchemaDefinition) IsEmpty() bool {
+	return d.Inline == "" && d.Ref == ""
+}
+
+// SchemaRef is a parsed reference to a JSON Schema.
+type SchemaRef struct {
+	// StoreType is either SchemaRefSecretStore or SchemaRefConfigStore.
+	StoreType string
+	StoreName string
+	// Name is the name of the secret, or the key of the configuration item.
+	Name string
+	// Key is the key in the secret. Empty for configuration stores.
+	Key string
+}
+
+// ParseSchemaRef parses a reference to a JSON Schema.
+func ParseSchemaRef(ref string) (SchemaRef, error) {
+	parts := strings.Split(ref, "/")
+	switch {
+	case len(parts) == 4 && parts[0] == SchemaRefSecretStore:
+		if parts[1] == "" || parts[2] == "" || parts[3] == "" {
+			break
+		}
+		return SchemaRef{StoreType: parts[0], StoreName: parts[1], Name: parts[2], Key: parts[3]}, nil
+	case len(parts) == 3 && parts[0] == SchemaRefConfigStore:
+		if parts[1] == "" || parts[2] == "" {
+			break
+		}
+		return SchemaRef{StoreType: parts[0], StoreName: parts[1], Name: parts[2]}, nil
+	}
+	return SchemaRef{}, fmt.Errorf("invalid schema reference '%s': must be '%s/<store>/<secret>/<key>' or '%s/<store>/<key>'", ref, SchemaRefSecretStore, SchemaRefConfigStore)
+}
+
+// GetTopicSchemas returns the schemas declared for topics in the metadata of
+// a pubsub component, keyed by topic.
+func GetTopicSchemas(props map[string]string) map[string]SchemaDefinition {
+	var schemas map[string]SchemaDefinition
+	for k, v := range props {
+		var topic string
+		var isRef bool
+		if t, ok := strings.CutPrefix(k, SchemaRefMetadataKey+"."); ok {
+			topic, isRef = t, true
+		} else if t, ok := strings.CutPrefix(k, SchemaMetadataKey+"."); ok {
+			topic = t
+		} else {
+			continue
+		}
+		if topic == "" || v == "" {
+			continue
+		}
+
+		if schemas == nil {
+			schemas = make(map[string]SchemaDefinition)
+		}
+		def := schemas[topic]
+		if isRef {
+			def.Ref = v
+		} else {
+			def.Inline = v
+		}
+		schemas[topic] = def
+	}
+	return schemas
+}
+
+// GetSubscriptionSchema returns the schema declared in the metadata of a
+// subscription.
+func GetSubscriptionSchema(md map[string]string) SchemaDefinition {
+	return SchemaDefinition{
+		Inline: md[SchemaMetadataKey],
+		Ref:    md[SchemaRefMetadataKey],
+	}
+}
+
+// Schema is a compiled JSON Schema used to validate the data of cloud events.
+type Schema struct {
+	schema *gojsonschema.Schema
+}
+
+// NewSchema compiles a JSON Schema.
+func NewSchema(raw string) (*Schema, error) {
+	s, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return &Schema{schema: s}, nil
+}
+
+// ValidateCloudEvent validates the data of a cloud event against the schema.
+// It returns a SchemaValidationError if the data does not conform.
+func (s *Schema) ValidateCloudEvent(pubsubName, topic string, cloudEvent map[string]any) error {
+	if b64, ok := cloudEvent[contribPubsub.DataBase64Field].(string); ok {
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return SchemaValidationError{PubsubName: pubsubName, Topic: topic, Errors: []string{"data_base64 is not valid base64"}}
+		}
+		return s.ValidateData(pubsubName, topic, data)
+	}
+	return s.validate(pubsubName, topic, gojsonschema.NewGoLoader(cloudEvent[contribPubsub.DataField]))
+}
+
+// ValidateData validates raw JSON data against the schema.
+// It returns a SchemaValidationError if the data is not JSON or does not
+// conform.
+func (s *Schema) ValidateData(pubsubName, topic string, data []byte) error {
+	if !json.Valid(data) {
+		return SchemaValidationError{PubsubName: pubsubName, Topic: topic, Errors: []string{"data is not valid JSON"}}
+	}
+	return s.validate(pubsubName, topic, gojsonschema.NewBytesLoader(data))
+}
+
+func (s *Schema) validate(pubsubName, topic string, loader gojsonschema.JSONLoader) error {
+	res, err := s.schema.Validate(loader)
+	if err != nil {
+		return SchemaValidationError{PubsubName: pubsubName, Topic: topic, Errors: []string{err.Error()}}
+	}
+	if res.Valid() {
+		return nil
+	}
+
+	errs := make([]string, len(res.Errors()))
+	for i, e := range res.Errors() {
+		errs[i] = e.String()
+	}
+	return SchemaValidationError{PubsubName: pubsubName, Topic: topic, Errors: errs}
+}
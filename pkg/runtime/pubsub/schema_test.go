@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"type": "object",
+	"properties": {"orderId": {"type": "integer"}},
+	"required": ["orderId"]
+}`
+
+func TestParseSchemaRef(t *testing.T) {
+	ref, err := ParseSchemaRef("secretstore/vault/schemas/orders")
+	require.NoError(t, err)
+	assert.Equal(t, SchemaRef{StoreType: SchemaRefSecretStore, StoreName: "vault", Name: "schemas", Key: "orders"}, ref)
+
+	ref, err = ParseSchemaRef("configstore/redis/orders-schema")
+	require.NoError(t, err)
+	assert.Equal(t, SchemaRef{StoreType: SchemaRefConfigStore, StoreName: "redis", Name: "orders-schema"}, ref)
+
+	for _, invalid := range []string{"", "vault/schemas/orders", "secretstore/vault/schemas", "configstore//key", "configstore/redis/a/b"} {
+		_, err = ParseSchemaRef(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGetTopicSchemas(t *testing.T) {
+	assert.Nil(t, GetTopicSchemas(map[string]string{"consumerID": "a"}))
+
+	schemas := GetTopicSchemas(map[string]string{
+		"cloudEventSchema.orders":      testSchema,
+		"cloudEventSchemaRef.invoices": "configstore/redis/invoices",
+		"cloudEventSchema.":            testSchema,
+		"cloudEventSchema":             testSchema,
+	})
+	assert.Equal(t, map[string]SchemaDefinition{
+		"orders":   {Inline: testSchema},
+		"invoices": {Ref: "configstore/redis/invoices"},
+	}, schemas)
+
+	assert.Equal(t, SchemaDefinition{Inline: testSchema}, GetSubscriptionSchema(map[string]string{SchemaMetadataKey: testSchema}))
+	assert.True(t, GetSubscriptionSchema(nil).IsEmpty())
+}
+
+func TestSchemaValidation(t *testing.T) {
+	_, err := NewSchema("{not json")
+	require.Error(t, err)
+
+	schema, err := NewSchema(testSchema)
+	require.NoError(t, err)
+
+	t.Run("valid cloud event", func(t *testing.T) {
+		err := schema.ValidateCloudEvent("ps", "orders", map[string]any{"data": map[string]any{"orderId": 1}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid cloud event", func(t *testing.T) {
+		err := schema.ValidateCloudEvent("ps", "orders", map[string]any{"data": map[string]any{"orderId": "a"}})
+		var vErr SchemaValidationError
+		require.ErrorAs(t, err, &vErr)
+		assert.Equal(t, "ps", vErr.PubsubName)
+		assert.Equal(t, "orders", vErr.Topic)
+		assert.Len(t, vErr.Errors, 1)
+	})
+
+	t.Run("missing data", func(t *testing.T) {
+		err := schema.ValidateCloudEvent("ps", "orders", map[string]any{})
+		assert.ErrorAs(t, err, &SchemaValidationError{})
+	})
+
+	t.Run("base64 data", func(t *testing.T) {
+		ce := map[string]any{"data_base64": base64.StdEncoding.EncodeToString([]byte(`{"orderId":1}`))}
+		assert.NoError(t, schema.ValidateCloudEvent("ps", "orders", ce))
+
+		ce = map[string]any{"data_base64": base64.StdEncoding.EncodeToString([]byte("binary"))}
+		assert.ErrorAs(t, schema.ValidateCloudEvent("ps", "orders", ce), &SchemaValidationError{})
+	})
+
+	t.Run("raw data", func(t *testing.T) {
+		assert.NoError(t, schema.ValidateData("ps", "orders", []byte(`{"orderId":1}`)))
+		assert.ErrorAs(t, schema.ValidateData("ps", "orders", []byte(`{}`)), &SchemaValidationError{})
+		assert.ErrorAs(t, schema.ValidateData("ps", "orders", []byte(`not json`)), &SchemaValidationError{})
+	})
+}
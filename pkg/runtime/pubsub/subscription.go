@@ -10,6 +10,7 @@ type Subscription struct {
 	Rules           []*Rule           `json:"rules,omitempty"`
 	Scopes          []string          `json:"scopes"`
 	BulkSubscribe   *BulkSubscribe    `json:"bulkSubscribe"`
+	DeliveryLimits  *DeliveryLimits   `json:"deliveryLimits,omitempty"`
 }
 
 type BulkSubscribe struct {
@@ -18,6 +19,13 @@ type BulkSubscribe struct {
 	MaxAwaitDurationMs int32 `json:"maxAwaitDurationMs,omitempty"`
 }
 
+// DeliveryLimits contains the limits applied when delivering messages of a
+// subscription to the app. A value of zero means no limit.
+type DeliveryLimits struct {
+	MaxConcurrency       int32 `json:"maxConcurrency,omitempty"`
+	MaxMessagesPerSecond int32 `json:"maxMessagesPerSecond,omitempty"`
+}
+
 type Rule struct {
 	Match Expr   `json:"match"`
 	Path  string `json:"path"`
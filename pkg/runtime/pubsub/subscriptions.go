@@ -47,6 +47,7 @@ type (
 		Route           string            `json:"route"`  // Single route from v1alpha1
 		Routes          RoutesJSON        `json:"routes"` // Multiple routes from v2alpha1
 		BulkSubscribe   BulkSubscribeJSON `json:"bulkSubscribe,omitempty"`
+		DeliveryLimits  *DeliveryLimits   `json:"deliveryLimits,omitempty"`
 	}
 
 	RoutesJSON struct {
@@ -145,6 +146,7 @@ func GetSubscriptionsHTTP(ctx context.Context, channel channel.AppChannel, log l
 				DeadLetterTopic: si.DeadLetterTopic,
 				Rules:           rules[:n],
 				BulkSubscribe:   bulkSubscribe,
+				DeliveryLimits:  si.DeliveryLimits,
 			}
 		}
 
@@ -332,6 +334,10 @@ func unmarshalSubscription(b []byte, namespace string) (*Subscription, error) {
 				MaxMessagesCount:   sub.Spec.BulkSubscribe.MaxMessagesCount,
 				MaxAwaitDurationMs: sub.Spec.BulkSubscribe.MaxAwaitDurationMs,
 			},
+			DeliveryLimits: &DeliveryLimits{
+				MaxConcurrency:       sub.Spec.DeliveryLimits.MaxConcurrency,
+				MaxMessagesPerSecond: sub.Spec.DeliveryLimits.MaxMessagesPerSecond,
+			},
 		}, nil
 
 	default:
@@ -361,6 +367,10 @@ func unmarshalSubscription(b []byte, namespace string) (*Subscription, error) {
 				MaxMessagesCount:   sub.Spec.BulkSubscribe.MaxMessagesCount,
 				MaxAwaitDurationMs: sub.Spec.BulkSubscribe.MaxAwaitDurationMs,
 			},
+			DeliveryLimits: &DeliveryLimits{
+				MaxConcurrency:       sub.Spec.DeliveryLimits.MaxConcurrency,
+				MaxMessagesPerSecond: sub.Spec.DeliveryLimits.MaxMessagesPerSecond,
+			},
 		}, nil
 	}
 }
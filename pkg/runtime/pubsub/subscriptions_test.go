@@ -363,6 +363,24 @@ func TestDeclarativeSubscriptionsV2(t *testing.T) {
 		}
 	})
 
+	t.Run("load subscription with delivery limits", func(t *testing.T) {
+		s := testDeclarativeSubscriptionV2()
+		s.Spec.DeliveryLimits = subscriptionsapiV2alpha1.DeliveryLimits{
+			MaxConcurrency:       4,
+			MaxMessagesPerSecond: 50,
+		}
+
+		filePath := filepath.Join(dir, "sub.yaml")
+		writeSubscriptionToDisk(s, filePath)
+		defer os.RemoveAll(filePath)
+
+		subs := DeclarativeLocal([]string{dir}, "", log)
+		if assert.Len(t, subs, 1) && assert.NotNil(t, subs[0].DeliveryLimits) {
+			assert.Equal(t, int32(4), subs[0].DeliveryLimits.MaxConcurrency)
+			assert.Equal(t, int32(50), subs[0].DeliveryLimits.MaxMessagesPerSecond)
+		}
+	})
+
 	t.Run("load multiple subscriptions in different files", func(t *testing.T) {
 		for i := 0; i < subscriptionCount; i++ {
 			iStr := fmt.Sprintf("%v", i)
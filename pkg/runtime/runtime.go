@@ -448,6 +448,17 @@ func (a *DaprRuntime) initRuntime(ctx context.Context) error {
 
 	a.flushOutstandingComponents(ctx)
 
+	// Complete the transactions spanning multiple state stores left by a restart
+	if err = a.processor.State().Transactions().Recover(ctx); err != nil {
+		log.Warnf("failed to recover state transactions: %s", err)
+	}
+	// and the ones left by other instances of the app that stopped
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		a.processor.State().Transactions().Run(ctx)
+	}()
+
 	err = a.loadHTTPEndpoints(ctx)
 	if err != nil {
 		log.Warnf("failed to load HTTP endpoints: %s", err)
@@ -478,6 +489,11 @@ func (a *DaprRuntime) initRuntime(ctx context.Context) error {
 		ShutdownFn:                  a.ShutdownWithWait,
 		AppConnectionConfig:         a.runtimeConfig.appConnectionConfig,
 		GlobalConfig:                a.globalConfig,
+		StateChanges:                a.processor.State().Changes(),
+		StateTransactions:           a.processor.State().Transactions(),
+		StateIndex:                  a.processor.State().Index(),
+		StateCache:                  a.processor.State().Cache(),
+		Outbox:                      a.processor.PubSub().Outbox(),
 	}
 
 	// Create and start internal and external gRPC servers
@@ -690,6 +706,8 @@ func (a *DaprRuntime) initDirectMessaging(resolver nr.Resolver) {
 		ReadBufferSize:     a.runtimeConfig.readBufferSize,
 		Resiliency:         a.resiliency,
 		CompStore:          a.compStore,
+		AppRouting:         a.globalConfig.Spec.AppRoutingSpec,
+		InvocationCache:    a.globalConfig.Spec.InvocationCacheSpec,
 	})
 }
 
@@ -805,6 +823,9 @@ func (a *DaprRuntime) onComponentUpdated(ctx context.Context, component componen
 // begin http endpoint updates for kubernetes mode.
 func (a *DaprRuntime) beginHTTPEndpointsUpdates(ctx context.Context) error {
 	if a.operatorClient == nil {
+		if a.runtimeConfig.mode == modes.StandaloneMode && len(a.runtimeConfig.standalone.ResourcesPath) > 0 {
+			return a.watchLocalHTTPEndpoints(ctx)
+		}
 		return nil
 	}
 
@@ -867,10 +888,9 @@ func (a *DaprRuntime) beginHTTPEndpointsUpdates(ctx context.Context) error {
 					continue
 				}
 
-				endpointsToUpdate := streamData.([][]byte)
-				for i := 0; i < len(endpointsToUpdate); i++ {
-					parseAndUpdate(endpointsToUpdate[i])
-				}
+				// Endpoints that are not listed anymore were deleted while the
+				// stream was down.
+				a.syncListedHTTPEndpoints(ctx, streamData.([][]byte))
 			}
 
 			for {
@@ -889,8 +909,13 @@ func (a *DaprRuntime) beginHTTPEndpointsUpdates(ctx context.Context) error {
 	return nil
 }
 
+// onHTTPEndpointUpdated queues an added, changed or deleted HTTP endpoint.
+// Deleted endpoints have their deletion timestamp set.
 func (a *DaprRuntime) onHTTPEndpointUpdated(ctx context.Context, endpoint httpEndpointV1alpha1.HTTPEndpoint) bool {
 	oldEndpoint, exists := a.compStore.GetHTTPEndpoint(endpoint.Name)
+	if endpoint.DeletionTimestamp != nil {
+		return exists && a.addPendingEndpoint(ctx, endpoint)
+	}
 	_, _ = a.processResourceSecrets(ctx, &endpoint)
 
 	if exists && reflect.DeepEqual(oldEndpoint.Spec, endpoint.Spec) {
@@ -971,6 +996,54 @@ func (a *DaprRuntime) processHTTPEndpointSecrets(ctx context.Context, endpoint *
 			}
 		}
 	}
+
+	if endpoint.HasOAuth2() {
+		a.processHTTPEndpointOAuth2Secrets(ctx, endpoint)
+	}
+}
+
+// processHTTPEndpointOAuth2Secrets resolves the OAuth2 client credentials of
+// an HTTP endpoint from its secret store.
+func (a *DaprRuntime) processHTTPEndpointOAuth2Secrets(ctx context.Context, endpoint *httpEndpointV1alpha1.HTTPEndpoint) {
+	oauth2 := endpoint.Spec.Authentication.OAuth2
+	values := map[string]*httpEndpointV1alpha1.SecretValue{
+		"clientId":     &oauth2.ClientID,
+		"clientSecret": &oauth2.ClientSecret,
+	}
+
+	resource := apis.GenericNameValueResource{
+		Name:        endpoint.ObjectMeta.Name,
+		Namespace:   endpoint.ObjectMeta.Namespace,
+		SecretStore: endpoint.Auth.SecretStore,
+		Pairs:       make([]commonapi.NameValuePair, 0, len(values)),
+	}
+	for name, v := range values {
+		pair := commonapi.NameValuePair{Name: name}
+		if v.Value != nil {
+			pair.Value = *v.Value
+		}
+		if v.HasSecret() {
+			pair.SecretKeyRef = *v.SecretKeyRef
+		}
+		resource.Pairs = append(resource.Pairs, pair)
+	}
+
+	if updated, _ := a.processResourceSecrets(ctx, &resource); !updated {
+		return
+	}
+	for _, np := range resource.Pairs {
+		if np.SecretKeyRef.Name != "" {
+			// The secret could not be resolved.
+			continue
+		}
+		v := values[np.Name]
+		v.Value = &commonapi.DynamicValue{
+			JSON: apiextensionsV1.JSON{
+				Raw: np.Value.Raw,
+			},
+		}
+		v.SecretKeyRef = nil
+	}
 }
 
 func (a *DaprRuntime) startHTTPServer(port int, publicPort *int, profilePort int, allowedOrigins string, pipeline httpMiddleware.Pipeline) error {
@@ -1248,19 +1321,24 @@ func (a *DaprRuntime) loadComponents(ctx context.Context) error {
 
 	authorizedComps := a.getAuthorizedObjects(comps, a.isObjectAuthorized).([]componentsV1alpha1.Component)
 
-	// Iterate through the list twice
-	// First, we look for secret stores and load those, then all other components
+	// Iterate through the list three times
+	// First, we look for secret stores and load those, then crypto providers (which can reference secrets, and which state stores can use for encryption), then all other components
 	// Sure, we could sort the list of authorizedComps... but this is simpler and most certainly faster
-	for _, comp := range authorizedComps {
-		if strings.HasPrefix(comp.Spec.Type, string(components.CategorySecretStore)+".") {
-			log.Debug("Found component: " + comp.LogName())
-			if !a.addPendingComponent(ctx, comp) {
-				return nil
-			}
+	loadPass := func(comp componentsV1alpha1.Component) int {
+		switch {
+		case strings.HasPrefix(comp.Spec.Type, string(components.CategorySecretStore)+"."):
+			return 0
+		case strings.HasPrefix(comp.Spec.Type, string(components.CategoryCryptoProvider)+"."):
+			return 1
+		default:
+			return 2
 		}
 	}
-	for _, comp := range authorizedComps {
-		if !strings.HasPrefix(comp.Spec.Type, string(components.CategorySecretStore)+".") {
+	for pass := 0; pass < 3; pass++ {
+		for _, comp := range authorizedComps {
+			if loadPass(comp) != pass {
+				continue
+			}
 			log.Debug("Found component: " + comp.LogName())
 			if !a.addPendingComponent(ctx, comp) {
 				return nil
@@ -1296,8 +1374,21 @@ func (a *DaprRuntime) processHTTPEndpoints(ctx context.Context) error {
 		if endpoint.Name == "" {
 			continue
 		}
-		a.processHTTPEndpointSecrets(ctx, &endpoint)
-		a.compStore.AddHTTPEndpoint(endpoint)
+
+		// Calls in flight keep using the endpoint they started with.
+		if endpoint.DeletionTimestamp != nil {
+			a.compStore.DeleteHTTPEndpoint(endpoint.Name)
+			log.Infof("http endpoint removed: %s", endpoint.Name)
+		} else {
+			a.processHTTPEndpointSecrets(ctx, &endpoint)
+			a.compStore.AddHTTPEndpoint(endpoint)
+		}
+
+		if a.channels != nil {
+			if err := a.channels.RefreshHTTPEndpoint(endpoint.Name); err != nil {
+				log.Errorf("failed to refresh channel of http endpoint %s: %s", endpoint.Name, err)
+			}
+		}
 	}
 
 	return nil
@@ -3370,6 +3370,140 @@ func TestHTTPEndpointsUpdate(t *testing.T) {
 	assert.True(t, exists, fmt.Sprintf("expect http endpoint with name: %s", endpoint3.Name))
 }
 
+func TestHTTPEndpointsDeletion(t *testing.T) {
+	rt, _ := NewTestDaprRuntime(t, modes.KubernetesMode)
+	defer stopRuntime(t, rt)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rt.processHTTPEndpoints(ctx)
+
+	endpoint1 := createTestEndpoint("mockEndpoint1", "http://testurl.com")
+	endpoint2 := createTestEndpoint("mockEndpoint2", "http://testurl2.com")
+	rt.syncHTTPEndpoints(ctx, []httpEndpointV1alpha1.HTTPEndpoint{endpoint1, endpoint2})
+	rt.flushOutstandingHTTPEndpoints(ctx)
+	assert.Len(t, rt.compStore.ListHTTPEndpoints(), 2)
+
+	// Deleting an unknown endpoint is a no-op.
+	deleted := createTestEndpoint("unknown", "http://testurl3.com")
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	assert.False(t, rt.onHTTPEndpointUpdated(ctx, deleted))
+
+	deleted = endpoint1
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	assert.True(t, rt.onHTTPEndpointUpdated(ctx, deleted))
+	rt.flushOutstandingHTTPEndpoints(ctx)
+	_, exists := rt.compStore.GetHTTPEndpoint(endpoint1.Name)
+	assert.False(t, exists)
+
+	// Endpoints that fail to deserialize when listed are kept.
+	invalid := []byte(`{"metadata":{"name":"mockEndpoint2"},"spec":{"baseUrl":1}}`)
+	rt.syncListedHTTPEndpoints(ctx, [][]byte{invalid})
+	rt.flushOutstandingHTTPEndpoints(ctx)
+	_, exists = rt.compStore.GetHTTPEndpoint(endpoint2.Name)
+	assert.True(t, exists)
+
+	rt.syncListedHTTPEndpoints(ctx, [][]byte{[]byte("{")})
+	rt.flushOutstandingHTTPEndpoints(ctx)
+	_, exists = rt.compStore.GetHTTPEndpoint(endpoint2.Name)
+	assert.True(t, exists)
+
+	// Endpoints missing from a sync are removed.
+	rt.syncHTTPEndpoints(ctx, []httpEndpointV1alpha1.HTTPEndpoint{})
+	rt.flushOutstandingHTTPEndpoints(ctx)
+	assert.Empty(t, rt.compStore.ListHTTPEndpoints())
+}
+
+func TestLoadComponentsOrder(t *testing.T) {
+	rt, _ := NewTestDaprRuntime(t, modes.StandaloneMode)
+	defer stopRuntime(t, rt)
+
+	dir := t.TempDir()
+	rt.runtimeConfig.standalone.ResourcesPath = []string{dir}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "components.yaml"), []byte(`apiVersion: dapr.io/v1alpha1
+kind: Component
+metadata:
+  name: mystate
+spec:
+  type: state.mock
+  version: v1
+---
+apiVersion: dapr.io/v1alpha1
+kind: Component
+metadata:
+  name: mycrypto
+spec:
+  type: crypto.mock
+  version: v1
+---
+apiVersion: dapr.io/v1alpha1
+kind: Component
+metadata:
+  name: mysecrets
+spec:
+  type: secretstores.mock
+  version: v1
+`), 0o600))
+
+	rt.pendingComponents = make(chan componentsV1alpha1.Component, 3)
+	require.NoError(t, rt.loadComponents(context.Background()))
+	close(rt.pendingComponents)
+
+	// Secret stores are loaded first, then crypto providers that may
+	// reference them, then the components that may use either.
+	var names []string
+	for comp := range rt.pendingComponents {
+		names = append(names, comp.Name)
+	}
+	assert.Equal(t, []string{"mysecrets", "mycrypto", "mystate"}, names)
+}
+
+func TestWatchLocalHTTPEndpoints(t *testing.T) {
+	rt, _ := NewTestDaprRuntime(t, modes.StandaloneMode)
+	defer stopRuntime(t, rt)
+
+	dir := t.TempDir()
+	rt.runtimeConfig.standalone.ResourcesPath = []string{dir}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rt.processHTTPEndpoints(ctx)
+	require.NoError(t, rt.watchLocalHTTPEndpoints(ctx))
+
+	file := filepath.Join(dir, "endpoint.yaml")
+	writeEndpoint := func(baseURL string) {
+		require.NoError(t, os.WriteFile(file, []byte(`apiVersion: dapr.io/v1alpha1
+kind: HTTPEndpoint
+metadata:
+  name: myendpoint
+spec:
+  baseUrl: `+baseURL+`
+`), 0o600))
+	}
+	baseURL := func() string {
+		endpoint, ok := rt.compStore.GetHTTPEndpoint("myendpoint")
+		if !ok {
+			return ""
+		}
+		return endpoint.Spec.BaseURL
+	}
+
+	writeEndpoint("http://testurl.com")
+	assert.Eventually(t, func() bool {
+		return baseURL() == "http://testurl.com"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	writeEndpoint("http://testurl2.com")
+	assert.Eventually(t, func() bool {
+		return baseURL() == "http://testurl2.com"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, os.Remove(file))
+	assert.Eventually(t, func() bool {
+		return baseURL() == ""
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
 type MockKubernetesStateStore struct {
 	callback func(context.Context) error
 	closeFn  func() error
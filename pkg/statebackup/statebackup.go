@@ -0,0 +1,485 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statebackup exports the records of an app from a state store, and
+// imports them into another one.
+//
+// Backups are JSON Lines files: a header, one line per record and a trailer
+// with the number of records, so truncated files are detected. Records hold
+// the keys of the app and decrypted values, so they can be imported in stores
+// with other key prefix strategies and encryption keys, and by other apps.
+// Actor state and reminders can be included too.
+package statebackup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+	"github.com/dapr/dapr/pkg/encryption"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	formatVersion   = 1
+	defaultPageSize = 100
+
+	recordHeader     = "header"
+	recordState      = "state"
+	recordActorState = "actorState"
+	recordReminders  = "reminders"
+	recordEnd        = "end"
+
+	daprSeparator        = "||"
+	remindersKeyPrefix   = "actors" + daprSeparator
+	metadataPartitionKey = "partitionKey"
+)
+
+var log = logger.NewLogger("dapr.runtime.statebackup")
+
+// ErrInvalidBackup is returned when a store can't be exported, or a backup
+// can't be imported.
+var ErrInvalidBackup = errors.New("invalid backup")
+
+// Store is a state store, as used by an app.
+type Store struct {
+	Name  string
+	Store state.Store
+	AppID string
+}
+
+// ExportOptions configures the records to export.
+type ExportOptions struct {
+	// Export the actor state of the app, and the reminders of ActorTypes.
+	Actors     bool     `json:"actors,omitempty"`
+	ActorTypes []string `json:"actorTypes,omitempty"`
+	// Number of keys requested to the store at a time.
+	PageSize int `json:"pageSize,omitempty"`
+}
+
+// Result reports the records exported or imported, by type.
+type Result struct {
+	State      int64 `json:"state"`
+	ActorState int64 `json:"actorState"`
+	Reminders  int64 `json:"reminders"`
+	// Records deleted or expired meanwhile.
+	Skipped int64 `json:"skipped"`
+	// Records that couldn't be imported.
+	Failed int64 `json:"failed"`
+}
+
+func (r *Result) add(recordType string) {
+	switch recordType {
+	case recordState:
+		r.State++
+	case recordActorState:
+		r.ActorState++
+	case recordReminders:
+		r.Reminders++
+	}
+}
+
+// WrittenFn is called with the operations an import saved for the keys of
+// the app.
+type WrittenFn func(ctx context.Context, ops []state.TransactionalStateOperation)
+
+// record is a line of a backup.
+type record struct {
+	Type string `json:"type"`
+
+	// Header.
+	Version int        `json:"version,omitempty"`
+	AppID   string     `json:"appId,omitempty"`
+	Store   string     `json:"store,omitempty"`
+	Time    *time.Time `json:"time,omitempty"`
+
+	// State, actor state and reminders.
+	Key          string     `json:"key,omitempty"`
+	ActorType    string     `json:"actorType,omitempty"`
+	ActorID      string     `json:"actorId,omitempty"`
+	PartitionKey string     `json:"partitionKey,omitempty"`
+	ExpireTime   *time.Time `json:"expireTime,omitempty"`
+	// JSON values are kept as they are, other ones are base64-encoded.
+	Value json.RawMessage `json:"value,omitempty"`
+	Data  []byte          `json:"data,omitempty"`
+
+	// Trailer.
+	Count int64 `json:"count,omitempty"`
+}
+
+func (r *record) setValue(data []byte) {
+	// The encoder compacts JSON values, so only compact ones are kept as JSON
+	var compact bytes.Buffer
+	if json.Compact(&compact, data) == nil && bytes.Equal(compact.Bytes(), data) {
+		r.Value = data
+	} else {
+		r.Data = data
+	}
+}
+
+func (r *record) value() []byte {
+	if r.Value != nil {
+		return r.Value
+	}
+	if r.Data != nil {
+		return r.Data
+	}
+	return []byte{}
+}
+
+// Export writes a backup of the records of the app in the store to w.
+// Nothing is written if the first keys can't be listed.
+func Export(ctx context.Context, w io.Writer, src Store, opts ExportOptions) (Result, error) {
+	var res Result
+	if !stateLoader.CanListKeys(src.Store) {
+		return res, fmt.Errorf("%w: state store %s supports neither querying nor listing keys", ErrInvalidBackup, src.Name)
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPageSize
+	}
+	actorTypes := make(map[string]struct{}, len(opts.ActorTypes))
+	if opts.Actors {
+		for _, t := range opts.ActorTypes {
+			actorTypes[t] = struct{}{}
+		}
+	}
+
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	now := time.Now().UTC()
+	err := enc.Encode(record{Type: recordHeader, Version: formatVersion, AppID: src.AppID, Store: src.Name, Time: &now})
+	if err != nil {
+		return res, err
+	}
+
+	var count int64
+	token := ""
+	for {
+		keys, next, err := stateLoader.ListKeys(ctx, src.Store, token, opts.PageSize)
+		if err != nil {
+			return res, fmt.Errorf("failed to list keys: %w", err)
+		}
+		for _, savedKey := range keys {
+			rec, ok := src.classify(savedKey, opts.Actors, actorTypes)
+			if !ok {
+				continue
+			}
+			found, err := src.read(ctx, savedKey, &rec)
+			if err != nil {
+				return res, fmt.Errorf("failed to read key %s: %w", savedKey, err)
+			}
+			if !found {
+				res.Skipped++
+				continue
+			}
+			if err = enc.Encode(rec); err != nil {
+				return res, err
+			}
+			res.add(rec.Type)
+			count++
+		}
+		if err = buf.Flush(); err != nil {
+			return res, err
+		}
+		if next == "" || len(keys) == 0 {
+			break
+		}
+		token = next
+	}
+
+	if err = enc.Encode(record{Type: recordEnd, Count: count}); err != nil {
+		return res, err
+	}
+	return res, buf.Flush()
+}
+
+// classify returns the record for a key of the store, and false if the key
+// isn't exported.
+func (s Store) classify(savedKey string, actors bool, actorTypes map[string]struct{}) (record, bool) {
+	// Reminders are saved by actor type, as actors||<type>[||...]
+	if rest, ok := strings.CutPrefix(savedKey, remindersKeyPrefix); ok {
+		actorType, _, _ := strings.Cut(rest, daprSeparator)
+		if _, ok = actorTypes[actorType]; !ok {
+			return record{}, false
+		}
+		return record{Type: recordReminders, Key: savedKey, PartitionKey: remindersPartitionKey(savedKey)}, true
+	}
+
+	// Actor state is saved as <app id>||<type>||<id>||<key>
+	if rest, ok := strings.CutPrefix(savedKey, s.AppID+daprSeparator); ok && s.AppID != "" {
+		parts := strings.SplitN(rest, daprSeparator, 3)
+		if len(parts) == 3 {
+			if !actors {
+				return record{}, false
+			}
+			return record{Type: recordActorState, ActorType: parts[0], ActorID: parts[1], Key: parts[2]}, true
+		}
+	}
+
+	key, ok := stateLoader.GetAppStateKey(savedKey, s.Name, s.AppID)
+	if !ok {
+		return record{}, false
+	}
+	return record{Type: recordState, Key: key}, true
+}
+
+// remindersPartitionKey returns the partition key of a record of reminders,
+// as the actors runtime reads it.
+func remindersPartitionKey(savedKey string) string {
+	// Partitions are saved as actors||<type>||<metadata id>||reminders||<n>,
+	// in the partition of the metadata.
+	parts := strings.Split(savedKey, daprSeparator)
+	if len(parts) == 5 && parts[3] == "reminders" {
+		return parts[2]
+	}
+	return parts[0] + daprSeparator + parts[1]
+}
+
+func actorPartitionKey(appID, actorType, actorID string) string {
+	return appID + daprSeparator + actorType + daprSeparator + actorID
+}
+
+// read reads the value of the key into rec. It returns false if the record
+// doesn't exist anymore.
+func (s Store) read(ctx context.Context, savedKey string, rec *record) (bool, error) {
+	req := &state.GetRequest{Key: savedKey}
+	switch rec.Type {
+	case recordActorState:
+		req.Metadata = map[string]string{metadataPartitionKey: actorPartitionKey(s.AppID, rec.ActorType, rec.ActorID)}
+	case recordReminders:
+		req.Metadata = map[string]string{metadataPartitionKey: rec.PartitionKey}
+	}
+	res, err := s.Store.Get(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	if res == nil || res.Data == nil {
+		return false, nil
+	}
+
+	if exp, ok := res.Metadata[state.GetRespMetaKeyTTLExpireTime]; ok {
+		if t, pErr := time.Parse(time.RFC3339, exp); pErr == nil {
+			if !t.After(time.Now()) {
+				return false, nil
+			}
+			rec.ExpireTime = &t
+		}
+	}
+
+	data := res.Data
+	// Actors don't encrypt their state
+	if rec.Type == recordState && encryption.EncryptedStateStore(s.Name) {
+		data, err = encryption.TryDecryptValue(ctx, s.Name, data)
+		if err != nil {
+			return false, err
+		}
+	}
+	rec.setValue(data)
+	return true, nil
+}
+
+// Import saves the records of a backup read from r in the store, replacing
+// the existing ones. Records that can't be saved are logged and counted.
+// written may be nil.
+//
+// The backup is spooled to a temporary file and validated before any record
+// is saved, so nothing is saved from invalid or truncated backups. Imports
+// aren't atomic though: if saving stops with an error, the records saved
+// until then are kept, and counted in the returned result.
+func Import(ctx context.Context, r io.Reader, dst Store, written WrittenFn) (Result, error) {
+	var res Result
+	spool, err := os.CreateTemp("", "dapr-state-import-*")
+	if err != nil {
+		return res, fmt.Errorf("failed to spool the backup: %w", err)
+	}
+	defer func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}()
+
+	sw := &spoolWriter{w: spool}
+	if err = validate(io.TeeReader(r, sw)); err != nil {
+		if sw.err != nil {
+			return res, fmt.Errorf("failed to spool the backup: %w", sw.err)
+		}
+		return res, err
+	}
+	if _, err = spool.Seek(0, io.SeekStart); err != nil {
+		return res, fmt.Errorf("failed to read the spooled backup: %w", err)
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(spool))
+	if err = dec.Decode(&record{}); err != nil {
+		return res, fmt.Errorf("failed to read the spooled backup: %w", err)
+	}
+	for {
+		if err = ctx.Err(); err != nil {
+			return res, err
+		}
+		var rec record
+		if err = dec.Decode(&rec); err != nil {
+			return res, fmt.Errorf("failed to read the spooled backup: %w", err)
+		}
+		if rec.Type == recordEnd {
+			return res, nil
+		}
+
+		req, err := dst.setRequest(ctx, &rec)
+		if err != nil {
+			log.Warnf("Failed to import a record of type %s with key %s in state store %s: %v", rec.Type, rec.Key, dst.Name, err)
+			res.Failed++
+			continue
+		}
+		if req == nil {
+			res.Skipped++
+			continue
+		}
+		if err = dst.Store.Set(ctx, req); err != nil {
+			log.Warnf("Failed to import key %s in state store %s: %v", req.Key, dst.Name, err)
+			res.Failed++
+			continue
+		}
+		res.add(rec.Type)
+		if written != nil && rec.Type == recordState {
+			written(ctx, []state.TransactionalStateOperation{*req})
+		}
+	}
+}
+
+// spoolWriter records the errors writing the spooled backup, which aren't
+// errors of the backup.
+type spoolWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *spoolWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// validate reads a whole backup, and checks its header, records and trailer.
+func validate(r io.Reader) error {
+	dec := json.NewDecoder(r)
+
+	var header record
+	if err := dec.Decode(&header); err != nil || header.Type != recordHeader {
+		return fmt.Errorf("%w: missing header", ErrInvalidBackup)
+	}
+	if header.Version != formatVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, header.Version)
+	}
+
+	var count int64
+	for {
+		var rec record
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: the backup is truncated", ErrInvalidBackup)
+		} else if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+		}
+		if rec.Type == recordEnd {
+			if rec.Count != count {
+				return fmt.Errorf("%w: read %d records, but the backup has %d", ErrInvalidBackup, count, rec.Count)
+			}
+			return nil
+		}
+		count++
+	}
+}
+
+// setRequest returns the request saving the record in the store, or nil if
+// the record has expired.
+func (s Store) setRequest(ctx context.Context, rec *record) (*state.SetRequest, error) {
+	req := &state.SetRequest{Value: rec.value(), Metadata: map[string]string{}}
+	switch rec.Type {
+	case recordState:
+		key, err := stateLoader.GetModifiedStateKey(rec.Key, s.Name, s.AppID)
+		if err != nil {
+			return nil, err
+		}
+		req.Key = key
+		if encryption.EncryptedStateStore(s.Name) {
+			req.Value, err = encryption.TryEncryptValue(ctx, s.Name, rec.value())
+			if err != nil {
+				return nil, err
+			}
+		}
+	case recordActorState:
+		if rec.ActorType == "" || rec.ActorID == "" || rec.Key == "" {
+			return nil, errors.New("actor type, actor id and key are required")
+		}
+		partitionKey := actorPartitionKey(s.AppID, rec.ActorType, rec.ActorID)
+		req.Key = partitionKey + daprSeparator + rec.Key
+		req.Metadata[metadataPartitionKey] = partitionKey
+	case recordReminders:
+		if !strings.HasPrefix(rec.Key, remindersKeyPrefix) {
+			return nil, errors.New("invalid key")
+		}
+		req.Key = rec.Key
+		if rec.PartitionKey != "" {
+			req.Metadata[metadataPartitionKey] = rec.PartitionKey
+		}
+	default:
+		return nil, fmt.Errorf("unknown record type %q", rec.Type)
+	}
+
+	// Keep the time-to-live of the record
+	if rec.ExpireTime != nil {
+		ttl := int64(math.Ceil(time.Until(*rec.ExpireTime).Seconds()))
+		if ttl <= 0 {
+			return nil, nil
+		}
+		req.Metadata[metadata.TTLMetadataKey] = strconv.FormatInt(ttl, 10)
+	}
+	return req, nil
+}
+
+// Copy streams the records of the app from a store to another, as Export and
+// Import would. Nothing is saved if the export fails.
+func Copy(ctx context.Context, src, dst Store, opts ExportOptions, written WrittenFn) (Result, error) {
+	if src.Name == dst.Name {
+		return Result{}, fmt.Errorf("%w: can't copy state store %s onto itself", ErrInvalidBackup, src.Name)
+	}
+
+	pr, pw := io.Pipe()
+	exportErr := make(chan error, 1)
+	go func() {
+		_, err := Export(ctx, pw, src, opts)
+		pw.CloseWithError(err)
+		exportErr <- err
+	}()
+
+	res, err := Import(ctx, pr, dst, written)
+	// Stops the export if the import failed
+	pr.CloseWithError(err)
+	if eErr := <-exportErr; eErr != nil {
+		return res, eErr
+	}
+	return res, err
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statebackup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+)
+
+// testStore is an in-memory store that can list its keys.
+type testStore struct {
+	state.Store
+	items    map[string][]byte
+	expire   map[string]time.Time
+	metadata map[string]map[string]string
+}
+
+func newTestStore(items map[string]string) *testStore {
+	s := &testStore{
+		items:    map[string][]byte{},
+		expire:   map[string]time.Time{},
+		metadata: map[string]map[string]string{},
+	}
+	for k, v := range items {
+		s.items[k] = []byte(v)
+	}
+	return s
+}
+
+func (s *testStore) Get(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	data, ok := s.items[req.Key]
+	if !ok {
+		return &state.GetResponse{}, nil
+	}
+	res := &state.GetResponse{Data: data}
+	if exp, ok := s.expire[req.Key]; ok {
+		res.Metadata = map[string]string{state.GetRespMetaKeyTTLExpireTime: exp.Format(time.RFC3339)}
+	}
+	return res, nil
+}
+
+func (s *testStore) Set(ctx context.Context, req *state.SetRequest) error {
+	s.items[req.Key] = req.Value.([]byte)
+	s.metadata[req.Key] = req.Metadata
+	return nil
+}
+
+func (s *testStore) ListKeys(ctx context.Context, token string, limit int) ([]string, string, error) {
+	keys := make([]string, 0, len(s.items))
+	for k := range s.items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(token)
+	end := start + limit
+	if end >= len(keys) {
+		return keys[start:], "", nil
+	}
+	return keys[start:end], strconv.Itoa(end), nil
+}
+
+// cancelingStore cancels the import after saving a record.
+type cancelingStore struct {
+	*testStore
+	cancel context.CancelFunc
+}
+
+func (s *cancelingStore) Set(ctx context.Context, req *state.SetRequest) error {
+	defer s.cancel()
+	return s.testStore.Set(ctx, req)
+}
+
+func sourceStore() *testStore {
+	s := newTestStore(map[string]string{
+		"app1||a":                           `{"n":1}`,
+		"app1||b":                           "binary",
+		"app1||c":                           `{ "n": 3 }`,
+		"app2||a":                           "other app",
+		"app1||counter||1||total":           "10",
+		"app2||counter||1||total":           "20",
+		"actors||counter":                   `[{"name":"r1"}]`,
+		"actors||counter||metadata":         `{"id":"m1"}`,
+		"actors||counter||m1||reminders||1": `[{"name":"r2"}]`,
+		"actors||other||m2||reminders||1":   `[{"name":"r3"}]`,
+		"app1||expired":                     "x",
+	})
+	s.expire["app1||a"] = time.Now().Add(time.Hour)
+	s.expire["app1||expired"] = time.Now().Add(-time.Minute)
+	return s
+}
+
+func TestExport(t *testing.T) {
+	src := Store{Name: "backup-src", Store: sourceStore(), AppID: "app1"}
+
+	t.Run("app state", func(t *testing.T) {
+		var buf bytes.Buffer
+		res, err := Export(context.Background(), &buf, src, ExportOptions{PageSize: 3})
+		require.NoError(t, err)
+		assert.Equal(t, Result{State: 3, Skipped: 1}, res)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 5)
+		var header record
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+		assert.Equal(t, recordHeader, header.Type)
+		assert.Equal(t, "app1", header.AppID)
+		assert.Contains(t, lines[1], `"key":"a","expireTime"`)
+		assert.Contains(t, lines[1], `"value":{"n":1}`)
+		assert.Contains(t, lines[2], `"data":"YmluYXJ5"`)
+		assert.Contains(t, lines[3], `"data":`)
+		assert.JSONEq(t, `{"type":"end","count":3}`, lines[4])
+	})
+
+	t.Run("actors", func(t *testing.T) {
+		var buf bytes.Buffer
+		res, err := Export(context.Background(), &buf, src, ExportOptions{Actors: true, ActorTypes: []string{"counter"}})
+		require.NoError(t, err)
+		assert.Equal(t, Result{State: 3, ActorState: 1, Reminders: 3, Skipped: 1}, res)
+		assert.Contains(t, buf.String(), `{"type":"actorState","key":"total","actorType":"counter","actorId":"1","value":10}`)
+		assert.Contains(t, buf.String(), `{"type":"reminders","key":"actors||counter||m1||reminders||1","partitionKey":"m1"`)
+		assert.Contains(t, buf.String(), `{"type":"reminders","key":"actors||counter||metadata","partitionKey":"actors||counter"`)
+		assert.NotContains(t, buf.String(), "other")
+	})
+
+	t.Run("stores that can't list keys", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := Export(context.Background(), &buf, Store{Name: "backup-src", Store: struct{ state.Store }{}, AppID: "app1"}, ExportOptions{})
+		require.ErrorIs(t, err, ErrInvalidBackup)
+		assert.Zero(t, buf.Len())
+	})
+}
+
+func TestImport(t *testing.T) {
+	require.NoError(t, stateLoader.SaveStateConfiguration("backup-dst", map[string]string{"keyPrefix": "name"}))
+	src := Store{Name: "backup-src", Store: sourceStore(), AppID: "app1"}
+	var buf bytes.Buffer
+	_, err := Export(context.Background(), &buf, src, ExportOptions{Actors: true, ActorTypes: []string{"counter"}})
+	require.NoError(t, err)
+	backup := buf.String()
+
+	t.Run("keys are translated", func(t *testing.T) {
+		dst := newTestStore(nil)
+		var written []string
+		res, err := Import(context.Background(), strings.NewReader(backup), Store{Name: "backup-dst", Store: dst, AppID: "app3"},
+			func(_ context.Context, ops []state.TransactionalStateOperation) {
+				for _, op := range ops {
+					written = append(written, op.GetKey())
+				}
+			})
+		require.NoError(t, err)
+		assert.Equal(t, Result{State: 3, ActorState: 1, Reminders: 3}, res)
+
+		assert.Equal(t, []string{"backup-dst||a", "backup-dst||b", "backup-dst||c"}, written)
+		assert.Equal(t, `{"n":1}`, string(dst.items["backup-dst||a"]))
+		assert.Equal(t, "binary", string(dst.items["backup-dst||b"]))
+		assert.Equal(t, `{ "n": 3 }`, string(dst.items["backup-dst||c"]))
+		assert.Contains(t, dst.metadata["backup-dst||a"], metadata.TTLMetadataKey)
+
+		assert.Equal(t, "10", string(dst.items["app3||counter||1||total"]))
+		assert.Equal(t, "app3||counter||1", dst.metadata["app3||counter||1||total"][metadataPartitionKey])
+		assert.Equal(t, "m1", dst.metadata["actors||counter||m1||reminders||1"][metadataPartitionKey])
+		assert.Equal(t, `{"id":"m1"}`, string(dst.items["actors||counter||metadata"]))
+	})
+
+	t.Run("invalid backups", func(t *testing.T) {
+		for name, b := range map[string]string{
+			"empty":     "",
+			"version":   `{"type":"header","version":2}`,
+			"truncated": backup[:strings.LastIndex(backup, `{"type":"end"`)],
+			"count":     strings.Replace(backup, `"count":7`, `"count":8`, 1),
+		} {
+			dst := newTestStore(nil)
+			_, err := Import(context.Background(), strings.NewReader(b), Store{Name: "backup-dst", Store: dst, AppID: "app3"}, nil)
+			require.ErrorIs(t, err, ErrInvalidBackup, name)
+			// Nothing is saved before the backup is validated
+			assert.Empty(t, dst.items, name)
+		}
+	})
+
+	t.Run("stopped imports return the records saved", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		dst := &cancelingStore{testStore: newTestStore(nil), cancel: cancel}
+		res, err := Import(ctx, strings.NewReader(backup), Store{Name: "backup-dst", Store: dst, AppID: "app3"}, nil)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, Result{Reminders: 1}, res)
+		assert.Len(t, dst.items, 1)
+	})
+
+	t.Run("invalid records", func(t *testing.T) {
+		b := `{"type":"header","version":1}
+{"type":"state","key":"a||b","value":1}
+{"type":"actorState","key":"total","value":1}
+{"type":"unknown"}
+{"type":"state","key":"old","value":1,"expireTime":"2020-01-01T00:00:00Z"}
+{"type":"end","count":4}
+`
+		res, err := Import(context.Background(), strings.NewReader(b), Store{Name: "backup-dst", Store: newTestStore(nil), AppID: "app3"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, Result{Failed: 3, Skipped: 1}, res)
+	})
+}
+
+func TestCopy(t *testing.T) {
+	require.NoError(t, stateLoader.SaveStateConfiguration("backup-copy", map[string]string{"keyPrefix": "none"}))
+	src := Store{Name: "backup-src", Store: sourceStore(), AppID: "app1"}
+	dst := newTestStore(nil)
+
+	res, err := Copy(context.Background(), src, Store{Name: "backup-copy", Store: dst, AppID: "app1"}, ExportOptions{PageSize: 2}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Result{State: 3}, res)
+	assert.Len(t, dst.items, 3)
+	assert.Equal(t, "binary", string(dst.items["b"]))
+
+	_, err = Copy(context.Background(), src, src, ExportOptions{}, nil)
+	require.ErrorIs(t, err, ErrInvalidBackup)
+
+	_, err = Copy(context.Background(), Store{Name: "backup-src", Store: struct{ state.Store }{}}, Store{Name: "backup-copy", Store: dst}, ExportOptions{}, nil)
+	require.ErrorIs(t, err, ErrInvalidBackup)
+}
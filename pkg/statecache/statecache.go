@@ -0,0 +1,263 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statecache keeps the values read from state stores in memory, so
+// hot keys don't require a round trip to the store on every read.
+//
+// Caching is enabled per state store component. Values are cached as they
+// are saved, so encrypted values are still decrypted on every read. Entries
+// are invalidated when keys are written through the sidecar; writes made by
+// other clients are only seen once the entries expire.
+package statecache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kclock "k8s.io/utils/clock"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	diag "github.com/dapr/dapr/pkg/diagnostics"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	// Metadata of the state store components.
+	ttlKey        = "cacheTTL"
+	maxEntriesKey = "cacheMaxEntries"
+
+	defaultMaxEntries = 1000
+)
+
+var log = logger.NewLogger("dapr.runtime.statecache")
+
+// GetFn reads a key from a state store.
+type GetFn func(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error)
+
+// Cache holds the caches of the state stores.
+type Cache struct {
+	clock kclock.Clock
+
+	lock   sync.RWMutex
+	stores map[string]*storeCache
+}
+
+// storeCache is the cache of a state store, evicting entries in LRU order.
+type storeCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	// Incremented by every invalidation, so reads that started before a
+	// write don't cache the values they got.
+	generation uint64
+}
+
+type entry struct {
+	key     string
+	res     *state.GetResponse
+	expires time.Time
+}
+
+// New returns a cache with no state stores.
+func New() *Cache {
+	return &Cache{
+		clock:  &kclock.RealClock{},
+		stores: map[string]*storeCache{},
+	}
+}
+
+// AddOrUpdateStore reads the cache configuration of a state store from its
+// component, dropping the values cached so far.
+func (c *Cache) AddOrUpdateStore(comp v1alpha1.Component) {
+	var (
+		ttl        time.Duration
+		maxEntries = defaultMaxEntries
+		err        error
+	)
+	for _, m := range comp.Spec.Metadata {
+		switch m.Name {
+		case ttlKey:
+			ttl, err = time.ParseDuration(strings.TrimSpace(m.Value.String()))
+			if err != nil || ttl < 0 {
+				log.Warnf("Invalid %s for state store %s, caching is disabled: %q", ttlKey, comp.Name, m.Value.String())
+				ttl = 0
+			}
+		case maxEntriesKey:
+			maxEntries, err = strconv.Atoi(strings.TrimSpace(m.Value.String()))
+			if err != nil || maxEntries <= 0 {
+				log.Warnf("Invalid %s for state store %s, using %d: %q", maxEntriesKey, comp.Name, defaultMaxEntries, m.Value.String())
+				maxEntries = defaultMaxEntries
+			}
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.stores, comp.Name)
+	if ttl == 0 {
+		return
+	}
+
+	log.Infof("Caching up to %d values of state store %s for %v", maxEntries, comp.Name, ttl)
+	c.stores[comp.Name] = &storeCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// RemoveStore stops caching the values of a state store.
+func (c *Cache) RemoveStore(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.stores, name)
+}
+
+func (c *Cache) store(name string) *storeCache {
+	if c == nil {
+		return nil
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.stores[name]
+}
+
+// Get returns the value of the key from the cache of the state store, or
+// reads it with get and caches it.
+// Requests with metadata or strong consistency always go to the store.
+func (c *Cache) Get(ctx context.Context, storeName string, req *state.GetRequest, get GetFn) (*state.GetResponse, error) {
+	sc := c.store(storeName)
+	if sc == nil || len(req.Metadata) > 0 || req.Options.Consistency == state.Strong {
+		return get(ctx, req)
+	}
+
+	now := c.clock.Now()
+	res, generation, ok := sc.get(req.Key, now)
+	if ok {
+		diag.DefaultComponentMonitoring.StateCacheRequest(ctx, storeName, diag.StateCacheHit)
+		return res, nil
+	}
+	diag.DefaultComponentMonitoring.StateCacheRequest(ctx, storeName, diag.StateCacheMiss)
+
+	res, err := get(ctx, req)
+	// Missing keys aren't cached, as they are often created right after.
+	if err == nil && res != nil && res.Data != nil {
+		sc.add(req.Key, res, now, generation)
+	}
+	return res, err
+}
+
+// Invalidate removes the keys of the operations, as saved, from the cache of
+// the state store.
+func (c *Cache) Invalidate(storeName string, ops []state.TransactionalStateOperation) {
+	sc := c.store(storeName)
+	if sc == nil {
+		return
+	}
+
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	sc.generation++
+	for _, op := range ops {
+		if el, ok := sc.entries[op.GetKey()]; ok {
+			sc.remove(el)
+		}
+	}
+}
+
+// Purge removes all the values from the cache of the state store.
+func (c *Cache) Purge(storeName string) {
+	sc := c.store(storeName)
+	if sc == nil {
+		return
+	}
+
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	sc.generation++
+	sc.entries = map[string]*list.Element{}
+	sc.lru.Init()
+}
+
+// get returns a copy of the cached value of the key if it's still fresh, and
+// the current generation.
+func (sc *storeCache) get(key string, now time.Time) (*state.GetResponse, uint64, bool) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	el, ok := sc.entries[key]
+	if !ok {
+		return nil, sc.generation, false
+	}
+	e := el.Value.(*entry)
+	if !now.Before(e.expires) {
+		sc.remove(el)
+		return nil, sc.generation, false
+	}
+	sc.lru.MoveToFront(el)
+	return copyResponse(e.res), sc.generation, true
+}
+
+// add caches the value read at now, unless keys were invalidated since the
+// given generation.
+func (sc *storeCache) add(key string, res *state.GetResponse, now time.Time, generation uint64) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	if sc.generation != generation {
+		return
+	}
+	if el, ok := sc.entries[key]; ok {
+		sc.remove(el)
+	}
+	for sc.lru.Len() >= sc.maxEntries {
+		sc.remove(sc.lru.Back())
+	}
+	sc.entries[key] = sc.lru.PushFront(&entry{
+		key:     key,
+		res:     copyResponse(res),
+		expires: now.Add(sc.ttl),
+	})
+}
+
+func (sc *storeCache) remove(el *list.Element) {
+	e := sc.lru.Remove(el).(*entry)
+	delete(sc.entries, e.key)
+}
+
+// copyResponse returns a deep copy of res, as callers modify the responses.
+func copyResponse(res *state.GetResponse) *state.GetResponse {
+	cp := *res
+	cp.Data = bytes.Clone(res.Data)
+	if res.ETag != nil {
+		etag := *res.ETag
+		cp.ETag = &etag
+	}
+	if res.Metadata != nil {
+		cp.Metadata = make(map[string]string, len(res.Metadata))
+		for k, v := range res.Metadata {
+			cp.Metadata[k] = v
+		}
+	}
+	return &cp
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statecache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsV1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/dapr/components-contrib/state"
+	commonapi "github.com/dapr/dapr/pkg/apis/common"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+)
+
+func component(name string, metadata map[string]string) v1alpha1.Component {
+	comp := v1alpha1.Component{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	for k, v := range metadata {
+		comp.Spec.Metadata = append(comp.Spec.Metadata, commonapi.NameValuePair{
+			Name:  k,
+			Value: commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte(v)}},
+		})
+	}
+	return comp
+}
+
+// fakeStore counts the reads of the keys.
+type fakeStore struct {
+	values map[string]string
+	reads  int
+}
+
+func (s *fakeStore) get(_ context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+	s.reads++
+	v, ok := s.values[req.Key]
+	if !ok {
+		return &state.GetResponse{}, nil
+	}
+	etag := "1"
+	return &state.GetResponse{Data: []byte(v), ETag: &etag}, nil
+}
+
+func newTestCache(t *testing.T, metadata map[string]string) (*Cache, *clocktesting.FakeClock, *fakeStore) {
+	clock := clocktesting.NewFakeClock(time.Now())
+	c := New()
+	c.clock = clock
+	c.AddOrUpdateStore(component("store1", metadata))
+	require.NotNil(t, c.store("store1"))
+	return c, clock, &fakeStore{values: map[string]string{"a": "1", "b": "2", "c": "3"}}
+}
+
+func (c *Cache) read(t *testing.T, s *fakeStore, req *state.GetRequest) string {
+	t.Helper()
+	res, err := c.Get(context.Background(), "store1", req, s.get)
+	require.NoError(t, err)
+	return string(res.Data)
+}
+
+func TestAddOrUpdateStore(t *testing.T) {
+	c := New()
+
+	c.AddOrUpdateStore(component("store1", map[string]string{maxEntriesKey: "10"}))
+	assert.Nil(t, c.store("store1"))
+	c.AddOrUpdateStore(component("store1", map[string]string{ttlKey: "forever"}))
+	assert.Nil(t, c.store("store1"))
+
+	c.AddOrUpdateStore(component("store1", map[string]string{ttlKey: "30s", maxEntriesKey: "-1"}))
+	require.NotNil(t, c.store("store1"))
+	assert.Equal(t, 30*time.Second, c.store("store1").ttl)
+	assert.Equal(t, defaultMaxEntries, c.store("store1").maxEntries)
+
+	c.RemoveStore("store1")
+	assert.Nil(t, c.store("store1"))
+
+	t.Run("nil cache", func(t *testing.T) {
+		var c *Cache
+		s := &fakeStore{values: map[string]string{"a": "1"}}
+		assert.Equal(t, "1", c.read(t, s, &state.GetRequest{Key: "a"}))
+		c.Invalidate("store1", nil)
+		c.Purge("store1")
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("values are cached until they expire", func(t *testing.T) {
+		c, clock, s := newTestCache(t, map[string]string{ttlKey: "10s"})
+
+		assert.Equal(t, "1", c.read(t, s, &state.GetRequest{Key: "a"}))
+		s.values["a"] = "changed elsewhere"
+		assert.Equal(t, "1", c.read(t, s, &state.GetRequest{Key: "a"}))
+		assert.Equal(t, 1, s.reads)
+
+		clock.Step(10 * time.Second)
+		assert.Equal(t, "changed elsewhere", c.read(t, s, &state.GetRequest{Key: "a"}))
+		assert.Equal(t, 2, s.reads)
+	})
+
+	t.Run("some reads bypass the cache", func(t *testing.T) {
+		c, _, s := newTestCache(t, map[string]string{ttlKey: "10s"})
+
+		c.read(t, s, &state.GetRequest{Key: "a"})
+		c.read(t, s, &state.GetRequest{Key: "a", Options: state.GetStateOption{Consistency: state.Strong}})
+		c.read(t, s, &state.GetRequest{Key: "a", Metadata: map[string]string{"partitionKey": "p"}})
+		c.read(t, s, &state.GetRequest{Key: "missing"})
+		c.read(t, s, &state.GetRequest{Key: "missing"})
+		assert.Equal(t, 5, s.reads)
+
+		_, err := c.Get(context.Background(), "store1", &state.GetRequest{Key: "d"}, func(context.Context, *state.GetRequest) (*state.GetResponse, error) {
+			return nil, errors.New("store unavailable")
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("cached responses can't be modified", func(t *testing.T) {
+		c, _, s := newTestCache(t, map[string]string{ttlKey: "10s"})
+
+		res, err := c.Get(context.Background(), "store1", &state.GetRequest{Key: "a"}, s.get)
+		require.NoError(t, err)
+		res.Data[0] = 'x'
+		res.Data = []byte("decrypted")
+		assert.Equal(t, "1", c.read(t, s, &state.GetRequest{Key: "a"}))
+	})
+
+	t.Run("least recently used values are evicted", func(t *testing.T) {
+		c, _, s := newTestCache(t, map[string]string{ttlKey: "10s", maxEntriesKey: "2"})
+
+		c.read(t, s, &state.GetRequest{Key: "a"})
+		c.read(t, s, &state.GetRequest{Key: "b"})
+		c.read(t, s, &state.GetRequest{Key: "a"})
+		c.read(t, s, &state.GetRequest{Key: "c"})
+		assert.Equal(t, 3, s.reads)
+
+		c.read(t, s, &state.GetRequest{Key: "a"})
+		assert.Equal(t, 3, s.reads)
+		c.read(t, s, &state.GetRequest{Key: "b"})
+		assert.Equal(t, 4, s.reads)
+	})
+}
+
+func TestInvalidate(t *testing.T) {
+	c, _, s := newTestCache(t, map[string]string{ttlKey: "10s"})
+
+	c.read(t, s, &state.GetRequest{Key: "a"})
+	c.read(t, s, &state.GetRequest{Key: "b"})
+	s.values["a"] = "4"
+	s.values["b"] = "5"
+	c.Invalidate("store1", []state.TransactionalStateOperation{state.SetRequest{Key: "a"}})
+	assert.Equal(t, "4", c.read(t, s, &state.GetRequest{Key: "a"}))
+	assert.Equal(t, "2", c.read(t, s, &state.GetRequest{Key: "b"}))
+
+	c.Purge("store1")
+	assert.Equal(t, "5", c.read(t, s, &state.GetRequest{Key: "b"}))
+
+	t.Run("reads concurrent with writes aren't cached", func(t *testing.T) {
+		_, err := c.Get(context.Background(), "store1", &state.GetRequest{Key: "c"}, func(ctx context.Context, req *state.GetRequest) (*state.GetResponse, error) {
+			res, err := s.get(ctx, req)
+			c.Invalidate("store1", []state.TransactionalStateOperation{state.DeleteRequest{Key: "c"}})
+			delete(s.values, "c")
+			return res, err
+		})
+		require.NoError(t, err)
+
+		res, err := c.Get(context.Background(), "store1", &state.GetRequest{Key: "c"}, s.get)
+		require.NoError(t, err)
+		assert.Nil(t, res.Data)
+	})
+}
@@ -0,0 +1,424 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statechanges
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	contribPubsub "github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+	"github.com/dapr/dapr/pkg/encryption"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/utils"
+)
+
+const (
+	pubsubKey = "changeEventsPubsub"
+	topicKey  = "changeEventsTopic"
+
+	// Metadata of the set operations that are outbox projections, which aren't saved.
+	outboxProjectionKey = "outbox.projection"
+
+	OperationUpsert = "upsert"
+	OperationDelete = "delete"
+
+	// Number of batches of changes buffered for each subscription.
+	subscriptionBuffer = 100
+	// Number of batches of changes queued to be published.
+	publishQueueSize = 1000
+)
+
+var log = logger.NewLogger("dapr.runtime.statechanges")
+
+// ErrSubscriptionOverflow is returned when a subscriber doesn't receive the
+// changes as fast as they happen, and some were dropped.
+var ErrSubscriptionOverflow = errors.New("the subscriber is too slow: changes were dropped")
+
+// Change is a change of a key in a state store.
+type Change struct {
+	Key       string            `json:"key"`
+	Operation string            `json:"operation"`
+	Value     []byte            `json:"-"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// PublishFn publishes a message to a pubsub topic.
+type PublishFn func(context.Context, *contribPubsub.PublishRequest) error
+
+// Notifier delivers the changes of keys in state stores to subscribers, and
+// publishes them to the topics configured in the state store components.
+type Notifier struct {
+	appID   string
+	publish PublishFn
+
+	lock   sync.Mutex
+	topics map[string]topic
+	subs   map[string]map[*Subscription]struct{}
+
+	// Changes are published in order by a single goroutine, so that writes
+	// don't wait for the pubsub.
+	queue          chan publishJob
+	startPublisher sync.Once
+	closeOnce      sync.Once
+	closed         chan struct{}
+}
+
+type topic struct {
+	pubsub string
+	topic  string
+}
+
+type publishJob struct {
+	storeName string
+	topic     topic
+	changes   []Change
+}
+
+// Subscription receives the changes of some keys of a state store.
+type Subscription struct {
+	n         *Notifier
+	storeName string
+	keys      map[string]struct{}
+	prefixes  []string
+
+	ch       chan []Change
+	lock     sync.Mutex
+	closed   bool
+	overflow bool
+}
+
+// NewNotifier returns a notifier for the changes made by an app.
+func NewNotifier(appID string, publish PublishFn) *Notifier {
+	return &Notifier{
+		appID:   appID,
+		publish: publish,
+		topics:  map[string]topic{},
+		subs:    map[string]map[*Subscription]struct{}{},
+		queue:   make(chan publishJob, publishQueueSize),
+		closed:  make(chan struct{}),
+	}
+}
+
+// AddOrUpdateStore reads the topic the changes of a state store are
+// published to, if any, from its component.
+func (n *Notifier) AddOrUpdateStore(comp v1alpha1.Component) {
+	var t topic
+	for _, m := range comp.Spec.Metadata {
+		switch m.Name {
+		case pubsubKey:
+			t.pubsub = m.Value.String()
+		case topicKey:
+			t.topic = m.Value.String()
+		}
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if t.pubsub == "" || t.topic == "" {
+		delete(n.topics, comp.Name)
+		return
+	}
+	n.topics[comp.Name] = t
+}
+
+// Subscribe returns a subscription to the changes of keys, or keys with the
+// given prefixes, of a state store. All the keys are watched if both are
+// empty.
+// Only the changes made through the Dapr state API of this app are delivered.
+func (n *Notifier) Subscribe(storeName string, keys []string, prefixes []string) *Subscription {
+	sub := &Subscription{
+		n:         n,
+		storeName: storeName,
+		keys:      make(map[string]struct{}, len(keys)),
+		prefixes:  prefixes,
+		ch:        make(chan []Change, subscriptionBuffer),
+	}
+	for _, k := range keys {
+		sub.keys[k] = struct{}{}
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.subs[storeName] == nil {
+		n.subs[storeName] = map[*Subscription]struct{}{}
+	}
+	n.subs[storeName][sub] = struct{}{}
+	return sub
+}
+
+// C returns the channel the changes are delivered on. It's closed when the
+// subscription ends.
+func (s *Subscription) C() <-chan []Change {
+	return s.ch
+}
+
+// Err returns ErrSubscriptionOverflow if the subscription was ended because
+// the subscriber was too slow.
+func (s *Subscription) Err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.overflow {
+		return ErrSubscriptionOverflow
+	}
+	return nil
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.n.remove(s)
+	s.close(false)
+}
+
+// remove stops delivering changes to a subscription.
+func (n *Notifier) remove(s *Subscription) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	delete(n.subs[s.storeName], s)
+	if len(n.subs[s.storeName]) == 0 {
+		delete(n.subs, s.storeName)
+	}
+}
+
+func (s *Subscription) close(overflow bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.closed {
+		s.closed = true
+		s.overflow = overflow
+		close(s.ch)
+	}
+}
+
+func (s *Subscription) matches(key string) bool {
+	if len(s.keys) == 0 && len(s.prefixes) == 0 {
+		return true
+	}
+	if _, ok := s.keys[key]; ok {
+		return true
+	}
+	for _, p := range s.prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver sends the changes of the watched keys, without blocking.
+func (s *Subscription) deliver(changes []Change) {
+	var matched []Change
+	for _, c := range changes {
+		if s.matches(c.Key) {
+			matched = append(matched, c)
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return
+	}
+	select {
+	case s.ch <- matched:
+		s.lock.Unlock()
+	default:
+		s.lock.Unlock()
+		log.Warnf("Ending subscription to the changes of state store %s: %v", s.storeName, ErrSubscriptionOverflow)
+		s.n.remove(s)
+		s.close(true)
+	}
+}
+
+// Notify is called after the operations were executed on a state store
+// through the Dapr API. Subscribers receive the changes without blocking, and
+// the changes are queued to be published; Notify only waits for the queue
+// when it's full, until ctx is done.
+func (n *Notifier) Notify(ctx context.Context, storeName string, ops []state.TransactionalStateOperation) {
+	if n == nil {
+		return
+	}
+
+	n.lock.Lock()
+	t, publish := n.topics[storeName]
+	subs := make([]*Subscription, 0, len(n.subs[storeName]))
+	for s := range n.subs[storeName] {
+		subs = append(subs, s)
+	}
+	n.lock.Unlock()
+
+	if !publish && len(subs) == 0 {
+		return
+	}
+
+	changes := n.changes(storeName, ops)
+	if len(changes) == 0 {
+		return
+	}
+	for _, s := range subs {
+		s.deliver(changes)
+	}
+	if publish {
+		n.startPublisher.Do(func() {
+			go n.runPublisher()
+		})
+		select {
+		case n.queue <- publishJob{storeName: storeName, topic: t, changes: changes}:
+		case <-n.closed:
+		case <-ctx.Done():
+			log.Warnf("Failed to publish the changes of state store %s to topic %s: %v", storeName, t.topic, ctx.Err())
+		}
+	}
+}
+
+// runPublisher publishes the queued changes until the notifier is closed.
+func (n *Notifier) runPublisher() {
+	for {
+		select {
+		case job := <-n.queue:
+			n.publishChanges(context.Background(), job.storeName, job.topic, job.changes)
+		case <-n.closed:
+			return
+		}
+	}
+}
+
+// changes converts the operations to changes of the keys of the app.
+func (n *Notifier) changes(storeName string, ops []state.TransactionalStateOperation) []Change {
+	encrypted := encryption.EncryptedStateStore(storeName)
+	changes := make([]Change, 0, len(ops))
+	for _, op := range ops {
+		switch req := op.(type) {
+		case state.SetRequest:
+			if utils.IsTruthy(req.Metadata[outboxProjectionKey]) {
+				continue
+			}
+			c := Change{
+				Key:       stateLoader.GetOriginalStateKeyForStore(req.Key, storeName, n.appID),
+				Operation: OperationUpsert,
+				Metadata:  req.Metadata,
+			}
+			if !encrypted {
+				c.Value = valueBytes(req.Value)
+			}
+			changes = append(changes, c)
+		case state.DeleteRequest:
+			changes = append(changes, Change{
+				Key:       stateLoader.GetOriginalStateKeyForStore(req.Key, storeName, n.appID),
+				Operation: OperationDelete,
+				Metadata:  req.Metadata,
+			})
+		}
+	}
+	return changes
+}
+
+// changeEvent is the data of the events published for the changes of a store.
+type changeEvent struct {
+	StoreName string        `json:"storeName"`
+	Changes   []eventChange `json:"changes"`
+}
+
+type eventChange struct {
+	Change
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func (n *Notifier) publishChanges(ctx context.Context, storeName string, t topic, changes []Change) {
+	event := changeEvent{
+		StoreName: storeName,
+		Changes:   make([]eventChange, len(changes)),
+	}
+	for i, c := range changes {
+		event.Changes[i].Change = c
+		if c.Value == nil {
+			continue
+		}
+		// Values that aren't JSON are sent as strings
+		if json.Valid(c.Value) {
+			event.Changes[i].Value = c.Value
+		} else {
+			event.Changes[i].Value, _ = json.Marshal(string(c.Value))
+		}
+	}
+
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = n.publish(ctx, &contribPubsub.PublishRequest{
+			PubsubName: t.pubsub,
+			Topic:      t.topic,
+			Data:       data,
+			Metadata:   map[string]string{"cloudevent.type": "dapr.io.state.changes"},
+		})
+	}
+	if err != nil {
+		log.Warnf("Failed to publish the changes of state store %s to topic %s: %v", storeName, t.topic, err)
+	}
+}
+
+// Close ends all the subscriptions and stops publishing changes.
+func (n *Notifier) Close() {
+	n.closeOnce.Do(func() {
+		close(n.closed)
+	})
+
+	n.lock.Lock()
+	var subs []*Subscription
+	for _, m := range n.subs {
+		for s := range m {
+			subs = append(subs, s)
+		}
+	}
+	n.subs = map[string]map[*Subscription]struct{}{}
+	n.lock.Unlock()
+
+	for _, s := range subs {
+		s.close(false)
+	}
+}
+
+func valueBytes(value any) []byte {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return v
+	case string:
+		b, _ := json.Marshal(v)
+		return b
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		return b
+	}
+}
+
+// Operations converts set or delete requests to the operations passed to Notify.
+func Operations[T state.SetRequest | state.DeleteRequest](reqs []T) []state.TransactionalStateOperation {
+	ops := make([]state.TransactionalStateOperation, len(reqs))
+	for i, req := range reqs {
+		ops[i] = any(req).(state.TransactionalStateOperation)
+	}
+	return ops
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statechanges
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsV1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	contribPubsub "github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/components-contrib/state"
+	commonapi "github.com/dapr/dapr/pkg/apis/common"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+)
+
+func receive(t *testing.T, sub *Subscription) []Change {
+	t.Helper()
+	select {
+	case changes := <-sub.C():
+		return changes
+	case <-time.After(time.Second):
+		require.Fail(t, "no changes received")
+		return nil
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	require.NoError(t, stateLoader.SaveStateConfiguration("store1", map[string]string{}))
+
+	t.Run("subscribers receive the changes of their keys", func(t *testing.T) {
+		n := NewNotifier("app1", nil)
+		sub := n.Subscribe("store1", []string{"a"}, []string{"order-"})
+		defer sub.Close()
+		all := n.Subscribe("store1", nil, nil)
+		defer all.Close()
+
+		n.Notify(context.Background(), "store1", []state.TransactionalStateOperation{
+			state.SetRequest{Key: "app1||a", Value: map[string]any{"x": 1}},
+			state.SetRequest{Key: "app1||b", Value: []byte("raw")},
+			state.DeleteRequest{Key: "app1||order-1"},
+			state.SetRequest{Key: "app1||a", Value: "projected", Metadata: map[string]string{outboxProjectionKey: "true"}},
+		})
+
+		assert.Equal(t, []Change{
+			{Key: "a", Operation: OperationUpsert, Value: []byte(`{"x":1}`)},
+			{Key: "order-1", Operation: OperationDelete},
+		}, receive(t, sub))
+		assert.Len(t, receive(t, all), 3)
+	})
+
+	t.Run("changes are published to the topic of the store", func(t *testing.T) {
+		published := make(chan *contribPubsub.PublishRequest, 1)
+		n := NewNotifier("app1", func(_ context.Context, req *contribPubsub.PublishRequest) error {
+			published <- req
+			return nil
+		})
+		defer n.Close()
+		n.AddOrUpdateStore(v1alpha1.Component{
+			ObjectMeta: metav1.ObjectMeta{Name: "store1"},
+			Spec: v1alpha1.ComponentSpec{Metadata: []commonapi.NameValuePair{
+				{Name: pubsubKey, Value: commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte("mypubsub")}}},
+				{Name: topicKey, Value: commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte("changes")}}},
+			}},
+		})
+
+		n.Notify(context.Background(), "store1", Operations([]state.SetRequest{
+			{Key: "app1||a", Value: []byte(`{"x":1}`)},
+			{Key: "app1||b", Value: []byte("not json")},
+		}))
+		var req *contribPubsub.PublishRequest
+		select {
+		case req = <-published:
+		case <-time.After(time.Second):
+			require.Fail(t, "the changes weren't published")
+		}
+		assert.Equal(t, "mypubsub", req.PubsubName)
+		assert.Equal(t, "changes", req.Topic)
+		assert.JSONEq(t, `{"storeName":"store1","changes":[
+			{"key":"a","operation":"upsert","value":{"x":1}},
+			{"key":"b","operation":"upsert","value":"not json"}
+		]}`, string(req.Data))
+
+		// Removing the metadata stops publishing
+		n.AddOrUpdateStore(v1alpha1.Component{ObjectMeta: metav1.ObjectMeta{Name: "store1"}})
+		n.Notify(context.Background(), "store1", Operations([]state.DeleteRequest{{Key: "app1||a"}}))
+		select {
+		case <-published:
+			require.Fail(t, "the changes were published")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("slow subscribers are dropped", func(t *testing.T) {
+		n := NewNotifier("app1", nil)
+		sub := n.Subscribe("store1", nil, nil)
+		defer sub.Close()
+
+		for i := 0; i <= subscriptionBuffer; i++ {
+			n.Notify(context.Background(), "store1", Operations([]state.DeleteRequest{{Key: "app1||a"}}))
+		}
+		for range sub.C() {
+		}
+		require.ErrorIs(t, sub.Err(), ErrSubscriptionOverflow)
+		n.lock.Lock()
+		assert.Empty(t, n.subs)
+		n.lock.Unlock()
+	})
+
+	t.Run("closing the last subscription removes the store", func(t *testing.T) {
+		n := NewNotifier("app1", nil)
+		sub := n.Subscribe("store1", []string{"a"}, nil)
+		sub.Close()
+		_, ok := <-sub.C()
+		assert.False(t, ok)
+		n.lock.Lock()
+		assert.Empty(t, n.subs)
+		n.lock.Unlock()
+	})
+
+	t.Run("nil notifier", func(t *testing.T) {
+		var n *Notifier
+		n.Notify(context.Background(), "store1", nil)
+	})
+}
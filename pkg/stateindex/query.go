@@ -0,0 +1,260 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateindex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/components-contrib/state/query"
+	"github.com/dapr/dapr/pkg/resiliency"
+)
+
+// Query answers a query on a state store using its index, then reads the
+// values of the results from the store. Tokens are offsets in the results.
+func (x *Indexer) Query(ctx context.Context, storeName string, store state.Store, req *state.QueryRequest) (*state.QueryResponse, error) {
+	idx, indexStore, err := x.get(storeName)
+	if err != nil {
+		return nil, err
+	}
+	if err = validate(idx.fields, &req.Query); err != nil {
+		return nil, err
+	}
+	offset := 0
+	if req.Query.Page.Token != "" {
+		offset, err = strconv.Atoi(req.Query.Page.Token)
+		if err != nil || offset < 0 {
+			return nil, fmt.Errorf("%w: invalid token %q", ErrInvalidQuery, req.Query.Page.Token)
+		}
+	}
+
+	loaded, err := x.entries(ctx, storeName, store, idx.store, indexStore)
+	if err != nil {
+		return nil, err
+	}
+	// The loaded entries are updated by the writes of the app
+	matched := entries{}
+	x.cacheLock.RLock()
+	for k, fields := range loaded.entries {
+		if matches(req.Query.Filter, fields) {
+			matched[k] = fields
+		}
+	}
+	x.cacheLock.RUnlock()
+	keys := make([]string, 0, len(matched))
+	for k := range matched {
+		keys = append(keys, k)
+	}
+	sortKeys(keys, matched, req.Query.Sort)
+
+	res := &state.QueryResponse{}
+	if offset >= len(keys) {
+		return res, nil
+	}
+	keys = keys[offset:]
+	if limit := req.Query.Page.Limit; limit > 0 && limit < len(keys) {
+		keys = keys[:limit]
+		res.Token = strconv.Itoa(offset + limit)
+	}
+
+	reqs := make([]state.GetRequest, len(keys))
+	for i, k := range keys {
+		reqs[i] = state.GetRequest{Key: k, Metadata: req.Metadata}
+	}
+	policyRunner := resiliency.NewRunner[[]state.BulkGetResponse](ctx,
+		x.resiliency.ComponentOutboundPolicy(storeName, resiliency.Statestore),
+	)
+	items, err := policyRunner(func(ctx context.Context) ([]state.BulkGetResponse, error) {
+		return store.BulkGet(ctx, reqs, state.BulkGetOpts{})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the order of the index, skipping the records deleted since indexed
+	byKey := make(map[string]state.BulkGetResponse, len(items))
+	for _, item := range items {
+		byKey[item.Key] = item
+	}
+	res.Results = make([]state.QueryItem, 0, len(keys))
+	for _, k := range keys {
+		item, ok := byKey[k]
+		if !ok || (item.Error == "" && item.Data == nil) {
+			continue
+		}
+		res.Results = append(res.Results, state.QueryItem{
+			Key:         k,
+			Data:        item.Data,
+			ETag:        item.ETag,
+			Error:       item.Error,
+			ContentType: item.ContentType,
+		})
+	}
+	return res, nil
+}
+
+// validate checks that the query filters and sorts by indexed fields only.
+func validate(fields []string, q *query.Query) error {
+	indexed := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		indexed[f] = true
+	}
+	check := func(key string) error {
+		if !indexed[key] {
+			return fmt.Errorf("%w: field %s is not indexed", ErrInvalidQuery, key)
+		}
+		return nil
+	}
+
+	for _, s := range q.Sort {
+		if err := check(s.Key); err != nil {
+			return err
+		}
+		if s.Order != "" && s.Order != query.ASC && s.Order != query.DESC {
+			return fmt.Errorf("%w: invalid sort order %s", ErrInvalidQuery, s.Order)
+		}
+	}
+
+	var checkFilter func(f query.Filter) error
+	checkFilter = func(f query.Filter) error {
+		switch v := f.(type) {
+		case nil:
+			return nil
+		case *query.EQ:
+			return check(v.Key)
+		case *query.IN:
+			return check(v.Key)
+		case *query.AND:
+			for _, child := range v.Filters {
+				if err := checkFilter(child); err != nil {
+					return err
+				}
+			}
+		case *query.OR:
+			for _, child := range v.Filters {
+				if err := checkFilter(child); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%w: unsupported filter %T", ErrInvalidQuery, f)
+		}
+		return nil
+	}
+	return checkFilter(q.Filter)
+}
+
+func matches(f query.Filter, fields map[string]any) bool {
+	switch v := f.(type) {
+	case *query.EQ:
+		return compare(fields[v.Key], v.Val) == 0
+	case *query.IN:
+		for _, val := range v.Vals {
+			if compare(fields[v.Key], val) == 0 {
+				return true
+			}
+		}
+		return false
+	case *query.AND:
+		for _, child := range v.Filters {
+			if !matches(child, fields) {
+				return false
+			}
+		}
+		return true
+	case *query.OR:
+		for _, child := range v.Filters {
+			if matches(child, fields) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// sortKeys sorts the keys by the fields of their entries, then by key so
+// that pages are stable.
+func sortKeys(keys []string, all entries, sorting []query.Sorting) {
+	sort.Slice(keys, func(i, j int) bool {
+		for _, s := range sorting {
+			c := compare(all[keys[i]][s.Key], all[keys[j]][s.Key])
+			if c == 0 {
+				continue
+			}
+			if s.Order == query.DESC {
+				return c > 0
+			}
+			return c < 0
+		}
+		return keys[i] < keys[j]
+	})
+}
+
+// compare orders JSON values: missing values and nulls first, then booleans,
+// numbers and strings. Objects and arrays are compared by representation.
+func compare(a, b any) int {
+	ra, rb := rank(a), rank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch va := a.(type) {
+	case nil:
+		return 0
+	case bool:
+		vb := b.(bool)
+		switch {
+		case va == vb:
+			return 0
+		case !va:
+			return -1
+		default:
+			return 1
+		}
+	case float64:
+		vb := b.(float64)
+		switch {
+		case va < vb:
+			return -1
+		case va > vb:
+			return 1
+		default:
+			return 0
+		}
+	case string:
+		return strings.Compare(va, b.(string))
+	default:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+func rank(v any) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case float64:
+		return 2
+	case string:
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateindex
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/dapr/pkg/resiliency"
+)
+
+// markStale marks the index of a state store as stale in the index store, so
+// that all the instances of the app stop using it, and rebuilds it.
+func (x *Indexer) markStale(ctx context.Context, storeName string, indexStoreName string, indexStore state.Store) {
+	policyRunner := resiliency.NewRunner[any](ctx,
+		x.resiliency.ComponentOutboundPolicy(indexStoreName, resiliency.Statestore),
+	)
+	_, err := policyRunner(func(ctx context.Context) (any, error) {
+		return nil, indexStore.Set(ctx, &state.SetRequest{
+			Key:   x.staleKey(storeName),
+			Value: []byte(strconv.FormatInt(time.Now().UnixNano(), 10)),
+		})
+	})
+	if err != nil {
+		log.Warnf("Failed to mark the index of state store %s as stale: %v", storeName, err)
+	}
+	x.rebuild(storeName)
+}
+
+// rebuild rebuilds the index of a state store in the background, unless it's
+// being rebuilt already.
+func (x *Indexer) rebuild(storeName string) {
+	x.cacheLock.Lock()
+	defer x.cacheLock.Unlock()
+	if x.rebuilding[storeName] {
+		return
+	}
+	x.rebuilding[storeName] = true
+	delete(x.cache, storeName)
+
+	go func() {
+		log.Infof("Rebuilding the index of state store %s", storeName)
+		err := x.rebuildIndex(context.Background(), storeName)
+		if err != nil {
+			log.Errorf("Failed to rebuild the index of state store %s: %v", storeName, err)
+		} else {
+			log.Infof("Rebuilt the index of state store %s", storeName)
+		}
+
+		x.cacheLock.Lock()
+		delete(x.rebuilding, storeName)
+		delete(x.cache, storeName)
+		x.cacheLock.Unlock()
+	}()
+}
+
+// rebuildIndex indexes again the records of the app in a state store. The
+// stale mark is removed at the end, unless the index was marked again
+// meanwhile.
+func (x *Indexer) rebuildIndex(ctx context.Context, storeName string) error {
+	idx, indexStore, err := x.get(storeName)
+	if err != nil {
+		return err
+	}
+	store, ok := x.compStore.GetStateStore(storeName)
+	if !ok {
+		return fmt.Errorf("state store %s is not found", storeName)
+	}
+	policyDef := x.resiliency.ComponentOutboundPolicy(idx.store, resiliency.Statestore)
+	mark, err := resiliency.NewRunner[*state.GetResponse](ctx, policyDef)(func(ctx context.Context) (*state.GetResponse, error) {
+		return indexStore.Get(ctx, &state.GetRequest{Key: x.staleKey(storeName)})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the stale mark: %w", err)
+	}
+
+	err = x.listKeys(ctx, storeName, store, func(keys []string) error {
+		found, err := x.readEntries(ctx, storeName, store, idx.fields, keys)
+		if err != nil {
+			return err
+		}
+		// Records deleted since listed have nil fields, so their entries are removed
+		changes := make(entries, len(keys))
+		for _, k := range keys {
+			changes[k] = found[k]
+		}
+		return x.saveEntries(ctx, storeName, idx.store, indexStore, changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	if mark == nil || mark.Data == nil {
+		return nil
+	}
+	_, err = resiliency.NewRunner[any](ctx, policyDef)(func(ctx context.Context) (any, error) {
+		return nil, indexStore.Delete(ctx, &state.DeleteRequest{
+			Key:     x.staleKey(storeName),
+			ETag:    mark.ETag,
+			Options: state.DeleteStateOption{Concurrency: state.FirstWrite},
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove the stale mark: %w", err)
+	}
+	return nil
+}
+
+// readEntries reads the records of the keys from the state store, and
+// returns their indexed fields. Deleted records have no entries.
+func (x *Indexer) readEntries(ctx context.Context, storeName string, store state.Store, fields []string, keys []string) (entries, error) {
+	reqs := make([]state.GetRequest, len(keys))
+	for i, k := range keys {
+		reqs[i] = state.GetRequest{Key: k}
+	}
+	policyRunner := resiliency.NewRunner[[]state.BulkGetResponse](ctx,
+		x.resiliency.ComponentOutboundPolicy(storeName, resiliency.Statestore),
+	)
+	items, err := policyRunner(func(ctx context.Context) ([]state.BulkGetResponse, error) {
+		return store.BulkGet(ctx, reqs, state.BulkGetOpts{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the records: %w", err)
+	}
+	res := make(entries, len(items))
+	for _, item := range items {
+		if item.Error != "" {
+			return nil, fmt.Errorf("failed to read key %s: %s", item.Key, item.Error)
+		}
+		if item.Data != nil {
+			res[item.Key] = extractFields(item.Data, fields)
+		}
+	}
+	return res, nil
+}
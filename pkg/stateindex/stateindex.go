@@ -0,0 +1,471 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package stateindex maintains secondary indexes of state stores that can't
+// be queried natively, so the state query API can be used with them.
+//
+// The values of the indexed JSON fields of each record are saved in a
+// companion state store, in an entry per record. Only the state stores that
+// can list their keys are indexed: queries list the keys of the app and read
+// their entries, which are kept in memory for a short time to answer the
+// following queries. Only the records written through the state API after the
+// index is enabled are indexed.
+//
+// If the index can't be updated, it's marked as stale in the index store, and
+// rebuilt in the background from the records of the state store; queries fail
+// until then.
+package stateindex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+	"github.com/dapr/dapr/pkg/encryption"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/utils"
+)
+
+const (
+	// Metadata of the state store components.
+	storeKey  = "queryIndexStore"
+	fieldsKey = "queryIndexFields"
+
+	keyPrefix = "stateindex||"
+	// Part of the keys of the entries after the name of the state store.
+	entryKeyInfix = "entry||"
+	// Suffix of the key marking an index as stale.
+	staleKeySuffix = "stale"
+
+	// How long the loaded entries answer queries.
+	cacheTTL = 5 * time.Second
+
+	// Number of keys listed and read at a time.
+	pageSize = 100
+
+	// Outbox projections are published, not saved.
+	outboxProjectionKey = "outbox.projection"
+)
+
+var log = logger.NewLogger("dapr.runtime.stateindex")
+
+var (
+	// ErrInvalidQuery is returned for queries the index can't answer.
+	ErrInvalidQuery = errors.New("invalid query")
+	// ErrStaleIndex is returned for queries while the index is rebuilt.
+	ErrStaleIndex = errors.New("the index is being rebuilt")
+)
+
+type index struct {
+	store  string
+	fields []string
+}
+
+// loadedEntries are the entries of all the indexed records of a state store.
+type loadedEntries struct {
+	entries entries
+	loaded  time.Time
+}
+
+// Indexer maintains the indexes of the state stores of an app.
+type Indexer struct {
+	appID      string
+	compStore  *compstore.ComponentStore
+	resiliency resiliency.Provider
+
+	lock    sync.RWMutex
+	indexes map[string]index
+
+	cacheLock  sync.RWMutex
+	cache      map[string]*loadedEntries
+	rebuilding map[string]bool
+}
+
+// NewIndexer returns an indexer saving the indexes of an app in the state
+// stores of the component store.
+func NewIndexer(appID string, compStore *compstore.ComponentStore, resiliency resiliency.Provider) *Indexer {
+	return &Indexer{
+		appID:      appID,
+		compStore:  compStore,
+		resiliency: resiliency,
+		indexes:    map[string]index{},
+		cache:      map[string]*loadedEntries{},
+		rebuilding: map[string]bool{},
+	}
+}
+
+// AddOrUpdateStore reads the index configuration of a state store from its
+// component. It must be called after its encryption is configured.
+func (x *Indexer) AddOrUpdateStore(comp v1alpha1.Component) {
+	var idx index
+	for _, m := range comp.Spec.Metadata {
+		switch m.Name {
+		case storeKey:
+			idx.store = strings.TrimSpace(m.Value.String())
+		case fieldsKey:
+			for _, f := range strings.Split(m.Value.String(), ",") {
+				if f = strings.TrimSpace(f); f != "" {
+					idx.fields = append(idx.fields, f)
+				}
+			}
+		}
+	}
+
+	x.dropCache(comp.Name)
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	delete(x.indexes, comp.Name)
+	if idx.store == "" || len(idx.fields) == 0 {
+		return
+	}
+
+	// The records can't be found to answer queries otherwise
+	if store, ok := x.compStore.GetStateStore(comp.Name); !ok || !stateLoader.CanListKeys(store) {
+		log.Warnf("State store %s can't list its keys and can't be indexed", comp.Name)
+		return
+	}
+
+	if encryption.EncryptedStateStore(comp.Name) {
+		if !encryption.EncryptedFieldsStateStore(comp.Name) {
+			log.Warnf("State store %s is encrypted and can't be indexed", comp.Name)
+			return
+		}
+		for _, f := range idx.fields {
+			for _, enc := range encryption.ComponentEncryptedFields(comp) {
+				if f == enc || strings.HasPrefix(f, enc+".") || strings.HasPrefix(enc, f+".") {
+					log.Warnf("Field %s of state store %s is encrypted and can't be indexed", f, comp.Name)
+					return
+				}
+			}
+		}
+	}
+
+	log.Infof("Indexing fields %v of state store %s in state store %s", idx.fields, comp.Name, idx.store)
+	x.indexes[comp.Name] = idx
+}
+
+// RemoveStore stops indexing a state store.
+func (x *Indexer) RemoveStore(name string) {
+	x.dropCache(name)
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	delete(x.indexes, name)
+}
+
+// Indexed returns true if the state store is indexed.
+func (x *Indexer) Indexed(storeName string) bool {
+	if x == nil {
+		return false
+	}
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	_, ok := x.indexes[storeName]
+	return ok
+}
+
+func (x *Indexer) get(storeName string) (index, state.Store, error) {
+	x.lock.RLock()
+	idx, ok := x.indexes[storeName]
+	x.lock.RUnlock()
+	if !ok {
+		return idx, nil, fmt.Errorf("state store %s is not indexed", storeName)
+	}
+	store, ok := x.compStore.GetStateStore(idx.store)
+	if !ok {
+		return idx, nil, fmt.Errorf("index store %s is not found", idx.store)
+	}
+	return idx, store, nil
+}
+
+// entries are the indexed fields of records, by saved key.
+type entries map[string]map[string]any
+
+// Update indexes the records written by the operations, which have the keys
+// and values as they were saved. If the index can't be updated, it's rebuilt
+// in the background.
+func (x *Indexer) Update(ctx context.Context, storeName string, ops []state.TransactionalStateOperation) error {
+	if !x.Indexed(storeName) {
+		return nil
+	}
+	idx, indexStore, err := x.get(storeName)
+	if err != nil {
+		return err
+	}
+	if !state.FeatureETag.IsPresent(indexStore.Features()) {
+		return fmt.Errorf("index store %s doesn't support ETags", idx.store)
+	}
+
+	// The last operation on a key wins; nil fields are deletions
+	changes := entries{}
+	for _, op := range ops {
+		var (
+			key    string
+			fields map[string]any
+		)
+		switch req := op.(type) {
+		case state.SetRequest:
+			if utils.IsTruthy(req.Metadata[outboxProjectionKey]) {
+				continue
+			}
+			key = req.Key
+			fields = extractFields(req.Value, idx.fields)
+		case state.DeleteRequest:
+			key = req.Key
+		default:
+			continue
+		}
+		if _, ok := stateLoader.GetAppStateKey(key, storeName, x.appID); !ok {
+			continue
+		}
+		changes[key] = fields
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err = x.saveEntries(ctx, storeName, idx.store, indexStore, changes); err != nil {
+		x.markStale(ctx, storeName, idx.store, indexStore)
+		return fmt.Errorf("the index is rebuilt: %w", err)
+	}
+	x.updateCache(storeName, changes)
+	return nil
+}
+
+// saveEntries saves the entries of the records in the index store, and
+// removes those with nil fields.
+func (x *Indexer) saveEntries(ctx context.Context, storeName string, indexStoreName string, indexStore state.Store, changes entries) error {
+	var (
+		sets []state.SetRequest
+		dels []state.DeleteRequest
+	)
+	for k, fields := range changes {
+		if fields == nil {
+			dels = append(dels, state.DeleteRequest{Key: x.entryKey(storeName, k)})
+			continue
+		}
+		value, err := json.Marshal(fields)
+		if err != nil {
+			return fmt.Errorf("invalid index entry for key %s: %w", k, err)
+		}
+		sets = append(sets, state.SetRequest{Key: x.entryKey(storeName, k), Value: value})
+	}
+
+	policyRunner := resiliency.NewRunner[any](ctx,
+		x.resiliency.ComponentOutboundPolicy(indexStoreName, resiliency.Statestore),
+	)
+	opts := stateLoader.GetBulkStoreOpts(indexStoreName)
+	if len(sets) > 0 {
+		_, err := policyRunner(func(ctx context.Context) (any, error) {
+			return nil, indexStore.BulkSet(ctx, sets, opts)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save the index entries: %w", err)
+		}
+	}
+	if len(dels) > 0 {
+		_, err := policyRunner(func(ctx context.Context) (any, error) {
+			return nil, indexStore.BulkDelete(ctx, dels, opts)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove the index entries: %w", err)
+		}
+	}
+	return nil
+}
+
+// entries returns the entries of all the indexed records of a state store,
+// loaded in the last cacheTTL if possible.
+func (x *Indexer) entries(ctx context.Context, storeName string, store state.Store, indexStoreName string, indexStore state.Store) (*loadedEntries, error) {
+	x.cacheLock.RLock()
+	loaded := x.cache[storeName]
+	rebuilding := x.rebuilding[storeName]
+	x.cacheLock.RUnlock()
+	if rebuilding {
+		return nil, ErrStaleIndex
+	}
+	if loaded != nil && time.Since(loaded.loaded) < cacheTTL {
+		return loaded, nil
+	}
+
+	all, stale, err := x.load(ctx, storeName, store, indexStoreName, indexStore)
+	if err != nil {
+		return nil, err
+	}
+	if stale {
+		// Marked by an instance of the app that stopped before rebuilding it
+		x.rebuild(storeName)
+		return nil, ErrStaleIndex
+	}
+	loaded = &loadedEntries{entries: all, loaded: time.Now()}
+	x.cacheLock.Lock()
+	x.cache[storeName] = loaded
+	x.cacheLock.Unlock()
+	return loaded, nil
+}
+
+// updateCache applies the changes of the entries to the loaded ones.
+func (x *Indexer) updateCache(storeName string, changes entries) {
+	x.cacheLock.Lock()
+	defer x.cacheLock.Unlock()
+	loaded := x.cache[storeName]
+	if loaded == nil {
+		return
+	}
+	for k, fields := range changes {
+		if fields == nil {
+			delete(loaded.entries, k)
+		} else {
+			loaded.entries[k] = fields
+		}
+	}
+}
+
+func (x *Indexer) dropCache(storeName string) {
+	x.cacheLock.Lock()
+	defer x.cacheLock.Unlock()
+	delete(x.cache, storeName)
+}
+
+// load returns the entries of the records of the app in a state store, and
+// whether the index is marked as stale. Records without entries aren't
+// indexed.
+func (x *Indexer) load(ctx context.Context, storeName string, store state.Store, indexStoreName string, indexStore state.Store) (entries, bool, error) {
+	policyDef := x.resiliency.ComponentOutboundPolicy(indexStoreName, resiliency.Statestore)
+	mark, err := resiliency.NewRunner[*state.GetResponse](ctx, policyDef)(func(ctx context.Context) (*state.GetResponse, error) {
+		return indexStore.Get(ctx, &state.GetRequest{Key: x.staleKey(storeName)})
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read the stale mark: %w", err)
+	}
+	if mark != nil && len(mark.Data) > 0 {
+		return nil, true, nil
+	}
+
+	all := entries{}
+	policyRunner := resiliency.NewRunner[[]state.BulkGetResponse](ctx, policyDef)
+	err = x.listKeys(ctx, storeName, store, func(keys []string) error {
+		reqs := make([]state.GetRequest, len(keys))
+		for i, k := range keys {
+			reqs[i].Key = x.entryKey(storeName, k)
+		}
+		res, err := policyRunner(func(ctx context.Context) ([]state.BulkGetResponse, error) {
+			return indexStore.BulkGet(ctx, reqs, state.BulkGetOpts{})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read the index: %w", err)
+		}
+		for _, r := range res {
+			if r.Error != "" {
+				return fmt.Errorf("failed to read index entry %s: %s", r.Key, r.Error)
+			}
+			if len(r.Data) == 0 {
+				continue
+			}
+			var fields map[string]any
+			if err = json.Unmarshal(r.Data, &fields); err != nil {
+				return fmt.Errorf("invalid index entry %s: %w", r.Key, err)
+			}
+			all[strings.TrimPrefix(r.Key, x.entryKey(storeName, ""))] = fields
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return all, false, nil
+}
+
+// listKeys calls fn with the pages of the keys of the app in a state store,
+// as they are saved.
+func (x *Indexer) listKeys(ctx context.Context, storeName string, store state.Store, fn func(keys []string) error) error {
+	token := ""
+	for {
+		page, next, err := stateLoader.ListKeys(ctx, store, token, pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list the keys of state store %s: %w", storeName, err)
+		}
+		keys := make([]string, 0, len(page))
+		for _, k := range page {
+			// Other apps may share the store
+			if _, ok := stateLoader.GetAppStateKey(k, storeName, x.appID); ok {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) > 0 {
+			if err = fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == "" || len(page) == 0 {
+			return nil
+		}
+		token = next
+	}
+}
+
+func (x *Indexer) entryKey(storeName string, key string) string {
+	return keyPrefix + x.appID + "||" + storeName + "||" + entryKeyInfix + key
+}
+
+func (x *Indexer) staleKey(storeName string) string {
+	return keyPrefix + x.appID + "||" + storeName + "||" + staleKeySuffix
+}
+
+// extractFields returns the values of the fields of a JSON object. Values
+// that aren't objects have no fields.
+func extractFields(value any, fields []string) map[string]any {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		data, _ = json.Marshal(v)
+	}
+
+	res := make(map[string]any, len(fields))
+	var obj map[string]any
+	if json.Unmarshal(data, &obj) != nil {
+		return res
+	}
+	for _, f := range fields {
+		if v, ok := lookup(obj, f); ok {
+			res[f] = v
+		}
+	}
+	return res
+}
+
+// lookup returns the value of a field, with a path separated by dots.
+func lookup(obj map[string]any, field string) (any, bool) {
+	path := strings.Split(field, ".")
+	for _, p := range path[:len(path)-1] {
+		next, ok := obj[p].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		obj = next
+	}
+	v, ok := obj[path[len(path)-1]]
+	return v, ok
+}
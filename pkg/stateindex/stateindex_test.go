@@ -0,0 +1,310 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stateindex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsV1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dapr/components-contrib/state"
+	commonapi "github.com/dapr/dapr/pkg/apis/common"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	"github.com/dapr/dapr/pkg/encryption"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	"github.com/dapr/dapr/pkg/statechanges"
+	daprt "github.com/dapr/dapr/pkg/testing"
+)
+
+// rawStore stores the values as they are, like real state stores.
+type rawStore struct {
+	*daprt.FakeStateStore
+	failures int
+}
+
+func (s *rawStore) Set(ctx context.Context, req *state.SetRequest) error {
+	return s.Multi(ctx, &state.TransactionalStateRequest{Operations: []state.TransactionalStateOperation{*req}})
+}
+
+func (s *rawStore) BulkSet(ctx context.Context, req []state.SetRequest, _ state.BulkStoreOpts) error {
+	return s.multi(ctx, statechanges.Operations(req))
+}
+
+func (s *rawStore) BulkDelete(ctx context.Context, req []state.DeleteRequest, _ state.BulkStoreOpts) error {
+	return s.multi(ctx, statechanges.Operations(req))
+}
+
+// multi fails the bulk operations while there are failures left.
+func (s *rawStore) multi(ctx context.Context, ops []state.TransactionalStateOperation) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	return s.Multi(ctx, &state.TransactionalStateRequest{Operations: ops})
+}
+
+// ListKeys makes the store listable, so its index can be rebuilt.
+func (s *rawStore) ListKeys(ctx context.Context, token string, limit int) ([]string, string, error) {
+	keys := make([]string, 0)
+	for k := range s.GetItems() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, "", nil
+}
+
+func component(name string, metadata map[string]string) v1alpha1.Component {
+	comp := v1alpha1.Component{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	for k, v := range metadata {
+		comp.Spec.Metadata = append(comp.Spec.Metadata, commonapi.NameValuePair{
+			Name:  k,
+			Value: commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte(v)}},
+		})
+	}
+	return comp
+}
+
+type testEnv struct {
+	x     *Indexer
+	store *rawStore
+	index *rawStore
+}
+
+func newTestEnv(t *testing.T) testEnv {
+	env := testEnv{
+		store: &rawStore{FakeStateStore: daprt.NewFakeStateStore()},
+		index: &rawStore{FakeStateStore: daprt.NewFakeStateStore()},
+	}
+	compStore := compstore.New()
+	compStore.AddStateStore("store1", env.store)
+	compStore.AddStateStore("index", env.index)
+	env.x = NewIndexer("app1", compStore, resiliency.New(nil))
+	env.x.AddOrUpdateStore(component("store1", map[string]string{
+		storeKey:  "index",
+		fieldsKey: "city, person.age,vip",
+	}))
+	require.True(t, env.x.Indexed("store1"))
+	return env
+}
+
+// write saves the values in the store and updates the index.
+func (env testEnv) write(t *testing.T, ops ...state.TransactionalStateOperation) {
+	t.Helper()
+	require.NoError(t, env.store.Multi(context.Background(), &state.TransactionalStateRequest{Operations: ops}))
+	require.NoError(t, env.x.Update(context.Background(), "store1", ops))
+}
+
+// query returns the keys of the results, without the prefix of the app.
+func (env testEnv) query(t *testing.T, q string) ([]string, string) {
+	t.Helper()
+	var req state.QueryRequest
+	require.NoError(t, json.Unmarshal([]byte(q), &req.Query))
+	res, err := env.x.Query(context.Background(), "store1", env.store, &req)
+	require.NoError(t, err)
+	keys := make([]string, len(res.Results))
+	for i, r := range res.Results {
+		keys[i] = strings.TrimPrefix(r.Key, "app1||")
+	}
+	return keys, res.Token
+}
+
+// waitRebuilt waits until the index can answer queries again.
+func (env testEnv) waitRebuilt(t *testing.T) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		_, err := env.x.Query(context.Background(), "store1", env.store, &state.QueryRequest{})
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func seed(t *testing.T, env testEnv) {
+	env.write(t,
+		state.SetRequest{Key: "app1||a", Value: []byte(`{"city":"Rome","person":{"age":30}}`)},
+		state.SetRequest{Key: "app1||b", Value: map[string]any{"city": "Paris", "person": map[string]any{"age": 25}, "vip": true}},
+		state.SetRequest{Key: "app1||c", Value: []byte(`{"city":"Rome","person":{"age":41}}`)},
+		state.SetRequest{Key: "app1||d", Value: []byte("not json")},
+	)
+}
+
+func TestAddOrUpdateStore(t *testing.T) {
+	compStore := compstore.New()
+	for _, name := range []string{"store1", "stateindex-secure", "stateindex-partial"} {
+		compStore.AddStateStore(name, &rawStore{FakeStateStore: daprt.NewFakeStateStore()})
+	}
+	compStore.AddStateStore("unlisted", daprt.NewFakeStateStore())
+	x := NewIndexer("app1", compStore, resiliency.New(nil))
+
+	x.AddOrUpdateStore(component("store1", map[string]string{storeKey: "index"}))
+	assert.False(t, x.Indexed("store1"))
+	x.AddOrUpdateStore(component("store1", map[string]string{storeKey: "index", fieldsKey: "city"}))
+	assert.True(t, x.Indexed("store1"))
+	x.RemoveStore("store1")
+	assert.False(t, x.Indexed("store1"))
+
+	t.Run("stores that can't list their keys aren't indexed", func(t *testing.T) {
+		x.AddOrUpdateStore(component("unlisted", map[string]string{storeKey: "index", fieldsKey: "city"}))
+		assert.False(t, x.Indexed("unlisted"))
+		x.AddOrUpdateStore(component("missing", map[string]string{storeKey: "index", fieldsKey: "city"}))
+		assert.False(t, x.Indexed("missing"))
+	})
+
+	t.Run("encrypted values aren't indexed", func(t *testing.T) {
+		encryption.AddEncryptedStateStore("stateindex-secure", encryption.ComponentEncryptionKeys{})
+		x.AddOrUpdateStore(component("stateindex-secure", map[string]string{storeKey: "index", fieldsKey: "city"}))
+		assert.False(t, x.Indexed("stateindex-secure"))
+
+		encryption.AddEncryptedStateStore("stateindex-partial", encryption.ComponentEncryptionKeys{Fields: []string{"card"}})
+		x.AddOrUpdateStore(component("stateindex-partial", map[string]string{storeKey: "index", fieldsKey: "city,card.number", "encryptionFields": "card"}))
+		assert.False(t, x.Indexed("stateindex-partial"))
+		x.AddOrUpdateStore(component("stateindex-partial", map[string]string{storeKey: "index", fieldsKey: "city", "encryptionFields": "card"}))
+		assert.True(t, x.Indexed("stateindex-partial"))
+	})
+
+	t.Run("nil indexer", func(t *testing.T) {
+		var x *Indexer
+		assert.False(t, x.Indexed("store1"))
+		require.NoError(t, x.Update(context.Background(), "store1", nil))
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("writes update the entries of the keys", func(t *testing.T) {
+		env := newTestEnv(t)
+		seed(t, env)
+		env.write(t,
+			state.DeleteRequest{Key: "app1||a"},
+			state.SetRequest{Key: "app1||b", Value: []byte(`{"city":"Oslo"}`)},
+			state.SetRequest{Key: "app1||e", Value: []byte(`{"city":"Oslo"}`), Metadata: map[string]string{outboxProjectionKey: "true"}},
+		)
+
+		all, stale, err := env.x.load(context.Background(), "store1", env.store, "index", env.index)
+		require.NoError(t, err)
+		assert.False(t, stale)
+		assert.Equal(t, entries{
+			"app1||b": {"city": "Oslo"},
+			"app1||c": {"city": "Rome", "person.age": float64(41)},
+			"app1||d": {},
+		}, all)
+
+		// Each record has its own entry
+		items := env.index.GetItems()
+		assert.Len(t, items, 3)
+		assert.NotContains(t, items, env.x.entryKey("store1", "app1||a"))
+		assert.Contains(t, items, env.x.entryKey("store1", "app1||b"))
+	})
+
+	t.Run("the index is rebuilt when it can't be updated", func(t *testing.T) {
+		env := newTestEnv(t)
+		seed(t, env)
+		env.index.failures = 1
+		require.NoError(t, env.store.Delete(context.Background(), &state.DeleteRequest{Key: "app1||b"}))
+		err := env.x.Update(context.Background(), "store1", []state.TransactionalStateOperation{state.DeleteRequest{Key: "app1||b"}})
+		require.Error(t, err)
+		env.waitRebuilt(t)
+		keys, _ := env.query(t, `{}`)
+		assert.Equal(t, []string{"a", "c", "d"}, keys)
+		assert.NotContains(t, env.index.GetItems(), env.x.staleKey("store1"))
+	})
+
+	t.Run("indexes marked as stale are rebuilt from the store", func(t *testing.T) {
+		env := newTestEnv(t)
+		seed(t, env)
+		require.NoError(t, env.store.Multi(context.Background(), &state.TransactionalStateRequest{Operations: []state.TransactionalStateOperation{
+			state.SetRequest{Key: "app1||e", Value: []byte(`{"city":"Rome"}`)},
+		}}))
+		require.NoError(t, env.index.Set(context.Background(), &state.SetRequest{Key: env.x.staleKey("store1"), Value: []byte("1")}))
+
+		var req state.QueryRequest
+		_, err := env.x.Query(context.Background(), "store1", env.store, &req)
+		require.ErrorIs(t, err, ErrStaleIndex)
+		env.waitRebuilt(t)
+		keys, _ := env.query(t, `{"filter":{"EQ":{"city":"Rome"}}}`)
+		assert.Equal(t, []string{"a", "c", "e"}, keys)
+	})
+
+	t.Run("records of other apps aren't indexed", func(t *testing.T) {
+		env := newTestEnv(t)
+		seed(t, env)
+		env.write(t, state.SetRequest{Key: "app2||e", Value: []byte(`{"city":"Rome"}`)})
+
+		keys, _ := env.query(t, `{"filter":{"EQ":{"city":"Rome"}}}`)
+		assert.Equal(t, []string{"a", "c"}, keys)
+		assert.Contains(t, env.index.GetItems(), env.x.entryKey("store1", "app1||a"))
+		assert.NotContains(t, env.index.GetItems(), env.x.entryKey("store1", "app2||e"))
+	})
+}
+
+func TestQuery(t *testing.T) {
+	env := newTestEnv(t)
+	seed(t, env)
+
+	t.Run("filters", func(t *testing.T) {
+		keys, _ := env.query(t, `{"filter":{"EQ":{"city":"Rome"}}}`)
+		assert.Equal(t, []string{"a", "c"}, keys)
+		keys, _ = env.query(t, `{"filter":{"IN":{"person.age":[25,41]}}}`)
+		assert.Equal(t, []string{"b", "c"}, keys)
+		keys, _ = env.query(t, `{"filter":{"OR":[{"EQ":{"vip":true}},{"AND":[{"EQ":{"city":"Rome"}},{"EQ":{"person.age":30}}]}]}}`)
+		assert.Equal(t, []string{"a", "b"}, keys)
+	})
+
+	t.Run("sorting and pagination", func(t *testing.T) {
+		q := `{"sort":[{"key":"city","order":"DESC"},{"key":"person.age","order":"DESC"}],"page":{"limit":3}}`
+		keys, token := env.query(t, q)
+		assert.Equal(t, []string{"c", "a", "b"}, keys)
+		require.Equal(t, "3", token)
+
+		keys, token = env.query(t, `{"sort":[{"key":"city","order":"DESC"},{"key":"person.age","order":"DESC"}],"page":{"limit":3,"token":"3"}}`)
+		assert.Equal(t, []string{"d"}, keys)
+		assert.Empty(t, token)
+	})
+
+	t.Run("values are read from the store", func(t *testing.T) {
+		var req state.QueryRequest
+		require.NoError(t, json.Unmarshal([]byte(`{"filter":{"EQ":{"person.age":30}}}`), &req.Query))
+		res, err := env.x.Query(context.Background(), "store1", env.store, &req)
+		require.NoError(t, err)
+		require.Len(t, res.Results, 1)
+		assert.JSONEq(t, `{"city":"Rome","person":{"age":30}}`, string(res.Results[0].Data))
+		assert.NotNil(t, res.Results[0].ETag)
+	})
+
+	t.Run("records deleted outside of the API are skipped", func(t *testing.T) {
+		require.NoError(t, env.store.Delete(context.Background(), &state.DeleteRequest{Key: "app1||a"}))
+		keys, _ := env.query(t, `{"filter":{"EQ":{"city":"Rome"}}}`)
+		assert.Equal(t, []string{"c"}, keys)
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		for _, q := range []string{
+			`{"filter":{"EQ":{"country":"Italy"}}}`,
+			`{"sort":[{"key":"city","order":"UP"}]}`,
+			`{"page":{"token":"next"}}`,
+		} {
+			var req state.QueryRequest
+			require.NoError(t, json.Unmarshal([]byte(q), &req.Query))
+			_, err := env.x.Query(context.Background(), "store1", env.store, &req)
+			require.ErrorIs(t, err, ErrInvalidQuery, q)
+		}
+	})
+}
@@ -0,0 +1,815 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package statetx coordinates transactions spanning multiple state stores.
+//
+// Transactions are executed in two phases. When preparing, the records the
+// transaction changes are read, to check the ETags of the request and save
+// the records as they were before. The decision to commit is then saved in a
+// transaction log, and the operations are committed on each store with the
+// ETags read when preparing, so they fail if the records were changed
+// meanwhile. If a store fails to commit, the stores that committed already
+// are rolled back.
+// Transactions left in the log by a restart are committed again, or rolled
+// back if they were being aborted, by Recover. The records in the log are
+// leased to the instance of the app saving them, so the other instances only
+// recover them once the lease expires. Recovering checks the ETags read when
+// preparing too: if the records changed meanwhile, the transaction is rolled
+// back, and records changed after the transaction committed are left as they
+// are.
+package statetx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	contribMetadata "github.com/dapr/components-contrib/metadata"
+	"github.com/dapr/components-contrib/state"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	stateLoader "github.com/dapr/dapr/pkg/components/state"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	"github.com/dapr/kit/logger"
+	"github.com/dapr/kit/utils"
+)
+
+const (
+	// Metadata of the state store component the transaction log is saved in.
+	logStoreKey = "transactionLogStore"
+
+	logKeyPrefix = "statetx||"
+
+	statusCommitting = "committing"
+	statusAborting   = "aborting"
+
+	// logLease is how long a transaction in the log is left to the instance
+	// that saved it last, before the other instances recover it.
+	logLease = time.Minute
+	// recoverInterval is how often the transactions left in the log by the
+	// instances that stopped are recovered.
+	recoverInterval = time.Minute
+)
+
+var log = logger.NewLogger("dapr.runtime.statetx")
+
+var (
+	// ErrInvalidTransaction is returned for malformed transactions.
+	ErrInvalidTransaction = errors.New("invalid transaction")
+	// ErrUnsupported is returned when a state store can't take part in a
+	// transaction, or no transaction log is configured.
+	ErrUnsupported = errors.New("not supported")
+	// ErrConflict is returned when the records were changed by others before
+	// the transaction was committed. The transaction was rolled back.
+	ErrConflict = errors.New("transaction conflict")
+	// ErrAborted is returned when a state store failed to commit and the
+	// transaction was rolled back.
+	ErrAborted = errors.New("transaction aborted")
+	// ErrInDoubt is returned when the outcome of the transaction isn't known
+	// yet. It is completed from the transaction log on the next start.
+	ErrInDoubt = errors.New("transaction in doubt")
+)
+
+// WrittenFn is called after the coordinator committed operations to a state
+// store when recovering or rolling back a transaction, with the keys and
+// values as saved.
+type WrittenFn func(ctx context.Context, storeName string, ops []state.TransactionalStateOperation)
+
+// Transaction holds the operations on a state store, with the keys and
+// values as they are saved.
+type Transaction struct {
+	StoreName  string
+	Operations []state.TransactionalStateOperation
+	Metadata   map[string]string
+}
+
+// Coordinator executes the transactions of an app.
+type Coordinator struct {
+	appID      string
+	instanceID string
+	started    time.Time
+	compStore  *compstore.ComponentStore
+	resiliency resiliency.Provider
+	written    WrittenFn
+
+	lock     sync.RWMutex
+	logStore string
+}
+
+// NewCoordinator returns a coordinator for the transactions of an app.
+// instanceName identifies the instance of the app across restarts, such as
+// its pod name; the host name is used if it's empty. written may be nil.
+func NewCoordinator(appID, instanceName string, compStore *compstore.ComponentStore, resiliency resiliency.Provider, written WrittenFn) *Coordinator {
+	if instanceName == "" {
+		instanceName, _ = os.Hostname()
+	}
+	return &Coordinator{
+		appID:      appID,
+		instanceID: appID + "||" + instanceName,
+		started:    time.Now(),
+		compStore:  compStore,
+		resiliency: resiliency,
+		written:    written,
+	}
+}
+
+// AddOrUpdateStore uses the state store as transaction log if its component
+// is marked as such.
+func (c *Coordinator) AddOrUpdateStore(comp v1alpha1.Component) error {
+	isLog := false
+	for _, m := range comp.Spec.Metadata {
+		if strings.EqualFold(m.Name, logStoreKey) {
+			isLog = utils.IsTruthy(m.Value.String())
+			break
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	switch {
+	case isLog && c.logStore == "":
+		log.Infof("Using state store %s as transaction log", comp.Name)
+		c.logStore = comp.Name
+	case isLog && c.logStore != comp.Name:
+		return fmt.Errorf("detected duplicate transaction log stores: %s and %s", c.logStore, comp.Name)
+	case !isLog && c.logStore == comp.Name:
+		c.logStore = ""
+	}
+	return nil
+}
+
+// RemoveStore stops using the state store as transaction log.
+func (c *Coordinator) RemoveStore(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.logStore == name {
+		c.logStore = ""
+	}
+}
+
+// logRecord is the entry of a transaction in the log.
+type logRecord struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// The instance of the app the record is leased to.
+	Owner        string        `json:"owner,omitempty"`
+	LeaseExpires time.Time     `json:"leaseExpires"`
+	Stores       []storeRecord `json:"stores"`
+}
+
+type storeRecord struct {
+	StoreName  string            `json:"storeName"`
+	Operations []operation       `json:"operations"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Committed  bool              `json:"committed,omitempty"`
+}
+
+type operation struct {
+	Key      string            `json:"key"`
+	Delete   bool              `json:"delete,omitempty"`
+	Value    []byte            `json:"value,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// The record before the transaction, set on the first operation on a key.
+	Prepared      bool    `json:"prepared,omitempty"`
+	Existed       bool    `json:"existed,omitempty"`
+	Before        []byte  `json:"before,omitempty"`
+	ETag          *string `json:"etag,omitempty"`
+	TTLExpireTime string  `json:"ttlExpireTime,omitempty"`
+}
+
+// Execute commits the transactions on all the state stores, or on none.
+func (c *Coordinator) Execute(ctx context.Context, txs []Transaction) error {
+	logStoreName, logStore, stores, err := c.validate(txs)
+	if err != nil {
+		return err
+	}
+
+	rec := logRecord{
+		ID:     uuid.NewString(),
+		Status: statusCommitting,
+		Stores: make([]storeRecord, len(txs)),
+	}
+	commits := make([][]state.TransactionalStateOperation, len(txs))
+	for i, tx := range txs {
+		rec.Stores[i], commits[i], err = c.prepare(ctx, stores[i], tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// From here on, the transaction is committed even if the sidecar stops
+	err = c.saveRecord(ctx, logStoreName, logStore, &rec, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save the transaction log: %w", err)
+	}
+
+	for i := range rec.Stores {
+		err = c.multi(ctx, txs[i].StoreName, stores[i], commits[i], txs[i].Metadata)
+		if err != nil {
+			return c.abort(ctx, logStoreName, logStore, stores, &rec, err)
+		}
+		rec.Stores[i].Committed = true
+		c.saveProgress(ctx, logStoreName, logStore, &rec)
+	}
+
+	c.deleteRecord(ctx, logStoreName, logStore, rec.ID)
+	return nil
+}
+
+func (c *Coordinator) validate(txs []Transaction) (string, state.Store, []state.Store, error) {
+	c.lock.RLock()
+	logStoreName := c.logStore
+	c.lock.RUnlock()
+
+	if logStoreName == "" {
+		return "", nil, nil, fmt.Errorf("%w: no state store is configured as transaction log", ErrUnsupported)
+	}
+	logStore, ok := c.compStore.GetStateStore(logStoreName)
+	if !ok {
+		return "", nil, nil, fmt.Errorf("%w: transaction log store %s is not found", ErrUnsupported, logStoreName)
+	}
+	if !stateLoader.CanListKeys(logStore) {
+		return "", nil, nil, fmt.Errorf("%w: transaction log store %s can't list its keys", ErrUnsupported, logStoreName)
+	}
+
+	if len(txs) == 0 {
+		return "", nil, nil, fmt.Errorf("%w: no state stores in the transaction", ErrInvalidTransaction)
+	}
+	stores := make([]state.Store, len(txs))
+	seen := make(map[string]struct{}, len(txs))
+	for i, tx := range txs {
+		if _, ok := seen[tx.StoreName]; ok {
+			return "", nil, nil, fmt.Errorf("%w: state store %s is listed more than once", ErrInvalidTransaction, tx.StoreName)
+		}
+		seen[tx.StoreName] = struct{}{}
+		if len(tx.Operations) == 0 {
+			return "", nil, nil, fmt.Errorf("%w: no operations on state store %s", ErrInvalidTransaction, tx.StoreName)
+		}
+
+		store, ok := c.compStore.GetStateStore(tx.StoreName)
+		if !ok {
+			return "", nil, nil, fmt.Errorf("%w: state store %s is not found", ErrInvalidTransaction, tx.StoreName)
+		}
+		features := store.Features()
+		if _, ok = store.(state.TransactionalStore); !ok || !state.FeatureTransactional.IsPresent(features) {
+			return "", nil, nil, fmt.Errorf("%w: state store %s doesn't support transactions", ErrUnsupported, tx.StoreName)
+		}
+		if !state.FeatureETag.IsPresent(features) {
+			return "", nil, nil, fmt.Errorf("%w: state store %s doesn't support ETags", ErrUnsupported, tx.StoreName)
+		}
+		if maxMulti, ok := store.(state.TransactionalStoreMultiMaxSize); ok {
+			if max := maxMulti.MultiMaxSize(); max > 0 && len(tx.Operations) > max {
+				return "", nil, nil, fmt.Errorf("%w: the transaction on state store %s has %d operations, more than the %d it supports", ErrInvalidTransaction, tx.StoreName, len(tx.Operations), max)
+			}
+		}
+		stores[i] = store
+	}
+	return logStoreName, logStore, stores, nil
+}
+
+// prepare reads the records changed by the transaction on a store, and returns
+// the operations to commit, which fail if the records change meanwhile.
+func (c *Coordinator) prepare(ctx context.Context, store state.Store, tx Transaction) (storeRecord, []state.TransactionalStateOperation, error) {
+	rec := storeRecord{
+		StoreName:  tx.StoreName,
+		Operations: make([]operation, len(tx.Operations)),
+		Metadata:   tx.Metadata,
+	}
+	commit := make([]state.TransactionalStateOperation, len(tx.Operations))
+	prepared := make(map[string]struct{}, len(tx.Operations))
+	for i, o := range tx.Operations {
+		var (
+			op      operation
+			reqETag *string
+			err     error
+		)
+		switch req := o.(type) {
+		case state.SetRequest:
+			op = operation{Key: req.Key, Metadata: req.Metadata}
+			op.Value, err = valueBytes(req.Value)
+			if err != nil {
+				return rec, nil, fmt.Errorf("%w: invalid value of key %s: %v", ErrInvalidTransaction, req.Key, err)
+			}
+			reqETag = req.ETag
+		case state.DeleteRequest:
+			op = operation{Key: req.Key, Delete: true, Metadata: req.Metadata}
+			reqETag = req.ETag
+		default:
+			return rec, nil, fmt.Errorf("%w: unsupported operation %T", ErrInvalidTransaction, o)
+		}
+
+		// Only the first operation on a key can be checked: the others see the
+		// ETag it sets
+		var etag *string
+		if _, ok := prepared[op.Key]; !ok {
+			prepared[op.Key] = struct{}{}
+			res, err := c.get(ctx, tx.StoreName, store, op.Key)
+			if err != nil {
+				return rec, nil, fmt.Errorf("failed to prepare key %s of state store %s: %w", op.Key, tx.StoreName, err)
+			}
+			op.Prepared = true
+			if res != nil && res.Data != nil {
+				op.Existed = true
+				op.Before = res.Data
+				op.ETag = res.ETag
+				op.TTLExpireTime = res.Metadata[state.GetRespMetaKeyTTLExpireTime]
+			}
+			if reqETag != nil && (op.ETag == nil || *reqETag != *op.ETag) {
+				return rec, nil, fmt.Errorf("%w: the ETag of key %s of state store %s doesn't match", ErrConflict, op.Key, tx.StoreName)
+			}
+			etag = op.ETag
+		} else if reqETag != nil {
+			return rec, nil, fmt.Errorf("%w: only the first operation on key %s of state store %s can have an ETag", ErrInvalidTransaction, op.Key, tx.StoreName)
+		}
+		rec.Operations[i] = op
+		if op.Prepared {
+			commit[i] = guard(o, etag)
+		} else {
+			commit[i] = o
+		}
+	}
+	return rec, commit, nil
+}
+
+// guard sets the ETag of an operation, which then fails if the record changed.
+// Without an ETag, the operation uses first-write concurrency too, which
+// state stores implement by failing if the record exists.
+func guard(o state.TransactionalStateOperation, etag *string) state.TransactionalStateOperation {
+	switch req := o.(type) {
+	case state.SetRequest:
+		req.ETag = etag
+		req.Options.Concurrency = state.FirstWrite
+		return req
+	case state.DeleteRequest:
+		req.ETag = etag
+		req.Options.Concurrency = state.FirstWrite
+		return req
+	}
+	return o
+}
+
+// abort rolls back the stores that committed already.
+func (c *Coordinator) abort(ctx context.Context, logStoreName string, logStore state.Store, stores []state.Store, rec *logRecord, cause error) error {
+	rec.Status = statusAborting
+	err := c.saveRecord(ctx, logStoreName, logStore, rec, nil)
+	if err != nil {
+		// The log still says the transaction is being committed, which will be
+		// completed on the next start
+		return fmt.Errorf("%w: failed to commit: %v; failed to save the transaction log: %v", ErrInDoubt, cause, err)
+	}
+
+	err = c.rollback(ctx, logStoreName, logStore, stores, rec)
+	if err != nil {
+		return fmt.Errorf("%w: failed to commit: %v; failed to roll back: %v", ErrInDoubt, cause, err)
+	}
+	c.deleteRecord(ctx, logStoreName, logStore, rec.ID)
+
+	var etagErr *state.ETagError
+	if errors.As(cause, &etagErr) {
+		return fmt.Errorf("%w: %v", ErrConflict, cause)
+	}
+	return fmt.Errorf("%w: %v", ErrAborted, cause)
+}
+
+// rollback restores the records the transaction changed. The stores not
+// known to have committed are checked too, as they may have committed before
+// the log was updated. Records changed by others after the transaction
+// committed are left as they are.
+func (c *Coordinator) rollback(ctx context.Context, logStoreName string, logStore state.Store, stores []state.Store, rec *logRecord) error {
+	for i, sr := range rec.Stores {
+		ops := make([]state.TransactionalStateOperation, 0, len(sr.Operations))
+		for _, op := range sr.Operations {
+			if !op.Prepared {
+				continue
+			}
+			res, err := c.get(ctx, sr.StoreName, stores[i], op.Key)
+			if err != nil {
+				return fmt.Errorf("state store %s: failed to read key %s: %w", sr.StoreName, op.Key, err)
+			}
+			switch {
+			case op.isBefore(res):
+				// Not committed, or restored already
+				continue
+			case !sr.isAfter(op.Key, res):
+				log.Warnf("Not rolling back key %s of state store %s in transaction %s: it was changed after the transaction committed", op.Key, sr.StoreName, rec.ID)
+				continue
+			}
+			ops = append(ops, guard(op.restore(), res.ETag))
+		}
+		err := c.multi(ctx, sr.StoreName, stores[i], ops, sr.Metadata)
+		if err != nil {
+			return fmt.Errorf("state store %s: %w", sr.StoreName, err)
+		}
+		if len(ops) > 0 {
+			c.stateWritten(ctx, sr.StoreName, ops)
+		}
+		if sr.Committed {
+			rec.Stores[i].Committed = false
+			c.saveProgress(ctx, logStoreName, logStore, rec)
+		}
+	}
+	return nil
+}
+
+// isBefore returns whether the record of the key is as it was prepared.
+func (op operation) isBefore(res *state.GetResponse) bool {
+	if res == nil || res.Data == nil {
+		return !op.Existed
+	}
+	if !op.Existed {
+		return false
+	}
+	return (op.ETag != nil && res.ETag != nil && *op.ETag == *res.ETag) || sameValue(op.Before, res.Data)
+}
+
+// isAfter returns whether the record of the key is as the transaction left it.
+func (sr storeRecord) isAfter(key string, res *state.GetResponse) bool {
+	var last *operation
+	for i := range sr.Operations {
+		if sr.Operations[i].Key == key {
+			last = &sr.Operations[i]
+		}
+	}
+	if last == nil {
+		return false
+	}
+	if res == nil || res.Data == nil {
+		return last.Delete
+	}
+	return !last.Delete && sameValue(last.Value, res.Data)
+}
+
+// restore returns the operation restoring the record as it was prepared,
+// with the time to live it had left.
+func (op operation) restore() state.TransactionalStateOperation {
+	if !op.Existed {
+		return state.DeleteRequest{Key: op.Key}
+	}
+	if op.TTLExpireTime == "" {
+		return state.SetRequest{Key: op.Key, Value: op.Before}
+	}
+	expire, err := time.Parse(time.RFC3339, op.TTLExpireTime)
+	if err != nil {
+		log.Warnf("Invalid expiration time %q of key %s: %v", op.TTLExpireTime, op.Key, err)
+		return state.SetRequest{Key: op.Key, Value: op.Before}
+	}
+	ttl := time.Until(expire)
+	if ttl <= 0 {
+		// The record would have expired already
+		return state.DeleteRequest{Key: op.Key}
+	}
+	return state.SetRequest{
+		Key:   op.Key,
+		Value: op.Before,
+		Metadata: map[string]string{
+			contribMetadata.TTLMetadataKey: strconv.FormatInt(int64(math.Ceil(ttl.Seconds())), 10),
+		},
+	}
+}
+
+// commitState is the state of the records of a store in a transaction being
+// recovered.
+type commitState int
+
+const (
+	commitPrepared commitState = iota
+	commitDone
+	commitChanged
+)
+
+// commitState reads the records of a store to find out whether the
+// transaction committed on it, or they changed since they were prepared.
+func (c *Coordinator) commitState(ctx context.Context, store state.Store, sr storeRecord) (commitState, error) {
+	before, after := true, true
+	for _, op := range sr.Operations {
+		if !op.Prepared {
+			continue
+		}
+		res, err := c.get(ctx, sr.StoreName, store, op.Key)
+		if err != nil {
+			return commitPrepared, fmt.Errorf("failed to read key %s: %w", op.Key, err)
+		}
+		// Only the ETag tells if the record is unchanged since it was prepared
+		before = before && (op.Existed == (res != nil && res.Data != nil)) &&
+			(!op.Existed || (op.ETag != nil && res.ETag != nil && *op.ETag == *res.ETag))
+		after = after && sr.isAfter(op.Key, res)
+	}
+	switch {
+	case after:
+		return commitDone, nil
+	case before:
+		return commitPrepared, nil
+	default:
+		return commitChanged, nil
+	}
+}
+
+// commitOperations returns the operations of a store to commit when
+// recovering, which fail if the records changed since they were prepared.
+func (sr storeRecord) commitOperations() []state.TransactionalStateOperation {
+	ops := make([]state.TransactionalStateOperation, len(sr.Operations))
+	for i, op := range sr.Operations {
+		var o state.TransactionalStateOperation
+		if op.Delete {
+			o = state.DeleteRequest{Key: op.Key, Metadata: op.Metadata}
+		} else {
+			o = state.SetRequest{Key: op.Key, Value: op.Value, Metadata: op.Metadata}
+		}
+		if op.Prepared {
+			o = guard(o, op.ETag)
+		}
+		ops[i] = o
+	}
+	return ops
+}
+
+// sameValue returns whether two values are the same, ignoring the formatting
+// of JSON values.
+func sameValue(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// Run recovers the transactions left in the log by the instances of the app
+// that stopped, until ctx is done.
+func (c *Coordinator) Run(ctx context.Context) {
+	ticker := time.NewTicker(recoverInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Recover(ctx); err != nil {
+				log.Warnf("Failed to recover state transactions: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Recover completes the transactions left in the log by a previous run, or
+// by other instances of the app whose lease expired.
+func (c *Coordinator) Recover(ctx context.Context) error {
+	c.lock.RLock()
+	logStoreName := c.logStore
+	c.lock.RUnlock()
+	if logStoreName == "" {
+		return nil
+	}
+	logStore, ok := c.compStore.GetStateStore(logStoreName)
+	if !ok || !stateLoader.CanListKeys(logStore) {
+		return fmt.Errorf("%w: transaction log store %s can't list its keys", ErrUnsupported, logStoreName)
+	}
+
+	// Collect the keys first, as they are deleted while recovering
+	prefix := c.logKey("")
+	var keys []string
+	token := ""
+	for {
+		page, next, err := stateLoader.ListKeys(ctx, logStore, token, 100)
+		if err != nil {
+			return fmt.Errorf("failed to list the transaction log: %w", err)
+		}
+		for _, k := range page {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		if next == "" || len(page) == 0 {
+			break
+		}
+		token = next
+	}
+
+	var errs []error
+	for _, k := range keys {
+		if err := c.recoverRecord(ctx, logStoreName, logStore, k); err != nil {
+			errs = append(errs, fmt.Errorf("transaction %s: %w", strings.TrimPrefix(k, prefix), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Coordinator) recoverRecord(ctx context.Context, logStoreName string, logStore state.Store, key string) error {
+	res, err := c.get(ctx, logStoreName, logStore, key)
+	if err != nil {
+		return err
+	}
+	if res == nil || res.Data == nil {
+		return nil
+	}
+	var rec logRecord
+	err = json.Unmarshal(res.Data, &rec)
+	if err != nil {
+		return fmt.Errorf("invalid transaction log: %w", err)
+	}
+
+	if time.Now().Before(rec.LeaseExpires) && !c.leftByPreviousRun(&rec) {
+		// Being executed or recovered by an instance of the app
+		return nil
+	}
+
+	stores := make([]state.Store, len(rec.Stores))
+	for i, sr := range rec.Stores {
+		var ok bool
+		stores[i], ok = c.compStore.GetStateStore(sr.StoreName)
+		if !ok {
+			return fmt.Errorf("state store %s is not found", sr.StoreName)
+		}
+	}
+
+	// Lease the record, unless another instance did meanwhile
+	err = c.saveRecord(ctx, logStoreName, logStore, &rec, res.ETag)
+	if err != nil {
+		var etagErr *state.ETagError
+		if errors.As(err, &etagErr) {
+			return nil
+		}
+		return fmt.Errorf("failed to lease the transaction log: %w", err)
+	}
+
+	switch rec.Status {
+	case statusCommitting:
+		log.Infof("Committing transaction %s, found in the transaction log", rec.ID)
+		err = c.recoverCommit(ctx, logStoreName, logStore, stores, &rec)
+		if err != nil {
+			return err
+		}
+	case statusAborting:
+		log.Infof("Rolling back transaction %s, found in the transaction log", rec.ID)
+		err = c.rollback(ctx, logStoreName, logStore, stores, &rec)
+		if err != nil {
+			return fmt.Errorf("failed to roll back: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown status %q", rec.Status)
+	}
+
+	c.deleteRecord(ctx, logStoreName, logStore, rec.ID)
+	return nil
+}
+
+// recoverCommit commits the transaction on the stores that didn't commit yet.
+// If the records of a store changed since they were prepared, the transaction
+// is rolled back instead.
+func (c *Coordinator) recoverCommit(ctx context.Context, logStoreName string, logStore state.Store, stores []state.Store, rec *logRecord) error {
+	for i, sr := range rec.Stores {
+		if sr.Committed {
+			continue
+		}
+		ops := sr.commitOperations()
+		err := c.multi(ctx, sr.StoreName, stores[i], ops, sr.Metadata)
+		if err != nil {
+			cs, csErr := c.commitState(ctx, stores[i], sr)
+			switch {
+			case csErr != nil:
+				return fmt.Errorf("failed to commit on state store %s: %w; %v", sr.StoreName, err, csErr)
+			case cs == commitPrepared:
+				return fmt.Errorf("failed to commit on state store %s: %w", sr.StoreName, err)
+			case cs == commitChanged:
+				log.Warnf("Aborting transaction %s: the records of state store %s changed since they were prepared", rec.ID, sr.StoreName)
+				rec.Status = statusAborting
+				err = c.saveRecord(ctx, logStoreName, logStore, rec, nil)
+				if err != nil {
+					return fmt.Errorf("failed to save the transaction log: %w", err)
+				}
+				err = c.rollback(ctx, logStoreName, logStore, stores, rec)
+				if err != nil {
+					return fmt.Errorf("failed to roll back: %w", err)
+				}
+				return nil
+			}
+			// Committed before the log was updated
+		}
+		c.stateWritten(ctx, sr.StoreName, ops)
+		rec.Stores[i].Committed = true
+		c.saveProgress(ctx, logStoreName, logStore, rec)
+	}
+	return nil
+}
+
+// leftByPreviousRun returns whether the record was leased by this instance
+// of the app before it restarted, so nothing is executing it anymore.
+func (c *Coordinator) leftByPreviousRun(rec *logRecord) bool {
+	return rec.Owner == c.instanceID && rec.LeaseExpires.Add(-logLease).Before(c.started)
+}
+
+func (c *Coordinator) stateWritten(ctx context.Context, storeName string, ops []state.TransactionalStateOperation) {
+	if c.written != nil {
+		c.written(ctx, storeName, ops)
+	}
+}
+
+func (c *Coordinator) logKey(id string) string {
+	return logKeyPrefix + c.appID + "||" + id
+}
+
+// saveRecord saves the record leased to this instance. With an ETag, it fails
+// if the record changed.
+func (c *Coordinator) saveRecord(ctx context.Context, logStoreName string, logStore state.Store, rec *logRecord, etag *string) error {
+	rec.Owner = c.instanceID
+	rec.LeaseExpires = time.Now().Add(logLease)
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	req := &state.SetRequest{Key: c.logKey(rec.ID), Value: data, ETag: etag}
+	if etag != nil {
+		req.Options.Concurrency = state.FirstWrite
+	}
+	policyRunner := resiliency.NewRunner[any](ctx,
+		c.resiliency.ComponentOutboundPolicy(logStoreName, resiliency.Statestore),
+	)
+	_, err = policyRunner(func(ctx context.Context) (any, error) {
+		return nil, logStore.Set(ctx, req)
+	})
+	return err
+}
+
+// saveProgress records which stores committed, and renews the lease. It's not
+// required for correctness, as the records of the stores are checked when
+// committing or rolling back again.
+func (c *Coordinator) saveProgress(ctx context.Context, logStoreName string, logStore state.Store, rec *logRecord) {
+	if err := c.saveRecord(ctx, logStoreName, logStore, rec, nil); err != nil {
+		log.Warnf("Failed to save the progress of transaction %s: %v", rec.ID, err)
+	}
+}
+
+func (c *Coordinator) deleteRecord(ctx context.Context, logStoreName string, logStore state.Store, id string) {
+	policyRunner := resiliency.NewRunner[any](ctx,
+		c.resiliency.ComponentOutboundPolicy(logStoreName, resiliency.Statestore),
+	)
+	_, err := policyRunner(func(ctx context.Context) (any, error) {
+		return nil, logStore.Delete(ctx, &state.DeleteRequest{Key: c.logKey(id)})
+	})
+	if err != nil {
+		log.Warnf("Failed to delete transaction %s from the transaction log: %v", id, err)
+	}
+}
+
+func (c *Coordinator) get(ctx context.Context, storeName string, store state.Store, key string) (*state.GetResponse, error) {
+	policyRunner := resiliency.NewRunner[*state.GetResponse](ctx,
+		c.resiliency.ComponentOutboundPolicy(storeName, resiliency.Statestore),
+	)
+	return policyRunner(func(ctx context.Context) (*state.GetResponse, error) {
+		return store.Get(ctx, &state.GetRequest{
+			Key:     key,
+			Options: state.GetStateOption{Consistency: state.Strong},
+		})
+	})
+}
+
+func (c *Coordinator) multi(ctx context.Context, storeName string, store state.Store, ops []state.TransactionalStateOperation, md map[string]string) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	policyRunner := resiliency.NewRunner[any](ctx,
+		c.resiliency.ComponentOutboundPolicy(storeName, resiliency.Statestore),
+	)
+	_, err := policyRunner(func(ctx context.Context) (any, error) {
+		return nil, store.(state.TransactionalStore).Multi(ctx, &state.TransactionalStateRequest{
+			Operations: ops,
+			Metadata:   md,
+		})
+	})
+	return err
+}
+
+func valueBytes(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	default:
+		return json.Marshal(v)
+	}
+}
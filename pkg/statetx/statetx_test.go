@@ -0,0 +1,430 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statetx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsV1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dapr/components-contrib/state"
+	commonapi "github.com/dapr/dapr/pkg/apis/common"
+	"github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	"github.com/dapr/dapr/pkg/resiliency"
+	"github.com/dapr/dapr/pkg/runtime/compstore"
+	daprt "github.com/dapr/dapr/pkg/testing"
+)
+
+// listingStore can list its keys, to be used as transaction log.
+type listingStore struct {
+	*daprt.FakeStateStore
+}
+
+func (s listingStore) ListKeys(ctx context.Context, token string, limit int) ([]string, string, error) {
+	keys := make([]string, 0)
+	for k := range s.GetItems() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, "", nil
+}
+
+// Set stores the values as they are, like real state stores.
+func (s listingStore) Set(ctx context.Context, req *state.SetRequest) error {
+	return s.Multi(ctx, &state.TransactionalStateRequest{Operations: []state.TransactionalStateOperation{*req}})
+}
+
+// failingStore fails to commit transactions when fail is set, and records the
+// transactions it's asked to commit. Operations with first-write concurrency
+// and no ETag fail if the key exists. beforeMulti, if set, is called before
+// the next transaction.
+type failingStore struct {
+	*daprt.FakeStateStore
+	fail        bool
+	requests    []*state.TransactionalStateRequest
+	beforeMulti func()
+}
+
+func (s *failingStore) Multi(ctx context.Context, req *state.TransactionalStateRequest) error {
+	if s.beforeMulti != nil {
+		s.beforeMulti()
+		s.beforeMulti = nil
+	}
+	s.requests = append(s.requests, req)
+	if s.fail {
+		return errors.New("simulated failure")
+	}
+	for _, o := range req.Operations {
+		var etag *string
+		concurrency := ""
+		switch r := o.(type) {
+		case state.SetRequest:
+			etag, concurrency = r.ETag, r.Options.Concurrency
+		case state.DeleteRequest:
+			etag, concurrency = r.ETag, r.Options.Concurrency
+		}
+		if concurrency == state.FirstWrite && etag == nil && s.GetItems()[o.GetKey()] != nil {
+			return state.NewETagError(state.ETagMismatch, nil)
+		}
+	}
+	return s.FakeStateStore.Multi(ctx, req)
+}
+
+// nonTransactionalStore doesn't support transactions.
+type nonTransactionalStore struct {
+	state.Store
+}
+
+func (nonTransactionalStore) Features() []state.Feature {
+	return []state.Feature{state.FeatureETag}
+}
+
+type testEnv struct {
+	c      *Coordinator
+	log    listingStore
+	store1 *daprt.FakeStateStore
+	store2 *failingStore
+	// Keys written by the coordinator, by store.
+	written map[string][]string
+}
+
+func newTestEnv(t *testing.T) testEnv {
+	env := testEnv{
+		log:     listingStore{daprt.NewFakeStateStore()},
+		store1:  daprt.NewFakeStateStore(),
+		store2:  &failingStore{FakeStateStore: daprt.NewFakeStateStore()},
+		written: map[string][]string{},
+	}
+	compStore := compstore.New()
+	compStore.AddStateStore("txlog", env.log)
+	compStore.AddStateStore("store1", env.store1)
+	compStore.AddStateStore("store2", env.store2)
+	compStore.AddStateStore("nontx", nonTransactionalStore{})
+
+	env.c = NewCoordinator("app1", "instance1", compStore, resiliency.New(nil), func(_ context.Context, storeName string, ops []state.TransactionalStateOperation) {
+		for _, op := range ops {
+			env.written[storeName] = append(env.written[storeName], op.GetKey())
+		}
+	})
+	require.NoError(t, env.c.AddOrUpdateStore(v1alpha1.Component{
+		ObjectMeta: metav1.ObjectMeta{Name: "txlog"},
+		Spec: v1alpha1.ComponentSpec{Metadata: []commonapi.NameValuePair{
+			{Name: logStoreKey, Value: commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte("true")}}},
+		}},
+	}))
+	return env
+}
+
+func set(t *testing.T, store *daprt.FakeStateStore, key, value string) {
+	t.Helper()
+	require.NoError(t, store.Multi(context.Background(), &state.TransactionalStateRequest{
+		Operations: []state.TransactionalStateOperation{state.SetRequest{Key: key, Value: []byte(value)}},
+	}))
+}
+
+func data(t *testing.T, store *daprt.FakeStateStore) map[string]string {
+	t.Helper()
+	res := map[string]string{}
+	for k := range store.GetItems() {
+		got, err := store.Get(context.Background(), &state.GetRequest{Key: k})
+		require.NoError(t, err)
+		res[k] = string(got.Data)
+	}
+	return res
+}
+
+func TestExecute(t *testing.T) {
+	t.Run("all the stores are committed", func(t *testing.T) {
+		env := newTestEnv(t)
+		set(t, env.store1, "gone", "x")
+
+		err := env.c.Execute(context.Background(), []Transaction{
+			{StoreName: "store1", Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "a", Value: []byte("1")},
+				state.DeleteRequest{Key: "gone"},
+			}},
+			{StoreName: "store2", Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "b", Value: map[string]any{"n": 2}},
+			}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"a": "1"}, data(t, env.store1))
+		assert.Equal(t, map[string]string{"b": `{"n":2}`}, data(t, env.store2.FakeStateStore))
+		assert.Empty(t, env.log.GetItems())
+	})
+
+	t.Run("ETags are checked when preparing", func(t *testing.T) {
+		env := newTestEnv(t)
+		set(t, env.store1, "a", "1")
+		etag := "wrong"
+
+		err := env.c.Execute(context.Background(), []Transaction{
+			{StoreName: "store1", Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "a", Value: []byte("2"), ETag: &etag},
+			}},
+			{StoreName: "store2", Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "b", Value: []byte("2")},
+			}},
+		})
+		require.ErrorIs(t, err, ErrConflict)
+		assert.Equal(t, map[string]string{"a": "1"}, data(t, env.store1))
+		assert.Empty(t, env.store2.GetItems())
+		assert.Empty(t, env.log.GetItems())
+	})
+
+	t.Run("keys created after they were prepared conflict", func(t *testing.T) {
+		env := newTestEnv(t)
+		env.store2.beforeMulti = func() {
+			set(t, env.store2.FakeStateStore, "b", "x")
+		}
+
+		err := env.c.Execute(context.Background(), []Transaction{
+			{StoreName: "store2", Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "b", Value: []byte("2")},
+			}},
+		})
+		require.ErrorIs(t, err, ErrConflict)
+		assert.Equal(t, map[string]string{"b": "x"}, data(t, env.store2.FakeStateStore))
+		assert.Empty(t, env.log.GetItems())
+	})
+
+	t.Run("committed stores are rolled back when a store fails", func(t *testing.T) {
+		env := newTestEnv(t)
+		set(t, env.store1, "a", "1")
+		env.store2.fail = true
+
+		err := env.c.Execute(context.Background(), []Transaction{
+			{StoreName: "store1", Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "a", Value: []byte("2")},
+				state.SetRequest{Key: "new", Value: []byte("3")},
+			}},
+			{StoreName: "store2", Operations: []state.TransactionalStateOperation{
+				state.SetRequest{Key: "b", Value: []byte("2")},
+			}},
+		})
+		require.ErrorIs(t, err, ErrAborted)
+		assert.Equal(t, map[string]string{"a": "1"}, data(t, env.store1))
+		assert.Empty(t, env.log.GetItems())
+		assert.Equal(t, map[string][]string{"store1": {"a", "new"}}, env.written)
+	})
+
+	t.Run("invalid transactions", func(t *testing.T) {
+		env := newTestEnv(t)
+		op := []state.TransactionalStateOperation{state.DeleteRequest{Key: "a"}}
+
+		err := env.c.Execute(context.Background(), nil)
+		require.ErrorIs(t, err, ErrInvalidTransaction)
+		err = env.c.Execute(context.Background(), []Transaction{{StoreName: "store1", Operations: op}, {StoreName: "store1", Operations: op}})
+		require.ErrorIs(t, err, ErrInvalidTransaction)
+		err = env.c.Execute(context.Background(), []Transaction{{StoreName: "nope", Operations: op}})
+		require.ErrorIs(t, err, ErrInvalidTransaction)
+		err = env.c.Execute(context.Background(), []Transaction{{StoreName: "nontx", Operations: op}})
+		require.ErrorIs(t, err, ErrUnsupported)
+
+		env.c.RemoveStore("txlog")
+		err = env.c.Execute(context.Background(), []Transaction{{StoreName: "store1", Operations: op}})
+		require.ErrorIs(t, err, ErrUnsupported)
+	})
+
+	t.Run("only one transaction log", func(t *testing.T) {
+		env := newTestEnv(t)
+		err := env.c.AddOrUpdateStore(v1alpha1.Component{
+			ObjectMeta: metav1.ObjectMeta{Name: "store1"},
+			Spec: v1alpha1.ComponentSpec{Metadata: []commonapi.NameValuePair{
+				{Name: logStoreKey, Value: commonapi.DynamicValue{JSON: apiextensionsV1.JSON{Raw: []byte("true")}}},
+			}},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestRecover(t *testing.T) {
+	saveRecord := func(t *testing.T, env testEnv, rec logRecord) {
+		data, err := json.Marshal(rec)
+		require.NoError(t, err)
+		require.NoError(t, env.log.Set(context.Background(), &state.SetRequest{Key: env.c.logKey(rec.ID), Value: data}))
+	}
+
+	t.Run("committing transactions are completed", func(t *testing.T) {
+		env := newTestEnv(t)
+		set(t, env.store1, "a", "2")
+		saveRecord(t, env, logRecord{
+			ID:     "tx1",
+			Status: statusCommitting,
+			Stores: []storeRecord{
+				{StoreName: "store1", Committed: true, Operations: []operation{{Key: "a", Value: []byte("2"), Prepared: true}}},
+				{StoreName: "store2", Operations: []operation{{Key: "b", Value: []byte("3"), Prepared: true}}},
+			},
+		})
+
+		require.NoError(t, env.c.Recover(context.Background()))
+		assert.Equal(t, map[string]string{"a": "2"}, data(t, env.store1))
+		assert.Equal(t, map[string]string{"b": "3"}, data(t, env.store2.FakeStateStore))
+		assert.Empty(t, env.log.GetItems())
+		assert.Equal(t, map[string][]string{"store2": {"b"}}, env.written)
+	})
+
+	t.Run("aborting transactions are rolled back", func(t *testing.T) {
+		env := newTestEnv(t)
+		set(t, env.store1, "a", "2")
+		set(t, env.store1, "new", "3")
+		saveRecord(t, env, logRecord{
+			ID:     "tx2",
+			Status: statusAborting,
+			Stores: []storeRecord{
+				{StoreName: "store1", Committed: true, Operations: []operation{
+					{Key: "a", Value: []byte("2"), Prepared: true, Existed: true, Before: []byte("1")},
+					{Key: "new", Value: []byte("3"), Prepared: true},
+				}},
+				{StoreName: "store2", Operations: []operation{{Key: "b", Value: []byte("3"), Prepared: true}}},
+			},
+		})
+
+		require.NoError(t, env.c.Recover(context.Background()))
+		assert.Equal(t, map[string]string{"a": "1"}, data(t, env.store1))
+		assert.Empty(t, env.store2.GetItems())
+		assert.Empty(t, env.log.GetItems())
+	})
+
+	t.Run("transactions whose records changed since they were prepared are rolled back", func(t *testing.T) {
+		env := newTestEnv(t)
+		set(t, env.store1, "a", "2")
+		set(t, env.store2.FakeStateStore, "b", "changed")
+		etag := "prepared"
+		saveRecord(t, env, logRecord{
+			ID:     "tx3",
+			Status: statusCommitting,
+			Stores: []storeRecord{
+				{StoreName: "store1", Committed: true, Operations: []operation{
+					{Key: "a", Value: []byte("2"), Prepared: true, Existed: true, Before: []byte("1")},
+				}},
+				{StoreName: "store2", Operations: []operation{
+					{Key: "b", Value: []byte("3"), Prepared: true, Existed: true, Before: []byte("0"), ETag: &etag},
+				}},
+			},
+		})
+
+		require.NoError(t, env.c.Recover(context.Background()))
+		assert.Equal(t, map[string]string{"a": "1"}, data(t, env.store1))
+		assert.Equal(t, map[string]string{"b": "changed"}, data(t, env.store2.FakeStateStore))
+		assert.Empty(t, env.log.GetItems())
+	})
+
+	t.Run("stores that committed before the log was updated are not committed again", func(t *testing.T) {
+		env := newTestEnv(t)
+		set(t, env.store2.FakeStateStore, "b", "3")
+		etag := "prepared"
+		saveRecord(t, env, logRecord{
+			ID:     "tx4",
+			Status: statusCommitting,
+			Stores: []storeRecord{
+				{StoreName: "store2", Operations: []operation{
+					{Key: "b", Value: []byte("3"), Prepared: true, Existed: true, Before: []byte("0"), ETag: &etag},
+				}},
+			},
+		})
+
+		require.NoError(t, env.c.Recover(context.Background()))
+		assert.Equal(t, map[string]string{"b": "3"}, data(t, env.store2.FakeStateStore))
+		assert.Empty(t, env.log.GetItems())
+	})
+
+	t.Run("the time to live of the records is kept when rolling back", func(t *testing.T) {
+		env := newTestEnv(t)
+		set(t, env.store2.FakeStateStore, "c", "2")
+		set(t, env.store2.FakeStateStore, "expired", "2")
+		saveRecord(t, env, logRecord{
+			ID:     "tx5",
+			Status: statusAborting,
+			Stores: []storeRecord{
+				{StoreName: "store2", Committed: true, Operations: []operation{
+					{Key: "c", Value: []byte("2"), Prepared: true, Existed: true, Before: []byte("1"), TTLExpireTime: time.Now().Add(time.Hour).Format(time.RFC3339)},
+					{Key: "expired", Value: []byte("2"), Prepared: true, Existed: true, Before: []byte("1"), TTLExpireTime: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+				}},
+			},
+		})
+
+		require.NoError(t, env.c.Recover(context.Background()))
+		require.Len(t, env.store2.requests, 1)
+		ops := env.store2.requests[0].Operations
+		require.Len(t, ops, 2)
+		restored, ok := ops[0].(state.SetRequest)
+		require.True(t, ok)
+		ttl, err := strconv.Atoi(restored.Metadata["ttlInSeconds"])
+		require.NoError(t, err)
+		assert.InDelta(t, 3600, ttl, 5)
+		assert.IsType(t, state.DeleteRequest{}, ops[1])
+		assert.Equal(t, map[string]string{"c": "1"}, data(t, env.store2.FakeStateStore))
+	})
+
+	t.Run("leased transactions are left to their instance", func(t *testing.T) {
+		env := newTestEnv(t)
+		saveRecord(t, env, logRecord{
+			ID:           "tx6",
+			Status:       statusCommitting,
+			Owner:        "other",
+			LeaseExpires: time.Now().Add(time.Minute),
+			Stores: []storeRecord{
+				{StoreName: "store2", Operations: []operation{{Key: "b", Value: []byte("3"), Prepared: true}}},
+			},
+		})
+
+		require.NoError(t, env.c.Recover(context.Background()))
+		assert.Empty(t, env.store2.GetItems())
+		assert.Len(t, env.log.GetItems(), 1)
+	})
+
+	t.Run("transactions leased before a restart are recovered", func(t *testing.T) {
+		env := newTestEnv(t)
+		saveRecord(t, env, logRecord{
+			ID:           "tx7",
+			Status:       statusCommitting,
+			Owner:        env.c.instanceID,
+			LeaseExpires: env.c.started.Add(logLease - time.Second),
+			Stores: []storeRecord{
+				{StoreName: "store2", Operations: []operation{{Key: "b", Value: []byte("3"), Prepared: true}}},
+			},
+		})
+		saveRecord(t, env, logRecord{
+			ID:           "tx8",
+			Status:       statusCommitting,
+			Owner:        env.c.instanceID,
+			LeaseExpires: time.Now().Add(logLease),
+			Stores: []storeRecord{
+				{StoreName: "store2", Operations: []operation{{Key: "c", Value: []byte("4"), Prepared: true}}},
+			},
+		})
+
+		// Only the transaction leased by the previous run is recovered.
+		require.NoError(t, env.c.Recover(context.Background()))
+		assert.Equal(t, map[string]string{"b": "3"}, data(t, env.store2.FakeStateStore))
+		assert.Len(t, env.log.GetItems(), 1)
+	})
+
+	t.Run("records of other apps are left alone", func(t *testing.T) {
+		env := newTestEnv(t)
+		require.NoError(t, env.log.Set(context.Background(), &state.SetRequest{Key: logKeyPrefix + "app2||tx", Value: []byte("{}")}))
+		require.NoError(t, env.c.Recover(context.Background()))
+		assert.Len(t, env.log.GetItems(), 1)
+	})
+}
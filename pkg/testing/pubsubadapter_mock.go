@@ -63,3 +63,7 @@ func (o *outboxMock) PublishInternal(ctx context.Context, stateStore string, sta
 func (o *outboxMock) SubscribeToInternalTopics(ctx context.Context, appID string) error {
 	return nil
 }
+
+func (o *outboxMock) Stores() []outbox.StoreConfig {
+	return nil
+}